@@ -0,0 +1,383 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/version"
+)
+
+const (
+	requestChannelSize = 100
+
+	// TrackCIDPrefix prefixes IDs of local tracks, the client IDs tracks are published with
+	TrackCIDPrefix = "BT_"
+)
+
+var ErrNoTracks = errors.New("bot has no tracks to publish")
+
+// Track is media a bot publishes
+type Track interface {
+	Name() string
+	Source() livekit.TrackSource
+	Info() MediaInfo
+	// Local is added to the publisher transport once the track is published, its ID is the client ID of the track
+	Local() webrtc.TrackLocal
+	// Write sends media of the track until it ends, or ctx is done
+	Write(ctx context.Context) error
+}
+
+// KeyFrameRequester is implemented by tracks which can ask their source for key frames, when subscribers need them
+type KeyFrameRequester interface {
+	RequestKeyFrame()
+}
+
+type Params struct {
+	RoomName livekit.RoomName
+	Identity livekit.ParticipantIdentity
+	Name     livekit.ParticipantName
+	Tracks   []Track
+	Region   string
+	// starts the session of the bot participant, on the node hosting the room
+	StartSession routing.NewParticipantCallback
+	Logger       logger.Logger
+}
+
+type botTrack struct {
+	Track
+	cid string
+
+	published atomic.Bool
+}
+
+// Bot is a server owned participant publishing media, like files, into a room. It signals and connects to the room
+// like any client, so its tracks are received and forwarded as tracks of other publishers.
+type Bot struct {
+	params Params
+	logger logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// signal messages to and from the participant session
+	requests  *routing.MessageChannel
+	responses *routing.MessageChannel
+	publisher *rtc.PCTransport
+	tracks    []*botTrack
+
+	writers   sync.WaitGroup
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	lock    sync.Mutex
+	onClose func(b *Bot)
+}
+
+func NewBot(params Params) (*Bot, error) {
+	if len(params.Tracks) == 0 {
+		return nil, ErrNoTracks
+	}
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+
+	b := &Bot{
+		params: params,
+		logger: params.Logger,
+		closed: make(chan struct{}),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
+	codecs := make([]*livekit.Codec, 0, len(params.Tracks))
+	for _, track := range params.Tracks {
+		b.tracks = append(b.tracks, &botTrack{
+			Track: track,
+			cid:   track.Local().ID(),
+		})
+		codecs = append(codecs, &livekit.Codec{Mime: track.Info().MimeType})
+	}
+
+	conf := rtc.WebRTCConfig{
+		WebRTCConfig: rtcconfig.WebRTCConfig{
+			Configuration: webrtc.Configuration{},
+		},
+	}
+	conf.SettingEngine.SetLite(false)
+	if err := conf.SettingEngine.SetAnsweringDTLSRole(webrtc.DTLSRoleClient); err != nil {
+		return nil, err
+	}
+
+	// publisher transport of a client is the offerer, and the send side
+	var err error
+	b.publisher, err = rtc.NewPCTransport(rtc.TransportParams{
+		ParticipantIdentity: params.Identity,
+		Config:              &conf,
+		DirectionConfig:     conf.Subscriber,
+		EnabledCodecs:       codecs,
+		Logger:              b.logger,
+		IsOfferer:           true,
+		IsSendSide:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.publisher.OnICECandidate(func(c *webrtc.ICECandidate) error {
+		if c == nil {
+			return nil
+		}
+		trickle := rtc.ToProtoTrickle(c.ToJSON())
+		trickle.Target = livekit.SignalTarget_PUBLISHER
+		return b.sendRequest(&livekit.SignalRequest{
+			Message: &livekit.SignalRequest_Trickle{Trickle: trickle},
+		})
+	})
+	b.publisher.OnOffer(func(offer webrtc.SessionDescription) error {
+		return b.sendRequest(&livekit.SignalRequest{
+			Message: &livekit.SignalRequest_Offer{Offer: rtc.ToProtoSessionDescription(offer)},
+		})
+	})
+	b.publisher.OnInitialConnected(b.startWriters)
+	b.publisher.OnFailed(func(_ bool) {
+		b.logger.Warnw("bot connection failed", nil)
+		go b.Close()
+	})
+
+	return b, nil
+}
+
+func (b *Bot) Identity() livekit.ParticipantIdentity {
+	return b.params.Identity
+}
+
+func (b *Bot) RoomName() livekit.RoomName {
+	return b.params.RoomName
+}
+
+func (b *Bot) OnClose(f func(b *Bot)) {
+	b.lock.Lock()
+	b.onClose = f
+	b.lock.Unlock()
+}
+
+// Start joins the room, tracks are published once connected
+func (b *Bot) Start(ctx context.Context) error {
+	connID := livekit.ConnectionID(utils.NewGuid("CO_"))
+	b.requests = routing.NewMessageChannel(connID, requestChannelSize)
+	b.responses = routing.NewMessageChannel(connID, requestChannelSize)
+
+	grants := &auth.ClaimGrants{
+		Identity: string(b.params.Identity),
+		Name:     string(b.params.Name),
+		Video: &auth.VideoGrant{
+			RoomJoin: true,
+			Room:     string(b.params.RoomName),
+		},
+	}
+	grants.Video.SetCanPublish(true)
+	grants.Video.SetCanSubscribe(false)
+	grants.Video.SetCanPublishData(false)
+
+	err := b.params.StartSession(ctx, b.params.RoomName, routing.ParticipantInit{
+		Identity: b.params.Identity,
+		Name:     b.params.Name,
+		Client: &livekit.ClientInfo{
+			Sdk:      livekit.ClientInfo_GO,
+			Version:  version.Version,
+			Protocol: int32(types.CurrentProtocol),
+		},
+		Grants: grants,
+		Region: b.params.Region,
+	}, b.requests, b.responses)
+	if err != nil {
+		b.Close()
+		return err
+	}
+
+	go b.signalWorker()
+	return nil
+}
+
+// Close leaves the room
+func (b *Bot) Close() {
+	b.closeOnce.Do(func() {
+		b.logger.Infow("closing bot")
+		b.cancel()
+		if b.requests != nil {
+			_ = b.requests.WriteMessage(&livekit.SignalRequest{
+				Message: &livekit.SignalRequest_Leave{
+					Leave: &livekit.LeaveRequest{Reason: livekit.DisconnectReason_CLIENT_INITIATED},
+				},
+			})
+			b.requests.Close()
+			b.responses.Close()
+		}
+		b.publisher.Close()
+		b.writers.Wait()
+		close(b.closed)
+
+		b.lock.Lock()
+		onClose := b.onClose
+		b.lock.Unlock()
+		if onClose != nil {
+			onClose(b)
+		}
+	})
+}
+
+func (b *Bot) Closed() <-chan struct{} {
+	return b.closed
+}
+
+func (b *Bot) sendRequest(req *livekit.SignalRequest) error {
+	return b.requests.WriteMessage(req)
+}
+
+func (b *Bot) signalWorker() {
+	defer func() {
+		go b.Close()
+	}()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case msg := <-b.responses.ReadChan():
+			if msg == nil {
+				return
+			}
+			res, ok := msg.(*livekit.SignalResponse)
+			if !ok {
+				continue
+			}
+			if !b.handleResponse(res) {
+				return
+			}
+		}
+	}
+}
+
+// handleResponse returns false when the bot should stop
+func (b *Bot) handleResponse(res *livekit.SignalResponse) bool {
+	switch msg := res.Message.(type) {
+	case *livekit.SignalResponse_Join:
+		b.logger = b.logger.WithValues("pID", msg.Join.Participant.Sid)
+		for _, t := range b.tracks {
+			if err := b.sendRequest(&livekit.SignalRequest{
+				Message: &livekit.SignalRequest_AddTrack{
+					AddTrack: &livekit.AddTrackRequest{
+						Cid:    t.cid,
+						Name:   t.Name(),
+						Type:   t.Info().Kind,
+						Source: t.Source(),
+						Width:  t.Info().Width,
+						Height: t.Info().Height,
+					},
+				},
+			}); err != nil {
+				b.logger.Warnw("could not add track", err, "track", t.Name())
+				return false
+			}
+		}
+
+	case *livekit.SignalResponse_TrackPublished:
+		for _, t := range b.tracks {
+			if t.cid != msg.TrackPublished.Cid || t.published.Swap(true) {
+				continue
+			}
+			sender, _, err := b.publisher.AddTrack(t.Local(), types.AddTrackParams{})
+			if err != nil {
+				b.logger.Warnw("could not publish track", err, "track", t.Name())
+				return false
+			}
+			go b.readRTCP(t, sender)
+			b.logger.Debugw("publishing track", "trackID", msg.TrackPublished.Track.Sid, "track", t.Name())
+			b.publisher.Negotiate(false)
+		}
+
+	case *livekit.SignalResponse_Answer:
+		b.publisher.HandleRemoteDescription(rtc.FromProtoSessionDescription(msg.Answer))
+
+	case *livekit.SignalResponse_Trickle:
+		if msg.Trickle.Target != livekit.SignalTarget_PUBLISHER {
+			return true
+		}
+		candidate, err := rtc.FromProtoTrickle(msg.Trickle)
+		if err != nil {
+			b.logger.Warnw("could not parse candidate", err)
+			return true
+		}
+		b.publisher.AddICECandidate(candidate)
+
+	case *livekit.SignalResponse_Leave:
+		b.logger.Infow("bot removed from room", "reason", msg.Leave.Reason)
+		return false
+	}
+	return true
+}
+
+func (b *Bot) startWriters() {
+	b.logger.Debugw("bot connected, writing tracks")
+	var remaining atomic.Int32
+	remaining.Store(int32(len(b.tracks)))
+	for _, t := range b.tracks {
+		b.writers.Add(1)
+		go func(t *botTrack) {
+			defer b.writers.Done()
+			if err := t.Write(b.ctx); err != nil {
+				b.logger.Warnw("could not write track", err, "track", t.Name())
+			}
+			if remaining.Dec() == 0 && b.ctx.Err() == nil {
+				// all tracks ended
+				go b.Close()
+			}
+		}(t)
+	}
+}
+
+// readRTCP passes key frame requests of subscribers to the track
+func (b *Bot) readRTCP(t *botTrack, sender *webrtc.RTPSender) {
+	requester, _ := t.Track.(KeyFrameRequester)
+	for {
+		pkts, _, err := sender.ReadRTCP()
+		if err != nil {
+			return
+		}
+		if requester == nil {
+			continue
+		}
+		for _, pkt := range pkts {
+			switch pkt.(type) {
+			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				requester.RequestKeyFrame()
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+// TrackParams is a media file published as a track
+type TrackParams struct {
+	Path   string
+	Name   string
+	Source livekit.TrackSource
+	// restart the file once it ends, instead of ending the track
+	Loop bool
+}
+
+// FileTrack publishes samples of a media file in real time, as a publisher would capture them
+type FileTrack struct {
+	params TrackParams
+	info   MediaInfo
+	local  *webrtc.TrackLocalStaticSample
+}
+
+// NewFileTrack probes the file for its codec, streamID groups tracks of a participant
+func NewFileTrack(params TrackParams, streamID string) (*FileTrack, error) {
+	info, err := ProbeFile(params.Path)
+	if err != nil {
+		return nil, err
+	}
+	local, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: info.MimeType}, utils.NewGuid(TrackCIDPrefix), streamID)
+	if err != nil {
+		return nil, err
+	}
+	return &FileTrack{
+		params: params,
+		info:   info,
+		local:  local,
+	}, nil
+}
+
+func (t *FileTrack) Name() string {
+	return t.params.Name
+}
+
+func (t *FileTrack) Source() livekit.TrackSource {
+	return t.params.Source
+}
+
+func (t *FileTrack) Info() MediaInfo {
+	return t.info
+}
+
+func (t *FileTrack) Local() webrtc.TrackLocal {
+	return t.local
+}
+
+func (t *FileTrack) Write(ctx context.Context) error {
+	next := time.Now()
+	for {
+		reader, _, err := OpenFile(t.params.Path)
+		if err != nil {
+			return err
+		}
+
+		samples := 0
+		for {
+			sample, err := reader.NextSample()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					_ = reader.Close()
+					return err
+				}
+				break
+			}
+
+			if err = t.local.WriteSample(sample); err != nil {
+				_ = reader.Close()
+				return err
+			}
+			samples++
+
+			next = next.Add(sample.Duration)
+			select {
+			case <-ctx.Done():
+				_ = reader.Close()
+				return nil
+			case <-time.After(time.Until(next)):
+			}
+		}
+		_ = reader.Close()
+
+		if !t.params.Loop || samples == 0 || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/pion/webrtc/v3/pkg/media/ivfreader"
+	"github.com/pion/webrtc/v3/pkg/media/oggreader"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	ErrUnsupportedFile  = errors.New("unsupported media file, expected Ogg/Opus or IVF with VP8, VP9 or AV1")
+	ErrUnsupportedCodec = errors.New("unsupported IVF codec")
+)
+
+const (
+	opusSampleRate = 48000
+	// used when IVF timebase is missing
+	defaultFrameDuration = 33 * time.Millisecond
+)
+
+var ivfMimeTypes = map[string]string{
+	"VP80": webrtc.MimeTypeVP8,
+	"VP90": webrtc.MimeTypeVP9,
+	"AV01": webrtc.MimeTypeAV1,
+}
+
+// SampleReader reads samples of a media file, in the order they should be played
+type SampleReader interface {
+	NextSample() (media.Sample, error)
+	Close() error
+}
+
+// MediaInfo describes media of a file
+type MediaInfo struct {
+	Kind     livekit.TrackType
+	MimeType string
+	Width    uint32
+	Height   uint32
+}
+
+// ProbeFile opens a media file to find its codec
+func ProbeFile(path string) (MediaInfo, error) {
+	r, info, err := OpenFile(path)
+	if err != nil {
+		return MediaInfo{}, err
+	}
+	_ = r.Close()
+	return info, nil
+}
+
+// OpenFile opens an Ogg/Opus or IVF file for reading, based on its extension
+func OpenFile(path string) (SampleReader, MediaInfo, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ogg", ".opus":
+		return openOgg(path)
+	case ".ivf":
+		return openIVF(path)
+	default:
+		return nil, MediaInfo{}, ErrUnsupportedFile
+	}
+}
+
+type oggSampleReader struct {
+	file        *os.File
+	ogg         *oggreader.OggReader
+	lastGranule uint64
+}
+
+func openOgg(path string) (SampleReader, MediaInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, MediaInfo{}, err
+	}
+	ogg, _, err := oggreader.NewWith(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, MediaInfo{}, fmt.Errorf("%w: %v", ErrUnsupportedFile, err)
+	}
+	return &oggSampleReader{file: file, ogg: ogg}, MediaInfo{
+		Kind:     livekit.TrackType_AUDIO,
+		MimeType: webrtc.MimeTypeOpus,
+	}, nil
+}
+
+func (r *oggSampleReader) NextSample() (media.Sample, error) {
+	for {
+		data, header, err := r.ogg.ParseNextPage()
+		if err != nil {
+			return media.Sample{}, err
+		}
+		// comment header isn't media
+		if bytes.HasPrefix(data, []byte("OpusTags")) {
+			continue
+		}
+
+		// samples in a page is the difference of granule positions
+		samples := header.GranulePosition - r.lastGranule
+		r.lastGranule = header.GranulePosition
+		return media.Sample{
+			Data:     data,
+			Duration: time.Duration(samples) * time.Second / opusSampleRate,
+		}, nil
+	}
+}
+
+func (r *oggSampleReader) Close() error {
+	return r.file.Close()
+}
+
+type ivfSampleReader struct {
+	file      *os.File
+	ivf       *ivfreader.IVFReader
+	timebase  time.Duration
+	frame     []byte
+	timestamp uint64
+	eof       bool
+}
+
+func openIVF(path string) (SampleReader, MediaInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, MediaInfo{}, err
+	}
+	ivf, header, err := ivfreader.NewWith(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, MediaInfo{}, fmt.Errorf("%w: %v", ErrUnsupportedFile, err)
+	}
+	mimeType, ok := ivfMimeTypes[header.FourCC]
+	if !ok {
+		_ = file.Close()
+		return nil, MediaInfo{}, fmt.Errorf("%w: %s", ErrUnsupportedCodec, header.FourCC)
+	}
+
+	r := &ivfSampleReader{file: file, ivf: ivf}
+	if header.TimebaseDenominator != 0 {
+		r.timebase = time.Second * time.Duration(header.TimebaseNumerator) / time.Duration(header.TimebaseDenominator)
+	}
+	return r, MediaInfo{
+		Kind:     livekit.TrackType_VIDEO,
+		MimeType: mimeType,
+		Width:    uint32(header.Width),
+		Height:   uint32(header.Height),
+	}, nil
+}
+
+// NextSample returns a frame once the next one is read, as its duration is the difference of their timestamps
+func (r *ivfSampleReader) NextSample() (media.Sample, error) {
+	if r.frame == nil {
+		if r.eof {
+			return media.Sample{}, io.EOF
+		}
+		frame, header, err := r.ivf.ParseNextFrame()
+		if err != nil {
+			return media.Sample{}, err
+		}
+		r.frame, r.timestamp = frame, header.Timestamp
+	}
+
+	frame, timestamp := r.frame, r.timestamp
+	r.frame = nil
+	duration := r.timebase
+	if duration <= 0 {
+		duration = defaultFrameDuration
+	}
+	next, header, err := r.ivf.ParseNextFrame()
+	switch {
+	case err == nil:
+		r.frame, r.timestamp = next, header.Timestamp
+		if header.Timestamp > timestamp && r.timebase > 0 {
+			duration = time.Duration(header.Timestamp-timestamp) * r.timebase
+		}
+	case errors.Is(err, io.EOF):
+		r.eof = true
+	default:
+		return media.Sample{}, err
+	}
+	return media.Sample{Data: frame, Duration: duration}, nil
+}
+
+func (r *ivfSampleReader) Close() error {
+	return r.file.Close()
+}
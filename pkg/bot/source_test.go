@@ -0,0 +1,152 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func writeIVF(t *testing.T, path string, fourCC string, timebase uint32, timestamps []uint64) {
+	header := make([]byte, 32)
+	copy(header[0:], "DKIF")
+	binary.LittleEndian.PutUint16(header[6:], 32)
+	copy(header[8:], fourCC)
+	binary.LittleEndian.PutUint16(header[12:], 640)
+	binary.LittleEndian.PutUint16(header[14:], 360)
+	binary.LittleEndian.PutUint32(header[16:], timebase)
+	binary.LittleEndian.PutUint32(header[20:], 1)
+	binary.LittleEndian.PutUint32(header[24:], uint32(len(timestamps)))
+
+	data := header
+	for i, ts := range timestamps {
+		frame := []byte{0x00, byte(i), 0xff, 0xff}
+		frameHeader := make([]byte, 12)
+		binary.LittleEndian.PutUint32(frameHeader[0:], uint32(len(frame)))
+		binary.LittleEndian.PutUint64(frameHeader[4:], ts)
+		data = append(data, frameHeader...)
+		data = append(data, frame...)
+	}
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func writeOgg(t *testing.T, path string, packets int) {
+	w, err := oggwriter.New(path, 48000, 2)
+	require.NoError(t, err)
+	for i := 0; i < packets; i++ {
+		require.NoError(t, w.WriteRTP(&rtp.Packet{
+			Header: rtp.Header{
+				SequenceNumber: uint16(i),
+				Timestamp:      uint32(i * 960),
+			},
+			Payload: []byte{0xfc, byte(i), 0xff},
+		}))
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("ivf", func(t *testing.T) {
+		path := filepath.Join(dir, "video.ivf")
+		writeIVF(t, path, "VP80", 30, []uint64{0, 1, 3})
+
+		r, info, err := OpenFile(path)
+		require.NoError(t, err)
+		defer r.Close()
+		require.Equal(t, MediaInfo{
+			Kind:     livekit.TrackType_VIDEO,
+			MimeType: webrtc.MimeTypeVP8,
+			Width:    640,
+			Height:   360,
+		}, info)
+
+		var durations []time.Duration
+		for {
+			sample, err := r.NextSample()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			durations = append(durations, sample.Duration)
+		}
+		frame := time.Second / 30
+		require.Equal(t, []time.Duration{frame, 2 * frame, frame}, durations)
+	})
+
+	t.Run("ivf codecs", func(t *testing.T) {
+		for fourCC, mimeType := range map[string]string{"VP90": webrtc.MimeTypeVP9, "AV01": webrtc.MimeTypeAV1} {
+			path := filepath.Join(dir, fourCC+".ivf")
+			writeIVF(t, path, fourCC, 30, []uint64{0})
+			info, err := ProbeFile(path)
+			require.NoError(t, err)
+			require.Equal(t, mimeType, info.MimeType)
+		}
+
+		path := filepath.Join(dir, "h264.ivf")
+		writeIVF(t, path, "H264", 30, []uint64{0})
+		_, err := ProbeFile(path)
+		require.ErrorIs(t, err, ErrUnsupportedCodec)
+	})
+
+	t.Run("ogg", func(t *testing.T) {
+		path := filepath.Join(dir, "audio.ogg")
+		writeOgg(t, path, 5)
+
+		r, info, err := OpenFile(path)
+		require.NoError(t, err)
+		defer r.Close()
+		require.Equal(t, livekit.TrackType_AUDIO, info.Kind)
+		require.Equal(t, webrtc.MimeTypeOpus, info.MimeType)
+
+		samples := 0
+		var total time.Duration
+		for {
+			sample, err := r.NextSample()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			samples++
+			total += sample.Duration
+		}
+		require.Equal(t, 5, samples)
+		require.InDelta(t, float64(80*time.Millisecond), float64(total), float64(time.Millisecond))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		path := filepath.Join(dir, "audio.wav")
+		require.NoError(t, os.WriteFile(path, []byte("RIFF"), 0644))
+		_, err := ProbeFile(path)
+		require.ErrorIs(t, err, ErrUnsupportedFile)
+
+		path = filepath.Join(dir, "broken.ivf")
+		require.NoError(t, os.WriteFile(path, []byte("RIFF"), 0644))
+		_, err = ProbeFile(path)
+		require.ErrorIs(t, err, ErrUnsupportedFile)
+	})
+}
@@ -49,6 +49,14 @@ func (m *ScriptMatch) Match(clientInfo *livekit.ClientInfo) (bool, error) {
 	return false, errors.New("invalid match expression result")
 }
 
+// NewClientObject exposes client info to tengo expressions with the same fields used by ScriptMatch
+func NewClientObject(clientInfo *livekit.ClientInfo) tengo.Object {
+	if clientInfo == nil {
+		return tengo.UndefinedValue
+	}
+	return &clientObject{info: clientInfo}
+}
+
 type clientObject struct {
 	tengo.ObjectImpl
 	info *livekit.ClientInfo
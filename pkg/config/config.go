@@ -34,7 +34,9 @@ import (
 )
 
 type CongestionControlProbeMode string
+type CongestionControlPacerType string
 type StreamTrackerType string
+type SubscriptionPriorityClass string
 
 const (
 	generatedCLIFlagUsage = "generated"
@@ -42,9 +44,17 @@ const (
 	CongestionControlProbeModePadding CongestionControlProbeMode = "padding"
 	CongestionControlProbeModeMedia   CongestionControlProbeMode = "media"
 
+	CongestionControlPacerTypePassThrough CongestionControlPacerType = "pass_through"
+	CongestionControlPacerTypeNoQueue     CongestionControlPacerType = "no_queue"
+	CongestionControlPacerTypePriority    CongestionControlPacerType = "priority"
+
 	StreamTrackerTypePacket StreamTrackerType = "packet"
 	StreamTrackerTypeFrame  StreamTrackerType = "frame"
 
+	SubscriptionPriorityClassScreenShare   SubscriptionPriorityClass = "screen_share"
+	SubscriptionPriorityClassActiveSpeaker SubscriptionPriorityClass = "active_speaker"
+	SubscriptionPriorityClassPinned        SubscriptionPriorityClass = "pinned"
+
 	StatsUpdateInterval          = time.Second * 10
 	TelemetryStatsUpdateInterval = time.Second * 30
 )
@@ -71,7 +81,14 @@ type Config struct {
 	KeyFile        string                   `yaml:"key_file,omitempty"`
 	Keys           map[string]string        `yaml:"keys,omitempty"`
 	Region         string                   `yaml:"region,omitempty"`
+	NodeLabels     map[string]string        `yaml:"node_labels,omitempty"`
 	SignalRelay    SignalRelayConfig        `yaml:"signal_relay,omitempty"`
+	Relay          RelayConfig              `yaml:"relay,omitempty"`
+	Bots           BotsConfig               `yaml:"bots,omitempty"`
+	Recorder       RecorderConfig           `yaml:"recorder,omitempty"`
+	PacketCapture  PacketCaptureConfig      `yaml:"packet_capture,omitempty"`
+	RTPForward     RTPForwardConfig         `yaml:"rtp_forward,omitempty"`
+	RTPIngest      RTPIngestConfig          `yaml:"rtp_ingest,omitempty"`
 	PSRPC          rpc.PSRPCConfig          `yaml:"psrpc,omitempty"`
 	// LogLevel is deprecated
 	LogLevel string        `yaml:"log_level,omitempty"`
@@ -94,6 +111,12 @@ type RTCConfig struct {
 	// Throttle periods for pli/fir rtcp packets
 	PLIThrottle PLIThrottleConfig `yaml:"pli_throttle,omitempty"`
 
+	// Cache of recent key frames to start subscribers without a pli
+	KeyFrameCache KeyFrameCacheConfig `yaml:"key_frame_cache,omitempty"`
+
+	// negotiate RTX with subscribers, retransmissions and probes are sent on a separate RTX stream
+	SubscriberRTX bool `yaml:"subscriber_rtx,omitempty"`
+
 	CongestionControl CongestionControlConfig `yaml:"congestion_control,omitempty"`
 
 	// allow TCP and TURN/TLS fallback
@@ -110,6 +133,19 @@ type RTCConfig struct {
 
 	// max number of bytes to buffer for data channel. 0 means unlimited
 	DataChannelMaxBufferedAmount uint64 `yaml:"data_channel_max_buffered_amount,omitempty"`
+
+	// limits on data packets received from each participant
+	DataLimits DataLimitsConfig `yaml:"data_limits,omitempty"`
+}
+
+// DataLimitsConfig limits data packets a participant could send, packets exceeding them are dropped.
+// 0 means unlimited
+type DataLimitsConfig struct {
+	MessagesPerSecond uint32 `yaml:"messages_per_second,omitempty"`
+	// budget of data packet bytes per second
+	BytesPerSecond uint64 `yaml:"bytes_per_second,omitempty"`
+	// packets larger than this are always dropped when set
+	MaxPacketSize uint32 `yaml:"max_packet_size,omitempty"`
 }
 
 type TURNServer struct {
@@ -126,6 +162,14 @@ type PLIThrottleConfig struct {
 	HighQuality time.Duration `yaml:"high_quality,omitempty"`
 }
 
+type KeyFrameCacheConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// cached key frames older than this are stale, a pli is sent instead
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+	// max packets cached per layer from a key frame on, the cache is stale once exceeded
+	MaxPackets int `yaml:"max_packets,omitempty"`
+}
+
 type CongestionControlProbeConfig struct {
 	BaseInterval  time.Duration `yaml:"base_interval,omitempty"`
 	BackoffFactor float64       `yaml:"backoff_factor,omitempty"`
@@ -168,6 +212,29 @@ type CongestionControlConfig struct {
 	ChannelObserverProbeConfig       CongestionControlChannelObserverConfig `yaml:"channel_observer_probe_config,omitempty"`
 	ChannelObserverNonProbeConfig    CongestionControlChannelObserverConfig `yaml:"channel_observer_non_probe_config,omitempty"`
 	DisableEstimationUnmanagedTracks bool                                   `yaml:"disable_etimation_unmanaged_tracks,omitempty"`
+	FEC                              CongestionControlFECConfig             `yaml:"fec,omitempty"`
+	Pacer                            CongestionControlPacerConfig           `yaml:"pacer,omitempty"`
+}
+
+// CongestionControlFECConfig configures forward error correction of video sent to subscribers
+type CongestionControlFECConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// fraction of packets lost, as reported by the subscriber, to start protecting a track,
+	// protection stops when loss drops below half of it
+	LossThreshold float64 `yaml:"loss_threshold,omitempty"`
+	// maximum ratio of FEC packets to media packets
+	MaxOverhead float64 `yaml:"max_overhead,omitempty"`
+}
+
+// CongestionControlPacerConfig configures pacing of packets sent to subscribers
+type CongestionControlPacerConfig struct {
+	Type CongestionControlPacerType `yaml:"type,omitempty"`
+	// priority pacer: budget accumulates for at most an interval
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// priority pacer: multiple of estimated channel capacity that can be sent
+	PacingFactor float64 `yaml:"pacing_factor,omitempty"`
+	// priority pacer: maximum number of packets queued per kind, packets are dropped when a queue is full
+	MaxQueued int `yaml:"max_queued,omitempty"`
 }
 
 type AudioConfig struct {
@@ -183,6 +250,14 @@ type AudioConfig struct {
 	SmoothIntervals uint32 `yaml:"smooth_intervals,omitempty"`
 	// enable red encoding downtrack for opus only audio up track
 	ActiveREDEncoding bool `yaml:"active_red_encoding,omitempty"`
+	// switch subscribers between red and primary opus based on the loss they report
+	AdaptiveRED AdaptiveREDConfig `yaml:"adaptive_red,omitempty"`
+}
+
+type AdaptiveREDConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// fraction of packets lost to forward red, primary opus is forwarded once loss stays below half of it
+	LossThreshold float64 `yaml:"loss_threshold,omitempty"`
 }
 
 type StreamTrackerPacketConfig struct {
@@ -220,14 +295,104 @@ type VideoConfig struct {
 
 type RoomConfig struct {
 	// enable rooms to be automatically created
-	AutoCreate         bool               `yaml:"auto_create,omitempty"`
-	EnabledCodecs      []CodecSpec        `yaml:"enabled_codecs,omitempty"`
-	MaxParticipants    uint32             `yaml:"max_participants,omitempty"`
-	EmptyTimeout       uint32             `yaml:"empty_timeout,omitempty"`
-	EnableRemoteUnmute bool               `yaml:"enable_remote_unmute,omitempty"`
-	MaxMetadataSize    uint32             `yaml:"max_metadata_size,omitempty"`
-	PlayoutDelay       PlayoutDelayConfig `yaml:"playout_delay,omitempty"`
-	SyncStreams        bool               `yaml:"sync_streams,omitempty"`
+	AutoCreate            bool                       `yaml:"auto_create,omitempty"`
+	EnabledCodecs         []CodecSpec                `yaml:"enabled_codecs,omitempty"`
+	MaxParticipants       uint32                     `yaml:"max_participants,omitempty"`
+	EmptyTimeout          uint32                     `yaml:"empty_timeout,omitempty"`
+	EnableRemoteUnmute    bool                       `yaml:"enable_remote_unmute,omitempty"`
+	MaxMetadataSize       uint32                     `yaml:"max_metadata_size,omitempty"`
+	MaxAttributeKeySize   uint32                     `yaml:"max_attribute_key_size,omitempty"`
+	MaxAttributeValueSize uint32                     `yaml:"max_attribute_value_size,omitempty"`
+	MaxAttributes         uint32                     `yaml:"max_attributes,omitempty"`
+	PlayoutDelay          PlayoutDelayConfig         `yaml:"playout_delay,omitempty"`
+	SyncStreams           bool                       `yaml:"sync_streams,omitempty"`
+	LastN                 LastNConfig                `yaml:"last_n,omitempty"`
+	Spatial               SpatialConfig              `yaml:"spatial,omitempty"`
+	DataHistory           DataHistoryConfig          `yaml:"data_history,omitempty"`
+	PublishBitrate        PublishBitrateConfig       `yaml:"publish_bitrate,omitempty"`
+	SubscriptionPriority  SubscriptionPriorityConfig `yaml:"subscription_priority,omitempty"`
+}
+
+// SubscriptionPriorityConfig ranks video subscribed to by its source, when downstream bandwidth of a subscriber is short,
+// higher ranked video keeps its quality while lower ranked video is reduced or paused first
+type SubscriptionPriorityConfig struct {
+	// classes of video in decreasing priority, out of screen_share, active_speaker and pinned (by admins), e.g.
+	// [screen_share, active_speaker, pinned]. other video comes last, client priorities apply when empty
+	Policy []SubscriptionPriorityClass `yaml:"policy,omitempty"`
+	// policies of rooms with names matching a pattern, the first match applies instead of Policy
+	Rooms []SubscriptionPriorityRoomConfig `yaml:"rooms,omitempty"`
+}
+
+type SubscriptionPriorityRoomConfig struct {
+	RoomPattern string                      `yaml:"room_pattern,omitempty"`
+	Policy      []SubscriptionPriorityClass `yaml:"policy,omitempty"`
+}
+
+// PublishBitrateConfig caps upstream video bitrate of publishers, by sending them REMB, and TMMBR when negotiated.
+// Caps can also be set with the publishBitrate claim of access tokens and the publish bitrate admin API,
+// the lowest cap applies
+type PublishBitrateConfig struct {
+	// bits per second of each video track, 0 for no cap
+	MaxTrackBitrate int64 `yaml:"max_track_bitrate,omitempty"`
+	// bits per second of all video tracks of a participant, split evenly between them, 0 for no cap
+	MaxParticipantBitrate int64 `yaml:"max_participant_bitrate,omitempty"`
+	// negotiate TMMBR with publishers, for clients which do not act on REMB
+	TMMBR bool `yaml:"tmmbr,omitempty"`
+}
+
+// LastNConfig keeps participants subscribed only to camera video of the most recent active speakers,
+// instead of every published camera track
+type LastNConfig struct {
+	// rooms with names matching the pattern use last-N subscriptions, all rooms when empty
+	RoomPattern string `yaml:"room_pattern,omitempty"`
+	// number of speakers whose video is subscribed to, 0 to disable
+	Speakers int `yaml:"speakers,omitempty"`
+	// minimum time a speaker stays selected before it could be replaced by a newer speaker
+	MinHold time.Duration `yaml:"min_hold,omitempty"`
+}
+
+// SpatialConfig subscribes participants to microphone and camera tracks of nearby participants only,
+// based on positions they report
+type SpatialConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// topic of data packets carrying positions, JSON encoded as {"x": 0, "y": 0, "z": 0}.
+	// positions can also be set in participant metadata, as a "position" field of a JSON object
+	PositionTopic string  `yaml:"position_topic,omitempty"`
+	AudioRadius   float64 `yaml:"audio_radius,omitempty"`
+	VideoRadius   float64 `yaml:"video_radius,omitempty"`
+	// max video quality within a distance, in increasing order of distance
+	VideoQualities []SpatialVideoQuality `yaml:"video_qualities,omitempty"`
+	// distance a participant has to move past a boundary before subscriptions change back, to avoid flapping
+	Hysteresis float64 `yaml:"hysteresis,omitempty"`
+}
+
+// Validate rejects radiuses that would cut off every participant once placed
+func (c *SpatialConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.AudioRadius <= 0 || c.VideoRadius <= 0 {
+		return errors.New("audio_radius and video_radius must be set when spatial subscriptions are enabled")
+	}
+	return nil
+}
+
+type SpatialVideoQuality struct {
+	MaxDistance float64 `yaml:"max_distance,omitempty"`
+	// low, medium or high
+	Quality string `yaml:"quality,omitempty"`
+}
+
+// DataHistoryConfig retains recent reliable data packets of rooms, delivering them to participants joining later
+type DataHistoryConfig struct {
+	// rooms with names matching the pattern retain data packets, all rooms when empty
+	RoomPattern string `yaml:"room_pattern,omitempty"`
+	// number of packets retained per topic, 0 to disable
+	Messages int `yaml:"messages,omitempty"`
+	// max total size of payloads retained per topic
+	MaxBytes int `yaml:"max_bytes,omitempty"`
+	// packets older than this are dropped
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
 }
 
 type CodecSpec struct {
@@ -264,6 +429,19 @@ type NodeSelectorConfig struct {
 	CPULoadLimit float32        `yaml:"cpu_load_limit,omitempty"`
 	SysloadLimit float32        `yaml:"sysload_limit,omitempty"`
 	Regions      []RegionConfig `yaml:"regions,omitempty"`
+	// path to a MaxMind-format database used to place new rooms in the region nearest to the joining client
+	GeoIPDatabase string `yaml:"geoip_database,omitempty"`
+	Script        string `yaml:"script,omitempty"`
+	// label constraints rooms place on the nodes hosting them, applied before any selector logic
+	Affinity []NodeAffinityConfig `yaml:"affinity,omitempty"`
+}
+
+// NodeAffinityConfig restricts rooms with names matching RoomPattern to nodes carrying RequiredLabels,
+// and prefers nodes carrying PreferredLabels when any are available
+type NodeAffinityConfig struct {
+	RoomPattern     string            `yaml:"room_pattern,omitempty"`
+	RequiredLabels  map[string]string `yaml:"required_labels,omitempty"`
+	PreferredLabels map[string]string `yaml:"preferred_labels,omitempty"`
 }
 
 type SignalRelayConfig struct {
@@ -274,6 +452,52 @@ type SignalRelayConfig struct {
 	StreamBufferSize int           `yaml:"stream_buffer_size,omitempty"`
 }
 
+// RelayConfig enables distributed rooms, participants are hosted by the node they connect to and
+// nodes hosting the same room relay published tracks to each other
+type RelayConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+}
+
+// BotsConfig allows server owned participants publishing media files into rooms
+type BotsConfig struct {
+	// directory media files are read from, bots are disabled when empty
+	MediaDir string `yaml:"media_dir,omitempty"`
+}
+
+// RecorderConfig allows recording tracks to local files, without an egress service
+type RecorderConfig struct {
+	// directory recordings are written to, recording is disabled when empty
+	OutputDir string `yaml:"output_dir,omitempty"`
+}
+
+// PacketCaptureConfig allows room admins to capture RTP/RTCP packets of a participant to pcapng files, for debugging
+type PacketCaptureConfig struct {
+	// directory captures are written to, capturing is disabled when empty
+	OutputDir string `yaml:"output_dir,omitempty"`
+	// limits of a capture, requests may ask for less
+	MaxDuration time.Duration `yaml:"max_duration,omitempty"`
+	MaxSize     uint64        `yaml:"max_size,omitempty"`
+}
+
+// RTPForwardConfig allows room admins to forward tracks as plain RTP or SRTP to UDP destinations
+type RTPForwardConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// CIDRs destinations have to be in, any destination is allowed when empty
+	AllowedNetworks []string `yaml:"allowed_networks,omitempty"`
+	// interval of key frame requests of forwarded video, requests may ask for a longer one
+	KeyFrameInterval time.Duration `yaml:"key_frame_interval,omitempty"`
+}
+
+// RTPIngestConfig allows room admins to publish plain RTP from UDP ports, e.g. of hardware encoders, into rooms
+type RTPIngestConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// address ports are bound to, all interfaces when empty
+	BindAddress string `yaml:"bind_address,omitempty"`
+	// ports allocated for ingested tracks, random ports when not set
+	PortRangeStart uint16 `yaml:"port_range_start,omitempty"`
+	PortRangeEnd   uint16 `yaml:"port_range_end,omitempty"`
+}
+
 // RegionConfig lists available regions and their latitude/longitude, so the selector would prefer
 // regions that are closer
 type RegionConfig struct {
@@ -327,6 +551,10 @@ var DefaultConfig = Config{
 			MidQuality:  time.Second,
 			HighQuality: time.Second,
 		},
+		KeyFrameCache: KeyFrameCacheConfig{
+			MaxAge:     2 * time.Second,
+			MaxPackets: 500,
+		},
 		CongestionControl: CongestionControlConfig{
 			Enabled:                true,
 			AllowPause:             false,
@@ -372,6 +600,16 @@ var DefaultConfig = Config{
 				NackWindowMaxDuration:          3 * time.Second,
 				NackRatioThreshold:             0.08,
 			},
+			FEC: CongestionControlFECConfig{
+				LossThreshold: 0.05,
+				MaxOverhead:   0.5,
+			},
+			Pacer: CongestionControlPacerConfig{
+				Type:         CongestionControlPacerTypePassThrough,
+				Interval:     5 * time.Millisecond,
+				PacingFactor: 2.5,
+				MaxQueued:    1000,
+			},
 		},
 	},
 	Audio: AudioConfig{
@@ -379,6 +617,9 @@ var DefaultConfig = Config{
 		MinPercentile:   40,
 		UpdateInterval:  400,
 		SmoothIntervals: 2,
+		AdaptiveRED: AdaptiveREDConfig{
+			LossThreshold: 0.03,
+		},
 	},
 	Video: VideoConfig{
 		DynacastPauseDelay: 5 * time.Second,
@@ -469,6 +710,12 @@ var DefaultConfig = Config{
 			{Mime: webrtc.MimeTypeAV1},
 		},
 		EmptyTimeout: 5 * 60,
+		LastN: LastNConfig{
+			MinHold: 5 * time.Second,
+		},
+		Spatial: SpatialConfig{
+			PositionTopic: "lk.position",
+		},
 	},
 	Logging: LoggingConfig{
 		PionLevel: "error",
@@ -489,6 +736,13 @@ var DefaultConfig = Config{
 		MaxRetryInterval: 4 * time.Second,
 		StreamBufferSize: 1000,
 	},
+	PacketCapture: PacketCaptureConfig{
+		MaxDuration: 5 * time.Minute,
+		MaxSize:     100 * 1024 * 1024,
+	},
+	RTPForward: RTPForwardConfig{
+		KeyFrameInterval: 2 * time.Second,
+	},
 	PSRPC: rpc.DefaultPSRPCConfig,
 	Keys:  map[string]string{},
 }
@@ -524,6 +778,10 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 		}
 	}
 
+	if err := conf.Room.Spatial.Validate(); err != nil {
+		return nil, fmt.Errorf("could not validate spatial config: %v", err)
+	}
+
 	// expand env vars in filenames
 	file, err := homedir.Expand(os.ExpandEnv(conf.KeyFile))
 	if err != nil {
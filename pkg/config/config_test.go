@@ -80,3 +80,17 @@ func TestGeneratedFlags(t *testing.T) {
 	require.NotNil(t, conf.RTC.ReconnectOnSubscriptionError)
 	require.False(t, *conf.RTC.ReconnectOnSubscriptionError)
 }
+
+func TestConfig_SpatialRadius(t *testing.T) {
+	const content = `room:
+  spatial:
+    enabled: true
+    audio_radius: 10`
+	_, err := NewConfig(content, true, nil, nil)
+	require.Error(t, err)
+
+	conf, err := NewConfig(content+`
+    video_radius: 5`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 5.0, conf.Room.Spatial.VideoRadius)
+}
@@ -0,0 +1,340 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	// PayloadFull keeps payloads of captured packets as they are
+	PayloadFull = -1
+
+	packetQueueSize = 4096
+
+	rtpHeaderSize = 12
+	ipv4Size      = 20
+	udpSize       = 8
+
+	rtpPort  = 5004
+	rtcpPort = 5005
+)
+
+var (
+	// addresses of the synthesized IP headers
+	sfuAddress         = [4]byte{10, 0, 0, 1}
+	participantAddress = [4]byte{10, 0, 0, 2}
+)
+
+type StopReason string
+
+const (
+	StopReasonRequested StopReason = "requested"
+	StopReasonDuration  StopReason = "duration"
+	StopReasonSize      StopReason = "size"
+	StopReasonError     StopReason = "error"
+)
+
+type Params struct {
+	// file to write, created with its directory
+	Path string
+	// capture stops on its own after Duration, or once the file reaches MaxSize bytes, when set
+	Duration time.Duration
+	MaxSize  uint64
+	// bytes of RTP payload kept after headers, 0 for headers only, PayloadFull for complete packets.
+	// RTCP packets are always complete.
+	PayloadBytes int
+	// written to the section header
+	Application string
+	Logger      logger.Logger
+}
+
+type Result struct {
+	Filename  string
+	StartedAt time.Time
+	EndedAt   time.Time
+	Packets   uint64
+	// packets lost as the writer could not keep up
+	Dropped uint64
+	Size    uint64
+	Reason  StopReason
+	Error   error
+}
+
+type packet struct {
+	at        time.Time
+	direction buffer.PacketDirection
+	rtcp      bool
+	data      []byte
+	length    int
+}
+
+// Capture writes RTP and RTCP packets into a pcapng file, as UDP datagrams between the SFU and a participant.
+// Packets are queued by media paths and written in a separate goroutine, dropping packets when the queue is full.
+type Capture struct {
+	params Params
+
+	file    *os.File
+	writer  *bufio.Writer
+	pcapng  *pcapngWriter
+	started time.Time
+	timer   *time.Timer
+
+	lock    sync.RWMutex
+	closed  bool
+	packets chan packet
+	reason  StopReason
+
+	dropped atomic.Uint64
+	done    chan struct{}
+	result  Result
+	onStop  func(Result)
+}
+
+var _ buffer.PacketCapture = (*Capture)(nil)
+
+func NewCapture(params Params) (*Capture, error) {
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+	if params.Application == "" {
+		params.Application = "livekit-server"
+	}
+	if err := os.MkdirAll(filepath.Dir(params.Path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(params.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Capture{
+		params:  params,
+		file:    file,
+		writer:  bufio.NewWriter(file),
+		packets: make(chan packet, packetQueueSize),
+		done:    make(chan struct{}),
+	}
+	c.pcapng = &pcapngWriter{w: c.writer}
+	return c, nil
+}
+
+func (c *Capture) Filename() string {
+	return c.params.Path
+}
+
+func (c *Capture) StartedAt() time.Time {
+	return c.started
+}
+
+// OnStop is called once the file is complete
+func (c *Capture) OnStop(f func(Result)) {
+	c.onStop = f
+}
+
+func (c *Capture) Done() <-chan struct{} {
+	return c.done
+}
+
+// Result is valid once Done
+func (c *Capture) Result() Result {
+	return c.result
+}
+
+func (c *Capture) Start() {
+	c.started = time.Now()
+	if c.params.Duration > 0 {
+		c.timer = time.AfterFunc(c.params.Duration, func() {
+			c.stop(StopReasonDuration)
+		})
+	}
+	go c.writeWorker()
+}
+
+func (c *Capture) Stop() {
+	c.stop(StopReasonRequested)
+}
+
+func (c *Capture) CaptureRTP(direction buffer.PacketDirection, pkt []byte) {
+	length := len(pkt)
+	if c.params.PayloadBytes != PayloadFull {
+		if keep := rtpHeaderLength(pkt) + c.params.PayloadBytes; keep < length {
+			pkt = pkt[:keep]
+		}
+	}
+	c.enqueue(direction, false, pkt, length)
+}
+
+func (c *Capture) CaptureRTCP(direction buffer.PacketDirection, pkt []byte) {
+	c.enqueue(direction, true, pkt, len(pkt))
+}
+
+func (c *Capture) enqueue(direction buffer.PacketDirection, rtcp bool, pkt []byte, length int) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.closed {
+		return
+	}
+	p := packet{
+		at:        time.Now(),
+		direction: direction,
+		rtcp:      rtcp,
+		data:      append([]byte(nil), pkt...),
+		length:    length,
+	}
+	select {
+	case c.packets <- p:
+	default:
+		c.dropped.Inc()
+	}
+}
+
+func (c *Capture) stop(reason StopReason) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.reason = reason
+	close(c.packets)
+}
+
+func (c *Capture) writeWorker() {
+	var size uint64
+	var packets uint64
+	n, err := c.pcapng.writeHeader(c.params.Application, "participant")
+	size += uint64(n)
+
+	if err == nil {
+		for p := range c.packets {
+			n, err = c.pcapng.writePacket(p.at, p.direction == buffer.PacketDirectionInbound, udpDatagram(p), ipv4Size+udpSize+p.length)
+			size += uint64(n)
+			if err != nil {
+				break
+			}
+			packets++
+			if c.params.MaxSize != 0 && size >= c.params.MaxSize {
+				c.stop(StopReasonSize)
+				break
+			}
+		}
+	}
+	if err != nil {
+		c.params.Logger.Warnw("could not write packet capture", err)
+		c.stop(StopReasonError)
+	}
+	// packets queued after the last write are not captured
+	for range c.packets {
+		c.dropped.Inc()
+	}
+
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if flushErr := c.writer.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	c.lock.RLock()
+	reason := c.reason
+	c.lock.RUnlock()
+	c.result = Result{
+		Filename:  c.params.Path,
+		StartedAt: c.started,
+		EndedAt:   time.Now(),
+		Packets:   packets,
+		Dropped:   c.dropped.Load(),
+		Size:      size,
+		Reason:    reason,
+		Error:     err,
+	}
+	close(c.done)
+	if c.onStop != nil {
+		c.onStop(c.result)
+	}
+}
+
+// rtpHeaderLength returns the length of the fixed header, CSRCs and header extension of a RTP packet
+func rtpHeaderLength(pkt []byte) int {
+	if len(pkt) < rtpHeaderSize {
+		return len(pkt)
+	}
+	length := rtpHeaderSize + 4*int(pkt[0]&0x0f)
+	if pkt[0]&0x10 != 0 && len(pkt) >= length+4 {
+		length += 4 + 4*int(binary.BigEndian.Uint16(pkt[length+2:]))
+	}
+	if length > len(pkt) {
+		return len(pkt)
+	}
+	return length
+}
+
+// udpDatagram wraps a packet into IPv4 and UDP headers, with addresses and ports telling direction and protocol apart
+func udpDatagram(p packet) []byte {
+	src, dst := participantAddress, sfuAddress
+	if p.direction == buffer.PacketDirectionOutbound {
+		src, dst = dst, src
+	}
+	port := uint16(rtpPort)
+	if p.rtcp {
+		port = rtcpPort
+	}
+
+	datagram := make([]byte, ipv4Size+udpSize, ipv4Size+udpSize+len(p.data))
+	ip := datagram[:ipv4Size]
+	ip[0] = 0x45 // version 4, 20 byte header
+	binary.BigEndian.PutUint16(ip[2:], uint16(ipv4Size+udpSize+p.length))
+	ip[8] = 64 // ttl
+	ip[9] = 17 // udp
+	copy(ip[12:], src[:])
+	copy(ip[16:], dst[:])
+	binary.BigEndian.PutUint16(ip[10:], ipv4Checksum(ip))
+
+	udp := datagram[ipv4Size:]
+	binary.BigEndian.PutUint16(udp[0:], port)
+	binary.BigEndian.PutUint16(udp[2:], port)
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpSize+p.length))
+	// no checksum, payloads may be truncated
+
+	return append(datagram, p.data...)
+}
+
+func ipv4Checksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i:]))
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcap
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type testBlock struct {
+	blockType uint32
+	body      []byte
+}
+
+func readBlocks(t *testing.T, filename string) []testBlock {
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var blocks []testBlock
+	for len(data) != 0 {
+		require.GreaterOrEqual(t, len(data), 12)
+		length := int(binary.LittleEndian.Uint32(data[4:]))
+		require.Zero(t, length%4)
+		require.GreaterOrEqual(t, len(data), length)
+		require.Equal(t, uint32(length), binary.LittleEndian.Uint32(data[length-4:]), "trailing block length")
+		blocks = append(blocks, testBlock{
+			blockType: binary.LittleEndian.Uint32(data),
+			body:      data[8 : length-4],
+		})
+		data = data[length:]
+	}
+	return blocks
+}
+
+func startCapture(t *testing.T, params Params) (*Capture, chan Result) {
+	params.Path = filepath.Join(t.TempDir(), "captures", "test.pcapng")
+	c, err := NewCapture(params)
+	require.NoError(t, err)
+
+	stopped := make(chan Result, 1)
+	c.OnStop(func(res Result) {
+		stopped <- res
+	})
+	c.Start()
+	return c, stopped
+}
+
+func waitForResult(t *testing.T, stopped chan Result) Result {
+	select {
+	case res := <-stopped:
+		return res
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "capture did not stop")
+		return Result{}
+	}
+}
+
+func TestCapture(t *testing.T) {
+	rtpPacket := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    111,
+			SequenceNumber: 1,
+			SSRC:           1234,
+		},
+		Payload: make([]byte, 100),
+	}
+	require.NoError(t, rtpPacket.SetExtension(1, []byte{0x01, 0x02}))
+	rtpBytes, err := rtpPacket.Marshal()
+	require.NoError(t, err)
+	headerLength := rtpPacket.Header.MarshalSize()
+
+	rtcpBytes, err := (&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 1234}).Marshal()
+	require.NoError(t, err)
+
+	t.Run("writes packets", func(t *testing.T) {
+		c, stopped := startCapture(t, Params{PayloadBytes: 4})
+		c.CaptureRTP(buffer.PacketDirectionInbound, rtpBytes)
+		c.CaptureRTCP(buffer.PacketDirectionOutbound, rtcpBytes)
+		c.Stop()
+
+		res := waitForResult(t, stopped)
+		require.NoError(t, res.Error)
+		require.Equal(t, StopReasonRequested, res.Reason)
+		require.EqualValues(t, 2, res.Packets)
+		require.Zero(t, res.Dropped)
+		info, err := os.Stat(res.Filename)
+		require.NoError(t, err)
+		require.EqualValues(t, info.Size(), res.Size)
+
+		blocks := readBlocks(t, res.Filename)
+		require.Len(t, blocks, 4)
+		require.EqualValues(t, blockTypeSectionHeader, blocks[0].blockType)
+		require.EqualValues(t, byteOrderMagic, binary.LittleEndian.Uint32(blocks[0].body))
+		require.EqualValues(t, blockTypeInterfaceDescription, blocks[1].blockType)
+		require.EqualValues(t, linkTypeRaw, binary.LittleEndian.Uint16(blocks[1].body))
+
+		// headers and the first bytes of payload
+		epb := blocks[2].body
+		require.EqualValues(t, blockTypeEnhancedPacket, blocks[2].blockType)
+		captured := int(binary.LittleEndian.Uint32(epb[12:]))
+		original := int(binary.LittleEndian.Uint32(epb[16:]))
+		require.Equal(t, ipv4Size+udpSize+headerLength+4, captured)
+		require.Equal(t, ipv4Size+udpSize+len(rtpBytes), original)
+		ip := epb[20 : 20+ipv4Size]
+		require.Zero(t, ipv4Checksum(ip))
+		require.Equal(t, participantAddress[:], ip[12:16])
+		require.Equal(t, sfuAddress[:], ip[16:20])
+		require.EqualValues(t, rtpPort, binary.BigEndian.Uint16(epb[20+ipv4Size+2:]))
+		require.Equal(t, rtpBytes[:headerLength+4], epb[20+ipv4Size+udpSize:20+captured])
+
+		// RTCP is not truncated
+		epb = blocks[3].body
+		captured = int(binary.LittleEndian.Uint32(epb[12:]))
+		require.Equal(t, ipv4Size+udpSize+len(rtcpBytes), captured)
+		ip = epb[20 : 20+ipv4Size]
+		require.Equal(t, sfuAddress[:], ip[12:16])
+		require.Equal(t, rtcpBytes, epb[20+ipv4Size+udpSize:20+captured])
+	})
+
+	t.Run("full payload", func(t *testing.T) {
+		c, stopped := startCapture(t, Params{PayloadBytes: PayloadFull})
+		c.CaptureRTP(buffer.PacketDirectionOutbound, rtpBytes)
+		c.Stop()
+
+		res := waitForResult(t, stopped)
+		blocks := readBlocks(t, res.Filename)
+		require.Len(t, blocks, 3)
+		epb := blocks[2].body
+		require.EqualValues(t, ipv4Size+udpSize+len(rtpBytes), binary.LittleEndian.Uint32(epb[12:]))
+	})
+
+	t.Run("stops at max size", func(t *testing.T) {
+		c, stopped := startCapture(t, Params{MaxSize: 1000})
+		for i := 0; i < 100; i++ {
+			c.CaptureRTP(buffer.PacketDirectionInbound, rtpBytes)
+		}
+
+		res := waitForResult(t, stopped)
+		require.NoError(t, res.Error)
+		require.Equal(t, StopReasonSize, res.Reason)
+		require.NotZero(t, res.Packets)
+		require.Less(t, res.Packets, uint64(100))
+
+		// packets after stopping are ignored
+		c.CaptureRTP(buffer.PacketDirectionInbound, rtpBytes)
+		require.Equal(t, res, c.Result())
+	})
+
+	t.Run("stops after duration", func(t *testing.T) {
+		_, stopped := startCapture(t, Params{Duration: 50 * time.Millisecond})
+
+		res := waitForResult(t, stopped)
+		require.NoError(t, res.Error)
+		require.Equal(t, StopReasonDuration, res.Reason)
+		require.Len(t, readBlocks(t, res.Filename), 2)
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// pcapng blocks and options, https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-01.html
+const (
+	blockTypeSectionHeader        = 0x0a0d0d0a
+	blockTypeInterfaceDescription = 0x00000001
+	blockTypeEnhancedPacket       = 0x00000006
+
+	byteOrderMagic = 0x1a2b3c4d
+
+	optionEndOfOptions = 0
+	optionShbUserAppl  = 4
+	optionIfName       = 2
+	optionIfTsResol    = 9
+	optionEpbFlags     = 2
+
+	// packets start with an IPv4 header
+	linkTypeRaw = 101
+
+	// timestamps in nanoseconds
+	tsResolNanoseconds = 9
+
+	epbFlagsInbound  = 0x1
+	epbFlagsOutbound = 0x2
+)
+
+type pcapngOption struct {
+	code  uint16
+	value []byte
+}
+
+// pcapngWriter writes a section with a single interface, returning the number of bytes written by each call
+type pcapngWriter struct {
+	w io.Writer
+}
+
+func (p *pcapngWriter) writeHeader(application, interfaceName string) (int, error) {
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:], 1)
+	binary.LittleEndian.PutUint16(shb[6:], 0)
+	// section length is not known
+	binary.LittleEndian.PutUint64(shb[8:], ^uint64(0))
+	n, err := p.writeBlock(blockTypeSectionHeader, shb, []pcapngOption{
+		{code: optionShbUserAppl, value: []byte(application)},
+	})
+	if err != nil {
+		return n, err
+	}
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:], linkTypeRaw)
+	// no snap length, packets are truncated before writing
+	binary.LittleEndian.PutUint32(idb[4:], 0)
+	m, err := p.writeBlock(blockTypeInterfaceDescription, idb, []pcapngOption{
+		{code: optionIfName, value: []byte(interfaceName)},
+		{code: optionIfTsResol, value: []byte{tsResolNanoseconds}},
+	})
+	return n + m, err
+}
+
+// writePacket writes a packet of the interface, data may be truncated from originalLength
+func (p *pcapngWriter) writePacket(at time.Time, inbound bool, data []byte, originalLength int) (int, error) {
+	ts := uint64(at.UnixNano())
+	epb := make([]byte, 20, 20+len(data)+3)
+	binary.LittleEndian.PutUint32(epb[0:], 0)
+	binary.LittleEndian.PutUint32(epb[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(epb[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(epb[12:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(epb[16:], uint32(originalLength))
+	epb = append(epb, data...)
+	epb = append(epb, make([]byte, padding(len(data)))...)
+
+	flags := make([]byte, 4)
+	if inbound {
+		binary.LittleEndian.PutUint32(flags, epbFlagsInbound)
+	} else {
+		binary.LittleEndian.PutUint32(flags, epbFlagsOutbound)
+	}
+	return p.writeBlock(blockTypeEnhancedPacket, epb, []pcapngOption{{code: optionEpbFlags, value: flags}})
+}
+
+func (p *pcapngWriter) writeBlock(blockType uint32, body []byte, options []pcapngOption) (int, error) {
+	length := 12 + len(body)
+	if len(options) != 0 {
+		for _, o := range options {
+			length += 4 + len(o.value) + padding(len(o.value))
+		}
+		// end of options
+		length += 4
+	}
+
+	block := make([]byte, 0, length)
+	block = binary.LittleEndian.AppendUint32(block, blockType)
+	block = binary.LittleEndian.AppendUint32(block, uint32(length))
+	block = append(block, body...)
+	if len(options) != 0 {
+		for _, o := range options {
+			block = binary.LittleEndian.AppendUint16(block, o.code)
+			block = binary.LittleEndian.AppendUint16(block, uint16(len(o.value)))
+			block = append(block, o.value...)
+			block = append(block, make([]byte, padding(len(o.value)))...)
+		}
+		block = binary.LittleEndian.AppendUint16(block, optionEndOfOptions)
+		block = binary.LittleEndian.AppendUint16(block, 0)
+	}
+	block = binary.LittleEndian.AppendUint32(block, uint32(length))
+
+	return p.w.Write(block)
+}
+
+// padding to 32 bits
+func padding(length int) int {
+	return (4 - length%4) % 4
+}
@@ -0,0 +1,311 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	packetQueueSize = 1024
+	// interval of key frame requests, until the first one is received
+	keyFrameRequestInterval = time.Second
+)
+
+type Params struct {
+	// ID of the recording, also identifies the recorder as a subscriber of the receiver
+	ID       string
+	Receiver sfu.TrackReceiver
+	// file extension of the codec is added when the path has none
+	Path   string
+	Logger logger.Logger
+}
+
+// Result describes a finished recording
+type Result struct {
+	Filename  string
+	StartedAt time.Time
+	EndedAt   time.Time
+	Size      int64
+	Error     error
+}
+
+// TrackRecorder writes media of a track to a local file, without transcoding.
+// It is attached to the receiver of the track as a TrackSender, receiving the packets forwarded to subscribers.
+type TrackRecorder struct {
+	params   Params
+	logger   logger.Logger
+	mimeType string
+	isVideo  bool
+	isSVC    bool
+	filename string
+
+	writer mediaWriter
+
+	// spatial layer of simulcast tracks that is recorded, highest published one at the first key frame
+	layer               atomic.Int32
+	seenKeyFrame        atomic.Bool
+	lastKeyFrameRequest atomic.Int64
+
+	// guards sending to packets against closing it
+	packetsLock sync.RWMutex
+	packets     chan *rtp.Packet
+	dropped     atomic.Uint32
+	startedAt   time.Time
+	closed      atomic.Bool
+	done        chan struct{}
+	err         error
+
+	lock  sync.Mutex
+	onEnd func(Result)
+}
+
+func NewTrackRecorder(params Params) (*TrackRecorder, error) {
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+
+	codec := params.Receiver.Codec()
+	ext, err := FileExtension(codec.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	filename := params.Path
+	if filepath.Ext(filename) == "" {
+		filename += ext
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var width, height uint32
+	if ti := params.Receiver.TrackInfo(); ti != nil {
+		width, height = ti.Width, ti.Height
+	}
+	writer, err := newMediaWriter(codec.MimeType, width, height, file)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(filename)
+		return nil, err
+	}
+
+	r := &TrackRecorder{
+		params:   params,
+		logger:   params.Logger,
+		mimeType: codec.MimeType,
+		isVideo:  strings.HasPrefix(strings.ToLower(codec.MimeType), "video/"),
+		isSVC:    sfu.IsSvcCodec(codec.MimeType),
+		filename: filename,
+		writer:   writer,
+		packets:  make(chan *rtp.Packet, packetQueueSize),
+		done:     make(chan struct{}),
+	}
+	r.layer.Store(buffer.InvalidLayerSpatial)
+	return r, nil
+}
+
+func (r *TrackRecorder) Filename() string {
+	return r.filename
+}
+
+func (r *TrackRecorder) MimeType() string {
+	return r.mimeType
+}
+
+// OnEnd is called once the file is complete, after Stop or when the track is closed
+func (r *TrackRecorder) OnEnd(f func(Result)) {
+	r.lock.Lock()
+	r.onEnd = f
+	r.lock.Unlock()
+}
+
+// Start attaches the recorder to the receiver
+func (r *TrackRecorder) Start() error {
+	r.startedAt = time.Now()
+	go r.writeWorker()
+
+	if err := r.params.Receiver.AddDownTrack(r); err != nil {
+		r.Close()
+		return err
+	}
+	r.requestKeyFrame()
+	return nil
+}
+
+// Stop detaches the recorder from the receiver and completes the file
+func (r *TrackRecorder) Stop() {
+	r.params.Receiver.DeleteDownTrack(r.SubscriberID())
+	r.Close()
+}
+
+// TrackSender interface
+
+func (r *TrackRecorder) UpTrackLayersChange() {}
+
+func (r *TrackRecorder) UpTrackBitrateAvailabilityChange() {}
+
+func (r *TrackRecorder) UpTrackMaxPublishedLayerChange(maxPublishedLayer int32) {
+	// keep to a layer once recording, switching would need a key frame of the new layer
+	if !r.seenKeyFrame.Load() && maxPublishedLayer != buffer.InvalidLayerSpatial {
+		r.layer.Store(maxPublishedLayer)
+	}
+}
+
+func (r *TrackRecorder) UpTrackMaxTemporalLayerSeenChange(_ int32) {}
+
+func (r *TrackRecorder) UpTrackBitrateReport(_ []int32, _ sfu.Bitrates) {}
+
+func (r *TrackRecorder) WriteRTP(p *buffer.ExtPacket, layer int32) error {
+	if len(p.Packet.Payload) == 0 {
+		return nil
+	}
+
+	if r.isVideo {
+		// all layers of SVC codecs are in a single stream
+		if !r.isSVC && layer != r.layer.Load() {
+			return nil
+		}
+		if !r.seenKeyFrame.Load() {
+			if !p.KeyFrame {
+				r.requestKeyFrame()
+				return nil
+			}
+			r.seenKeyFrame.Store(true)
+			r.logger.Debugw("recording from key frame", "layer", layer)
+		}
+	}
+
+	r.packetsLock.RLock()
+	defer r.packetsLock.RUnlock()
+	if r.closed.Load() {
+		return nil
+	}
+	// packet buffers are reused by the receiver
+	select {
+	case r.packets <- p.Packet.Clone():
+	default:
+		r.dropped.Inc()
+	}
+	return nil
+}
+
+func (r *TrackRecorder) Close() {
+	r.packetsLock.Lock()
+	defer r.packetsLock.Unlock()
+	if r.closed.Swap(true) {
+		return
+	}
+	close(r.packets)
+}
+
+func (r *TrackRecorder) IsClosed() bool {
+	return r.closed.Load()
+}
+
+func (r *TrackRecorder) ID() string {
+	return r.params.ID
+}
+
+func (r *TrackRecorder) SubscriberID() livekit.ParticipantID {
+	return livekit.ParticipantID(r.params.ID)
+}
+
+func (r *TrackRecorder) TrackInfoAvailable() {}
+
+func (r *TrackRecorder) HandleRTCPSenderReportData(_ webrtc.PayloadType, _ bool, _ int32, _ *buffer.RTCPSenderReportData) error {
+	return nil
+}
+
+// ------------------------------------------------
+
+func (r *TrackRecorder) requestKeyFrame() {
+	if !r.isVideo {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := r.lastKeyFrameRequest.Load()
+	if now-last < int64(keyFrameRequestInterval) || !r.lastKeyFrameRequest.CompareAndSwap(last, now) {
+		return
+	}
+
+	layer := r.layer.Load()
+	if layer == buffer.InvalidLayerSpatial {
+		layer = 0
+	}
+	r.params.Receiver.SendPLI(layer, true)
+}
+
+func (r *TrackRecorder) writeWorker() {
+	defer r.finish()
+
+	for pkt := range r.packets {
+		if r.err != nil {
+			continue
+		}
+		if err := r.writer.WriteRTP(pkt); err != nil {
+			r.logger.Warnw("could not write packet, stopping recording", err)
+			r.err = err
+			r.Stop()
+		}
+	}
+}
+
+func (r *TrackRecorder) finish() {
+	if err := r.writer.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+
+	res := Result{
+		Filename:  r.filename,
+		StartedAt: r.startedAt,
+		EndedAt:   time.Now(),
+		Error:     r.err,
+	}
+	if info, err := os.Stat(r.filename); err == nil {
+		res.Size = info.Size()
+	}
+	r.logger.Infow("recording ended", "filename", r.filename, "size", res.Size, "dropped", r.dropped.Load())
+	close(r.done)
+
+	r.lock.Lock()
+	onEnd := r.onEnd
+	r.lock.Unlock()
+	if onEnd != nil {
+		onEnd(res)
+	}
+}
+
+// Done is closed once the file is complete
+func (r *TrackRecorder) Done() <-chan struct{} {
+	return r.done
+}
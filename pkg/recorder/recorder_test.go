@@ -0,0 +1,205 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media/ivfreader"
+	"github.com/pion/webrtc/v3/pkg/media/oggreader"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type testReceiver struct {
+	sfu.TrackReceiver
+
+	codec      webrtc.RTPCodecParameters
+	downTracks map[livekit.ParticipantID]sfu.TrackSender
+	plis       int
+}
+
+func newTestReceiver(mimeType string) *testReceiver {
+	return &testReceiver{
+		codec:      webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: mimeType}},
+		downTracks: make(map[livekit.ParticipantID]sfu.TrackSender),
+	}
+}
+
+func (r *testReceiver) Codec() webrtc.RTPCodecParameters { return r.codec }
+
+func (r *testReceiver) TrackInfo() *livekit.TrackInfo {
+	return &livekit.TrackInfo{Width: 320, Height: 180}
+}
+
+func (r *testReceiver) AddDownTrack(track sfu.TrackSender) error {
+	r.downTracks[track.SubscriberID()] = track
+	track.UpTrackMaxPublishedLayerChange(0)
+	return nil
+}
+
+func (r *testReceiver) DeleteDownTrack(subscriberID livekit.ParticipantID) {
+	delete(r.downTracks, subscriberID)
+}
+
+func (r *testReceiver) SendPLI(_ int32, _ bool) { r.plis++ }
+
+func startRecorder(t *testing.T, receiver *testReceiver) (*TrackRecorder, chan Result) {
+	rec, err := NewTrackRecorder(Params{
+		ID:       "EG_test",
+		Receiver: receiver,
+		Path:     filepath.Join(t.TempDir(), "recordings", "track"),
+	})
+	require.NoError(t, err)
+
+	ended := make(chan Result, 1)
+	rec.OnEnd(func(res Result) {
+		ended <- res
+	})
+	require.NoError(t, rec.Start())
+	require.Contains(t, receiver.downTracks, livekit.ParticipantID("EG_test"))
+	return rec, ended
+}
+
+func waitForResult(t *testing.T, ended chan Result) Result {
+	select {
+	case res := <-ended:
+		return res
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "recording did not end")
+		return Result{}
+	}
+}
+
+func TestTrackRecorder(t *testing.T) {
+	t.Run("opus", func(t *testing.T) {
+		receiver := newTestReceiver(webrtc.MimeTypeOpus)
+		rec, ended := startRecorder(t, receiver)
+		require.Equal(t, ".ogg", filepath.Ext(rec.Filename()))
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, rec.WriteRTP(&buffer.ExtPacket{
+				Packet: &rtp.Packet{
+					Header:  rtp.Header{SequenceNumber: uint16(i), Timestamp: uint32(i * 960)},
+					Payload: []byte{0xfc, 0xff, 0xfe},
+				},
+			}, 0))
+		}
+		rec.Stop()
+		require.Empty(t, receiver.downTracks)
+
+		res := waitForResult(t, ended)
+		require.NoError(t, res.Error)
+		require.Equal(t, rec.Filename(), res.Filename)
+
+		file, err := os.Open(res.Filename)
+		require.NoError(t, err)
+		defer file.Close()
+		info, err := file.Stat()
+		require.NoError(t, err)
+		require.Equal(t, info.Size(), res.Size)
+		ogg, header, err := oggreader.NewWith(file)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, header.Channels)
+
+		pages := 0
+		for {
+			_, _, err = ogg.ParseNextPage()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			pages++
+		}
+		// comment header and a page per packet
+		require.Equal(t, 11, pages)
+	})
+
+	t.Run("vp8 starts at key frame", func(t *testing.T) {
+		receiver := newTestReceiver(webrtc.MimeTypeVP8)
+		rec, ended := startRecorder(t, receiver)
+		require.Equal(t, ".ivf", filepath.Ext(rec.Filename()))
+		require.Equal(t, 1, receiver.plis)
+
+		sn := uint16(0)
+		writeFrame := func(ts uint32, keyFrame bool, layer int32) {
+			// single packet frames, with the start of partition bit set
+			payload := []byte{0x10, 0x01, 0x02, 0x03}
+			if keyFrame {
+				payload = []byte{0x10, 0x00, 0x02, 0x03}
+			}
+			require.NoError(t, rec.WriteRTP(&buffer.ExtPacket{
+				Packet: &rtp.Packet{
+					Header:  rtp.Header{SequenceNumber: sn, Timestamp: ts, Marker: true},
+					Payload: payload,
+				},
+				KeyFrame: keyFrame,
+			}, layer))
+			sn++
+		}
+		writeFrame(0, false, 0)
+		writeFrame(1000, true, 1)
+		writeFrame(3000, true, 0)
+		writeFrame(6000, false, 0)
+		writeFrame(9000, false, 0)
+		writeFrame(12000, false, 0)
+		rec.Stop()
+
+		res := waitForResult(t, ended)
+		require.NoError(t, res.Error)
+
+		file, err := os.Open(res.Filename)
+		require.NoError(t, err)
+		defer file.Close()
+		ivf, header, err := ivfreader.NewWith(file)
+		require.NoError(t, err)
+		require.Equal(t, "VP80", header.FourCC)
+		require.EqualValues(t, 320, header.Width)
+		require.EqualValues(t, 90000, header.TimebaseDenominator)
+
+		var timestamps []uint64
+		for {
+			_, frameHeader, err := ivf.ParseNextFrame()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			timestamps = append(timestamps, frameHeader.Timestamp)
+		}
+		// frames before the key frame and of other layers are not recorded,
+		// frames at the end stay in the sample builder, waiting for later packets
+		require.Equal(t, []uint64{0, 3000, 6000}, timestamps)
+	})
+
+	t.Run("unsupported codec", func(t *testing.T) {
+		_, err := NewTrackRecorder(Params{
+			ID:       "EG_test",
+			Receiver: newTestReceiver("audio/G722"),
+			Path:     filepath.Join(t.TempDir(), "track"),
+		})
+		require.ErrorIs(t, err, ErrUnsupportedCodec)
+	})
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+	"github.com/pion/rtp/pkg/frame"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media/h264writer"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"github.com/pion/webrtc/v3/pkg/media/samplebuilder"
+)
+
+var ErrUnsupportedCodec = errors.New("unsupported codec, expected Opus, VP8, VP9, AV1 or H.264")
+
+const (
+	opusSampleRate   = 48000
+	opusChannelCount = 2
+
+	videoClockRate = 90000
+	// packets a frame may be late by, before it's dropped
+	maxLatePackets = 256
+
+	ivfHeaderSize      = 32
+	ivfFrameHeaderSize = 12
+)
+
+// mediaWriter writes RTP packets of a track to a container
+type mediaWriter interface {
+	WriteRTP(pkt *rtp.Packet) error
+	Close() error
+}
+
+// FileExtension returns the extension of files a codec is recorded to
+func FileExtension(mimeType string) (string, error) {
+	switch strings.ToLower(mimeType) {
+	case strings.ToLower(webrtc.MimeTypeOpus):
+		return ".ogg", nil
+	case strings.ToLower(webrtc.MimeTypeVP8),
+		strings.ToLower(webrtc.MimeTypeVP9),
+		strings.ToLower(webrtc.MimeTypeAV1):
+		return ".ivf", nil
+	case strings.ToLower(webrtc.MimeTypeH264):
+		return ".h264", nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCodec, mimeType)
+	}
+}
+
+func newMediaWriter(mimeType string, width, height uint32, out io.WriteCloser) (mediaWriter, error) {
+	switch strings.ToLower(mimeType) {
+	case strings.ToLower(webrtc.MimeTypeOpus):
+		return oggwriter.NewWith(out, opusSampleRate, opusChannelCount)
+	case strings.ToLower(webrtc.MimeTypeVP8):
+		return newIVFWriter(out, "VP80", width, height, &codecs.VP8Packet{})
+	case strings.ToLower(webrtc.MimeTypeVP9):
+		return newIVFWriter(out, "VP90", width, height, &codecs.VP9Packet{})
+	case strings.ToLower(webrtc.MimeTypeAV1):
+		return newIVFWriter(out, "AV01", width, height, nil)
+	case strings.ToLower(webrtc.MimeTypeH264):
+		// Annex-B byte stream
+		return h264writer.NewWith(out), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCodec, mimeType)
+	}
+}
+
+// ivfWriter writes VP8, VP9 or AV1 frames with their RTP timestamps, so variable frame rates play back in time
+type ivfWriter struct {
+	out    io.WriteCloser
+	frames uint32
+
+	// VP8 and VP9 frames are assembled by a sample builder, AV1 OBUs as packets arrive
+	builder  *samplebuilder.SampleBuilder
+	av1Frame frame.AV1
+
+	firstTS   uint32
+	seenFirst bool
+}
+
+func newIVFWriter(out io.WriteCloser, fourCC string, width, height uint32, depacketizer rtp.Depacketizer) (*ivfWriter, error) {
+	w := &ivfWriter{out: out}
+	if depacketizer != nil {
+		w.builder = samplebuilder.New(maxLatePackets, depacketizer, videoClockRate)
+	}
+
+	header := make([]byte, ivfHeaderSize)
+	copy(header[0:], "DKIF")
+	binary.LittleEndian.PutUint16(header[6:], ivfHeaderSize)
+	copy(header[8:], fourCC)
+	binary.LittleEndian.PutUint16(header[12:], uint16(width))
+	binary.LittleEndian.PutUint16(header[14:], uint16(height))
+	// timebase of 1/90000, timestamps are RTP timestamps
+	binary.LittleEndian.PutUint32(header[16:], videoClockRate)
+	binary.LittleEndian.PutUint32(header[20:], 1)
+	if _, err := out.Write(header); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *ivfWriter) WriteRTP(pkt *rtp.Packet) error {
+	if w.builder == nil {
+		av1Packet := &codecs.AV1Packet{}
+		if _, err := av1Packet.Unmarshal(pkt.Payload); err != nil {
+			return err
+		}
+		obus, err := w.av1Frame.ReadFrames(av1Packet)
+		if err != nil {
+			return err
+		}
+		for _, obu := range obus {
+			if err = w.writeFrame(obu, pkt.Timestamp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w.builder.Push(pkt)
+	for {
+		sample, ts := w.builder.PopWithTimestamp()
+		if sample == nil {
+			return nil
+		}
+		if err := w.writeFrame(sample.Data, ts); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *ivfWriter) writeFrame(data []byte, ts uint32) error {
+	if !w.seenFirst {
+		w.firstTS, w.seenFirst = ts, true
+	}
+
+	header := make([]byte, ivfFrameHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], uint32(len(data)))
+	binary.LittleEndian.PutUint64(header[4:], uint64(ts-w.firstTS))
+	if _, err := w.out.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.out.Write(data); err != nil {
+		return err
+	}
+	w.frames++
+	return nil
+}
+
+func (w *ivfWriter) Close() error {
+	// update frame count of the header when possible
+	if ws, ok := w.out.(io.WriteSeeker); ok {
+		count := make([]byte, 4)
+		binary.LittleEndian.PutUint32(count, w.frames)
+		if _, err := ws.Seek(24, io.SeekStart); err == nil {
+			_, _ = ws.Write(count)
+		}
+	}
+	return w.out.Close()
+}
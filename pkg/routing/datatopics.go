@@ -0,0 +1,58 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"strings"
+)
+
+// DataTopicGrant limits topics of data packets a participant can publish and receive.
+// It's set with the dataTopics claim of the access token, e.g.
+//
+//	"dataTopics": {"canPublish": ["chat"], "canSubscribe": ["chat", "cursor.*"]}
+//
+// Patterns ending with * match topics by prefix. A missing list doesn't restrict topics,
+// an empty list allows none. Publishing data without a topic is allowed only when publishing isn't restricted.
+type DataTopicGrant struct {
+	CanPublish   []string `json:"canPublish"`
+	CanSubscribe []string `json:"canSubscribe"`
+}
+
+func (g *DataTopicGrant) AllowsPublish(topic string) bool {
+	if g == nil || g.CanPublish == nil {
+		return true
+	}
+	return topic != "" && matchesDataTopic(g.CanPublish, topic)
+}
+
+func (g *DataTopicGrant) AllowsSubscribe(topic string) bool {
+	if g == nil || g.CanSubscribe == nil {
+		return true
+	}
+	return matchesDataTopic(g.CanSubscribe, topic)
+}
+
+func matchesDataTopic(patterns []string, topic string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(topic, prefix) {
+				return true
+			}
+		} else if pattern == topic {
+			return true
+		}
+	}
+	return false
+}
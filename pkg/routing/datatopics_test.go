@@ -0,0 +1,80 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+)
+
+func TestDataTopicGrant(t *testing.T) {
+	t.Run("no restrictions", func(t *testing.T) {
+		var g *DataTopicGrant
+		require.True(t, g.AllowsPublish(""))
+		require.True(t, g.AllowsPublish("chat"))
+		require.True(t, g.AllowsSubscribe("chat"))
+
+		g = &DataTopicGrant{}
+		require.True(t, g.AllowsPublish("chat"))
+		require.True(t, g.AllowsSubscribe("chat"))
+	})
+
+	t.Run("patterns", func(t *testing.T) {
+		g := &DataTopicGrant{
+			CanPublish:   []string{"chat"},
+			CanSubscribe: []string{"chat", "cursor.*"},
+		}
+		require.True(t, g.AllowsPublish("chat"))
+		require.False(t, g.AllowsPublish("chat2"))
+		require.False(t, g.AllowsPublish(""))
+		require.True(t, g.AllowsSubscribe("cursor.p1"))
+		require.False(t, g.AllowsSubscribe("telemetry"))
+	})
+
+	t.Run("empty lists allow none", func(t *testing.T) {
+		g := &DataTopicGrant{CanPublish: []string{}, CanSubscribe: []string{}}
+		require.False(t, g.AllowsPublish("chat"))
+		require.False(t, g.AllowsSubscribe("chat"))
+	})
+
+	t.Run("carried in start session", func(t *testing.T) {
+		pi := &ParticipantInit{
+			Identity: "p1",
+			Grants: &auth.ClaimGrants{
+				Name:  "name",
+				Video: &auth.VideoGrant{RoomJoin: true, Room: "room"},
+			},
+			DataTopics: &DataTopicGrant{CanPublish: []string{}, CanSubscribe: []string{"chat"}},
+		}
+		ss, err := pi.ToStartSession("room", "connection")
+		require.NoError(t, err)
+
+		decoded, err := ParticipantInitFromStartSession(ss, "region")
+		require.NoError(t, err)
+		require.Equal(t, pi.Grants.Name, decoded.Grants.Name)
+		require.Equal(t, pi.Grants.Video, decoded.Grants.Video)
+		require.Equal(t, pi.DataTopics, decoded.DataTopics)
+
+		pi.DataTopics = nil
+		ss, err = pi.ToStartSession("room", "connection")
+		require.NoError(t, err)
+		decoded, err = ParticipantInitFromStartSession(ss, "region")
+		require.NoError(t, err)
+		require.Nil(t, decoded.DataTopics)
+	})
+}
@@ -61,6 +61,17 @@ type ParticipantInit struct {
 	AdaptiveStream       bool
 	ID                   livekit.ParticipantID
 	SubscriberAllowPause *bool
+	DataTopics           *DataTopicGrant
+	PublishBitrate       *PublishBitrateGrant
+	// not part of StartSession, only for sessions started on the node hosting the room
+	ReceiveOnly bool
+}
+
+// grants of StartSession, extended with claims not part of auth.ClaimGrants
+type startSessionGrants struct {
+	*auth.ClaimGrants
+	DataTopics     *DataTopicGrant      `json:"dataTopics,omitempty"`
+	PublishBitrate *PublishBitrateGrant `json:"publishBitrate,omitempty"`
 }
 
 type NewParticipantCallback func(
@@ -89,11 +100,18 @@ type Router interface {
 	RemoveDeadNodes() error
 
 	ListNodes() ([]*livekit.Node, error)
+	// ListNodeLabels returns labels registered by each node, keyed by node ID
+	ListNodeLabels() (map[livekit.NodeID]map[string]string, error)
 
 	GetNodeForRoom(ctx context.Context, roomName livekit.RoomName) (*livekit.Node, error)
 	SetNodeForRoom(ctx context.Context, roomName livekit.RoomName, nodeId livekit.NodeID) error
 	ClearRoomState(ctx context.Context, roomName livekit.RoomName) error
 
+	// nodes hosting a distributed room, used when relay is enabled
+	AddNodeForRoom(ctx context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error
+	RemoveNodeForRoom(ctx context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error
+	ListNodesForRoom(ctx context.Context, roomName livekit.RoomName) ([]livekit.NodeID, error)
+
 	GetRegion() string
 
 	Start() error
@@ -105,6 +123,11 @@ type Router interface {
 
 	// OnRTCMessage is called to execute actions on the RTC node
 	OnRTCMessage(callback RTCMessageCallback)
+
+	// WriteRelayMessage sends a message to another node hosting the same distributed room
+	WriteRelayMessage(ctx context.Context, nodeID livekit.NodeID, msg *RelayMessage) error
+	// OnRelayMessage is called with messages from other nodes hosting the same distributed room
+	OnRelayMessage(callback RelayMessageCallback)
 }
 
 type MessageRouter interface {
@@ -117,7 +140,7 @@ type MessageRouter interface {
 }
 
 func CreateRouter(config *config.Config, rc redis.UniversalClient, node LocalNode, signalClient SignalClient) Router {
-	lr := NewLocalRouter(node, signalClient)
+	lr := NewLocalRouter(node, config.NodeLabels, signalClient)
 
 	if rc != nil {
 		return NewRedisRouter(config, lr, rc)
@@ -129,7 +152,11 @@ func CreateRouter(config *config.Config, rc redis.UniversalClient, node LocalNod
 }
 
 func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionID livekit.ConnectionID) (*livekit.StartSession, error) {
-	claims, err := json.Marshal(pi.Grants)
+	claims, err := json.Marshal(startSessionGrants{
+		ClaimGrants:    pi.Grants,
+		DataTopics:     pi.DataTopics,
+		PublishBitrate: pi.PublishBitrate,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +185,8 @@ func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionI
 
 func ParticipantInitFromStartSession(ss *livekit.StartSession, region string) (*ParticipantInit, error) {
 	claims := &auth.ClaimGrants{}
-	if err := json.Unmarshal([]byte(ss.GrantsJson), claims); err != nil {
+	grants := startSessionGrants{ClaimGrants: claims}
+	if err := json.Unmarshal([]byte(ss.GrantsJson), &grants); err != nil {
 		return nil, err
 	}
 
@@ -173,6 +201,8 @@ func ParticipantInitFromStartSession(ss *livekit.StartSession, region string) (*
 		Region:          region,
 		AdaptiveStream:  ss.AdaptiveStream,
 		ID:              livekit.ParticipantID(ss.ParticipantId),
+		DataTopics:      grants.DataTopics,
+		PublishBitrate:  grants.PublishBitrate,
 	}
 	if ss.SubscriberAllowPause != nil {
 		subscriberAllowPause := *ss.SubscriberAllowPause
@@ -32,6 +32,7 @@ const localRTCChannelSize = 10000
 // a router of messages on the same node, basic implementation for local testing
 type LocalRouter struct {
 	currentNode  LocalNode
+	nodeLabels   map[string]string
 	signalClient SignalClient
 
 	lock sync.RWMutex
@@ -44,11 +45,13 @@ type LocalRouter struct {
 
 	onNewParticipant NewParticipantCallback
 	onRTCMessage     RTCMessageCallback
+	onRelayMessage   RelayMessageCallback
 }
 
-func NewLocalRouter(currentNode LocalNode, signalClient SignalClient) *LocalRouter {
+func NewLocalRouter(currentNode LocalNode, nodeLabels map[string]string, signalClient SignalClient) *LocalRouter {
 	return &LocalRouter{
 		currentNode:      currentNode,
+		nodeLabels:       nodeLabels,
 		signalClient:     signalClient,
 		requestChannels:  make(map[string]*MessageChannel),
 		responseChannels: make(map[string]*MessageChannel),
@@ -72,6 +75,18 @@ func (r *LocalRouter) ClearRoomState(_ context.Context, _ livekit.RoomName) erro
 	return nil
 }
 
+func (r *LocalRouter) AddNodeForRoom(_ context.Context, _ livekit.RoomName, _ livekit.NodeID) error {
+	return nil
+}
+
+func (r *LocalRouter) RemoveNodeForRoom(_ context.Context, _ livekit.RoomName, _ livekit.NodeID) error {
+	return nil
+}
+
+func (r *LocalRouter) ListNodesForRoom(_ context.Context, _ livekit.RoomName) ([]livekit.NodeID, error) {
+	return []livekit.NodeID{livekit.NodeID(r.currentNode.Id)}, nil
+}
+
 func (r *LocalRouter) RegisterNode() error {
 	return nil
 }
@@ -97,6 +112,12 @@ func (r *LocalRouter) ListNodes() ([]*livekit.Node, error) {
 	}, nil
 }
 
+func (r *LocalRouter) ListNodeLabels() (map[livekit.NodeID]map[string]string, error) {
+	return map[livekit.NodeID]map[string]string{
+		livekit.NodeID(r.currentNode.Id): r.nodeLabels,
+	}, nil
+}
+
 func (r *LocalRouter) StartParticipantSignal(ctx context.Context, roomName livekit.RoomName, pi ParticipantInit) (connectionID livekit.ConnectionID, reqSink MessageSink, resSource MessageSource, err error) {
 	return r.StartParticipantSignalWithNodeID(ctx, roomName, pi, livekit.NodeID(r.currentNode.Id))
 }
@@ -155,6 +176,15 @@ func (r *LocalRouter) OnRTCMessage(callback RTCMessageCallback) {
 	r.onRTCMessage = callback
 }
 
+func (r *LocalRouter) WriteRelayMessage(_ context.Context, _ livekit.NodeID, _ *RelayMessage) error {
+	// rooms never span nodes with local routing
+	return ErrNodeNotFound
+}
+
+func (r *LocalRouter) OnRelayMessage(callback RelayMessageCallback) {
+	r.onRelayMessage = callback
+}
+
 func (r *LocalRouter) Start() error {
 	if r.isStarted.Swap(true) {
 		return nil
@@ -0,0 +1,41 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+// PublishBitrateGrant caps upstream video bitrate of a participant, in bits per second.
+// It's set with the publishBitrate claim of the access token, e.g.
+//
+//	"publishBitrate": {"maxTrackBitrate": 1500000, "maxBitrate": 2500000}
+//
+// maxTrackBitrate applies to each video track, maxBitrate is split evenly between video tracks.
+// 0 or a missing field doesn't cap. Caps configured for rooms still apply, the lowest cap wins.
+type PublishBitrateGrant struct {
+	MaxTrackBitrate int64 `json:"maxTrackBitrate,omitempty"`
+	MaxBitrate      int64 `json:"maxBitrate,omitempty"`
+}
+
+func (g *PublishBitrateGrant) GetMaxTrackBitrate() int64 {
+	if g == nil {
+		return 0
+	}
+	return g.MaxTrackBitrate
+}
+
+func (g *PublishBitrateGrant) GetMaxBitrate() int64 {
+	if g == nil {
+		return 0
+	}
+	return g.MaxBitrate
+}
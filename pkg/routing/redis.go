@@ -30,6 +30,9 @@ const (
 
 	// hash of room_name => node_id
 	NodeRoomKey = "room_node_map"
+
+	// hash of node_id => json encoded node labels
+	NodeLabelsKey = "node_labels"
 )
 
 var redisCtx = context.Background()
@@ -44,6 +47,15 @@ func participantSignalKey(connectionID livekit.ConnectionID) string {
 	return "participant_signal:" + string(connectionID)
 }
 
+// set of node_ids hosting a distributed room
+func roomNodesKey(roomName livekit.RoomName) string {
+	return "room_nodes:" + string(roomName)
+}
+
+func relayNodeChannel(nodeID livekit.NodeID) string {
+	return "relay_channel:" + string(nodeID)
+}
+
 func rtcNodeChannel(nodeID livekit.NodeID) string {
 	return "rtc_channel:" + string(nodeID)
 }
@@ -17,6 +17,7 @@ package routing
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"runtime/pprof"
 	"sync"
 	"time"
@@ -50,6 +51,7 @@ type RedisRouter struct {
 
 	rc             redis.UniversalClient
 	usePSRPCSignal bool
+	relayEnabled   bool
 	ctx            context.Context
 	isStarted      atomic.Bool
 	nodeMu         sync.RWMutex
@@ -65,6 +67,7 @@ func NewRedisRouter(config *config.Config, lr *LocalRouter, rc redis.UniversalCl
 		LocalRouter:    lr,
 		rc:             rc,
 		usePSRPCSignal: config.SignalRelay.Enabled,
+		relayEnabled:   config.Relay.Enabled,
 	}
 	rr.ctx, rr.cancel = context.WithCancel(context.Background())
 	return rr
@@ -80,11 +83,24 @@ func (r *RedisRouter) RegisterNode() error {
 	if err := r.rc.HSet(r.ctx, NodesKey, r.currentNode.Id, data).Err(); err != nil {
 		return errors.Wrap(err, "could not register node")
 	}
+
+	if len(r.nodeLabels) != 0 {
+		labels, err := json.Marshal(r.nodeLabels)
+		if err != nil {
+			return err
+		}
+		if err := r.rc.HSet(r.ctx, NodeLabelsKey, r.currentNode.Id, labels).Err(); err != nil {
+			return errors.Wrap(err, "could not register node labels")
+		}
+	}
 	return nil
 }
 
 func (r *RedisRouter) UnregisterNode() error {
 	// could be called after Stop(), so we'd want to use an unrelated context
+	if err := r.rc.HDel(context.Background(), NodeLabelsKey, r.currentNode.Id).Err(); err != nil {
+		return err
+	}
 	return r.rc.HDel(context.Background(), NodesKey, r.currentNode.Id).Err()
 }
 
@@ -98,6 +114,9 @@ func (r *RedisRouter) RemoveDeadNodes() error {
 			if err := r.rc.HDel(context.Background(), NodesKey, n.Id).Err(); err != nil {
 				return err
 			}
+			if err := r.rc.HDel(context.Background(), NodeLabelsKey, n.Id).Err(); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -122,9 +141,40 @@ func (r *RedisRouter) ClearRoomState(_ context.Context, roomName livekit.RoomNam
 	if err := r.rc.HDel(context.Background(), NodeRoomKey, string(roomName)).Err(); err != nil {
 		return errors.Wrap(err, "could not clear room state")
 	}
+	if err := r.rc.Del(context.Background(), roomNodesKey(roomName)).Err(); err != nil {
+		return errors.Wrap(err, "could not clear room state")
+	}
 	return nil
 }
 
+func (r *RedisRouter) AddNodeForRoom(_ context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
+	return r.rc.SAdd(r.ctx, roomNodesKey(roomName), string(nodeID)).Err()
+}
+
+func (r *RedisRouter) RemoveNodeForRoom(_ context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
+	// could be called after Stop(), so we'd want to use an unrelated context
+	return r.rc.SRem(context.Background(), roomNodesKey(roomName), string(nodeID)).Err()
+}
+
+func (r *RedisRouter) ListNodesForRoom(_ context.Context, roomName livekit.RoomName) ([]livekit.NodeID, error) {
+	items, err := r.rc.SMembers(r.ctx, roomNodesKey(roomName)).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list nodes for room")
+	}
+	return livekit.StringsAsIDs[livekit.NodeID](items), nil
+}
+
+// GetRTCNodeForRoom returns the node that should host participants of the room connecting through the current node.
+// With relay enabled, rooms span multiple nodes and participants are hosted by the node they connected to.
+func (r *RedisRouter) GetRTCNodeForRoom(ctx context.Context, roomName livekit.RoomName) (*livekit.Node, error) {
+	if r.relayEnabled {
+		r.nodeMu.RLock()
+		defer r.nodeMu.RUnlock()
+		return proto.Clone((*livekit.Node)(r.currentNode)).(*livekit.Node), nil
+	}
+	return r.GetNodeForRoom(ctx, roomName)
+}
+
 func (r *RedisRouter) GetNode(nodeID livekit.NodeID) (*livekit.Node, error) {
 	data, err := r.rc.HGet(r.ctx, NodesKey, string(nodeID)).Result()
 	if err == redis.Nil {
@@ -155,10 +205,26 @@ func (r *RedisRouter) ListNodes() ([]*livekit.Node, error) {
 	return nodes, nil
 }
 
+func (r *RedisRouter) ListNodeLabels() (map[livekit.NodeID]map[string]string, error) {
+	items, err := r.rc.HGetAll(r.ctx, NodeLabelsKey).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list node labels")
+	}
+	nodeLabels := make(map[livekit.NodeID]map[string]string, len(items))
+	for nodeID, item := range items {
+		labels := make(map[string]string)
+		if err := json.Unmarshal([]byte(item), &labels); err != nil {
+			return nil, err
+		}
+		nodeLabels[livekit.NodeID(nodeID)] = labels
+	}
+	return nodeLabels, nil
+}
+
 // StartParticipantSignal signal connection sets up paths to the RTC node, and starts to route messages to that message queue
 func (r *RedisRouter) StartParticipantSignal(ctx context.Context, roomName livekit.RoomName, pi ParticipantInit) (connectionID livekit.ConnectionID, reqSink MessageSink, resSource MessageSource, err error) {
 	// find the node where the room is hosted at
-	rtcNode, err := r.GetNodeForRoom(ctx, roomName)
+	rtcNode, err := r.GetRTCNodeForRoom(ctx, roomName)
 	if err != nil {
 		return
 	}
@@ -219,12 +285,29 @@ func (r *RedisRouter) WriteParticipantRTC(_ context.Context, roomName livekit.Ro
 }
 
 func (r *RedisRouter) WriteRoomRTC(ctx context.Context, roomName livekit.RoomName, msg *livekit.RTCNodeMessage) error {
+	msg.ParticipantKey = string(ParticipantKeyLegacy(roomName, ""))
+	msg.ParticipantKeyB62 = string(ParticipantKey(roomName, ""))
+
+	if r.relayEnabled {
+		// distributed rooms need to be updated on every node hosting them
+		nodeIDs, err := r.ListNodesForRoom(ctx, roomName)
+		if err != nil {
+			return err
+		}
+		if len(nodeIDs) != 0 {
+			for _, nodeID := range nodeIDs {
+				if err := r.WriteNodeRTC(ctx, string(nodeID), proto.Clone(msg).(*livekit.RTCNodeMessage)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
 	node, err := r.GetNodeForRoom(ctx, roomName)
 	if err != nil {
 		return err
 	}
-	msg.ParticipantKey = string(ParticipantKeyLegacy(roomName, ""))
-	msg.ParticipantKeyB62 = string(ParticipantKey(roomName, ""))
 	return r.WriteNodeRTC(ctx, node.Id, msg)
 }
 
@@ -236,7 +319,7 @@ func (r *RedisRouter) WriteNodeRTC(_ context.Context, rtcNodeID string, msg *liv
 func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantKey livekit.ParticipantKey, participantKeyB62 livekit.ParticipantKey) error {
 	prometheus.IncrementParticipantRtcInit(1)
 	// find the node where the room is hosted at
-	rtcNode, err := r.GetNodeForRoom(r.ctx, livekit.RoomName(ss.RoomName))
+	rtcNode, err := r.GetRTCNodeForRoom(r.ctx, livekit.RoomName(ss.RoomName))
 	if err != nil {
 		return err
 	}
@@ -311,6 +394,14 @@ func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantK
 	return nil
 }
 
+func (r *RedisRouter) WriteRelayMessage(_ context.Context, nodeID livekit.NodeID, msg *RelayMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return r.rc.Publish(r.ctx, relayNodeChannel(nodeID), data).Err()
+}
+
 func (r *RedisRouter) Start() error {
 	if r.isStarted.Swap(true) {
 		return nil
@@ -442,7 +533,8 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 
 	sigChannel := signalNodeChannel(livekit.NodeID(r.currentNode.Id))
 	rtcChannel := rtcNodeChannel(livekit.NodeID(r.currentNode.Id))
-	r.pubsub = r.rc.Subscribe(r.ctx, sigChannel, rtcChannel)
+	relayChannel := relayNodeChannel(livekit.NodeID(r.currentNode.Id))
+	r.pubsub = r.rc.Subscribe(r.ctx, sigChannel, rtcChannel, relayChannel)
 
 	close(startedChan)
 	for msg := range r.pubsub.Channel() {
@@ -476,6 +568,17 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 				continue
 			}
 			prometheus.MessageCounter.WithLabelValues("rtc", "success").Add(1)
+		} else if msg.Channel == relayChannel {
+			rm := RelayMessage{}
+			if err := json.Unmarshal([]byte(msg.Payload), &rm); err != nil {
+				logger.Errorw("could not unmarshal relay message on relaychan", err)
+				prometheus.MessageCounter.WithLabelValues("relay", "failure").Add(1)
+				continue
+			}
+			if r.onRelayMessage != nil {
+				r.onRelayMessage(&rm)
+			}
+			prometheus.MessageCounter.WithLabelValues("relay", "success").Add(1)
 		}
 	}
 }
@@ -0,0 +1,57 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"github.com/livekit/protocol/livekit"
+)
+
+type RelayMessageType string
+
+const (
+	// a node started hosting the room
+	RelayMessageJoin RelayMessageType = "join"
+	// a node stopped hosting the room
+	RelayMessageLeave RelayMessageType = "leave"
+	// WebRTC negotiation of the relay transport between two nodes
+	RelayMessageOffer     RelayMessageType = "offer"
+	RelayMessageAnswer    RelayMessageType = "answer"
+	RelayMessageCandidate RelayMessageType = "candidate"
+	// state of a participant hosted by the sending node, including when it has left
+	RelayMessageParticipant RelayMessageType = "participant"
+	// subscription permissions of a participant hosted by the sending node, sent ahead of its state
+	RelayMessagePermission RelayMessageType = "permission"
+	// max subscribed qualities of relayed tracks on the sending node
+	RelayMessageMaxQuality RelayMessageType = "max_quality"
+	// data packet published by a participant hosted by the sending node
+	RelayMessageData RelayMessageType = "data"
+	// active speakers among participants hosted by the sending node, sent when they change
+	RelayMessageSpeakers RelayMessageType = "speakers"
+)
+
+// RelayMessage is exchanged between nodes hosting the same distributed room
+type RelayMessage struct {
+	Type     RelayMessageType `json:"type"`
+	RoomName livekit.RoomName `json:"room"`
+	// node sending the message
+	NodeID livekit.NodeID `json:"node"`
+	// set on negotiation messages sent by the side forwarding media
+	FromSender bool `json:"from_sender,omitempty"`
+	// identifies the relay transport negotiation messages belong to
+	Session string `json:"session,omitempty"`
+	Payload []byte `json:"payload,omitempty"`
+}
+
+type RelayMessageCallback func(msg *RelayMessage)
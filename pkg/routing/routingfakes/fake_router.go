@@ -10,6 +10,19 @@ import (
 )
 
 type FakeRouter struct {
+	AddNodeForRoomStub        func(context.Context, livekit.RoomName, livekit.NodeID) error
+	addNodeForRoomMutex       sync.RWMutex
+	addNodeForRoomArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.NodeID
+	}
+	addNodeForRoomReturns struct {
+		result1 error
+	}
+	addNodeForRoomReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ClearRoomStateStub        func(context.Context, livekit.RoomName) error
 	clearRoomStateMutex       sync.RWMutex
 	clearRoomStateArgsForCall []struct {
@@ -50,6 +63,18 @@ type FakeRouter struct {
 	getRegionReturnsOnCall map[int]struct {
 		result1 string
 	}
+	ListNodeLabelsStub        func() (map[livekit.NodeID]map[string]string, error)
+	listNodeLabelsMutex       sync.RWMutex
+	listNodeLabelsArgsForCall []struct {
+	}
+	listNodeLabelsReturns struct {
+		result1 map[livekit.NodeID]map[string]string
+		result2 error
+	}
+	listNodeLabelsReturnsOnCall map[int]struct {
+		result1 map[livekit.NodeID]map[string]string
+		result2 error
+	}
 	ListNodesStub        func() ([]*livekit.Node, error)
 	listNodesMutex       sync.RWMutex
 	listNodesArgsForCall []struct {
@@ -62,6 +87,20 @@ type FakeRouter struct {
 		result1 []*livekit.Node
 		result2 error
 	}
+	ListNodesForRoomStub        func(context.Context, livekit.RoomName) ([]livekit.NodeID, error)
+	listNodesForRoomMutex       sync.RWMutex
+	listNodesForRoomArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	listNodesForRoomReturns struct {
+		result1 []livekit.NodeID
+		result2 error
+	}
+	listNodesForRoomReturnsOnCall map[int]struct {
+		result1 []livekit.NodeID
+		result2 error
+	}
 	OnNewParticipantRTCStub        func(routing.NewParticipantCallback)
 	onNewParticipantRTCMutex       sync.RWMutex
 	onNewParticipantRTCArgsForCall []struct {
@@ -72,6 +111,11 @@ type FakeRouter struct {
 	onRTCMessageArgsForCall []struct {
 		arg1 routing.RTCMessageCallback
 	}
+	OnRelayMessageStub        func(routing.RelayMessageCallback)
+	onRelayMessageMutex       sync.RWMutex
+	onRelayMessageArgsForCall []struct {
+		arg1 routing.RelayMessageCallback
+	}
 	RegisterNodeStub        func() error
 	registerNodeMutex       sync.RWMutex
 	registerNodeArgsForCall []struct {
@@ -92,6 +136,19 @@ type FakeRouter struct {
 	removeDeadNodesReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RemoveNodeForRoomStub        func(context.Context, livekit.RoomName, livekit.NodeID) error
+	removeNodeForRoomMutex       sync.RWMutex
+	removeNodeForRoomArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.NodeID
+	}
+	removeNodeForRoomReturns struct {
+		result1 error
+	}
+	removeNodeForRoomReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetNodeForRoomStub        func(context.Context, livekit.RoomName, livekit.NodeID) error
 	setNodeForRoomMutex       sync.RWMutex
 	setNodeForRoomArgsForCall []struct {
@@ -162,6 +219,19 @@ type FakeRouter struct {
 	writeParticipantRTCReturnsOnCall map[int]struct {
 		result1 error
 	}
+	WriteRelayMessageStub        func(context.Context, livekit.NodeID, *routing.RelayMessage) error
+	writeRelayMessageMutex       sync.RWMutex
+	writeRelayMessageArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.NodeID
+		arg3 *routing.RelayMessage
+	}
+	writeRelayMessageReturns struct {
+		result1 error
+	}
+	writeRelayMessageReturnsOnCall map[int]struct {
+		result1 error
+	}
 	WriteRoomRTCStub        func(context.Context, livekit.RoomName, *livekit.RTCNodeMessage) error
 	writeRoomRTCMutex       sync.RWMutex
 	writeRoomRTCArgsForCall []struct {
@@ -179,6 +249,69 @@ type FakeRouter struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeRouter) AddNodeForRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.NodeID) error {
+	fake.addNodeForRoomMutex.Lock()
+	ret, specificReturn := fake.addNodeForRoomReturnsOnCall[len(fake.addNodeForRoomArgsForCall)]
+	fake.addNodeForRoomArgsForCall = append(fake.addNodeForRoomArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.NodeID
+	}{arg1, arg2, arg3})
+	stub := fake.AddNodeForRoomStub
+	fakeReturns := fake.addNodeForRoomReturns
+	fake.recordInvocation("AddNodeForRoom", []interface{}{arg1, arg2, arg3})
+	fake.addNodeForRoomMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRouter) AddNodeForRoomCallCount() int {
+	fake.addNodeForRoomMutex.RLock()
+	defer fake.addNodeForRoomMutex.RUnlock()
+	return len(fake.addNodeForRoomArgsForCall)
+}
+
+func (fake *FakeRouter) AddNodeForRoomCalls(stub func(context.Context, livekit.RoomName, livekit.NodeID) error) {
+	fake.addNodeForRoomMutex.Lock()
+	defer fake.addNodeForRoomMutex.Unlock()
+	fake.AddNodeForRoomStub = stub
+}
+
+func (fake *FakeRouter) AddNodeForRoomArgsForCall(i int) (context.Context, livekit.RoomName, livekit.NodeID) {
+	fake.addNodeForRoomMutex.RLock()
+	defer fake.addNodeForRoomMutex.RUnlock()
+	argsForCall := fake.addNodeForRoomArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRouter) AddNodeForRoomReturns(result1 error) {
+	fake.addNodeForRoomMutex.Lock()
+	defer fake.addNodeForRoomMutex.Unlock()
+	fake.AddNodeForRoomStub = nil
+	fake.addNodeForRoomReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRouter) AddNodeForRoomReturnsOnCall(i int, result1 error) {
+	fake.addNodeForRoomMutex.Lock()
+	defer fake.addNodeForRoomMutex.Unlock()
+	fake.AddNodeForRoomStub = nil
+	if fake.addNodeForRoomReturnsOnCall == nil {
+		fake.addNodeForRoomReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addNodeForRoomReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRouter) ClearRoomState(arg1 context.Context, arg2 livekit.RoomName) error {
 	fake.clearRoomStateMutex.Lock()
 	ret, specificReturn := fake.clearRoomStateReturnsOnCall[len(fake.clearRoomStateArgsForCall)]
@@ -383,6 +516,62 @@ func (fake *FakeRouter) GetRegionReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeRouter) ListNodeLabels() (map[livekit.NodeID]map[string]string, error) {
+	fake.listNodeLabelsMutex.Lock()
+	ret, specificReturn := fake.listNodeLabelsReturnsOnCall[len(fake.listNodeLabelsArgsForCall)]
+	fake.listNodeLabelsArgsForCall = append(fake.listNodeLabelsArgsForCall, struct {
+	}{})
+	stub := fake.ListNodeLabelsStub
+	fakeReturns := fake.listNodeLabelsReturns
+	fake.recordInvocation("ListNodeLabels", []interface{}{})
+	fake.listNodeLabelsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRouter) ListNodeLabelsCallCount() int {
+	fake.listNodeLabelsMutex.RLock()
+	defer fake.listNodeLabelsMutex.RUnlock()
+	return len(fake.listNodeLabelsArgsForCall)
+}
+
+func (fake *FakeRouter) ListNodeLabelsCalls(stub func() (map[livekit.NodeID]map[string]string, error)) {
+	fake.listNodeLabelsMutex.Lock()
+	defer fake.listNodeLabelsMutex.Unlock()
+	fake.ListNodeLabelsStub = stub
+}
+
+func (fake *FakeRouter) ListNodeLabelsReturns(result1 map[livekit.NodeID]map[string]string, result2 error) {
+	fake.listNodeLabelsMutex.Lock()
+	defer fake.listNodeLabelsMutex.Unlock()
+	fake.ListNodeLabelsStub = nil
+	fake.listNodeLabelsReturns = struct {
+		result1 map[livekit.NodeID]map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRouter) ListNodeLabelsReturnsOnCall(i int, result1 map[livekit.NodeID]map[string]string, result2 error) {
+	fake.listNodeLabelsMutex.Lock()
+	defer fake.listNodeLabelsMutex.Unlock()
+	fake.ListNodeLabelsStub = nil
+	if fake.listNodeLabelsReturnsOnCall == nil {
+		fake.listNodeLabelsReturnsOnCall = make(map[int]struct {
+			result1 map[livekit.NodeID]map[string]string
+			result2 error
+		})
+	}
+	fake.listNodeLabelsReturnsOnCall[i] = struct {
+		result1 map[livekit.NodeID]map[string]string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRouter) ListNodes() ([]*livekit.Node, error) {
 	fake.listNodesMutex.Lock()
 	ret, specificReturn := fake.listNodesReturnsOnCall[len(fake.listNodesArgsForCall)]
@@ -439,6 +628,71 @@ func (fake *FakeRouter) ListNodesReturnsOnCall(i int, result1 []*livekit.Node, r
 	}{result1, result2}
 }
 
+func (fake *FakeRouter) ListNodesForRoom(arg1 context.Context, arg2 livekit.RoomName) ([]livekit.NodeID, error) {
+	fake.listNodesForRoomMutex.Lock()
+	ret, specificReturn := fake.listNodesForRoomReturnsOnCall[len(fake.listNodesForRoomArgsForCall)]
+	fake.listNodesForRoomArgsForCall = append(fake.listNodesForRoomArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.ListNodesForRoomStub
+	fakeReturns := fake.listNodesForRoomReturns
+	fake.recordInvocation("ListNodesForRoom", []interface{}{arg1, arg2})
+	fake.listNodesForRoomMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRouter) ListNodesForRoomCallCount() int {
+	fake.listNodesForRoomMutex.RLock()
+	defer fake.listNodesForRoomMutex.RUnlock()
+	return len(fake.listNodesForRoomArgsForCall)
+}
+
+func (fake *FakeRouter) ListNodesForRoomCalls(stub func(context.Context, livekit.RoomName) ([]livekit.NodeID, error)) {
+	fake.listNodesForRoomMutex.Lock()
+	defer fake.listNodesForRoomMutex.Unlock()
+	fake.ListNodesForRoomStub = stub
+}
+
+func (fake *FakeRouter) ListNodesForRoomArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.listNodesForRoomMutex.RLock()
+	defer fake.listNodesForRoomMutex.RUnlock()
+	argsForCall := fake.listNodesForRoomArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRouter) ListNodesForRoomReturns(result1 []livekit.NodeID, result2 error) {
+	fake.listNodesForRoomMutex.Lock()
+	defer fake.listNodesForRoomMutex.Unlock()
+	fake.ListNodesForRoomStub = nil
+	fake.listNodesForRoomReturns = struct {
+		result1 []livekit.NodeID
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRouter) ListNodesForRoomReturnsOnCall(i int, result1 []livekit.NodeID, result2 error) {
+	fake.listNodesForRoomMutex.Lock()
+	defer fake.listNodesForRoomMutex.Unlock()
+	fake.ListNodesForRoomStub = nil
+	if fake.listNodesForRoomReturnsOnCall == nil {
+		fake.listNodesForRoomReturnsOnCall = make(map[int]struct {
+			result1 []livekit.NodeID
+			result2 error
+		})
+	}
+	fake.listNodesForRoomReturnsOnCall[i] = struct {
+		result1 []livekit.NodeID
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRouter) OnNewParticipantRTC(arg1 routing.NewParticipantCallback) {
 	fake.onNewParticipantRTCMutex.Lock()
 	fake.onNewParticipantRTCArgsForCall = append(fake.onNewParticipantRTCArgsForCall, struct {
@@ -503,6 +757,38 @@ func (fake *FakeRouter) OnRTCMessageArgsForCall(i int) routing.RTCMessageCallbac
 	return argsForCall.arg1
 }
 
+func (fake *FakeRouter) OnRelayMessage(arg1 routing.RelayMessageCallback) {
+	fake.onRelayMessageMutex.Lock()
+	fake.onRelayMessageArgsForCall = append(fake.onRelayMessageArgsForCall, struct {
+		arg1 routing.RelayMessageCallback
+	}{arg1})
+	stub := fake.OnRelayMessageStub
+	fake.recordInvocation("OnRelayMessage", []interface{}{arg1})
+	fake.onRelayMessageMutex.Unlock()
+	if stub != nil {
+		fake.OnRelayMessageStub(arg1)
+	}
+}
+
+func (fake *FakeRouter) OnRelayMessageCallCount() int {
+	fake.onRelayMessageMutex.RLock()
+	defer fake.onRelayMessageMutex.RUnlock()
+	return len(fake.onRelayMessageArgsForCall)
+}
+
+func (fake *FakeRouter) OnRelayMessageCalls(stub func(routing.RelayMessageCallback)) {
+	fake.onRelayMessageMutex.Lock()
+	defer fake.onRelayMessageMutex.Unlock()
+	fake.OnRelayMessageStub = stub
+}
+
+func (fake *FakeRouter) OnRelayMessageArgsForCall(i int) routing.RelayMessageCallback {
+	fake.onRelayMessageMutex.RLock()
+	defer fake.onRelayMessageMutex.RUnlock()
+	argsForCall := fake.onRelayMessageArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeRouter) RegisterNode() error {
 	fake.registerNodeMutex.Lock()
 	ret, specificReturn := fake.registerNodeReturnsOnCall[len(fake.registerNodeArgsForCall)]
@@ -609,6 +895,69 @@ func (fake *FakeRouter) RemoveDeadNodesReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRouter) RemoveNodeForRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.NodeID) error {
+	fake.removeNodeForRoomMutex.Lock()
+	ret, specificReturn := fake.removeNodeForRoomReturnsOnCall[len(fake.removeNodeForRoomArgsForCall)]
+	fake.removeNodeForRoomArgsForCall = append(fake.removeNodeForRoomArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.NodeID
+	}{arg1, arg2, arg3})
+	stub := fake.RemoveNodeForRoomStub
+	fakeReturns := fake.removeNodeForRoomReturns
+	fake.recordInvocation("RemoveNodeForRoom", []interface{}{arg1, arg2, arg3})
+	fake.removeNodeForRoomMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRouter) RemoveNodeForRoomCallCount() int {
+	fake.removeNodeForRoomMutex.RLock()
+	defer fake.removeNodeForRoomMutex.RUnlock()
+	return len(fake.removeNodeForRoomArgsForCall)
+}
+
+func (fake *FakeRouter) RemoveNodeForRoomCalls(stub func(context.Context, livekit.RoomName, livekit.NodeID) error) {
+	fake.removeNodeForRoomMutex.Lock()
+	defer fake.removeNodeForRoomMutex.Unlock()
+	fake.RemoveNodeForRoomStub = stub
+}
+
+func (fake *FakeRouter) RemoveNodeForRoomArgsForCall(i int) (context.Context, livekit.RoomName, livekit.NodeID) {
+	fake.removeNodeForRoomMutex.RLock()
+	defer fake.removeNodeForRoomMutex.RUnlock()
+	argsForCall := fake.removeNodeForRoomArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRouter) RemoveNodeForRoomReturns(result1 error) {
+	fake.removeNodeForRoomMutex.Lock()
+	defer fake.removeNodeForRoomMutex.Unlock()
+	fake.RemoveNodeForRoomStub = nil
+	fake.removeNodeForRoomReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRouter) RemoveNodeForRoomReturnsOnCall(i int, result1 error) {
+	fake.removeNodeForRoomMutex.Lock()
+	defer fake.removeNodeForRoomMutex.Unlock()
+	fake.RemoveNodeForRoomStub = nil
+	if fake.removeNodeForRoomReturnsOnCall == nil {
+		fake.removeNodeForRoomReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeNodeForRoomReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRouter) SetNodeForRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.NodeID) error {
 	fake.setNodeForRoomMutex.Lock()
 	ret, specificReturn := fake.setNodeForRoomReturnsOnCall[len(fake.setNodeForRoomArgsForCall)]
@@ -938,6 +1287,69 @@ func (fake *FakeRouter) WriteParticipantRTCReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRouter) WriteRelayMessage(arg1 context.Context, arg2 livekit.NodeID, arg3 *routing.RelayMessage) error {
+	fake.writeRelayMessageMutex.Lock()
+	ret, specificReturn := fake.writeRelayMessageReturnsOnCall[len(fake.writeRelayMessageArgsForCall)]
+	fake.writeRelayMessageArgsForCall = append(fake.writeRelayMessageArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.NodeID
+		arg3 *routing.RelayMessage
+	}{arg1, arg2, arg3})
+	stub := fake.WriteRelayMessageStub
+	fakeReturns := fake.writeRelayMessageReturns
+	fake.recordInvocation("WriteRelayMessage", []interface{}{arg1, arg2, arg3})
+	fake.writeRelayMessageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRouter) WriteRelayMessageCallCount() int {
+	fake.writeRelayMessageMutex.RLock()
+	defer fake.writeRelayMessageMutex.RUnlock()
+	return len(fake.writeRelayMessageArgsForCall)
+}
+
+func (fake *FakeRouter) WriteRelayMessageCalls(stub func(context.Context, livekit.NodeID, *routing.RelayMessage) error) {
+	fake.writeRelayMessageMutex.Lock()
+	defer fake.writeRelayMessageMutex.Unlock()
+	fake.WriteRelayMessageStub = stub
+}
+
+func (fake *FakeRouter) WriteRelayMessageArgsForCall(i int) (context.Context, livekit.NodeID, *routing.RelayMessage) {
+	fake.writeRelayMessageMutex.RLock()
+	defer fake.writeRelayMessageMutex.RUnlock()
+	argsForCall := fake.writeRelayMessageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRouter) WriteRelayMessageReturns(result1 error) {
+	fake.writeRelayMessageMutex.Lock()
+	defer fake.writeRelayMessageMutex.Unlock()
+	fake.WriteRelayMessageStub = nil
+	fake.writeRelayMessageReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRouter) WriteRelayMessageReturnsOnCall(i int, result1 error) {
+	fake.writeRelayMessageMutex.Lock()
+	defer fake.writeRelayMessageMutex.Unlock()
+	fake.WriteRelayMessageStub = nil
+	if fake.writeRelayMessageReturnsOnCall == nil {
+		fake.writeRelayMessageReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeRelayMessageReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRouter) WriteRoomRTC(arg1 context.Context, arg2 livekit.RoomName, arg3 *livekit.RTCNodeMessage) error {
 	fake.writeRoomRTCMutex.Lock()
 	ret, specificReturn := fake.writeRoomRTCReturnsOnCall[len(fake.writeRoomRTCArgsForCall)]
@@ -1004,6 +1416,8 @@ func (fake *FakeRouter) WriteRoomRTCReturnsOnCall(i int, result1 error) {
 func (fake *FakeRouter) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addNodeForRoomMutex.RLock()
+	defer fake.addNodeForRoomMutex.RUnlock()
 	fake.clearRoomStateMutex.RLock()
 	defer fake.clearRoomStateMutex.RUnlock()
 	fake.drainMutex.RLock()
@@ -1012,16 +1426,24 @@ func (fake *FakeRouter) Invocations() map[string][][]interface{} {
 	defer fake.getNodeForRoomMutex.RUnlock()
 	fake.getRegionMutex.RLock()
 	defer fake.getRegionMutex.RUnlock()
+	fake.listNodeLabelsMutex.RLock()
+	defer fake.listNodeLabelsMutex.RUnlock()
 	fake.listNodesMutex.RLock()
 	defer fake.listNodesMutex.RUnlock()
+	fake.listNodesForRoomMutex.RLock()
+	defer fake.listNodesForRoomMutex.RUnlock()
 	fake.onNewParticipantRTCMutex.RLock()
 	defer fake.onNewParticipantRTCMutex.RUnlock()
 	fake.onRTCMessageMutex.RLock()
 	defer fake.onRTCMessageMutex.RUnlock()
+	fake.onRelayMessageMutex.RLock()
+	defer fake.onRelayMessageMutex.RUnlock()
 	fake.registerNodeMutex.RLock()
 	defer fake.registerNodeMutex.RUnlock()
 	fake.removeDeadNodesMutex.RLock()
 	defer fake.removeDeadNodesMutex.RUnlock()
+	fake.removeNodeForRoomMutex.RLock()
+	defer fake.removeNodeForRoomMutex.RUnlock()
 	fake.setNodeForRoomMutex.RLock()
 	defer fake.setNodeForRoomMutex.RUnlock()
 	fake.startMutex.RLock()
@@ -1034,6 +1456,8 @@ func (fake *FakeRouter) Invocations() map[string][][]interface{} {
 	defer fake.unregisterNodeMutex.RUnlock()
 	fake.writeParticipantRTCMutex.RLock()
 	defer fake.writeParticipantRTCMutex.RUnlock()
+	fake.writeRelayMessageMutex.RLock()
+	defer fake.writeRelayMessageMutex.RUnlock()
 	fake.writeRoomRTCMutex.RLock()
 	defer fake.writeRoomRTCMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
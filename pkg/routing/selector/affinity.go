@@ -0,0 +1,101 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"regexp"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type affinityRule struct {
+	roomPattern     *regexp.Regexp
+	requiredLabels  map[string]string
+	preferredLabels map[string]string
+}
+
+// AffinitySelector narrows candidates down to nodes satisfying label constraints of the room being placed,
+// then delegates to the wrapped selector
+type AffinitySelector struct {
+	NodeSelector
+	rules []affinityRule
+}
+
+func NewAffinitySelector(s NodeSelector, affinity []config.NodeAffinityConfig) (*AffinitySelector, error) {
+	as := &AffinitySelector{
+		NodeSelector: s,
+	}
+	for _, a := range affinity {
+		pattern, err := regexp.Compile(a.RoomPattern)
+		if err != nil {
+			return nil, err
+		}
+		as.rules = append(as.rules, affinityRule{
+			roomPattern:     pattern,
+			requiredLabels:  a.RequiredLabels,
+			preferredLabels: a.PreferredLabels,
+		})
+	}
+	return as, nil
+}
+
+func (s *AffinitySelector) SelectNode(nodes []*livekit.Node) (*livekit.Node, error) {
+	return s.SelectNodeForSession(nodes, SessionInfo{})
+}
+
+func (s *AffinitySelector) SelectNodeForSession(nodes []*livekit.Node, session SessionInfo) (*livekit.Node, error) {
+	for _, rule := range s.rules {
+		if !rule.roomPattern.MatchString(string(session.RoomName)) {
+			continue
+		}
+
+		nodes = filterByLabels(nodes, session.NodeLabels, rule.requiredLabels)
+		if len(nodes) == 0 {
+			return nil, ErrNoMatchingNodes
+		}
+
+		if preferred := filterByLabels(nodes, session.NodeLabels, rule.preferredLabels); len(preferred) > 0 {
+			nodes = preferred
+		}
+	}
+
+	return SelectNodeForSession(s.NodeSelector, nodes, session)
+}
+
+func filterByLabels(nodes []*livekit.Node, nodeLabels map[livekit.NodeID]map[string]string, labels map[string]string) []*livekit.Node {
+	if len(labels) == 0 {
+		return nodes
+	}
+
+	filtered := make([]*livekit.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if HasLabels(nodeLabels[livekit.NodeID(node.Id)], labels) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// HasLabels returns true if all labels are present in nodeLabels with the same value
+func HasLabels(nodeLabels map[string]string, labels map[string]string) bool {
+	for k, v := range labels {
+		if nv, ok := nodeLabels[k]; !ok || nv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+)
+
+func TestAffinitySelector(t *testing.T) {
+	premiumNode := &livekit.Node{
+		Id:    "premium",
+		State: livekit.NodeState_SERVING,
+		Stats: &livekit.NodeStats{UpdatedAt: time.Now().Unix(), NumCpus: 1, LoadAvgLast1Min: 0.9},
+	}
+	egressNode := &livekit.Node{
+		Id:    "egress",
+		State: livekit.NodeState_SERVING,
+		Stats: &livekit.NodeStats{UpdatedAt: time.Now().Unix(), NumCpus: 1, LoadAvgLast1Min: 0.9},
+	}
+	freeNode := &livekit.Node{
+		Id:    "free",
+		State: livekit.NodeState_SERVING,
+		Stats: &livekit.NodeStats{UpdatedAt: time.Now().Unix(), NumCpus: 1, LoadAvgLast1Min: 0.1},
+	}
+	nodes := []*livekit.Node{premiumNode, egressNode, freeNode}
+	nodeLabels := map[livekit.NodeID]map[string]string{
+		"premium": {"tier": "premium"},
+		"egress":  {"tier": "free", "pool": "egress"},
+		"free":    {"tier": "free"},
+	}
+
+	sel, err := selector.NewAffinitySelector(&selector.SystemLoadSelector{SysloadLimit: 0.5, SortBy: "sysload"}, []config.NodeAffinityConfig{
+		{
+			RoomPattern:    "^premium-",
+			RequiredLabels: map[string]string{"tier": "premium"},
+		},
+		{
+			RoomPattern:     "^broadcast-",
+			PreferredLabels: map[string]string{"pool": "egress"},
+		},
+		{
+			RoomPattern:    "^dedicated-",
+			RequiredLabels: map[string]string{"tier": "dedicated"},
+		},
+	})
+	require.NoError(t, err)
+
+	selectForRoom := func(roomName livekit.RoomName) (*livekit.Node, error) {
+		return selector.SelectNodeForSession(sel, nodes, selector.SessionInfo{
+			RoomName:   roomName,
+			NodeLabels: nodeLabels,
+		})
+	}
+
+	t.Run("required labels override load", func(t *testing.T) {
+		node, err := selectForRoom("premium-standup")
+		require.NoError(t, err)
+		require.Equal(t, premiumNode, node)
+	})
+
+	t.Run("preferred labels", func(t *testing.T) {
+		node, err := selectForRoom("broadcast-town-hall")
+		require.NoError(t, err)
+		require.Equal(t, egressNode, node)
+	})
+
+	t.Run("unconstrained room uses wrapped selector", func(t *testing.T) {
+		node, err := selectForRoom("standup")
+		require.NoError(t, err)
+		require.Equal(t, freeNode, node)
+	})
+
+	t.Run("no node with required labels", func(t *testing.T) {
+		_, err := selectForRoom("dedicated-standup")
+		require.ErrorIs(t, err, selector.ErrNoMatchingNodes)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := selector.NewAffinitySelector(&selector.AnySelector{SortBy: sortBy}, []config.NodeAffinityConfig{{RoomPattern: "("}})
+		require.Error(t, err)
+	})
+}
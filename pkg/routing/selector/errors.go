@@ -22,4 +22,6 @@ var (
 	ErrCurrentRegionUnknownLatLon = errors.New("unknown lat and lon for the current region")
 	ErrSortByNotSet               = errors.New("sort by option cannot be blank")
 	ErrSortByUnknown              = errors.New("unknown sort by option")
+	ErrScriptNotSet               = errors.New("script cannot be blank")
+	ErrNoMatchingNodes            = errors.New("could not find any nodes matching selection constraints")
 )
@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Locator resolves the approximate location of an IP address
+type Locator interface {
+	Locate(address string) (lat float64, lon float64, ok bool)
+}
+
+// GeoIPLocator resolves locations from an offline MaxMind-format (GeoLite2/GeoIP2 City) database
+type GeoIPLocator struct {
+	reader *maxminddb.Reader
+}
+
+type geoIPRecord struct {
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+func NewGeoIPLocator(path string) (*GeoIPLocator, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &GeoIPLocator{reader: reader}, nil
+}
+
+// Locate accepts a plain IP, or a X-Forwarded-For style list in which case the first (client) address is used
+func (l *GeoIPLocator) Locate(address string) (float64, float64, bool) {
+	if idx := strings.IndexByte(address, ','); idx >= 0 {
+		address = address[:idx]
+	}
+	ip := net.ParseIP(strings.TrimSpace(address))
+	if ip == nil {
+		return 0, 0, false
+	}
+
+	var record geoIPRecord
+	if err := l.reader.Lookup(ip, &record); err != nil {
+		return 0, 0, false
+	}
+	if record.Location.Latitude == nil || record.Location.Longitude == nil {
+		return 0, 0, false
+	}
+	return *record.Location.Latitude, *record.Location.Longitude, true
+}
+
+func (l *GeoIPLocator) Close() error {
+	return l.reader.Close()
+}
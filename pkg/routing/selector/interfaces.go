@@ -30,7 +30,39 @@ type NodeSelector interface {
 	SelectNode(nodes []*livekit.Node) (*livekit.Node, error)
 }
 
+// SessionInfo describes the session a node is being selected for
+type SessionInfo struct {
+	RoomName   livekit.RoomName
+	ClientInfo *livekit.ClientInfo
+	// labels registered by candidate nodes, keyed by node ID
+	NodeLabels map[livekit.NodeID]map[string]string
+}
+
+// SessionAwareSelector is implemented by selectors that take the room and the joining client into account
+type SessionAwareSelector interface {
+	SelectNodeForSession(nodes []*livekit.Node, session SessionInfo) (*livekit.Node, error)
+}
+
+// SelectNodeForSession uses session info when the selector supports it, falling back to SelectNode otherwise
+func SelectNodeForSession(s NodeSelector, nodes []*livekit.Node, session SessionInfo) (*livekit.Node, error) {
+	if ss, ok := s.(SessionAwareSelector); ok {
+		return ss.SelectNodeForSession(nodes, session)
+	}
+	return s.SelectNode(nodes)
+}
+
 func CreateNodeSelector(conf *config.Config) (NodeSelector, error) {
+	s, err := createNodeSelector(conf)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.NodeSelector.Affinity) == 0 {
+		return s, nil
+	}
+	return NewAffinitySelector(s, conf.NodeSelector.Affinity)
+}
+
+func createNodeSelector(conf *config.Config) (NodeSelector, error) {
 	kind := conf.NodeSelector.Kind
 	if kind == "" {
 		kind = "any"
@@ -54,7 +86,14 @@ func CreateNodeSelector(conf *config.Config) (NodeSelector, error) {
 			return nil, err
 		}
 		s.SysloadLimit = conf.NodeSelector.SysloadLimit
+		if conf.NodeSelector.GeoIPDatabase != "" {
+			if s.Locator, err = NewGeoIPLocator(conf.NodeSelector.GeoIPDatabase); err != nil {
+				return nil, err
+			}
+		}
 		return s, nil
+	case "script":
+		return NewScriptSelector(conf.NodeSelector.Script, conf.NodeSelector.SortBy)
 	case "random":
 		logger.Warnw("random node selector is deprecated, please switch to \"any\" or another selector", nil)
 		return &AnySelector{conf.NodeSelector.SortBy}, nil
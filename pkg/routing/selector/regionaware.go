@@ -22,10 +22,13 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 )
 
-// RegionAwareSelector prefers available nodes that are closest to the region of the current instance
+// RegionAwareSelector prefers available nodes that are closest to the region of the current instance.
+// When a Locator is set, it prefers regions closest to the joining client instead, falling back to the
+// region of the current instance when the client could not be located
 type RegionAwareSelector struct {
 	SystemLoadSelector
 	CurrentRegion   string
+	Locator         Locator
 	regionDistances map[string]float64
 	regions         []config.RegionConfig
 	SortBy          string
@@ -37,10 +40,9 @@ func NewRegionAwareSelector(currentRegion string, regions []config.RegionConfig,
 	}
 	// build internal map of distances
 	s := &RegionAwareSelector{
-		CurrentRegion:   currentRegion,
-		regionDistances: make(map[string]float64),
-		regions:         regions,
-		SortBy:          sortBy,
+		CurrentRegion: currentRegion,
+		regions:       regions,
+		SortBy:        sortBy,
 	}
 
 	var currentRC *config.RegionConfig
@@ -57,21 +59,34 @@ func NewRegionAwareSelector(currentRegion string, regions []config.RegionConfig,
 	}
 
 	if currentRC != nil {
-		for _, region := range regions {
-			s.regionDistances[region.Name] = distanceBetween(currentRC.Lat, currentRC.Lon, region.Lat, region.Lon)
-		}
+		s.regionDistances = s.distancesFrom(currentRC.Lat, currentRC.Lon)
+	} else {
+		s.regionDistances = make(map[string]float64)
 	}
 
 	return s, nil
 }
 
 func (s *RegionAwareSelector) SelectNode(nodes []*livekit.Node) (*livekit.Node, error) {
+	return s.selectNode(nodes, s.regionDistances)
+}
+
+func (s *RegionAwareSelector) SelectNodeForSession(nodes []*livekit.Node, session SessionInfo) (*livekit.Node, error) {
+	if s.Locator != nil && session.ClientInfo != nil && session.ClientInfo.Address != "" {
+		if lat, lon, ok := s.Locator.Locate(session.ClientInfo.Address); ok {
+			return s.selectNode(nodes, s.distancesFrom(lat, lon))
+		}
+	}
+	return s.selectNode(nodes, s.regionDistances)
+}
+
+func (s *RegionAwareSelector) selectNode(nodes []*livekit.Node, regionDistances map[string]float64) (*livekit.Node, error) {
 	nodes, err := s.SystemLoadSelector.filterNodes(nodes)
 	if err != nil {
 		return nil, err
 	}
 
-	// find nodes nearest to current region
+	// find nodes nearest to the reference location
 	var nearestNodes []*livekit.Node
 	nearestRegion := ""
 	minDist := math.MaxFloat64
@@ -80,7 +95,7 @@ func (s *RegionAwareSelector) SelectNode(nodes []*livekit.Node) (*livekit.Node,
 			nearestNodes = append(nearestNodes, node)
 			continue
 		}
-		if dist, ok := s.regionDistances[node.Region]; ok {
+		if dist, ok := regionDistances[node.Region]; ok {
 			if dist < minDist {
 				minDist = dist
 				nearestRegion = node.Region
@@ -97,6 +112,14 @@ func (s *RegionAwareSelector) SelectNode(nodes []*livekit.Node) (*livekit.Node,
 	return SelectSortedNode(nodes, s.SortBy)
 }
 
+func (s *RegionAwareSelector) distancesFrom(lat, lon float64) map[string]float64 {
+	distances := make(map[string]float64, len(s.regions))
+	for _, region := range s.regions {
+		distances[region.Name] = distanceBetween(lat, lon, region.Lat, region.Lon)
+	}
+	return distances
+}
+
 // haversine(θ) function
 func hsin(theta float64) float64 {
 	return math.Pow(math.Sin(theta/2), 2)
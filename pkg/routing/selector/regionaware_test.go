@@ -133,6 +133,35 @@ func TestRegionAwareRouting(t *testing.T) {
 		require.Equal(t, expectedNode, node)
 	})
 
+	t.Run("picks region closest to located client", func(t *testing.T) {
+		expectedNode := newTestNodeInRegion(regionEast, true)
+		nodes := []*livekit.Node{
+			newTestNodeInRegion(regionSeattle, true),
+			newTestNodeInRegion(regionWest, true),
+			expectedNode,
+		}
+		s, err := selector.NewRegionAwareSelector(regionSeattle, rc, sortBy)
+		require.NoError(t, err)
+		s.SysloadLimit = loadLimit
+		s.Locator = &testLocator{
+			// Boston
+			"203.0.113.10": {42.36, -71.06},
+		}
+
+		node, err := s.SelectNodeForSession(nodes, selector.SessionInfo{
+			ClientInfo: &livekit.ClientInfo{Address: "203.0.113.10"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, expectedNode, node)
+
+		// falls back to current region when client cannot be located
+		node, err = s.SelectNodeForSession(nodes, selector.SessionInfo{
+			ClientInfo: &livekit.ClientInfo{Address: "198.51.100.1"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, nodes[0], node)
+	})
+
 	t.Run("functions when current region is full", func(t *testing.T) {
 		nodes := []*livekit.Node{
 			newTestNodeInRegion(regionWest, true),
@@ -162,3 +191,10 @@ func newTestNodeInRegion(region string, available bool) *livekit.Node {
 		},
 	}
 }
+
+type testLocator map[string][2]float64
+
+func (l testLocator) Locate(address string) (float64, float64, bool) {
+	loc, ok := l[address]
+	return loc[0], loc[1], ok
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/d5/tengo/v2"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/clientconfiguration"
+)
+
+// ScriptSelector evaluates a tengo expression against each available node and selects among the highest scoring ones.
+// The expression has access to `node` (including its `labels`), `room` (name of the room being placed)
+// and `c` (info of the joining client, same fields as clientconfiguration.ScriptMatch). It should evaluate to
+//   - a number: score of the node, higher is preferred
+//   - true: node is accepted with a score of 0
+//   - false or undefined: node is rejected
+//
+// expression examples:
+// pin a tenant to a pool : has_prefix(room, "acme-") == (node.labels.tenant == "acme")
+// keep mobile clients on dedicated nodes : (c.os == "ios" || c.os == "android") == (node.labels.pool == "mobile")
+// prefer idle nodes : 1 - node.cpu_load
+type ScriptSelector struct {
+	Expr   string
+	SortBy string
+
+	compiled *tengo.Compiled
+}
+
+const (
+	scriptResult = "__res__"
+	// evaluation of the script for a single node is aborted after this
+	scriptTimeout = 100 * time.Millisecond
+)
+
+func NewScriptSelector(expr string, sortBy string) (*ScriptSelector, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, ErrScriptNotSet
+	}
+
+	script := tengo.NewScript([]byte(fmt.Sprintf("%s := (%s)", scriptResult, expr)))
+	for name, value := range map[string]interface{}{
+		"node":       tengo.UndefinedValue,
+		"room":       "",
+		"c":          tengo.UndefinedValue,
+		"has_prefix": &tengo.UserFunction{Name: "has_prefix", Value: hasPrefix},
+	} {
+		if err := script.Add(name, value); err != nil {
+			return nil, err
+		}
+	}
+	compiled, err := script.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("could not compile node selector script: %w", err)
+	}
+
+	return &ScriptSelector{
+		Expr:     expr,
+		SortBy:   sortBy,
+		compiled: compiled,
+	}, nil
+}
+
+func (s *ScriptSelector) SelectNode(nodes []*livekit.Node) (*livekit.Node, error) {
+	return s.SelectNodeForSession(nodes, SessionInfo{})
+}
+
+func (s *ScriptSelector) SelectNodeForSession(nodes []*livekit.Node, session SessionInfo) (*livekit.Node, error) {
+	nodes = GetAvailableNodes(nodes)
+	if len(nodes) == 0 {
+		return nil, ErrNoAvailableNodes
+	}
+
+	var bestNodes []*livekit.Node
+	var bestScore float64
+	for _, node := range nodes {
+		score, ok, err := s.score(node, session)
+		if err != nil {
+			logger.Warnw("could not evaluate node selector script", err, "nodeID", node.Id, "room", session.RoomName)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		switch {
+		case len(bestNodes) == 0 || score > bestScore:
+			bestScore = score
+			bestNodes = append(bestNodes[:0], node)
+		case score == bestScore:
+			bestNodes = append(bestNodes, node)
+		}
+	}
+
+	if len(bestNodes) == 0 {
+		return nil, ErrNoMatchingNodes
+	}
+
+	return SelectSortedNode(bestNodes, s.SortBy)
+}
+
+func (s *ScriptSelector) score(node *livekit.Node, session SessionInfo) (float64, bool, error) {
+	// clones are cheap and safe for concurrent selections
+	compiled := s.compiled.Clone()
+	for name, value := range map[string]interface{}{
+		"node": &nodeObject{node: node, labels: session.NodeLabels[livekit.NodeID(node.Id)]},
+		"room": string(session.RoomName),
+		"c":    clientconfiguration.NewClientObject(session.ClientInfo),
+	} {
+		if err := compiled.Set(name, value); err != nil {
+			return 0, false, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+	if err := compiled.RunContext(ctx); err != nil {
+		return 0, false, err
+	}
+
+	res := compiled.Get(scriptResult).Value()
+	switch val := res.(type) {
+	case nil:
+		return 0, false, nil
+	case bool:
+		return 0, val, nil
+	case int64:
+		return float64(val), true, nil
+	case float64:
+		return val, true, nil
+	default:
+		return 0, false, fmt.Errorf("invalid node selector script result type: %T", res)
+	}
+}
+
+func hasPrefix(args ...tengo.Object) (tengo.Object, error) {
+	if len(args) != 2 {
+		return nil, tengo.ErrWrongNumArguments
+	}
+	str, ok := tengo.ToString(args[0])
+	if !ok {
+		return nil, tengo.ErrInvalidArgumentType{Name: "first", Expected: "string", Found: args[0].TypeName()}
+	}
+	prefix, ok := tengo.ToString(args[1])
+	if !ok {
+		return nil, tengo.ErrInvalidArgumentType{Name: "second", Expected: "string", Found: args[1].TypeName()}
+	}
+	if strings.HasPrefix(str, prefix) {
+		return tengo.TrueValue, nil
+	}
+	return tengo.FalseValue, nil
+}
+
+// ------------------------------------------------
+
+type nodeObject struct {
+	tengo.ObjectImpl
+	node   *livekit.Node
+	labels map[string]string
+}
+
+func (n *nodeObject) TypeName() string {
+	return "nodeObject"
+}
+
+func (n *nodeObject) String() string {
+	return n.node.String()
+}
+
+func (n *nodeObject) IndexGet(index tengo.Object) (res tengo.Object, err error) {
+	field, ok := index.(*tengo.String)
+	if !ok {
+		return nil, tengo.ErrInvalidIndexType
+	}
+
+	switch field.Value {
+	case "id":
+		return &tengo.String{Value: n.node.Id}, nil
+	case "ip":
+		return &tengo.String{Value: n.node.Ip}, nil
+	case "region":
+		return &tengo.String{Value: n.node.Region}, nil
+	case "num_cpus":
+		return &tengo.Int{Value: int64(n.node.NumCpus)}, nil
+	case "labels":
+		labels := make(map[string]tengo.Object, len(n.labels))
+		for k, v := range n.labels {
+			labels[k] = &tengo.String{Value: v}
+		}
+		return &tengo.ImmutableMap{Value: labels}, nil
+	}
+
+	stats := n.node.Stats
+	if stats == nil {
+		return tengo.UndefinedValue, nil
+	}
+	switch field.Value {
+	case "cpu_load":
+		return &tengo.Float{Value: float64(stats.CpuLoad)}, nil
+	case "sysload":
+		return &tengo.Float{Value: float64(GetNodeSysload(n.node))}, nil
+	case "num_rooms":
+		return &tengo.Int{Value: int64(stats.NumRooms)}, nil
+	case "num_clients":
+		return &tengo.Int{Value: int64(stats.NumClients)}, nil
+	case "num_tracks_in":
+		return &tengo.Int{Value: int64(stats.NumTracksIn)}, nil
+	case "num_tracks_out":
+		return &tengo.Int{Value: int64(stats.NumTracksOut)}, nil
+	case "bytes_in_per_sec":
+		return &tengo.Float{Value: float64(stats.BytesInPerSec)}, nil
+	case "bytes_out_per_sec":
+		return &tengo.Float{Value: float64(stats.BytesOutPerSec)}, nil
+	}
+	return tengo.UndefinedValue, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+)
+
+func TestScriptSelector(t *testing.T) {
+	mobileNode := &livekit.Node{
+		Id:     "mobile",
+		Region: "mobile",
+		State:  livekit.NodeState_SERVING,
+		Stats:  &livekit.NodeStats{UpdatedAt: time.Now().Unix(), CpuLoad: 0.5},
+	}
+	defaultNode := &livekit.Node{
+		Id:     "default",
+		Region: "default",
+		State:  livekit.NodeState_SERVING,
+		Stats:  &livekit.NodeStats{UpdatedAt: time.Now().Unix(), CpuLoad: 0.1},
+	}
+	nodes := []*livekit.Node{mobileNode, defaultNode}
+
+	t.Run("blank script", func(t *testing.T) {
+		_, err := selector.NewScriptSelector("", sortBy)
+		require.ErrorIs(t, err, selector.ErrScriptNotSet)
+	})
+
+	t.Run("score", func(t *testing.T) {
+		sel, err := selector.NewScriptSelector("1 - node.cpu_load", sortBy)
+		require.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			node, err := sel.SelectNode(nodes)
+			require.NoError(t, err)
+			require.Equal(t, defaultNode, node)
+		}
+	})
+
+	t.Run("client and room", func(t *testing.T) {
+		sel, err := selector.NewScriptSelector(`(c.os == "ios") == (node.region == "mobile") && has_prefix(room, "acme-")`, sortBy)
+		require.NoError(t, err)
+
+		node, err := selector.SelectNodeForSession(sel, nodes, selector.SessionInfo{
+			RoomName:   "acme-standup",
+			ClientInfo: &livekit.ClientInfo{Os: "iOS"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, mobileNode, node)
+
+		node, err = selector.SelectNodeForSession(sel, nodes, selector.SessionInfo{
+			RoomName:   "acme-standup",
+			ClientInfo: &livekit.ClientInfo{Os: "macOS"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, defaultNode, node)
+
+		_, err = selector.SelectNodeForSession(sel, nodes, selector.SessionInfo{
+			RoomName:   "other-standup",
+			ClientInfo: &livekit.ClientInfo{Os: "macOS"},
+		})
+		require.ErrorIs(t, err, selector.ErrNoMatchingNodes)
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		sel, err := selector.NewScriptSelector(`node.labels.pool == "mobile"`, sortBy)
+		require.NoError(t, err)
+		node, err := selector.SelectNodeForSession(sel, nodes, selector.SessionInfo{
+			NodeLabels: map[livekit.NodeID]map[string]string{"mobile": {"pool": "mobile"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, mobileNode, node)
+	})
+
+	t.Run("invalid result rejects node", func(t *testing.T) {
+		sel, err := selector.NewScriptSelector(`node.region == "mobile" ? "yes" : true`, sortBy)
+		require.NoError(t, err)
+		node, err := sel.SelectNode(nodes)
+		require.NoError(t, err)
+		require.Equal(t, defaultNode, node)
+	})
+
+	t.Run("invalid script", func(t *testing.T) {
+		_, err := selector.NewScriptSelector(`node.region ==`, sortBy)
+		require.Error(t, err)
+	})
+
+	t.Run("long running script rejects node", func(t *testing.T) {
+		sel, err := selector.NewScriptSelector(`node.region == "mobile" || func() { for {} }()`, sortBy)
+		require.NoError(t, err)
+		node, err := sel.SelectNode(nodes)
+		require.NoError(t, err)
+		require.Equal(t, mobileNode, node)
+	})
+}
@@ -0,0 +1,253 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// Participant attributes are a map<string, string> field on ParticipantInfo, UpdateParticipantRequest and
+// UpdateParticipantMetadata. The protocol version in use does not define them yet, so they are carried as
+// unknown fields using the field numbers of the protocol definitions, keeping the wire format compatible.
+const (
+	participantInfoAttributesField           protowire.Number = 15
+	updateParticipantRequestAttributesField  protowire.Number = 6
+	updateParticipantMetadataAttributesField protowire.Number = 3
+)
+
+type AttributeLimits struct {
+	MaxKeySize    int
+	MaxValueSize  int
+	MaxAttributes int
+}
+
+func AttributeLimitsFromConfig(conf *config.RoomConfig) AttributeLimits {
+	if conf == nil {
+		return AttributeLimits{}
+	}
+	return AttributeLimits{
+		MaxKeySize:    int(conf.MaxAttributeKeySize),
+		MaxValueSize:  int(conf.MaxAttributeValueSize),
+		MaxAttributes: int(conf.MaxAttributes),
+	}
+}
+
+// ValidateUpdate checks sizes of keys and values of an update
+func (l AttributeLimits) ValidateUpdate(update map[string]string) error {
+	for k, v := range update {
+		if k == "" {
+			return ErrAttributeKeyEmpty
+		}
+		if l.MaxKeySize > 0 && len(k) > l.MaxKeySize {
+			return ErrAttributesExceedLimits
+		}
+		if l.MaxValueSize > 0 && len(v) > l.MaxValueSize {
+			return ErrAttributesExceedLimits
+		}
+	}
+	return nil
+}
+
+// Validate checks an update, and the number of attributes after it is applied to current
+func (l AttributeLimits) Validate(current map[string]string, update map[string]string) error {
+	if err := l.ValidateUpdate(update); err != nil {
+		return err
+	}
+	if l.MaxAttributes > 0 {
+		count := len(current)
+		for k, v := range update {
+			_, exists := current[k]
+			switch {
+			case v == "" && exists:
+				count--
+			case v != "" && !exists:
+				count++
+			}
+		}
+		if count > l.MaxAttributes {
+			return ErrAttributesExceedLimits
+		}
+	}
+	return nil
+}
+
+// ApplyAttributes applies a partial update to attributes, where an empty value deletes the key.
+// It returns the updated attributes and the changed keys, with an empty value for deleted keys.
+func ApplyAttributes(current map[string]string, update map[string]string) (map[string]string, map[string]string) {
+	var changed map[string]string
+	for k, v := range update {
+		if existing, exists := current[k]; (v == "" && !exists) || (exists && existing == v) {
+			continue
+		}
+		if changed == nil {
+			changed = make(map[string]string)
+		}
+		changed[k] = v
+	}
+	if len(changed) == 0 {
+		return current, nil
+	}
+
+	attributes := make(map[string]string, len(current)+len(changed))
+	for k, v := range current {
+		attributes[k] = v
+	}
+	for k, v := range changed {
+		if v == "" {
+			delete(attributes, k)
+		} else {
+			attributes[k] = v
+		}
+	}
+	return attributes, changed
+}
+
+func GetParticipantInfoAttributes(pi *livekit.ParticipantInfo) map[string]string {
+	return getMapField(pi, participantInfoAttributesField)
+}
+
+func SetParticipantInfoAttributes(pi *livekit.ParticipantInfo, attributes map[string]string) {
+	setMapField(pi, participantInfoAttributesField, attributes)
+}
+
+func GetUpdateParticipantRequestAttributes(req *livekit.UpdateParticipantRequest) map[string]string {
+	return getMapField(req, updateParticipantRequestAttributesField)
+}
+
+func SetUpdateParticipantRequestAttributes(req *livekit.UpdateParticipantRequest, attributes map[string]string) {
+	setMapField(req, updateParticipantRequestAttributesField, attributes)
+}
+
+func GetUpdateParticipantMetadataAttributes(msg *livekit.UpdateParticipantMetadata) map[string]string {
+	return getMapField(msg, updateParticipantMetadataAttributesField)
+}
+
+func SetUpdateParticipantMetadataAttributes(msg *livekit.UpdateParticipantMetadata, attributes map[string]string) {
+	setMapField(msg, updateParticipantMetadataAttributesField, attributes)
+}
+
+func getMapField(m proto.Message, field protowire.Number) map[string]string {
+	var values map[string]string
+	b := m.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return values
+		}
+		b = b[n:]
+
+		if num != field || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return values
+			}
+			b = b[n:]
+			continue
+		}
+
+		entry, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return values
+		}
+		b = b[n:]
+
+		key, value, ok := consumeMapEntry(entry)
+		if !ok {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]string)
+		}
+		values[key] = value
+	}
+	return values
+}
+
+func consumeMapEntry(b []byte) (string, string, bool) {
+	var key, value string
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", "", false
+		}
+		b = b[n:]
+
+		if typ != protowire.BytesType || (num != 1 && num != 2) {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return "", "", false
+			}
+			b = b[n:]
+			continue
+		}
+
+		v, n := protowire.ConsumeString(b)
+		if n < 0 {
+			return "", "", false
+		}
+		b = b[n:]
+		if num == 1 {
+			key = v
+		} else {
+			value = v
+		}
+	}
+	return key, value, true
+}
+
+func setMapField(m proto.Message, field protowire.Number, values map[string]string) {
+	// keep other unknown fields
+	var unknown []byte
+	b := m.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		l := protowire.ConsumeFieldValue(num, typ, b[n:])
+		if l < 0 {
+			break
+		}
+		if num != field {
+			unknown = append(unknown, b[:n+l]...)
+		}
+		b = b[n+l:]
+	}
+
+	// sorted, for deterministic encoding
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, values[k])
+
+		unknown = protowire.AppendTag(unknown, field, protowire.BytesType)
+		unknown = protowire.AppendBytes(unknown, entry)
+	}
+	m.ProtoReflect().SetUnknown(unknown)
+}
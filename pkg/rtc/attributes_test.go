@@ -0,0 +1,68 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestAttributes(t *testing.T) {
+	t.Run("survive encoding", func(t *testing.T) {
+		pi := &livekit.ParticipantInfo{Identity: "p1", Metadata: "metadata"}
+		SetParticipantInfoAttributes(pi, map[string]string{"a": "1", "b": "2"})
+		SetParticipantInfoAttributes(pi, map[string]string{"a": "3"})
+
+		b, err := proto.Marshal(pi)
+		require.NoError(t, err)
+		decoded := &livekit.ParticipantInfo{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+		require.Equal(t, "metadata", decoded.Metadata)
+		require.Equal(t, map[string]string{"a": "3"}, GetParticipantInfoAttributes(decoded))
+
+		cloned := proto.Clone(decoded).(*livekit.ParticipantInfo)
+		require.Equal(t, map[string]string{"a": "3"}, GetParticipantInfoAttributes(cloned))
+
+		require.Nil(t, GetParticipantInfoAttributes(&livekit.ParticipantInfo{}))
+	})
+
+	t.Run("partial updates", func(t *testing.T) {
+		current := map[string]string{"a": "1", "b": "2"}
+		attributes, changed := ApplyAttributes(current, map[string]string{"a": "1", "b": "", "c": "3", "d": ""})
+		require.Equal(t, map[string]string{"a": "1", "c": "3"}, attributes)
+		require.Equal(t, map[string]string{"b": "", "c": "3"}, changed)
+		// current is not modified
+		require.Equal(t, map[string]string{"a": "1", "b": "2"}, current)
+
+		attributes, changed = ApplyAttributes(current, map[string]string{"a": "1"})
+		require.Equal(t, current, attributes)
+		require.Nil(t, changed)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		limits := AttributeLimits{MaxKeySize: 3, MaxValueSize: 3, MaxAttributes: 2}
+		current := map[string]string{"a": "1", "b": "2"}
+		require.NoError(t, limits.Validate(current, map[string]string{"a": "abc"}))
+		require.ErrorIs(t, limits.Validate(current, map[string]string{"a": "abcd"}), ErrAttributesExceedLimits)
+		require.ErrorIs(t, limits.Validate(current, map[string]string{"abcd": "1"}), ErrAttributesExceedLimits)
+		require.ErrorIs(t, limits.Validate(current, map[string]string{"c": "3"}), ErrAttributesExceedLimits)
+		require.NoError(t, limits.Validate(current, map[string]string{"b": "", "c": "3"}))
+		require.ErrorIs(t, limits.Validate(current, map[string]string{"": "3"}), ErrAttributeKeyEmpty)
+	})
+}
@@ -55,6 +55,10 @@ type DirectionConfig struct {
 	RTPHeaderExtension RTPHeaderExtensionConfig
 	RTCPFeedback       RTCPFeedbackConfig
 	StrictACKs         bool
+	// negotiate RTX for video
+	RTX bool
+	// negotiate RED encapsulated ULPFEC for video
+	FEC bool
 }
 
 func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
@@ -102,9 +106,15 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 		},
 	}
 
+	if conf.Room.PublishBitrate.TMMBR {
+		publisherConfig.RTCPFeedback.Video = append(publisherConfig.RTCPFeedback.Video, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBCCM, Parameter: "tmmbr"})
+	}
+
 	// subscriber configuration
 	subscriberConfig := DirectionConfig{
 		StrictACKs: conf.RTC.StrictACKs,
+		RTX:        conf.RTC.SubscriberRTX,
+		FEC:        conf.RTC.CongestionControl.FEC.Enabled,
 		RTPHeaderExtension: RTPHeaderExtensionConfig{
 			Video: []string{dd.ExtensionURI},
 		},
@@ -0,0 +1,152 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// topics with this prefix are used by the server, packets sent to them are not retained
+const reservedDataTopicPrefix = "lk."
+
+type DataHistoryParams struct {
+	// packets retained per topic
+	Messages int
+	// max total size of payloads retained per topic, 0 for no limit
+	MaxBytes int
+	// max age of retained packets, 0 for no limit
+	MaxAge time.Duration
+}
+
+type dataHistoryEntry struct {
+	packet *livekit.DataPacket
+	size   int
+	at     time.Time
+	seq    uint64
+}
+
+type dataHistoryTopic struct {
+	entries []dataHistoryEntry
+	size    int
+}
+
+// DataHistory retains recent reliable data packets sent to everyone in a room, per topic,
+// so that they could be delivered to participants joining later
+type DataHistory struct {
+	params DataHistoryParams
+
+	lock   sync.Mutex
+	topics map[string]*dataHistoryTopic
+	seq    uint64
+}
+
+func NewDataHistory(params DataHistoryParams) *DataHistory {
+	return &DataHistory{
+		params: params,
+		topics: make(map[string]*dataHistoryTopic),
+	}
+}
+
+// IsRetained checks if a packet should be retained. Only reliable user packets sent to everyone
+// in the room, on topics that aren't reserved are
+func IsRetained(dp *livekit.DataPacket) bool {
+	if dp.Kind != livekit.DataPacket_RELIABLE {
+		return false
+	}
+	up := dp.GetUser()
+	if up == nil || len(up.DestinationSids) != 0 || len(up.DestinationIdentities) != 0 {
+		return false
+	}
+	return !strings.HasPrefix(up.GetTopic(), reservedDataTopicPrefix)
+}
+
+// Add retains a packet, returning false when it isn't retained
+func (h *DataHistory) Add(dp *livekit.DataPacket, now time.Time) bool {
+	if !IsRetained(dp) {
+		return false
+	}
+	up := dp.GetUser()
+	size := len(up.Payload)
+	if h.params.MaxBytes > 0 && size > h.params.MaxBytes {
+		return false
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	t := h.topics[up.GetTopic()]
+	if t == nil {
+		t = &dataHistoryTopic{}
+		h.topics[up.GetTopic()] = t
+	}
+	h.seq++
+	t.entries = append(t.entries, dataHistoryEntry{packet: dp, size: size, at: now, seq: h.seq})
+	t.size += size
+	for len(t.entries) > h.params.Messages || (h.params.MaxBytes > 0 && t.size > h.params.MaxBytes) {
+		t.size -= t.entries[0].size
+		t.entries = t.entries[1:]
+	}
+	h.expireLocked(now)
+	return true
+}
+
+// Get returns retained packets of all topics, in the order they were sent
+func (h *DataHistory) Get(now time.Time) []*livekit.DataPacket {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.expireLocked(now)
+
+	var entries []dataHistoryEntry
+	for _, t := range h.topics {
+		entries = append(entries, t.entries...)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+
+	packets := make([]*livekit.DataPacket, 0, len(entries))
+	for _, e := range entries {
+		packets = append(packets, e.packet)
+	}
+	return packets
+}
+
+func (h *DataHistory) Clear() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.topics = make(map[string]*dataHistoryTopic)
+}
+
+func (h *DataHistory) expireLocked(now time.Time) {
+	if h.params.MaxAge <= 0 {
+		return
+	}
+	for topic, t := range h.topics {
+		for len(t.entries) > 0 && now.Sub(t.entries[0].at) > h.params.MaxAge {
+			t.size -= t.entries[0].size
+			t.entries = t.entries[1:]
+		}
+		if len(t.entries) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
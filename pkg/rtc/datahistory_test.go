@@ -0,0 +1,102 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestDataHistory(t *testing.T) {
+	newPacket := func(topic string, payload string) *livekit.DataPacket {
+		up := &livekit.UserPacket{Payload: []byte(payload)}
+		if topic != "" {
+			up.Topic = &topic
+		}
+		return &livekit.DataPacket{
+			Kind:  livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{User: up},
+		}
+	}
+	payloads := func(packets []*livekit.DataPacket) []string {
+		var p []string
+		for _, dp := range packets {
+			p = append(p, string(dp.GetUser().Payload))
+		}
+		return p
+	}
+
+	t.Run("retains last messages per topic", func(t *testing.T) {
+		h := NewDataHistory(DataHistoryParams{Messages: 2})
+		now := time.Now()
+		require.True(t, h.Add(newPacket("a", "a1"), now))
+		require.True(t, h.Add(newPacket("b", "b1"), now))
+		require.True(t, h.Add(newPacket("a", "a2"), now))
+		require.True(t, h.Add(newPacket("a", "a3"), now))
+		require.True(t, h.Add(newPacket("", "none"), now))
+
+		require.Equal(t, []string{"b1", "a2", "a3", "none"}, payloads(h.Get(now)))
+
+		h.Clear()
+		require.Empty(t, h.Get(now))
+	})
+
+	t.Run("ignores packets not sent to everyone", func(t *testing.T) {
+		h := NewDataHistory(DataHistoryParams{Messages: 2})
+		now := time.Now()
+
+		lossy := newPacket("a", "lossy")
+		lossy.Kind = livekit.DataPacket_LOSSY
+		require.False(t, h.Add(lossy, now))
+
+		direct := newPacket("a", "direct")
+		direct.GetUser().DestinationIdentities = []string{"p1"}
+		require.False(t, h.Add(direct, now))
+
+		require.False(t, h.Add(newPacket("lk.position", "reserved"), now))
+
+		require.False(t, h.Add(&livekit.DataPacket{
+			Kind:  livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_Speaker{Speaker: &livekit.ActiveSpeakerUpdate{}},
+		}, now))
+
+		require.Empty(t, h.Get(now))
+	})
+
+	t.Run("limits size", func(t *testing.T) {
+		h := NewDataHistory(DataHistoryParams{Messages: 10, MaxBytes: 5})
+		now := time.Now()
+		require.False(t, h.Add(newPacket("a", "too large"), now))
+		require.True(t, h.Add(newPacket("a", "abc"), now))
+		require.True(t, h.Add(newPacket("a", "de"), now))
+		require.True(t, h.Add(newPacket("a", "f"), now))
+
+		require.Equal(t, []string{"de", "f"}, payloads(h.Get(now)))
+	})
+
+	t.Run("expires old messages", func(t *testing.T) {
+		h := NewDataHistory(DataHistoryParams{Messages: 10, MaxAge: time.Minute})
+		now := time.Now()
+		require.True(t, h.Add(newPacket("a", "old"), now))
+		require.True(t, h.Add(newPacket("b", "old"), now))
+		require.True(t, h.Add(newPacket("a", "new"), now.Add(30*time.Second)))
+
+		require.Equal(t, []string{"new"}, payloads(h.Get(now.Add(90*time.Second))))
+	})
+}
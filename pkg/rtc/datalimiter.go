@@ -0,0 +1,81 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type DataDropReason string
+
+const (
+	DataDropReasonSize DataDropReason = "size"
+	DataDropReasonRate DataDropReason = "rate"
+)
+
+// DataLimiter enforces limits on data packets received from a participant.
+// Rates are enforced with token buckets holding up to one second worth of tokens
+type DataLimiter struct {
+	conf config.DataLimitsConfig
+
+	lock     sync.Mutex
+	messages float64
+	bytes    float64
+	updateAt time.Time
+}
+
+func NewDataLimiter(conf config.DataLimitsConfig) *DataLimiter {
+	return &DataLimiter{
+		conf:     conf,
+		messages: float64(conf.MessagesPerSecond),
+		bytes:    float64(conf.BytesPerSecond),
+	}
+}
+
+// Allow checks if a packet of size could be accepted, returning the reason when it should be dropped
+func (l *DataLimiter) Allow(size int, now time.Time) (DataDropReason, bool) {
+	if l.conf.MaxPacketSize > 0 && size > int(l.conf.MaxPacketSize) {
+		return DataDropReasonSize, false
+	}
+	if l.conf.MessagesPerSecond == 0 && l.conf.BytesPerSecond == 0 {
+		return "", true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.updateAt.IsZero() {
+		elapsed := now.Sub(l.updateAt).Seconds()
+		if elapsed > 0 {
+			l.messages = math.Min(l.messages+elapsed*float64(l.conf.MessagesPerSecond), float64(l.conf.MessagesPerSecond))
+			l.bytes = math.Min(l.bytes+elapsed*float64(l.conf.BytesPerSecond), float64(l.conf.BytesPerSecond))
+		}
+	}
+	l.updateAt = now
+
+	if l.conf.MessagesPerSecond > 0 && l.messages < 1 {
+		return DataDropReasonRate, false
+	}
+	if l.conf.BytesPerSecond > 0 && l.bytes < float64(size) {
+		return DataDropReasonRate, false
+	}
+	l.messages--
+	l.bytes -= float64(size)
+	return "", true
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestDataLimiter(t *testing.T) {
+	t.Run("unlimited", func(t *testing.T) {
+		l := NewDataLimiter(config.DataLimitsConfig{})
+		now := time.Now()
+		for i := 0; i < 1000; i++ {
+			_, ok := l.Allow(1<<20, now)
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("max packet size", func(t *testing.T) {
+		l := NewDataLimiter(config.DataLimitsConfig{MaxPacketSize: 100})
+		_, ok := l.Allow(100, time.Now())
+		require.True(t, ok)
+		reason, ok := l.Allow(101, time.Now())
+		require.False(t, ok)
+		require.Equal(t, DataDropReasonSize, reason)
+	})
+
+	t.Run("messages per second", func(t *testing.T) {
+		l := NewDataLimiter(config.DataLimitsConfig{MessagesPerSecond: 10})
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			_, ok := l.Allow(1, now)
+			require.True(t, ok)
+		}
+		reason, ok := l.Allow(1, now)
+		require.False(t, ok)
+		require.Equal(t, DataDropReasonRate, reason)
+
+		// refills over time
+		now = now.Add(200 * time.Millisecond)
+		for i := 0; i < 2; i++ {
+			_, ok = l.Allow(1, now)
+			require.True(t, ok)
+		}
+		_, ok = l.Allow(1, now)
+		require.False(t, ok)
+
+		// up to one second worth
+		now = now.Add(time.Minute)
+		for i := 0; i < 10; i++ {
+			_, ok = l.Allow(1, now)
+			require.True(t, ok)
+		}
+		_, ok = l.Allow(1, now)
+		require.False(t, ok)
+	})
+
+	t.Run("bytes per second", func(t *testing.T) {
+		l := NewDataLimiter(config.DataLimitsConfig{BytesPerSecond: 1000})
+		now := time.Now()
+		_, ok := l.Allow(600, now)
+		require.True(t, ok)
+		_, ok = l.Allow(600, now)
+		require.False(t, ok)
+		_, ok = l.Allow(400, now)
+		require.True(t, ok)
+
+		_, ok = l.Allow(600, now.Add(600*time.Millisecond))
+		require.True(t, ok)
+	})
+}
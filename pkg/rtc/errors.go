@@ -29,12 +29,15 @@ var (
 	ErrEmptyParticipantID      = errors.New("participant ID cannot be empty")
 	ErrMissingGrants           = errors.New("VideoGrant is missing")
 	ErrInternalError           = errors.New("internal error")
+	ErrAttributeKeyEmpty       = errors.New("attribute key cannot be empty")
+	ErrAttributesExceedLimits  = errors.New("attributes exceed limits")
 
 	// Track subscription related
-	ErrNoTrackPermission         = errors.New("participant is not allowed to subscribe to this track")
-	ErrNoSubscribePermission     = errors.New("participant is not given permission to subscribe to tracks")
-	ErrTrackNotFound             = errors.New("track cannot be found")
-	ErrTrackNotAttached          = errors.New("track is not yet attached")
-	ErrTrackNotBound             = errors.New("track not bound")
-	ErrSubscriptionLimitExceeded = errors.New("participant has exceeded its subscription limit")
+	ErrNoTrackPermission           = errors.New("participant is not allowed to subscribe to this track")
+	ErrNoSubscribePermission       = errors.New("participant is not given permission to subscribe to tracks")
+	ErrTrackNotFound               = errors.New("track cannot be found")
+	ErrTrackNotAttached            = errors.New("track is not yet attached")
+	ErrTrackNotBound               = errors.New("track not bound")
+	ErrSubscriptionLimitExceeded   = errors.New("participant has exceeded its subscription limit")
+	ErrSubscriptionPinningDisabled = errors.New("room allocation policy does not rank pinned video")
 )
@@ -37,6 +37,8 @@ func newMockParticipant(identity livekit.ParticipantIdentity, protocol types.Pro
 	p.CanSubscribeReturns(true)
 	p.CanPublishSourceReturns(!hidden)
 	p.CanPublishDataReturns(!hidden)
+	p.CanPublishDataTopicReturns(!hidden)
+	p.IsSubscribedToDataTopicReturns(true)
 	p.HiddenReturns(hidden)
 	p.ToProtoReturns(&livekit.ParticipantInfo{
 		Sid:         sid,
@@ -0,0 +1,212 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+type LastNParams struct {
+	// number of publishers selected
+	Count int
+	// minimum time a publisher stays selected before it could be replaced
+	MinHold time.Duration
+}
+
+// LastN selects the publishers whose camera video is subscribed to, the most recent active speakers,
+// filled up with other publishers in the order they joined while there aren't enough speakers.
+// Subscribers can pin tracks, which are kept subscribed regardless of selection.
+type LastN struct {
+	params LastNParams
+
+	lock       sync.RWMutex
+	selected   map[livekit.ParticipantID]time.Time // publisher -> time selected
+	lastActive map[livekit.ParticipantID]time.Time
+	pins       map[livekit.ParticipantID]map[livekit.TrackID]struct{} // subscriber -> pinned tracks
+}
+
+func NewLastN(params LastNParams) *LastN {
+	return &LastN{
+		params:     params,
+		selected:   make(map[livekit.ParticipantID]time.Time),
+		lastActive: make(map[livekit.ParticipantID]time.Time),
+		pins:       make(map[livekit.ParticipantID]map[livekit.TrackID]struct{}),
+	}
+}
+
+// IsLastNTrack returns true for tracks subscriptions are managed for
+func IsLastNTrack(track types.MediaTrack) bool {
+	return track.Kind() == livekit.TrackType_VIDEO && track.Source() == livekit.TrackSource_CAMERA
+}
+
+// Update refreshes the selection given publishers of camera video in join order, and the active speakers,
+// returning the publishers that were selected and deselected
+func (l *LastN) Update(
+	publishers []livekit.ParticipantID,
+	speakers []livekit.ParticipantID,
+	now time.Time,
+) (added []livekit.ParticipantID, removed []livekit.ParticipantID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	isPublisher := make(map[livekit.ParticipantID]bool, len(publishers))
+	for _, pID := range publishers {
+		isPublisher[pID] = true
+	}
+	for pID := range l.selected {
+		if !isPublisher[pID] {
+			delete(l.selected, pID)
+			removed = append(removed, pID)
+		}
+	}
+	for pID := range l.lastActive {
+		if !isPublisher[pID] {
+			delete(l.lastActive, pID)
+		}
+	}
+
+	isSpeaking := make(map[livekit.ParticipantID]bool, len(speakers))
+	for _, pID := range speakers {
+		if isPublisher[pID] {
+			isSpeaking[pID] = true
+			l.lastActive[pID] = now
+		}
+	}
+
+	// speakers, loudest first, replace publishers that have been quiet for the longest
+	for _, pID := range speakers {
+		if !isPublisher[pID] {
+			continue
+		}
+		if _, ok := l.selected[pID]; ok {
+			continue
+		}
+		if len(l.selected) >= l.params.Count {
+			victim, ok := l.findReplaceableLocked(isSpeaking, now)
+			if !ok {
+				// everyone selected spoke too recently, hold on to current selection
+				break
+			}
+			delete(l.selected, victim)
+			removed = append(removed, victim)
+		}
+		l.selected[pID] = now
+		added = append(added, pID)
+	}
+
+	// fill remaining slots
+	for _, pID := range publishers {
+		if len(l.selected) >= l.params.Count {
+			break
+		}
+		if _, ok := l.selected[pID]; ok {
+			continue
+		}
+		l.selected[pID] = now
+		added = append(added, pID)
+	}
+
+	// a publisher removed and re-added in the same update is not a change
+	if len(added) != 0 && len(removed) != 0 {
+		isAdded := make(map[livekit.ParticipantID]bool, len(added))
+		for _, pID := range added {
+			isAdded[pID] = true
+		}
+		filtered := removed[:0]
+		for _, pID := range removed {
+			if isAdded[pID] {
+				delete(isAdded, pID)
+				continue
+			}
+			filtered = append(filtered, pID)
+		}
+		removed = filtered
+	}
+	return
+}
+
+func (l *LastN) findReplaceableLocked(isSpeaking map[livekit.ParticipantID]bool, now time.Time) (livekit.ParticipantID, bool) {
+	var victim livekit.ParticipantID
+	var victimAt time.Time
+	found := false
+	for pID, selectedAt := range l.selected {
+		if isSpeaking[pID] {
+			continue
+		}
+		at := selectedAt
+		if lastActive := l.lastActive[pID]; lastActive.After(at) {
+			at = lastActive
+		}
+		if now.Sub(at) < l.params.MinHold {
+			continue
+		}
+		if !found || at.Before(victimAt) {
+			victim, victimAt, found = pID, at, true
+		}
+	}
+	return victim, found
+}
+
+func (l *LastN) IsSelected(publisherID livekit.ParticipantID) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	_, ok := l.selected[publisherID]
+	return ok
+}
+
+func (l *LastN) Pin(subscriberID livekit.ParticipantID, trackID livekit.TrackID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	pins := l.pins[subscriberID]
+	if pins == nil {
+		pins = make(map[livekit.TrackID]struct{})
+		l.pins[subscriberID] = pins
+	}
+	pins[trackID] = struct{}{}
+}
+
+func (l *LastN) Unpin(subscriberID livekit.ParticipantID, trackID livekit.TrackID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if pins := l.pins[subscriberID]; pins != nil {
+		delete(pins, trackID)
+		if len(pins) == 0 {
+			delete(l.pins, subscriberID)
+		}
+	}
+}
+
+func (l *LastN) IsPinned(subscriberID livekit.ParticipantID, trackID livekit.TrackID) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	_, ok := l.pins[subscriberID][trackID]
+	return ok
+}
+
+func (l *LastN) RemoveSubscriber(subscriberID livekit.ParticipantID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.pins, subscriberID)
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestLastN(t *testing.T) {
+	publishers := []livekit.ParticipantID{"p1", "p2", "p3", "p4"}
+
+	t.Run("fills with publishers in join order", func(t *testing.T) {
+		l := NewLastN(LastNParams{Count: 2, MinHold: time.Second})
+		added, removed := l.Update(publishers, nil, time.Now())
+		require.Equal(t, []livekit.ParticipantID{"p1", "p2"}, added)
+		require.Empty(t, removed)
+
+		added, removed = l.Update(publishers, nil, time.Now())
+		require.Empty(t, added)
+		require.Empty(t, removed)
+	})
+
+	t.Run("speakers replace publishers after hold", func(t *testing.T) {
+		l := NewLastN(LastNParams{Count: 2, MinHold: time.Second})
+		now := time.Now()
+		l.Update(publishers, nil, now)
+
+		// too soon, selection is held
+		added, removed := l.Update(publishers, []livekit.ParticipantID{"p3"}, now.Add(100*time.Millisecond))
+		require.Empty(t, added)
+		require.Empty(t, removed)
+		require.False(t, l.IsSelected("p3"))
+
+		added, removed = l.Update(publishers, []livekit.ParticipantID{"p3"}, now.Add(2*time.Second))
+		require.Equal(t, []livekit.ParticipantID{"p3"}, added)
+		require.Len(t, removed, 1)
+		require.True(t, l.IsSelected("p3"))
+
+		// p3 just spoke, a new speaker replaces the other one
+		added, removed = l.Update(publishers, []livekit.ParticipantID{"p4"}, now.Add(3*time.Second))
+		require.Equal(t, []livekit.ParticipantID{"p4"}, added)
+		require.Len(t, removed, 1)
+		require.NotEqual(t, livekit.ParticipantID("p3"), removed[0])
+		require.True(t, l.IsSelected("p3"))
+	})
+
+	t.Run("speaking publishers are never replaced", func(t *testing.T) {
+		l := NewLastN(LastNParams{Count: 1, MinHold: time.Second})
+		now := time.Now()
+		l.Update(publishers, []livekit.ParticipantID{"p2"}, now)
+		require.True(t, l.IsSelected("p2"))
+
+		added, removed := l.Update(publishers, []livekit.ParticipantID{"p2", "p3"}, now.Add(5*time.Second))
+		require.Empty(t, added)
+		require.Empty(t, removed)
+	})
+
+	t.Run("publishers leaving free slots", func(t *testing.T) {
+		l := NewLastN(LastNParams{Count: 2, MinHold: time.Second})
+		now := time.Now()
+		l.Update(publishers, nil, now)
+
+		added, removed := l.Update(publishers[1:], nil, now)
+		require.Equal(t, []livekit.ParticipantID{"p3"}, added)
+		require.Equal(t, []livekit.ParticipantID{"p1"}, removed)
+	})
+
+	t.Run("pins", func(t *testing.T) {
+		l := NewLastN(LastNParams{Count: 1})
+		l.Pin("s1", "t1")
+		require.True(t, l.IsPinned("s1", "t1"))
+		require.False(t, l.IsPinned("s2", "t1"))
+
+		l.Unpin("s1", "t1")
+		require.False(t, l.IsPinned("s1", "t1"))
+
+		l.Pin("s1", "t1")
+		l.RemoveSubscriber("s1")
+		require.False(t, l.IsPinned("s1", "t1"))
+	})
+}
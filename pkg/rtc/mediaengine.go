@@ -15,6 +15,7 @@
 package rtc
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/pion/webrtc/v3"
@@ -27,7 +28,23 @@ import (
 var opusCodecCapability = webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1"}
 var redCodecCapability = webrtc.RTPCodecCapability{MimeType: sfu.MimeTypeAudioRed, ClockRate: 48000, Channels: 2, SDPFmtpLine: "111/111"}
 
-func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedback RTCPFeedbackConfig, filterOutH264HighProfile bool) error {
+// payload types of RTX for video payload types
+var rtxPayloadTypes = map[webrtc.PayloadType]webrtc.PayloadType{
+	96:  97,
+	98:  99,
+	100: 101,
+	125: 107,
+	108: 109,
+	123: 118,
+	35:  36,
+}
+
+const (
+	videoRedPayloadType = 116
+	ulpfecPayloadType   = 117
+)
+
+func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedback RTCPFeedbackConfig, filterOutH264HighProfile bool, rtx bool, fec bool) error {
 	opusCodec := opusCodecCapability
 	opusCodec.RTCPFeedback = rtcpFeedback.Audio
 	var opusPayload webrtc.PayloadType
@@ -51,6 +68,7 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 	}
 
 	h264HighProfileFmtp := "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032"
+	videoRegistered := false
 	for _, codec := range []webrtc.RTPCodecParameters{
 		{
 			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000, RTCPFeedback: rtcpFeedback.Video},
@@ -88,6 +106,38 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
 				return err
 			}
+			videoRegistered = true
+
+			if rtx {
+				if err := me.RegisterCodec(webrtc.RTPCodecParameters{
+					RTPCodecCapability: webrtc.RTPCodecCapability{
+						MimeType:    sfu.MimeTypeRTX,
+						ClockRate:   codec.ClockRate,
+						SDPFmtpLine: fmt.Sprintf("apt=%d", codec.PayloadType),
+					},
+					PayloadType: rtxPayloadTypes[codec.PayloadType],
+				}, webrtc.RTPCodecTypeVideo); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// ULPFEC is sent encapsulated in RED
+	if fec && videoRegistered {
+		for _, codec := range []webrtc.RTPCodecParameters{
+			{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: sfu.MimeTypeVideoRed, ClockRate: 90000},
+				PayloadType:        videoRedPayloadType,
+			},
+			{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: sfu.MimeTypeULPFEC, ClockRate: 90000},
+				PayloadType:        ulpfecPayloadType,
+			},
+		} {
+			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -111,7 +161,7 @@ func registerHeaderExtensions(me *webrtc.MediaEngine, rtpHeaderExtension RTPHead
 
 func createMediaEngine(codecs []*livekit.Codec, config DirectionConfig, filterOutH264HighProfile bool) (*webrtc.MediaEngine, error) {
 	me := &webrtc.MediaEngine{}
-	if err := registerCodecs(me, codecs, config.RTCPFeedback, filterOutH264HighProfile); err != nil {
+	if err := registerCodecs(me, codecs, config.RTCPFeedback, filterOutH264HighProfile, config.RTX, config.FEC); err != nil {
 		return nil, err
 	}
 
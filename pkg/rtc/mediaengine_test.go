@@ -21,6 +21,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
 )
 
 func TestIsCodecEnabled(t *testing.T) {
@@ -38,3 +40,56 @@ func TestIsCodecEnabled(t *testing.T) {
 		require.False(t, IsCodecEnabled(enabledCodecs, webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}))
 	})
 }
+
+func TestRegisterRTXCodecs(t *testing.T) {
+	enabledCodecs := []*livekit.Codec{{Mime: "video/vp8"}, {Mime: "audio/opus"}}
+	for _, rtx := range []bool{false, true} {
+		me := &webrtc.MediaEngine{}
+		require.NoError(t, registerCodecs(me, enabledCodecs, RTCPFeedbackConfig{}, false, rtx, false))
+
+		pc, err := webrtc.NewAPI(webrtc.WithMediaEngine(me)).NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		tr, err := pc.AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, webrtc.RtpTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+
+		var rtxCodecs []webrtc.RTPCodecParameters
+		for _, codec := range tr.Sender().GetParameters().Codecs {
+			if codec.MimeType == sfu.MimeTypeRTX {
+				rtxCodecs = append(rtxCodecs, codec)
+			}
+		}
+		if rtx {
+			require.Len(t, rtxCodecs, 1)
+			require.Equal(t, "apt=96", rtxCodecs[0].SDPFmtpLine)
+			require.EqualValues(t, 97, rtxCodecs[0].PayloadType)
+		} else {
+			require.Empty(t, rtxCodecs)
+		}
+		require.NoError(t, pc.Close())
+	}
+}
+
+func TestRegisterFECCodecs(t *testing.T) {
+	for _, fec := range []bool{false, true} {
+		me := &webrtc.MediaEngine{}
+		require.NoError(t, registerCodecs(me, []*livekit.Codec{{Mime: "video/vp8"}}, RTCPFeedbackConfig{}, false, false, fec))
+
+		pc, err := webrtc.NewAPI(webrtc.WithMediaEngine(me)).NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		tr, err := pc.AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, webrtc.RtpTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+
+		payloadTypes := make(map[string]webrtc.PayloadType)
+		for _, codec := range tr.Sender().GetParameters().Codecs {
+			payloadTypes[codec.MimeType] = codec.PayloadType
+		}
+		if fec {
+			require.EqualValues(t, videoRedPayloadType, payloadTypes[sfu.MimeTypeVideoRed])
+			require.EqualValues(t, ulpfecPayloadType, payloadTypes[sfu.MimeTypeULPFEC])
+		} else {
+			require.NotContains(t, payloadTypes, sfu.MimeTypeVideoRed)
+			require.NotContains(t, payloadTypes, sfu.MimeTypeULPFEC)
+		}
+		require.NoError(t, pc.Close())
+	}
+}
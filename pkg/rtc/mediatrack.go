@@ -48,6 +48,9 @@ type MediaTrack struct {
 
 	dynacastManager *DynacastManager
 
+	// upstream bitrate cap, 0 when not capped
+	maxPublishBitrate atomic.Int64
+
 	lock sync.RWMutex
 }
 
@@ -58,17 +61,20 @@ type MediaTrackParams struct {
 	ParticipantID       livekit.ParticipantID
 	ParticipantIdentity livekit.ParticipantIdentity
 	ParticipantVersion  uint32
+	// track is relayed from another node hosting the room, publisher stats are reported by that node
+	IsRelayed bool
 	// channel to send RTCP packets to the source
-	RTCPChan          chan []rtcp.Packet
-	BufferFactory     *buffer.Factory
-	ReceiverConfig    ReceiverConfig
-	SubscriberConfig  DirectionConfig
-	PLIThrottleConfig config.PLIThrottleConfig
-	AudioConfig       config.AudioConfig
-	VideoConfig       config.VideoConfig
-	Telemetry         telemetry.TelemetryService
-	Logger            logger.Logger
-	SimTracks         map[uint32]SimulcastTrackInfo
+	RTCPChan            chan []rtcp.Packet
+	BufferFactory       *buffer.Factory
+	ReceiverConfig      ReceiverConfig
+	SubscriberConfig    DirectionConfig
+	PLIThrottleConfig   config.PLIThrottleConfig
+	KeyFrameCacheConfig config.KeyFrameCacheConfig
+	AudioConfig         config.AudioConfig
+	VideoConfig         config.VideoConfig
+	Telemetry           telemetry.TelemetryService
+	Logger              logger.Logger
+	SimTracks           map[uint32]SimulcastTrackInfo
 }
 
 func NewMediaTrack(params MediaTrackParams) *MediaTrack {
@@ -79,7 +85,7 @@ func NewMediaTrack(params MediaTrackParams) *MediaTrack {
 	t.MediaTrackReceiver = NewMediaTrackReceiver(MediaTrackReceiverParams{
 		TrackInfo:           params.TrackInfo,
 		MediaTrack:          t,
-		IsRelayed:           false,
+		IsRelayed:           params.IsRelayed,
 		ParticipantID:       params.ParticipantID,
 		ParticipantIdentity: params.ParticipantIdentity,
 		ParticipantVersion:  params.ParticipantVersion,
@@ -90,6 +96,9 @@ func NewMediaTrack(params MediaTrackParams) *MediaTrack {
 		Logger:              params.Logger,
 	})
 	t.MediaTrackReceiver.OnVideoLayerUpdate(func(layers []*livekit.VideoLayer) {
+		if t.params.IsRelayed {
+			return
+		}
 		t.params.Telemetry.TrackPublishedUpdate(context.Background(), t.PublisherID(),
 			&livekit.TrackInfo{
 				Sid:       string(t.ID()),
@@ -254,11 +263,13 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			twcc,
 			t.params.VideoConfig.StreamTracker,
 			sfu.WithPliThrottleConfig(t.params.PLIThrottleConfig),
+			sfu.WithKeyFrameCacheConfig(t.params.KeyFrameCacheConfig),
 			sfu.WithAudioConfig(t.params.AudioConfig),
 			sfu.WithLoadBalanceThreshold(20),
 			sfu.WithStreamTrackers(),
 		)
 		newWR.SetRTCPCh(t.params.RTCPChan)
+		newWR.SetMaxBitrate(t.maxPublishBitrate.Load())
 		newWR.OnCloseHandler(func() {
 			t.MediaTrackReceiver.SetClosing()
 			t.MediaTrackReceiver.ClearReceiver(mime, false)
@@ -269,6 +280,9 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			}
 		})
 		newWR.OnStatsUpdate(func(_ *sfu.WebRTCReceiver, stat *livekit.AnalyticsStat) {
+			if t.params.IsRelayed {
+				return
+			}
 			// LK-TODO: this needs to be receiver/mime aware
 			key := telemetry.StatsKeyForTrack(livekit.StreamType_UPSTREAM, t.PublisherID(), t.ID(), t.params.TrackInfo.Source, t.params.TrackInfo.Type)
 			t.params.Telemetry.TrackStats(key, stat)
@@ -328,6 +342,9 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 	})
 
 	buff.OnFinalRtpStats(func(stats *livekit.RTPStats) {
+		if t.params.IsRelayed {
+			return
+		}
 		t.params.Telemetry.TrackPublishRTPStats(
 			context.Background(),
 			t.params.ParticipantID,
@@ -358,6 +375,10 @@ func (t *MediaTrack) HasPendingCodec() bool {
 }
 
 func (t *MediaTrack) onMaxLayerChange(maxLayer int32) {
+	if t.params.IsRelayed {
+		return
+	}
+
 	ti := &livekit.TrackInfo{
 		Sid:  t.trackInfo.Sid,
 		Type: t.trackInfo.Type,
@@ -371,6 +392,26 @@ func (t *MediaTrack) onMaxLayerChange(maxLayer int32) {
 	t.params.Telemetry.TrackPublishedUpdate(context.Background(), t.PublisherID(), ti)
 }
 
+// SetMaxPublishBitrate caps bitrate of the publisher, 0 removes the cap
+func (t *MediaTrack) SetMaxPublishBitrate(bitrate int64) {
+	if t.params.IsRelayed || t.maxPublishBitrate.Swap(bitrate) == bitrate {
+		return
+	}
+
+	for _, receiver := range t.Receivers() {
+		if dr, ok := receiver.(*DummyReceiver); ok {
+			receiver = dr.Receiver()
+		}
+		if wr, ok := receiver.(*sfu.WebRTCReceiver); ok {
+			wr.SetMaxBitrate(bitrate)
+		}
+	}
+}
+
+func (t *MediaTrack) GetMaxPublishBitrate() int64 {
+	return t.maxPublishBitrate.Load()
+}
+
 func (t *MediaTrack) Restart() {
 	t.MediaTrackReceiver.Restart()
 
@@ -129,6 +129,7 @@ func NewMediaTrackReceiver(params MediaTrackReceiverParams) *MediaTrackReceiver
 		IsRelayed:        params.IsRelayed,
 		ReceiverConfig:   params.ReceiverConfig,
 		SubscriberConfig: params.SubscriberConfig,
+		AdaptiveRED:      params.AudioConfig.AdaptiveRED,
 		Telemetry:        params.Telemetry,
 		Logger:           params.Logger,
 	})
@@ -508,7 +509,7 @@ func (t *MediaTrackReceiver) AddSubscriber(sub types.LocalParticipant) (types.Su
 		StreamId:       streamId,
 		UpstreamCodecs: potentialCodecs,
 		Logger:         tLogger,
-		DisableRed:     t.trackInfo.GetDisableRed() || !t.params.AudioConfig.ActiveREDEncoding,
+		DisableRed:     t.trackInfo.GetDisableRed() || !(t.params.AudioConfig.ActiveREDEncoding || t.params.AudioConfig.AdaptiveRED.Enabled),
 	})
 	return t.MediaTrackSubscriptions.AddSubscriber(sub, wr)
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/telemetry"
@@ -53,6 +54,7 @@ type MediaTrackSubscriptionsParams struct {
 
 	ReceiverConfig   ReceiverConfig
 	SubscriberConfig DirectionConfig
+	AdaptiveRED      config.AdaptiveREDConfig
 
 	Telemetry telemetry.TelemetryService
 
@@ -134,6 +136,7 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		PlayoutDelayLimit: sub.GetPlayoutDelayConfig(),
 		Pacer:             sub.GetPacer(),
 		Trailer:           trailer,
+		AdaptiveRED:       t.params.AdaptiveRED,
 		Logger:            LoggerWithTrack(sub.GetLogger().WithComponent(sutils.ComponentSub), trackID, t.params.IsRelayed),
 	})
 	if err != nil {
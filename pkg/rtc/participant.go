@@ -99,6 +99,7 @@ type ParticipantParams struct {
 	Telemetry               telemetry.TelemetryService
 	Trailer                 []byte
 	PLIThrottleConfig       config.PLIThrottleConfig
+	KeyFrameCacheConfig     config.KeyFrameCacheConfig
 	CongestionControlConfig config.CongestionControlConfig
 	// codecs that are enabled for this room
 	EnabledCodecs                []*livekit.Codec
@@ -120,6 +121,7 @@ type ParticipantParams struct {
 	ReconnectOnSubscriptionError bool
 	ReconnectOnDataChannelError  bool
 	DataChannelMaxBufferedAmount uint64
+	DataLimits                   config.DataLimitsConfig
 	VersionGenerator             utils.TimedVersionGenerator
 	TrackResolver                types.MediaTrackResolver
 	DisableDynacast              bool
@@ -128,6 +130,13 @@ type ParticipantParams struct {
 	SubscriptionLimitVideo       int32
 	PlayoutDelay                 *livekit.PlayoutDelay
 	SyncStreams                  bool
+	DataTopics                   *routing.DataTopicGrant
+	PublishBitrate               *routing.PublishBitrateGrant
+	PublishBitrateConfig         config.PublishBitrateConfig
+	// priority of a subscribed track by room allocation policy, 0 when there is none
+	GetSubscriptionPriority func(subscriberID livekit.ParticipantID, track types.MediaTrack) uint8
+	// receive only participants offer their subscriber connection, e. g. WHEP players
+	ReceiveOnly bool
 }
 
 type ParticipantImpl struct {
@@ -138,7 +147,32 @@ type ParticipantImpl struct {
 	resSinkMu   sync.Mutex
 	resSink     routing.MessageSink
 	grants      *auth.ClaimGrants
+	attributes  map[string]string
 	isPublisher atomic.Bool
+	// reliable data channel of the primary transport is open, notified once with the participant active
+	reliableDCOpen            atomic.Bool
+	isDataChannelOpenNotified atomic.Bool
+	// topics of data packets the participant receives, all when nil
+	dataTopicSubscriptions map[string]struct{}
+
+	dataLimiter *DataLimiter
+
+	// serializes applying caps to tracks
+	publishBitrateLock sync.Mutex
+	// set by admins, guarded by lock
+	publishBitrateCap       int64
+	trackPublishBitrateCaps map[livekit.TrackID]int64
+
+	// serializes applying priorities to subscribed tracks
+	subscriptionPriorityLock sync.Mutex
+	// set by the participant and admins, guarded by lock
+	clientSubscriptionPriorities map[livekit.TrackID]uint8
+	adminSubscriptionPriorities  map[livekit.TrackID]uint8
+	// drops not reported to the participant yet, reported when the timer fires if no more packets are dropped
+	pendingDataDrops      int
+	pendingDataDropReason DataDropReason
+	lastDataDropNoticeAt  time.Time
+	dataDropNoticeTimer   *time.Timer
 
 	// when first connected
 	connectedAt time.Time
@@ -198,6 +232,7 @@ type ParticipantImpl struct {
 	onMigrateStateChange func(p types.LocalParticipant, migrateState types.MigrateState)
 	onParticipantUpdate  func(types.LocalParticipant)
 	onDataPacket         func(types.LocalParticipant, *livekit.DataPacket)
+	onDataChannelOpen    func(types.LocalParticipant)
 
 	migrateState atomic.Value // types.MigrateState
 
@@ -238,6 +273,7 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 			telemetry.BytesTrackIDForParticipantID(telemetry.BytesTrackTypeData, params.SID),
 			params.SID,
 			params.Telemetry),
+		dataLimiter:   NewDataLimiter(params.DataLimits),
 		supervisor:    supervisor.NewParticipantSupervisor(supervisor.ParticipantSupervisorParams{Logger: params.Logger}),
 		tracksQuality: make(map[livekit.TrackID]livekit.ConnectionQuality),
 		pubLogger:     params.Logger.WithComponent(sutils.ComponentPub),
@@ -403,6 +439,36 @@ func (p *ParticipantImpl) SetMetadata(metadata string) {
 	}
 }
 
+func (p *ParticipantImpl) Attributes() map[string]string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.attributes
+}
+
+// UpdateAttributes applies a partial update to attributes, an empty value deletes the key.
+// Returns the attributes that changed
+func (p *ParticipantImpl) UpdateAttributes(update map[string]string) map[string]string {
+	p.lock.Lock()
+	attributes, changed := ApplyAttributes(p.attributes, update)
+	if len(changed) == 0 {
+		p.lock.Unlock()
+		return nil
+	}
+
+	p.attributes = attributes
+	p.requireBroadcast = true
+	p.dirty.Store(true)
+
+	onParticipantUpdate := p.onParticipantUpdate
+	p.lock.Unlock()
+
+	if onParticipantUpdate != nil {
+		onParticipantUpdate(p)
+	}
+	return changed
+}
+
 func (p *ParticipantImpl) ClaimGrants() *auth.ClaimGrants {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -494,6 +560,9 @@ func (p *ParticipantImpl) ToProtoWithVersion() (*livekit.ParticipantInfo, utils.
 		Region:      p.params.Region,
 		IsPublisher: p.IsPublisher(),
 	}
+	if len(p.attributes) != 0 {
+		SetParticipantInfoAttributes(pi, p.attributes)
+	}
 	p.lock.RUnlock()
 	pi.Tracks = p.UpTrackManager.ToProto()
 
@@ -525,6 +594,12 @@ func (p *ParticipantImpl) OnStateChange(callback func(p types.LocalParticipant,
 	p.lock.Unlock()
 }
 
+func (p *ParticipantImpl) OnDataChannelOpen(callback func(types.LocalParticipant)) {
+	p.lock.Lock()
+	p.onDataChannelOpen = callback
+	p.lock.Unlock()
+}
+
 func (p *ParticipantImpl) OnMigrateStateChange(callback func(p types.LocalParticipant, state types.MigrateState)) {
 	p.lock.Lock()
 	p.onMigrateStateChange = callback
@@ -580,6 +655,12 @@ func (p *ParticipantImpl) HandleSignalSourceClose() {
 
 // HandleOffer an offer from remote participant, used when clients make the initial connection
 func (p *ParticipantImpl) HandleOffer(offer webrtc.SessionDescription) {
+	if p.params.ReceiveOnly {
+		p.subLogger.Debugw("received offer", "transport", livekit.SignalTarget_SUBSCRIBER)
+		p.TransportManager.HandleOffer(offer, false)
+		return
+	}
+
 	p.pubLogger.Debugw("received offer", "transport", livekit.SignalTarget_PUBLISHER)
 	shouldPend := false
 	if p.MigrateState() == types.MigrateStateInit {
@@ -738,6 +819,7 @@ func (p *ParticipantImpl) Close(sendLeave bool, reason types.ParticipantCloseRea
 	)
 	p.clearDisconnectTimer()
 	p.clearMigrationTimer()
+	p.clearDataDropNoticeTimer()
 
 	// send leave message
 	if sendLeave {
@@ -1062,7 +1144,7 @@ func (p *ParticipantImpl) onTrackSubscribed(subTrack types.SubscribedTrack) {
 		if p.TransportManager.HasSubscriberEverConnected() {
 			subTrack.DownTrack().SetConnected()
 		}
-		p.TransportManager.AddSubscribedTrack(subTrack)
+		p.addSubscribedTrackToAllocator(subTrack)
 	})
 }
 
@@ -1126,6 +1208,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 		TURNSEnabled:                 p.params.TURNSEnabled,
 		AllowPlayoutDelay:            p.params.PlayoutDelay.GetEnabled(),
 		DataChannelMaxBufferedAmount: p.params.DataChannelMaxBufferedAmount,
+		ReceiveOnly:                  p.params.ReceiveOnly,
 		Logger:                       p.params.Logger.WithComponent(sutils.ComponentTransport),
 	}
 	if p.params.SyncStreams && p.params.PlayoutDelay.GetEnabled() && p.params.ClientInfo.isFirefox() {
@@ -1166,6 +1249,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 	tm.OnPublisherInitialConnected(p.onPublisherInitialConnected)
 
 	tm.OnSubscriberOffer(p.onSubscriberOffer)
+	tm.OnSubscriberAnswer(p.onSubscriberAnswer)
 	tm.OnSubscriberICECandidate(func(c *webrtc.ICECandidate) error {
 		return p.onICECandidate(c, livekit.SignalTarget_SUBSCRIBER)
 	})
@@ -1174,6 +1258,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 
 	tm.OnPrimaryTransportInitialConnected(p.onPrimaryTransportInitialConnected)
 	tm.OnPrimaryTransportFullyEstablished(p.onPrimaryTransportFullyEstablished)
+	tm.OnPrimaryTransportReliableDataChannelOpen(p.onPrimaryTransportReliableDataChannelOpen)
 	tm.OnAnyTransportFailed(p.onAnyTransportFailed)
 	tm.OnAnyTransportNegotiationFailed(p.onAnyTransportNegotiationFailed)
 
@@ -1277,6 +1362,20 @@ func (p *ParticipantImpl) onSubscriberOffer(offer webrtc.SessionDescription) err
 	})
 }
 
+// when the server answers an offer of a receive only participant
+func (p *ParticipantImpl) onSubscriberAnswer(answer webrtc.SessionDescription) error {
+	if p.IsClosed() || p.IsDisconnected() {
+		return nil
+	}
+
+	p.subLogger.Debugw("sending answer", "transport", livekit.SignalTarget_SUBSCRIBER)
+	return p.writeMessage(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Answer{
+			Answer: ToProtoSessionDescription(answer),
+		},
+	})
+}
+
 func (p *ParticipantImpl) removePublishedTrack(track types.MediaTrack) {
 	p.RemovePublishedTrack(track, false, false)
 	if p.ProtocolVersion().SupportsUnpublish() {
@@ -1342,6 +1441,12 @@ func (p *ParticipantImpl) onDataMessage(kind livekit.DataPacket_Kind, data []byt
 
 	p.dataChannelStats.AddBytes(uint64(len(data)), false)
 
+	if reason, ok := p.dataLimiter.Allow(len(data), time.Now()); !ok {
+		p.dataChannelStats.AddDropped(1)
+		p.onDataDropped(reason)
+		return
+	}
+
 	dp := livekit.DataPacket{}
 	if err := proto.Unmarshal(data, &dp); err != nil {
 		p.pubLogger.Warnw("could not parse data packet", err)
@@ -1354,6 +1459,16 @@ func (p *ParticipantImpl) onDataMessage(kind livekit.DataPacket_Kind, data []byt
 	// only forward on user payloads
 	switch payload := dp.Value.(type) {
 	case *livekit.DataPacket_User:
+		topic := payload.User.GetTopic()
+		if topic == DataTopicSubscriptionsTopic {
+			p.handleDataTopicSubscriptions(payload.User.Payload)
+			return
+		}
+		if !p.CanPublishDataTopic(topic) {
+			p.pubLogger.Debugw("dropping data packet, not allowed to publish to topic", "topic", topic)
+			return
+		}
+
 		p.lock.RLock()
 		onDataPacket := p.onDataPacket
 		p.lock.RUnlock()
@@ -1400,6 +1515,29 @@ func (p *ParticipantImpl) onPrimaryTransportInitialConnected() {
 
 func (p *ParticipantImpl) onPrimaryTransportFullyEstablished() {
 	p.updateState(livekit.ParticipantInfo_ACTIVE)
+	p.maybeNotifyDataChannelOpen()
+}
+
+func (p *ParticipantImpl) onPrimaryTransportReliableDataChannelOpen() {
+	p.reliableDCOpen.Store(true)
+	p.maybeNotifyDataChannelOpen()
+}
+
+// maybeNotifyDataChannelOpen notifies once, when the participant is active and the reliable data channel is open
+func (p *ParticipantImpl) maybeNotifyDataChannelOpen() {
+	if !p.reliableDCOpen.Load() || p.State() != livekit.ParticipantInfo_ACTIVE {
+		return
+	}
+	if p.isDataChannelOpenNotified.Swap(true) {
+		return
+	}
+
+	p.lock.RLock()
+	onDataChannelOpen := p.onDataChannelOpen
+	p.lock.RUnlock()
+	if onDataChannelOpen != nil {
+		go onDataChannelOpen(p)
+	}
 }
 
 func (p *ParticipantImpl) clearDisconnectTimer() {
@@ -1709,6 +1847,10 @@ func (p *ParticipantImpl) setTrackMuted(trackID livekit.TrackID, muted bool) *li
 	var trackInfo *livekit.TrackInfo
 	if track != nil {
 		trackInfo = track.ToProto()
+		if track.Kind() == livekit.TrackType_VIDEO {
+			// participant cap is split between unmuted tracks
+			p.updatePublishBitrateCaps()
+		}
 	}
 
 	isPending := false
@@ -1862,6 +2004,7 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 		Logger:              LoggerWithTrack(p.pubLogger, livekit.TrackID(ti.Sid), false),
 		SubscriberConfig:    p.params.Config.Subscriber,
 		PLIThrottleConfig:   p.params.PLIThrottleConfig,
+		KeyFrameCacheConfig: p.params.KeyFrameCacheConfig,
 		SimTracks:           p.params.SimTracks,
 	})
 
@@ -1911,7 +2054,13 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 
 		p.dirty.Store(true)
 
+		p.lock.Lock()
+		delete(p.trackPublishBitrateCaps, trackID)
+		p.lock.Unlock()
+
 		if !p.IsClosed() {
+			p.updatePublishBitrateCaps()
+
 			// unpublished events aren't necessary when participant is closed
 			p.pubLogger.Infow("unpublished track", "trackID", ti.Sid, "trackInfo", ti)
 			p.lock.RLock()
@@ -1927,6 +2076,10 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 }
 
 func (p *ParticipantImpl) handleTrackPublished(track types.MediaTrack) {
+	if track.Kind() == livekit.TrackType_VIDEO {
+		p.updatePublishBitrateCaps()
+	}
+
 	p.lock.RLock()
 	onTrackPublished := p.onTrackPublished
 	p.lock.RUnlock()
@@ -0,0 +1,158 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// DataTopicSubscriptionsTopic is the topic of data packets participants send to set the topics they receive,
+// with a JSON array of topics as payload, or null to receive all topics they are allowed to.
+// These packets are handled by the server and not forwarded.
+const DataTopicSubscriptionsTopic = "lk.data_topics"
+
+// DataDroppedTopic is the topic of data packets notifying participants their data packets were dropped for
+// exceeding limits, with a JSON object as payload, e.g. {"reason": "rate", "dropped": 3}.
+// Drops are aggregated, with at most one notice every dataDropNoticeInterval.
+const DataDroppedTopic = "lk.data_dropped"
+
+const dataDropNoticeInterval = time.Second
+
+type dataDropNotice struct {
+	Reason  DataDropReason `json:"reason"`
+	Dropped int            `json:"dropped"`
+}
+
+func (p *ParticipantImpl) CanPublishDataTopic(topic string) bool {
+	return p.params.DataTopics.AllowsPublish(topic)
+}
+
+// IsSubscribedToDataTopic checks if data packets of topic should be delivered to the participant,
+// data packets without a topic are always delivered
+func (p *ParticipantImpl) IsSubscribedToDataTopic(topic string) bool {
+	if topic == "" {
+		return true
+	}
+	if !p.params.DataTopics.AllowsSubscribe(topic) {
+		return false
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.dataTopicSubscriptions == nil {
+		return true
+	}
+	_, ok := p.dataTopicSubscriptions[topic]
+	return ok
+}
+
+// SetDataTopicSubscriptions sets topics the participant receives, nil to receive all topics
+func (p *ParticipantImpl) SetDataTopicSubscriptions(topics []string) {
+	var subscriptions map[string]struct{}
+	if topics != nil {
+		subscriptions = make(map[string]struct{}, len(topics))
+		for _, topic := range topics {
+			subscriptions[topic] = struct{}{}
+		}
+	}
+
+	p.lock.Lock()
+	p.dataTopicSubscriptions = subscriptions
+	p.lock.Unlock()
+}
+
+func (p *ParticipantImpl) handleDataTopicSubscriptions(payload []byte) {
+	var topics []string
+	if err := json.Unmarshal(payload, &topics); err != nil {
+		p.pubLogger.Warnw("could not parse data topic subscriptions", err)
+		return
+	}
+	p.pubLogger.Debugw("updating data topic subscriptions", "topics", topics)
+	p.SetDataTopicSubscriptions(topics)
+}
+
+func (p *ParticipantImpl) onDataDropped(reason DataDropReason) {
+	p.lock.Lock()
+	p.pendingDataDrops++
+	p.pendingDataDropReason = reason
+	if wait := dataDropNoticeInterval - time.Since(p.lastDataDropNoticeAt); wait > 0 {
+		// report when the interval expires, even if no more packets are dropped
+		if p.dataDropNoticeTimer == nil {
+			p.dataDropNoticeTimer = time.AfterFunc(wait, p.sendDataDropNotice)
+		}
+		p.lock.Unlock()
+		return
+	}
+	p.lock.Unlock()
+
+	p.sendDataDropNotice()
+}
+
+func (p *ParticipantImpl) clearDataDropNoticeTimer() {
+	p.lock.Lock()
+	if p.dataDropNoticeTimer != nil {
+		p.dataDropNoticeTimer.Stop()
+		p.dataDropNoticeTimer = nil
+	}
+	p.lock.Unlock()
+}
+
+func (p *ParticipantImpl) sendDataDropNotice() {
+	p.lock.Lock()
+	if p.dataDropNoticeTimer != nil {
+		p.dataDropNoticeTimer.Stop()
+		p.dataDropNoticeTimer = nil
+	}
+	if p.pendingDataDrops == 0 || p.IsClosed() {
+		p.lock.Unlock()
+		return
+	}
+	notice := dataDropNotice{
+		Reason:  p.pendingDataDropReason,
+		Dropped: p.pendingDataDrops,
+	}
+	p.pendingDataDrops = 0
+	p.lastDataDropNoticeAt = time.Now()
+	p.lock.Unlock()
+
+	p.pubLogger.Infow("dropped data packets exceeding limits", "reason", notice.Reason, "dropped", notice.Dropped)
+
+	payload, err := json.Marshal(notice)
+	if err != nil {
+		return
+	}
+	topic := DataDroppedTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		return
+	}
+	if err = p.SendDataPacket(dp, data); err != nil {
+		p.pubLogger.Debugw("could not send data drop notice", "error", err)
+	}
+}
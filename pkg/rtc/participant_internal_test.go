@@ -239,6 +239,184 @@ func TestOutOfOrderUpdates(t *testing.T) {
 	require.Equal(t, "second update", sent.GetUpdate().Participants[0].Metadata)
 }
 
+func TestUpdateAttributes(t *testing.T) {
+	p := newParticipantForTest("test")
+	updates := 0
+	p.OnParticipantUpdate(func(_ types.LocalParticipant) {
+		updates++
+	})
+	pi1 := p.ToProto()
+
+	changed := p.UpdateAttributes(map[string]string{"a": "1", "b": "2"})
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, changed)
+	require.Equal(t, 1, updates)
+	require.False(t, p.CanSkipBroadcast())
+
+	// no changes, no update
+	require.Nil(t, p.UpdateAttributes(map[string]string{"a": "1"}))
+	require.Equal(t, 1, updates)
+
+	changed = p.UpdateAttributes(map[string]string{"a": ""})
+	require.Equal(t, map[string]string{"a": ""}, changed)
+	require.Equal(t, 2, updates)
+
+	pi2 := p.ToProto()
+	require.Greater(t, pi2.Version, pi1.Version)
+	require.Equal(t, map[string]string{"b": "2"}, GetParticipantInfoAttributes(pi2))
+}
+
+func TestDataTopics(t *testing.T) {
+	newPacket := func(topic string, payload string) []byte {
+		data, err := proto.Marshal(&livekit.DataPacket{
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					Topic:   proto.String(topic),
+					Payload: []byte(payload),
+				},
+			},
+		})
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("publish permissions", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.DataTopics = &routing.DataTopicGrant{CanPublish: []string{"chat"}}
+		var topics []string
+		p.OnDataPacket(func(_ types.LocalParticipant, dp *livekit.DataPacket) {
+			topics = append(topics, dp.GetUser().GetTopic())
+		})
+
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket("chat", "hello"))
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket("telemetry", "{}"))
+		require.Equal(t, []string{"chat"}, topics)
+	})
+
+	t.Run("subscriptions", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.DataTopics = &routing.DataTopicGrant{CanSubscribe: []string{"chat", "cursor.*"}}
+		forwarded := 0
+		p.OnDataPacket(func(_ types.LocalParticipant, _ *livekit.DataPacket) {
+			forwarded++
+		})
+
+		require.True(t, p.IsSubscribedToDataTopic(""))
+		require.True(t, p.IsSubscribedToDataTopic("cursor.p1"))
+		require.False(t, p.IsSubscribedToDataTopic("telemetry"))
+
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket(DataTopicSubscriptionsTopic, `["chat", "telemetry"]`))
+		require.Zero(t, forwarded)
+		require.True(t, p.IsSubscribedToDataTopic("chat"))
+		require.False(t, p.IsSubscribedToDataTopic("cursor.p1"))
+		// not allowed, even when subscribed
+		require.False(t, p.IsSubscribedToDataTopic("telemetry"))
+
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket(DataTopicSubscriptionsTopic, "null"))
+		require.True(t, p.IsSubscribedToDataTopic("cursor.p1"))
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.dataLimiter = NewDataLimiter(config.DataLimitsConfig{MessagesPerSecond: 2, MaxPacketSize: 32})
+		forwarded := 0
+		p.OnDataPacket(func(_ types.LocalParticipant, _ *livekit.DataPacket) {
+			forwarded++
+		})
+
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket("chat", strings.Repeat("a", 64)))
+		require.Zero(t, forwarded)
+		// first drop is reported right away
+		require.Zero(t, p.pendingDataDrops)
+		require.False(t, p.lastDataDropNoticeAt.IsZero())
+
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket("chat", "1"))
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket("chat", "2"))
+		p.onDataMessage(livekit.DataPacket_RELIABLE, newPacket("chat", "3"))
+		require.Equal(t, 2, forwarded)
+		// later drops are aggregated
+		require.Equal(t, 1, p.pendingDataDrops)
+
+		// and reported when the interval expires
+		require.Eventually(t, func() bool {
+			p.lock.RLock()
+			defer p.lock.RUnlock()
+			return p.pendingDataDrops == 0 && p.dataDropNoticeTimer == nil
+		}, 2*dataDropNoticeInterval, 10*time.Millisecond)
+	})
+}
+
+func TestPublishBitrateCaps(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.PublishBitrateConfig = config.PublishBitrateConfig{MaxTrackBitrate: 2_000_000}
+	p.params.PublishBitrate = &routing.PublishBitrateGrant{MaxBitrate: 3_000_000}
+	addVideoTrack := func(trackID livekit.TrackID) *MediaTrack {
+		mt := NewMediaTrack(MediaTrackParams{
+			TrackInfo: &livekit.TrackInfo{Sid: string(trackID), Type: livekit.TrackType_VIDEO},
+			Logger:    logger.GetLogger(),
+		})
+		p.UpTrackManager.AddPublishedTrack(mt)
+		p.updatePublishBitrateCaps()
+		return mt
+	}
+
+	camera := addVideoTrack("camera")
+	require.EqualValues(t, 2_000_000, camera.GetMaxPublishBitrate())
+
+	// participant cap is split between video tracks
+	screen := addVideoTrack("screen")
+	require.EqualValues(t, 1_500_000, camera.GetMaxPublishBitrate())
+	require.EqualValues(t, 1_500_000, screen.GetMaxPublishBitrate())
+
+	// lowest cap applies
+	require.NoError(t, p.SetPublishBitrateCap("screen", 500_000))
+	require.EqualValues(t, 1_500_000, camera.GetMaxPublishBitrate())
+	require.EqualValues(t, 500_000, screen.GetMaxPublishBitrate())
+
+	// muted tracks do not take a share
+	p.UpTrackManager.SetPublishedTrackMuted("screen", true)
+	p.updatePublishBitrateCaps()
+	require.EqualValues(t, 2_000_000, camera.GetMaxPublishBitrate())
+
+	require.NoError(t, p.SetPublishBitrateCap("", 1_000_000))
+	caps := p.GetPublishBitrateCaps()
+	require.EqualValues(t, 1_000_000, caps.MaxBitrate)
+	require.Equal(t, map[livekit.TrackID]int64{"screen": 500_000}, caps.MaxTrackBitrates)
+	require.Equal(t, map[livekit.TrackID]int64{"camera": 1_000_000, "screen": 500_000}, caps.TrackBitrates)
+
+	require.ErrorIs(t, p.SetPublishBitrateCap("unknown", 1_000_000), ErrTrackNotFound)
+}
+
+func TestSubscriptionPriority(t *testing.T) {
+	p := newParticipantForTest("test")
+	policyPriority := uint8(0)
+	p.params.GetSubscriptionPriority = func(_ livekit.ParticipantID, _ types.MediaTrack) uint8 {
+		return policyPriority
+	}
+	subTrack := &typesfakes.FakeSubscribedTrack{}
+	subTrack.IDReturns("video")
+	subTrack.MediaTrackReturns(&typesfakes.FakeMediaTrack{})
+
+	// allocator picks a default
+	require.Zero(t, p.subscriptionPriority(subTrack).Priority)
+
+	p.UpdateSubscribedTrackSettings("video", &livekit.UpdateTrackSettings{Priority: 1000})
+	require.Equal(t, types.SubscriptionPriority{TrackID: "video", Client: 255, Priority: 255}, p.subscriptionPriority(subTrack))
+
+	// higher of policy and client priority applies
+	p.UpdateSubscribedTrackSettings("video", &livekit.UpdateTrackSettings{Priority: 10})
+	policyPriority = 200
+	require.EqualValues(t, 200, p.subscriptionPriority(subTrack).Priority)
+
+	// admins override both
+	p.lock.Lock()
+	p.adminSubscriptionPriorities = map[livekit.TrackID]uint8{"video": 5}
+	p.lock.Unlock()
+	require.Equal(t, types.SubscriptionPriority{TrackID: "video", Admin: 5, Policy: 200, Client: 10, Priority: 5}, p.subscriptionPriority(subTrack))
+
+	// admins can only set priorities of subscribed tracks
+	require.ErrorIs(t, p.SetSubscriptionPriority("unknown", 10), ErrTrackNotFound)
+}
+
 // after disconnection, things should continue to function and not panic
 func TestDisconnectTiming(t *testing.T) {
 	t.Run("Negotiate doesn't panic after channel closed", func(t *testing.T) {
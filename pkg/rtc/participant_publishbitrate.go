@@ -0,0 +1,119 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// SetPublishBitrateCap sets an admin cap of upstream video bitrate, in bits per second, 0 removes it.
+// Without a trackID, the cap applies to all video tracks of the participant and is split evenly between them.
+func (p *ParticipantImpl) SetPublishBitrateCap(trackID livekit.TrackID, bitrate int64) error {
+	if trackID != "" {
+		track := p.GetPublishedTrack(trackID)
+		if track == nil || track.Kind() != livekit.TrackType_VIDEO {
+			return ErrTrackNotFound
+		}
+	}
+
+	p.lock.Lock()
+	if trackID == "" {
+		p.publishBitrateCap = bitrate
+	} else if bitrate > 0 {
+		if p.trackPublishBitrateCaps == nil {
+			p.trackPublishBitrateCaps = make(map[livekit.TrackID]int64)
+		}
+		p.trackPublishBitrateCaps[trackID] = bitrate
+	} else {
+		delete(p.trackPublishBitrateCaps, trackID)
+	}
+	p.lock.Unlock()
+
+	p.pubLogger.Infow("setting publish bitrate cap", "trackID", trackID, "bitrate", bitrate)
+	p.updatePublishBitrateCaps()
+	return nil
+}
+
+func (p *ParticipantImpl) GetPublishBitrateCaps() types.PublishBitrateCaps {
+	p.lock.RLock()
+	caps := types.PublishBitrateCaps{
+		MaxBitrate:       p.publishBitrateCap,
+		MaxTrackBitrates: make(map[livekit.TrackID]int64, len(p.trackPublishBitrateCaps)),
+		TrackBitrates:    make(map[livekit.TrackID]int64),
+	}
+	for trackID, bitrate := range p.trackPublishBitrateCaps {
+		caps.MaxTrackBitrates[trackID] = bitrate
+	}
+	p.lock.RUnlock()
+
+	for _, track := range p.GetPublishedTracks() {
+		if mt, ok := track.(*MediaTrack); ok && mt.Kind() == livekit.TrackType_VIDEO {
+			caps.TrackBitrates[mt.ID()] = mt.GetMaxPublishBitrate()
+		}
+	}
+	return caps
+}
+
+// updatePublishBitrateCaps applies the lowest cap of admins, grants and room configuration to each video track.
+// Participant caps are split evenly between unmuted video tracks, so it needs updating when those change
+func (p *ParticipantImpl) updatePublishBitrateCaps() {
+	p.publishBitrateLock.Lock()
+	defer p.publishBitrateLock.Unlock()
+
+	var videoTracks []*MediaTrack
+	numUnmuted := int64(0)
+	for _, track := range p.GetPublishedTracks() {
+		if mt, ok := track.(*MediaTrack); ok && mt.Kind() == livekit.TrackType_VIDEO {
+			videoTracks = append(videoTracks, mt)
+			if !mt.IsMuted() {
+				numUnmuted++
+			}
+		}
+	}
+	if len(videoTracks) == 0 {
+		return
+	}
+
+	conf := p.params.PublishBitrateConfig
+	grant := p.params.PublishBitrate
+	p.lock.RLock()
+	participantCap := minBitrateCap(conf.MaxParticipantBitrate, grant.GetMaxBitrate(), p.publishBitrateCap)
+	trackCaps := make(map[livekit.TrackID]int64, len(videoTracks))
+	for _, mt := range videoTracks {
+		trackCaps[mt.ID()] = minBitrateCap(conf.MaxTrackBitrate, grant.GetMaxTrackBitrate(), p.trackPublishBitrateCaps[mt.ID()])
+	}
+	p.lock.RUnlock()
+
+	for _, mt := range videoTracks {
+		bitrate := trackCaps[mt.ID()]
+		if participantCap > 0 && numUnmuted > 0 && !mt.IsMuted() {
+			bitrate = minBitrateCap(bitrate, participantCap/numUnmuted)
+		}
+		mt.SetMaxPublishBitrate(bitrate)
+	}
+}
+
+// minBitrateCap returns the lowest cap, where 0 is no cap
+func minBitrateCap(caps ...int64) int64 {
+	var bitrate int64
+	for _, c := range caps {
+		if c > 0 && (bitrate == 0 || c < bitrate) {
+			bitrate = c
+		}
+	}
+	return bitrate
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"math"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// UpdateSubscribedTrackSettings applies settings of the participant, and the priority they set for the track
+func (p *ParticipantImpl) UpdateSubscribedTrackSettings(trackID livekit.TrackID, settings *livekit.UpdateTrackSettings) {
+	p.lock.Lock()
+	if settings.Priority > 0 {
+		if p.clientSubscriptionPriorities == nil {
+			p.clientSubscriptionPriorities = make(map[livekit.TrackID]uint8)
+		}
+		priority := settings.Priority
+		if priority > math.MaxUint8 {
+			priority = math.MaxUint8
+		}
+		p.clientSubscriptionPriorities[trackID] = uint8(priority)
+	} else {
+		delete(p.clientSubscriptionPriorities, trackID)
+	}
+	p.lock.Unlock()
+
+	p.SubscriptionManager.UpdateSubscribedTrackSettings(trackID, settings)
+	p.updateSubscriptionPriority(trackID)
+}
+
+// SetSubscriptionPriority sets an admin priority of a subscribed video track, overriding room policy and
+// client priority, 0 removes it
+func (p *ParticipantImpl) SetSubscriptionPriority(trackID livekit.TrackID, priority uint8) error {
+	subTrack := p.getSubscribedTrack(trackID)
+	if subTrack == nil || subTrack.MediaTrack().Kind() != livekit.TrackType_VIDEO {
+		return ErrTrackNotFound
+	}
+
+	p.lock.Lock()
+	if priority > 0 {
+		if p.adminSubscriptionPriorities == nil {
+			p.adminSubscriptionPriorities = make(map[livekit.TrackID]uint8)
+		}
+		p.adminSubscriptionPriorities[trackID] = priority
+	} else {
+		delete(p.adminSubscriptionPriorities, trackID)
+	}
+	p.lock.Unlock()
+
+	p.subLogger.Infow("setting subscription priority", "trackID", trackID, "priority", priority)
+	p.updateSubscriptionPriority(trackID)
+	return nil
+}
+
+func (p *ParticipantImpl) GetSubscriptionPriorities() []types.SubscriptionPriority {
+	var priorities []types.SubscriptionPriority
+	for _, subTrack := range p.SubscriptionManager.GetSubscribedTracks() {
+		if subTrack.MediaTrack().Kind() == livekit.TrackType_VIDEO {
+			priorities = append(priorities, p.subscriptionPriority(subTrack))
+		}
+	}
+	return priorities
+}
+
+func (p *ParticipantImpl) UpdateSubscriptionPriorities() {
+	for _, subTrack := range p.SubscriptionManager.GetSubscribedTracks() {
+		if subTrack.MediaTrack().Kind() == livekit.TrackType_VIDEO {
+			p.updateSubscriptionPriority(subTrack.ID())
+		}
+	}
+}
+
+func (p *ParticipantImpl) addSubscribedTrackToAllocator(subTrack types.SubscribedTrack) {
+	p.subscriptionPriorityLock.Lock()
+	defer p.subscriptionPriorityLock.Unlock()
+
+	p.TransportManager.AddSubscribedTrack(subTrack, p.subscriptionPriority(subTrack).Priority)
+}
+
+func (p *ParticipantImpl) updateSubscriptionPriority(trackID livekit.TrackID) {
+	p.subscriptionPriorityLock.Lock()
+	defer p.subscriptionPriorityLock.Unlock()
+
+	subTrack := p.getSubscribedTrack(trackID)
+	if subTrack == nil || subTrack.MediaTrack().Kind() != livekit.TrackType_VIDEO {
+		// applied once subscribed
+		return
+	}
+	p.TransportManager.SetSubscribedTrackPriority(subTrack, p.subscriptionPriority(subTrack).Priority)
+}
+
+// subscriptionPriority lets admins override the higher of room policy and client priority
+func (p *ParticipantImpl) subscriptionPriority(subTrack types.SubscribedTrack) types.SubscriptionPriority {
+	sp := types.SubscriptionPriority{
+		TrackID: subTrack.ID(),
+	}
+	if f := p.params.GetSubscriptionPriority; f != nil {
+		sp.Policy = f(p.ID(), subTrack.MediaTrack())
+	}
+	p.lock.RLock()
+	sp.Admin = p.adminSubscriptionPriorities[sp.TrackID]
+	sp.Client = p.clientSubscriptionPriorities[sp.TrackID]
+	p.lock.RUnlock()
+
+	switch {
+	case sp.Admin > 0:
+		sp.Priority = sp.Admin
+	case sp.Policy > sp.Client:
+		sp.Priority = sp.Policy
+	default:
+		sp.Priority = sp.Client
+	}
+	return sp
+}
+
+func (p *ParticipantImpl) getSubscribedTrack(trackID livekit.TrackID) types.SubscribedTrack {
+	for _, subTrack := range p.SubscriptionManager.GetSubscribedTracks() {
+		if subTrack.ID() == trackID {
+			return subTrack
+		}
+	}
+	return nil
+}
@@ -16,9 +16,11 @@ package rtc
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"math"
+	"regexp"
 	"sort"
 	"sync"
 	"time"
@@ -70,12 +72,17 @@ type Room struct {
 	protoProxy *utils.ProtoProxy[*livekit.Room]
 	Logger     logger.Logger
 
-	config         WebRTCConfig
-	audioConfig    *config.AudioConfig
-	serverInfo     *livekit.ServerInfo
-	telemetry      telemetry.TelemetryService
-	egressLauncher EgressLauncher
-	trackManager   *RoomTrackManager
+	config          WebRTCConfig
+	audioConfig     *config.AudioConfig
+	attributeLimits AttributeLimits
+	lastN           *LastN
+	subPriority     *SubscriptionPriorityPolicy
+	spatial         *Spatial
+	spatialQueue    *sutils.OpsQueue
+	serverInfo      *livekit.ServerInfo
+	telemetry       telemetry.TelemetryService
+	egressLauncher  EgressLauncher
+	trackManager    *RoomTrackManager
 
 	// map of identity -> Participant
 	participants              map[livekit.ParticipantIdentity]types.LocalParticipant
@@ -84,6 +91,14 @@ type Room struct {
 	hasPublished              sync.Map // map of identity -> bool
 	bufferFactory             *buffer.FactoryOfBufferFactory
 
+	// set when the room is distributed across nodes
+	relay *RoomRelay
+	// map of identity -> participants hosted by other nodes
+	remoteParticipants map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
+
+	// recent data packets, delivered to participants joining later
+	dataHistory *DataHistory
+
 	// batch update participant info for non-publishers
 	batchedUpdates   map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
 	batchedUpdatesMu sync.Mutex
@@ -111,6 +126,7 @@ func NewRoom(
 	internal *livekit.RoomInternal,
 	config WebRTCConfig,
 	audioConfig *config.AudioConfig,
+	roomConfig *config.RoomConfig,
 	serverInfo *livekit.ServerInfo,
 	telemetry telemetry.TelemetryService,
 	egressLauncher EgressLauncher,
@@ -132,12 +148,33 @@ func NewRoom(
 		participants:              make(map[livekit.ParticipantIdentity]types.LocalParticipant),
 		participantOpts:           make(map[livekit.ParticipantIdentity]*ParticipantOptions),
 		participantRequestSources: make(map[livekit.ParticipantIdentity]routing.MessageSource),
+		remoteParticipants:        make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
 		bufferFactory:             buffer.NewFactoryOfBufferFactory(config.Receiver.PacketBufferSize),
 		batchedUpdates:            make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
 		closed:                    make(chan struct{}),
 		trailer:                   []byte(utils.RandomSecret()),
 	}
 	r.protoProxy = utils.NewProtoProxy[*livekit.Room](roomUpdateInterval, r.updateProto)
+	r.attributeLimits = AttributeLimitsFromConfig(roomConfig)
+	if roomConfig != nil && roomConfig.LastN.Speakers > 0 && r.matchesRoomPattern("last-N", roomConfig.LastN.RoomPattern) {
+		r.lastN = NewLastN(LastNParams{
+			Count:   roomConfig.LastN.Speakers,
+			MinHold: roomConfig.LastN.MinHold,
+		})
+	}
+	if roomConfig != nil {
+		if conf := r.roomSubscriptionPriority(roomConfig.SubscriptionPriority); len(conf.Policy) != 0 {
+			r.subPriority = NewSubscriptionPriorityPolicy(conf, r.Logger)
+		}
+	}
+	if roomConfig != nil && roomConfig.Spatial.Enabled {
+		r.spatial = NewSpatial(roomConfig.Spatial)
+		r.spatialQueue = sutils.NewOpsQueue(r.Logger, "spatial", 100)
+		r.spatialQueue.Start()
+	}
+	if roomConfig != nil && roomConfig.DataHistory.Messages > 0 {
+		r.dataHistory = r.newDataHistory(roomConfig.DataHistory)
+	}
 	if r.protoRoom.EmptyTimeout == 0 {
 		r.protoRoom.EmptyTimeout = DefaultEmptyTimeout
 	}
@@ -206,7 +243,12 @@ func (r *Room) GetLocalParticipants() []types.LocalParticipant {
 	return r.GetParticipants()
 }
 
+// GetActiveSpeakers returns active speakers, including participants hosted by other nodes when the room is distributed
 func (r *Room) GetActiveSpeakers() []*livekit.SpeakerInfo {
+	return r.withRemoteSpeakers(r.getLocalActiveSpeakers())
+}
+
+func (r *Room) getLocalActiveSpeakers() []*livekit.SpeakerInfo {
 	participants := r.GetParticipants()
 	speakers := make([]*livekit.SpeakerInfo, 0, len(participants))
 	for _, p := range participants {
@@ -233,6 +275,53 @@ func (r *Room) GetActiveSpeakers() []*livekit.SpeakerInfo {
 	return speakers
 }
 
+func (r *Room) withRemoteSpeakers(speakers []*livekit.SpeakerInfo) []*livekit.SpeakerInfo {
+	relay := r.Relay()
+	if relay == nil {
+		return speakers
+	}
+	remoteSpeakers := relay.RemoteSpeakers()
+	if len(remoteSpeakers) == 0 {
+		return speakers
+	}
+
+	merged := make([]*livekit.SpeakerInfo, 0, len(speakers)+len(remoteSpeakers))
+	merged = append(merged, speakers...)
+	merged = append(merged, remoteSpeakers...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Level > merged[j].Level
+	})
+	return merged
+}
+
+// GetParticipantInfoByID returns a participant hosted by this node, or by another node when the room is distributed
+func (r *Room) GetParticipantInfoByID(participantID livekit.ParticipantID) *livekit.ParticipantInfo {
+	if p := r.GetParticipantByID(participantID); p != nil {
+		return p.ToProto()
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, pi := range r.remoteParticipants {
+		if livekit.ParticipantID(pi.Sid) == participantID {
+			return pi
+		}
+	}
+	return nil
+}
+
+func (r *Room) SetRelay(relay *RoomRelay) {
+	r.lock.Lock()
+	r.relay = relay
+	r.lock.Unlock()
+}
+
+func (r *Room) Relay() *RoomRelay {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.relay
+}
+
 func (r *Room) GetBufferFactory() *buffer.Factory {
 	return r.bufferFactory.CreateBufferFactory()
 }
@@ -332,6 +421,8 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	participant.OnTrackUnpublished(r.onTrackUnpublished)
 	participant.OnParticipantUpdate(r.onParticipantUpdate)
 	participant.OnDataPacket(r.onDataPacket)
+	// catch up on data sent before joining
+	participant.OnDataChannelOpen(r.sendDataHistory)
 	participant.OnSubscribeStatusChanged(func(publisherID livekit.ParticipantID, subscribed bool) {
 		if subscribed {
 			pub := r.GetParticipantByID(publisherID)
@@ -510,6 +601,17 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		r.trackManager.RemoveTrack(t)
 	}
 	r.hasPublished.Delete(p.Identity())
+	if r.lastN != nil {
+		r.lastN.RemoveSubscriber(p.ID())
+	}
+	if r.subPriority != nil {
+		r.subPriority.RemoveSubscriber(p.ID())
+	}
+	if r.spatial != nil {
+		r.spatialQueue.Enqueue(func() {
+			r.spatial.RemoveParticipant(p.ID())
+		})
+	}
 
 	p.OnTrackUpdated(nil)
 	p.OnTrackPublished(nil)
@@ -539,6 +641,25 @@ func (r *Room) UpdateSubscriptions(
 	participantTracks []*livekit.ParticipantTracks,
 	subscribe bool,
 ) {
+	if r.lastN != nil {
+		// explicit subscriptions to tracks managed by last-N are kept regardless of selection
+		allTrackIDs := append([]livekit.TrackID{}, trackIDs...)
+		for _, pt := range participantTracks {
+			allTrackIDs = append(allTrackIDs, livekit.StringsAsIDs[livekit.TrackID](pt.TrackSids)...)
+		}
+		for _, trackID := range allTrackIDs {
+			info := r.trackManager.GetTrackInfo(trackID)
+			if info == nil || !IsLastNTrack(info.Track) {
+				continue
+			}
+			if subscribe {
+				r.lastN.Pin(participant.ID(), trackID)
+			} else {
+				r.lastN.Unpin(participant.ID(), trackID)
+			}
+		}
+	}
+
 	// handle subscription changes
 	for _, trackID := range trackIDs {
 		if subscribe {
@@ -614,9 +735,23 @@ func (r *Room) UpdateSubscriptionPermission(participant types.LocalParticipant,
 	for _, track := range participant.GetPublishedTracks() {
 		r.trackManager.NotifyTrackChanged(track.ID())
 	}
+	if relay := r.Relay(); relay != nil && !participant.Hidden() {
+		relay.SyncParticipant(participant)
+	}
 	return nil
 }
 
+// relayedTrackAllowedSubscribers returns local participants allowed to subscribe to a track relayed from another node
+func (r *Room) relayedTrackAllowedSubscribers(permission *livekit.SubscriptionPermission, trackID livekit.TrackID) []livekit.ParticipantIdentity {
+	var allowed []livekit.ParticipantIdentity
+	for _, p := range r.GetParticipants() {
+		if relayedPermissionAllows(permission, trackID, p.Identity(), p.ID()) {
+			allowed = append(allowed, p.Identity())
+		}
+	}
+	return allowed
+}
+
 func (r *Room) UpdateVideoLayers(participant types.Participant, updateVideoLayers *livekit.UpdateVideoLayers) error {
 	return participant.UpdateVideoLayers(updateVideoLayers)
 }
@@ -640,6 +775,10 @@ func (r *Room) ResolveMediaTrackForSubscriber(subIdentity livekit.ParticipantIde
 	// when publisher is not found, we will assume it doesn't have permission to access
 	if pub != nil {
 		res.HasPermission = pub.HasPermission(trackID, subIdentity)
+	} else if relay := r.Relay(); relay != nil && r.isRemoteParticipant(info.PublisherIdentity, info.PublisherID) {
+		if sub := r.GetParticipant(subIdentity); sub != nil {
+			res.HasPermission = relay.HasPermission(info.PublisherID, trackID, sub)
+		}
 	}
 
 	return res
@@ -702,6 +841,12 @@ func (r *Room) Close() {
 	for _, p := range r.GetParticipants() {
 		_ = p.Close(true, types.ParticipantCloseReasonRoomClose, false)
 	}
+	if relay := r.Relay(); relay != nil {
+		relay.Close()
+	}
+	if r.spatialQueue != nil {
+		r.spatialQueue.Stop()
+	}
 	r.protoProxy.Stop()
 	if r.onClose != nil {
 		r.onClose()
@@ -742,6 +887,30 @@ func (r *Room) UpdateParticipantMetadata(participant types.LocalParticipant, nam
 	}
 }
 
+// ValidateParticipantAttributes checks a partial update to attributes of participant against limits of the room,
+// so that updates changing other fields of the participant too can be rejected before anything is applied
+func (r *Room) ValidateParticipantAttributes(participant types.LocalParticipant, attributes map[string]string) error {
+	if len(attributes) == 0 {
+		return nil
+	}
+	return r.attributeLimits.Validate(participant.Attributes(), attributes)
+}
+
+// UpdateParticipantAttributes applies a partial update to attributes of participant, an empty value deletes the key
+func (r *Room) UpdateParticipantAttributes(participant types.LocalParticipant, attributes map[string]string) error {
+	if len(attributes) == 0 {
+		return nil
+	}
+	if err := r.ValidateParticipantAttributes(participant, attributes); err != nil {
+		return err
+	}
+
+	if changed := participant.UpdateAttributes(attributes); len(changed) != 0 {
+		r.telemetry.ParticipantAttributesChanged(context.Background(), r.ToProto(), participant.ToProto(), changed)
+	}
+	return nil
+}
+
 func (r *Room) sendRoomUpdate() {
 	roomInfo := r.ToProto()
 	// Send update to participants
@@ -821,6 +990,14 @@ func (r *Room) getOtherParticipantInfo(identity livekit.ParticipantIdentity) []*
 		}
 	}
 
+	r.lock.RLock()
+	for _, rpi := range r.remoteParticipants {
+		if livekit.ParticipantIdentity(rpi.Identity) != identity {
+			pi = append(pi, rpi)
+		}
+	}
+	r.lock.RUnlock()
+
 	return pi
 }
 
@@ -842,6 +1019,11 @@ func (r *Room) createJoinResponseLocked(participant types.LocalParticipant, iceS
 			otherParticipants = append(otherParticipants, p.ToProto())
 		}
 	}
+	for _, pi := range r.remoteParticipants {
+		if pi.Identity != string(participant.Identity()) {
+			otherParticipants = append(otherParticipants, pi)
+		}
+	}
 
 	return &livekit.JoinResponse{
 		Room:              r.ToProto(),
@@ -880,6 +1062,9 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 		if !r.autoSubscribe(existingParticipant) {
 			continue
 		}
+		if !r.subscriptionAllowed(existingParticipant, participant.ID(), track) {
+			continue
+		}
 
 		r.Logger.Debugw("subscribing to new track",
 			"participant", existingParticipant.Identity(),
@@ -888,6 +1073,7 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 			"publisherID", participant.ID(),
 			"trackID", track.ID())
 		existingParticipant.SubscribeToTrack(track.ID())
+		r.applySpatialQuality(existingParticipant, participant.ID(), track)
 	}
 	onParticipantChanged := r.onParticipantChanged
 	r.lock.RUnlock()
@@ -951,6 +1137,11 @@ func (r *Room) onTrackUnpublished(p types.LocalParticipant, track types.MediaTra
 
 func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
 	r.protoProxy.MarkDirty(false)
+	if r.spatial != nil {
+		if position, ok := ParsePositionMetadata(p.ToProto().Metadata); ok {
+			r.updatePosition(p.ID(), position)
+		}
+	}
 	// immediately notify when permissions or metadata changed
 	r.broadcastParticipantState(p, broadcastOptions{immediate: true})
 	if r.onParticipantChanged != nil {
@@ -959,7 +1150,90 @@ func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
 }
 
 func (r *Room) onDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) {
+	if source != nil {
+		r.updatePositionFromData(source.ID(), dp)
+	}
+	r.retainDataPacket(dp)
 	BroadcastDataPacketForRoom(r, source, dp, r.Logger)
+	if relay := r.Relay(); relay != nil {
+		relay.SendData(dp)
+	}
+}
+
+// matchesRoomPattern checks if a feature applies to the room, it applies to all rooms when the pattern is empty
+func (r *Room) matchesRoomPattern(feature string, roomPattern string) bool {
+	if roomPattern == "" {
+		return true
+	}
+	pattern, err := regexp.Compile(roomPattern)
+	if err != nil {
+		r.Logger.Errorw("invalid room pattern", err, "feature", feature, "pattern", roomPattern)
+		return false
+	}
+	return pattern.MatchString(r.protoRoom.Name)
+}
+
+// roomSubscriptionPriority returns the subscription priority policy applying to the room
+func (r *Room) roomSubscriptionPriority(conf config.SubscriptionPriorityConfig) config.SubscriptionPriorityConfig {
+	for _, rc := range conf.Rooms {
+		if r.matchesRoomPattern("subscription priority", rc.RoomPattern) {
+			return config.SubscriptionPriorityConfig{Policy: rc.Policy}
+		}
+	}
+	return config.SubscriptionPriorityConfig{Policy: conf.Policy}
+}
+
+func (r *Room) newDataHistory(conf config.DataHistoryConfig) *DataHistory {
+	if !r.matchesRoomPattern("data history", conf.RoomPattern) {
+		return nil
+	}
+	return NewDataHistory(DataHistoryParams{
+		Messages: conf.Messages,
+		MaxBytes: conf.MaxBytes,
+		MaxAge:   conf.MaxAge,
+	})
+}
+
+func (r *Room) retainDataPacket(dp *livekit.DataPacket) {
+	if r.dataHistory != nil {
+		r.dataHistory.Add(dp, time.Now())
+	}
+}
+
+// DataHistory returns data packets retained by the room, when it retains them
+func (r *Room) DataHistory() ([]*livekit.DataPacket, bool) {
+	if r.dataHistory == nil {
+		return nil, false
+	}
+	return r.dataHistory.Get(time.Now()), true
+}
+
+func (r *Room) ClearDataHistory() bool {
+	if r.dataHistory == nil {
+		return false
+	}
+	r.dataHistory.Clear()
+	return true
+}
+
+func (r *Room) sendDataHistory(p types.LocalParticipant) {
+	packets, ok := r.DataHistory()
+	if !ok {
+		return
+	}
+	for _, dp := range packets {
+		if !p.IsSubscribedToDataTopic(dp.GetUser().GetTopic()) {
+			continue
+		}
+		data, err := proto.Marshal(dp)
+		if err != nil {
+			r.Logger.Errorw("failed to marshal data packet", err)
+			continue
+		}
+		if err = p.SendDataPacket(dp, data); err != nil {
+			r.Logger.Infow("could not send data history", "error", err, "participant", p.Identity(), "pID", p.ID())
+		}
+	}
 }
 
 func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
@@ -979,8 +1253,22 @@ func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
 
 		// subscribe to all
 		for _, track := range op.GetPublishedTracks() {
+			if !r.subscriptionAllowed(p, op.ID(), track) {
+				continue
+			}
 			trackIDs = append(trackIDs, track.ID())
 			p.SubscribeToTrack(track.ID())
+			r.applySpatialQuality(p, op.ID(), track)
+		}
+	}
+	if relay := r.Relay(); relay != nil {
+		for _, track := range relay.RelayedTracks() {
+			if !r.subscriptionAllowed(p, track.PublisherID(), track) {
+				continue
+			}
+			trackIDs = append(trackIDs, track.ID())
+			p.SubscribeToTrack(track.ID())
+			r.applySpatialQuality(p, track.PublisherID(), track)
 		}
 	}
 	if len(trackIDs) > 0 {
@@ -992,6 +1280,10 @@ func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
 func (r *Room) broadcastParticipantState(p types.LocalParticipant, opts broadcastOptions) {
 	pi := p.ToProto()
 
+	if relay := r.Relay(); relay != nil && !p.Hidden() {
+		relay.SyncParticipant(p)
+	}
+
 	if p.Hidden() {
 		if !opts.skipSource {
 			// send update only to hidden participant
@@ -1008,6 +1300,74 @@ func (r *Room) broadcastParticipantState(p types.LocalParticipant, opts broadcas
 	r.sendParticipantUpdates(updates)
 }
 
+// a participant hosted by another node has changed
+func (r *Room) updateRemoteParticipant(pi *livekit.ParticipantInfo) {
+	identity := livekit.ParticipantIdentity(pi.Identity)
+	r.lock.Lock()
+	if pi.State == livekit.ParticipantInfo_DISCONNECTED {
+		if existing := r.remoteParticipants[identity]; existing != nil && existing.Sid == pi.Sid {
+			delete(r.remoteParticipants, identity)
+		}
+	} else {
+		r.remoteParticipants[identity] = pi
+	}
+	r.lock.Unlock()
+
+	if r.spatial != nil {
+		participantID := livekit.ParticipantID(pi.Sid)
+		if pi.State == livekit.ParticipantInfo_DISCONNECTED {
+			r.spatialQueue.Enqueue(func() {
+				r.spatial.RemoveParticipant(participantID)
+			})
+		} else if position, ok := ParsePositionMetadata(pi.Metadata); ok {
+			r.updatePosition(participantID, position)
+		}
+	}
+
+	r.sendParticipantUpdates(r.pushAndDequeueUpdates(pi, true))
+}
+
+func (r *Room) isRemoteParticipant(identity livekit.ParticipantIdentity, participantID livekit.ParticipantID) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	pi := r.remoteParticipants[identity]
+	return pi != nil && livekit.ParticipantID(pi.Sid) == participantID
+}
+
+// a track was relayed from another node, subscribe participants to it as if it was published locally
+func (r *Room) addRelayedTrack(pi *livekit.ParticipantInfo, track types.MediaTrack) {
+	r.trackManager.AddTrack(track, livekit.ParticipantIdentity(pi.Identity), livekit.ParticipantID(pi.Sid))
+
+	publisherID := livekit.ParticipantID(pi.Sid)
+	r.lock.RLock()
+	subscribers := make([]types.LocalParticipant, 0, len(r.participants))
+	for _, p := range r.participants {
+		if p.State() == livekit.ParticipantInfo_ACTIVE && r.autoSubscribe(p) {
+			subscribers = append(subscribers, p)
+		}
+	}
+	r.lock.RUnlock()
+
+	for _, p := range subscribers {
+		if !r.subscriptionAllowed(p, publisherID, track) {
+			continue
+		}
+		r.Logger.Debugw("subscribing to relayed track",
+			"participant", p.Identity(),
+			"pID", p.ID(),
+			"publisher", pi.Identity,
+			"publisherID", pi.Sid,
+			"trackID", track.ID())
+		p.SubscribeToTrack(track.ID())
+		r.applySpatialQuality(p, publisherID, track)
+	}
+}
+
+func (r *Room) removeRelayedTrack(track types.MediaTrack) {
+	r.trackManager.RemoveTrack(track)
+}
+
 func (r *Room) sendParticipantUpdates(updates []*livekit.ParticipantInfo) {
 	if len(updates) == 0 {
 		return
@@ -1166,14 +1526,299 @@ func (r *Room) changeUpdateWorker() {
 	}
 }
 
+// checks if subscriber should be automatically subscribed to track when last-N or spatial subscriptions are enabled
+func (r *Room) subscriptionAllowed(subscriber types.LocalParticipant, publisherID livekit.ParticipantID, track types.MediaTrack) bool {
+	return r.lastNAllows(subscriber.ID(), publisherID, track) && r.spatialAllows(subscriber, publisherID, track)
+}
+
+func (r *Room) lastNAllows(subscriberID livekit.ParticipantID, publisherID livekit.ParticipantID, track types.MediaTrack) bool {
+	if r.lastN == nil || !IsLastNTrack(track) {
+		return true
+	}
+	return r.lastN.IsSelected(publisherID) || r.lastN.IsPinned(subscriberID, track.ID())
+}
+
+func (r *Room) spatialAllows(subscriber types.LocalParticipant, publisherID livekit.ParticipantID, track types.MediaTrack) bool {
+	if !r.isSpatialSubscriber(subscriber) {
+		return true
+	}
+	return r.spatial.Allows(subscriber.ID(), publisherID, track)
+}
+
+// hidden participants and recorders are not placed in the space, they get everything
+func (r *Room) isSpatialSubscriber(p types.LocalParticipant) bool {
+	return r.spatial != nil && !p.Hidden() && !p.IsRecorder()
+}
+
+func (r *Room) applySpatialQuality(subscriber types.LocalParticipant, publisherID livekit.ParticipantID, track types.MediaTrack) {
+	if !r.isSpatialSubscriber(subscriber) || track.Kind() != livekit.TrackType_VIDEO || !IsSpatialTrack(track) {
+		return
+	}
+	if quality := r.spatial.GetState(subscriber.ID(), publisherID).Quality; quality != livekit.VideoQuality_OFF {
+		subscriber.UpdateSubscribedTrackSettings(track.ID(), &livekit.UpdateTrackSettings{
+			TrackSids: []string{string(track.ID())},
+			Quality:   quality,
+		})
+	}
+}
+
+// positions are published by participants in data packets of the position topic, including those hosted by other nodes
+func (r *Room) updatePositionFromData(participantID livekit.ParticipantID, dp *livekit.DataPacket) {
+	if r.spatial == nil || participantID == "" {
+		return
+	}
+	up := dp.GetUser()
+	if up == nil || up.GetTopic() != r.spatial.PositionTopic() {
+		return
+	}
+
+	var position Position
+	if err := json.Unmarshal(up.Payload, &position); err != nil {
+		r.Logger.Debugw("invalid position", "pID", participantID, "error", err)
+		return
+	}
+	r.updatePosition(participantID, position)
+}
+
+func (r *Room) updatePosition(participantID livekit.ParticipantID, position Position) {
+	r.spatialQueue.Enqueue(func() {
+		changes := r.spatial.SetPosition(participantID, position)
+		if len(changes) != 0 {
+			r.applySpatialChanges(changes)
+		}
+	})
+}
+
+// updates subscriptions of participants to match changes in proximity
+func (r *Room) applySpatialChanges(changes []SpatialChange) {
+	r.lock.RLock()
+	participants := make(map[livekit.ParticipantID]types.LocalParticipant, len(r.participants))
+	for _, p := range r.participants {
+		participants[p.ID()] = p
+	}
+	r.lock.RUnlock()
+
+	// publishers can be hosted by other nodes, subscribers are always local
+	publishedTracks := make(map[livekit.ParticipantID][]types.MediaTrack)
+	for _, p := range participants {
+		publishedTracks[p.ID()] = p.GetPublishedTracks()
+	}
+	if relay := r.Relay(); relay != nil {
+		for _, track := range relay.RelayedTracks() {
+			publishedTracks[track.PublisherID()] = append(publishedTracks[track.PublisherID()], track)
+		}
+	}
+
+	for _, change := range changes {
+		sub := participants[change.SubscriberID]
+		if sub == nil || !r.isSpatialSubscriber(sub) {
+			continue
+		}
+		if sub.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+		r.lock.RLock()
+		autoSubscribe := r.autoSubscribe(sub)
+		r.lock.RUnlock()
+		if !autoSubscribe {
+			continue
+		}
+
+		for _, track := range publishedTracks[change.PublisherID] {
+			if !IsSpatialTrack(track) {
+				continue
+			}
+
+			var wasAllowed, isAllowed bool
+			if track.Kind() == livekit.TrackType_AUDIO {
+				wasAllowed, isAllowed = change.Previous.Audio, change.Current.Audio
+			} else {
+				wasAllowed, isAllowed = change.Previous.Video, change.Current.Video
+			}
+			switch {
+			case isAllowed && !wasAllowed:
+				if r.lastNAllows(sub.ID(), change.PublisherID, track) {
+					sub.SubscribeToTrack(track.ID())
+					r.applySpatialQuality(sub, change.PublisherID, track)
+				}
+			case !isAllowed && wasAllowed:
+				if r.lastN == nil || !r.lastN.IsPinned(sub.ID(), track.ID()) {
+					sub.UnsubscribeFromTrack(track.ID())
+				}
+			case isAllowed && change.Previous.Quality != change.Current.Quality:
+				r.applySpatialQuality(sub, change.PublisherID, track)
+			}
+		}
+	}
+}
+
+// updates last-N selection with current speakers, and subscriptions of participants to match
+func (r *Room) updateLastN(speakers []*livekit.SpeakerInfo) {
+	if r.lastN == nil {
+		return
+	}
+
+	// publishers, including those hosted by other nodes, are ordered by the time they joined
+	type lastNPublisher struct {
+		id       livekit.ParticipantID
+		joinedAt time.Time
+	}
+	var candidates []lastNPublisher
+	publishedTracks := make(map[livekit.ParticipantID][]types.MediaTrack)
+	for _, p := range r.GetParticipants() {
+		for _, track := range p.GetPublishedTracks() {
+			if IsLastNTrack(track) {
+				publishedTracks[p.ID()] = append(publishedTracks[p.ID()], track)
+			}
+		}
+		if len(publishedTracks[p.ID()]) != 0 {
+			candidates = append(candidates, lastNPublisher{id: p.ID(), joinedAt: p.ConnectedAt()})
+		}
+	}
+	if relay := r.Relay(); relay != nil {
+		joinedAt := make(map[livekit.ParticipantID]time.Time)
+		r.lock.RLock()
+		for _, pi := range r.remoteParticipants {
+			joinedAt[livekit.ParticipantID(pi.Sid)] = time.Unix(pi.JoinedAt, 0)
+		}
+		r.lock.RUnlock()
+
+		for _, track := range relay.RelayedTracks() {
+			if !IsLastNTrack(track) {
+				continue
+			}
+			publisherID := track.PublisherID()
+			if len(publishedTracks[publisherID]) == 0 {
+				candidates = append(candidates, lastNPublisher{id: publisherID, joinedAt: joinedAt[publisherID]})
+			}
+			publishedTracks[publisherID] = append(publishedTracks[publisherID], track)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].joinedAt.Before(candidates[j].joinedAt)
+	})
+	publishers := make([]livekit.ParticipantID, 0, len(candidates))
+	for _, c := range candidates {
+		publishers = append(publishers, c.id)
+	}
+	speakerIDs := make([]livekit.ParticipantID, 0, len(speakers))
+	for _, speaker := range speakers {
+		speakerIDs = append(speakerIDs, livekit.ParticipantID(speaker.Sid))
+	}
+
+	added, removed := r.lastN.Update(publishers, speakerIDs, time.Now())
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	r.Logger.Debugw("last-N selection changed", "added", added, "removed", removed)
+
+	r.lock.RLock()
+	subscribers := make([]types.LocalParticipant, 0, len(r.participants))
+	for _, p := range r.participants {
+		if p.State() == livekit.ParticipantInfo_ACTIVE && r.autoSubscribe(p) {
+			subscribers = append(subscribers, p)
+		}
+	}
+	r.lock.RUnlock()
+
+	for _, sub := range subscribers {
+		for _, publisherID := range added {
+			if publisherID == sub.ID() {
+				continue
+			}
+			for _, track := range publishedTracks[publisherID] {
+				if r.spatialAllows(sub, publisherID, track) {
+					sub.SubscribeToTrack(track.ID())
+					r.applySpatialQuality(sub, publisherID, track)
+				}
+			}
+		}
+		for _, publisherID := range removed {
+			if publisherID == sub.ID() {
+				continue
+			}
+			for _, track := range publishedTracks[publisherID] {
+				if !r.lastN.IsPinned(sub.ID(), track.ID()) {
+					sub.UnsubscribeFromTrack(track.ID())
+				}
+			}
+		}
+	}
+}
+
+// GetSubscriptionPolicyPriority returns the priority of a track for a subscriber by room allocation policy,
+// 0 when there is no policy
+func (r *Room) GetSubscriptionPolicyPriority(subscriberID livekit.ParticipantID, track types.MediaTrack) uint8 {
+	if r.subPriority == nil {
+		return 0
+	}
+	return r.subPriority.Priority(subscriberID, track)
+}
+
+// SetSubscriptionPinned pins or unpins a video track for a subscriber, for room allocation policy
+func (r *Room) SetSubscriptionPinned(participant types.LocalParticipant, trackID livekit.TrackID, pinned bool) error {
+	if r.subPriority == nil || !r.subPriority.isRanked(config.SubscriptionPriorityClassPinned) {
+		return ErrSubscriptionPinningDisabled
+	}
+	info := r.trackManager.GetTrackInfo(trackID)
+	if info == nil || info.Track.Kind() != livekit.TrackType_VIDEO {
+		return ErrTrackNotFound
+	}
+
+	var changed bool
+	if pinned {
+		changed = r.subPriority.Pin(participant.ID(), trackID)
+	} else {
+		changed = r.subPriority.Unpin(participant.ID(), trackID)
+	}
+	if changed {
+		participant.UpdateSubscriptionPriorities()
+	}
+	return nil
+}
+
+// IsSubscriptionPinned returns true when a track is pinned for a subscriber
+func (r *Room) IsSubscriptionPinned(subscriberID livekit.ParticipantID, trackID livekit.TrackID) bool {
+	return r.subPriority != nil && r.subPriority.IsPinned(subscriberID, trackID)
+}
+
+// re-evaluates priorities of subscribed tracks when active speakers change
+func (r *Room) updateSubscriptionPriorities(speakers []*livekit.SpeakerInfo) {
+	if r.subPriority == nil {
+		return
+	}
+
+	speakerIDs := make([]livekit.ParticipantID, 0, len(speakers))
+	for _, speaker := range speakers {
+		speakerIDs = append(speakerIDs, livekit.ParticipantID(speaker.Sid))
+	}
+	if !r.subPriority.SetActiveSpeakers(speakerIDs) {
+		return
+	}
+
+	for _, p := range r.GetParticipants() {
+		if p.State() == livekit.ParticipantInfo_ACTIVE {
+			p.UpdateSubscriptionPriorities()
+		}
+	}
+}
+
 func (r *Room) audioUpdateWorker() {
 	lastActiveMap := make(map[livekit.ParticipantID]*livekit.SpeakerInfo)
+	var lastRelayedSpeakers []*livekit.SpeakerInfo
 	for {
 		if r.IsClosed() {
 			return
 		}
 
-		activeSpeakers := r.GetActiveSpeakers()
+		localSpeakers := r.getLocalActiveSpeakers()
+		if relay := r.Relay(); relay != nil && !isSameSpeakers(lastRelayedSpeakers, localSpeakers) {
+			relay.SendSpeakers(localSpeakers)
+			lastRelayedSpeakers = localSpeakers
+		}
+		activeSpeakers := r.withRemoteSpeakers(localSpeakers)
+		r.updateLastN(activeSpeakers)
+		r.updateSubscriptionPriorities(activeSpeakers)
 		changedSpeakers := make([]*livekit.SpeakerInfo, 0, len(activeSpeakers))
 		nextActiveMap := make(map[livekit.ParticipantID]*livekit.SpeakerInfo, len(activeSpeakers))
 		for _, speaker := range activeSpeakers {
@@ -1206,6 +1851,18 @@ func (r *Room) audioUpdateWorker() {
 	}
 }
 
+func isSameSpeakers(a []*livekit.SpeakerInfo, b []*livekit.SpeakerInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *Room) connectionQualityWorker() {
 	ticker := time.NewTicker(connectionquality.UpdateInterval)
 	defer ticker.Stop()
@@ -1322,6 +1979,9 @@ func BroadcastDataPacketForRoom(r types.Room, source types.LocalParticipant, dp
 		if source != nil && op.ID() == source.ID() {
 			continue
 		}
+		if !op.IsSubscribedToDataTopic(dp.GetUser().GetTopic()) {
+			continue
+		}
 		if len(dest) > 0 || len(destIdentities) > 0 {
 			found := false
 			for _, dID := range dest {
@@ -590,6 +590,34 @@ func TestDataChannel(t *testing.T) {
 		}
 	})
 
+	t.Run("only participants subscribed to the topic should receive the data", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		defer rm.Close()
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+		p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+		p2 := participants[2].(*typesfakes.FakeLocalParticipant)
+		p2.IsSubscribedToDataTopicCalls(func(topic string) bool {
+			return topic != "cursor"
+		})
+
+		packet := livekit.DataPacket{
+			Kind: livekit.DataPacket_LOSSY,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					ParticipantSid: string(p.ID()),
+					Payload:        []byte("{}"),
+					Topic:          proto.String("cursor"),
+				},
+			},
+		}
+		p.OnDataPacketArgsForCall(0)(p, &packet)
+
+		require.Equal(t, 1, p1.SendDataPacketCallCount())
+		require.Zero(t, p2.SendDataPacketCallCount())
+		require.Equal(t, "cursor", p2.IsSubscribedToDataTopicArgsForCall(0))
+	})
+
 	t.Run("only one participant should receive the data", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 4})
 		defer rm.Close()
@@ -648,6 +676,74 @@ func TestDataChannel(t *testing.T) {
 	})
 }
 
+func TestRoomDataHistory(t *testing.T) {
+	t.Parallel()
+
+	newPacket := func(p types.LocalParticipant, topic string, payload string) *livekit.DataPacket {
+		return &livekit.DataPacket{
+			Kind: livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					ParticipantSid: string(p.ID()),
+					Payload:        []byte(payload),
+					Topic:          &topic,
+				},
+			},
+		}
+	}
+
+	t.Run("new participants receive retained packets when the data channel opens", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2, dataHistory: config.DataHistoryConfig{Messages: 2}})
+		defer rm.Close()
+		p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		onData := p.OnDataPacketArgsForCall(0)
+		onData(p, newPacket(p, "chat", "one"))
+		onData(p, newPacket(p, "chat", "two"))
+		onData(p, newPacket(p, "chat", "three"))
+		onData(p, newPacket(p, "other", "four"))
+		lossy := newPacket(p, "chat", "lossy")
+		lossy.Kind = livekit.DataPacket_LOSSY
+		onData(p, lossy)
+
+		pNew := newMockParticipant("new", types.CurrentProtocol, false, false)
+		pNew.IsSubscribedToDataTopicCalls(func(topic string) bool {
+			return topic != "other"
+		})
+		require.NoError(t, rm.Join(pNew, nil, &ParticipantOptions{AutoSubscribe: true}, iceServersForRoom))
+		require.Zero(t, pNew.SendDataPacketCallCount())
+
+		pNew.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		pNew.OnStateChangeArgsForCall(0)(pNew, livekit.ParticipantInfo_JOINED)
+		require.Zero(t, pNew.SendDataPacketCallCount())
+
+		// a failed packet does not stop the rest
+		pNew.SendDataPacketReturnsOnCall(0, ErrDataChannelUnavailable)
+		pNew.OnDataChannelOpenArgsForCall(0)(pNew)
+
+		require.Equal(t, 2, pNew.SendDataPacketCallCount())
+		dp, _ := pNew.SendDataPacketArgsForCall(0)
+		require.Equal(t, []byte("two"), dp.GetUser().Payload)
+		dp, _ = pNew.SendDataPacketArgsForCall(1)
+		require.Equal(t, []byte("three"), dp.GetUser().Payload)
+
+		require.True(t, rm.ClearDataHistory())
+		packets, ok := rm.DataHistory()
+		require.True(t, ok)
+		require.Empty(t, packets)
+	})
+
+	t.Run("rooms not matching the pattern do not retain packets", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2, dataHistory: config.DataHistoryConfig{RoomPattern: "^chat-", Messages: 2}})
+		defer rm.Close()
+		p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		p.OnDataPacketArgsForCall(0)(p, newPacket(p, "chat", "one"))
+
+		_, ok := rm.DataHistory()
+		require.False(t, ok)
+		require.False(t, rm.ClearDataHistory())
+	})
+}
+
 func TestHiddenParticipants(t *testing.T) {
 	t.Run("other participants don't receive hidden updates", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 2, numHidden: 1})
@@ -715,11 +811,351 @@ func TestRoomUpdate(t *testing.T) {
 	})
 }
 
+func TestRemoteParticipants(t *testing.T) {
+	remote := &livekit.ParticipantInfo{
+		Sid:      "PA_remote",
+		Identity: "remote",
+		State:    livekit.ParticipantInfo_ACTIVE,
+		Tracks:   []*livekit.TrackInfo{{Sid: "TR_remote", Type: livekit.TrackType_AUDIO}},
+	}
+
+	t.Run("remote participants are included in join response", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer rm.Close()
+
+		rm.updateRemoteParticipant(remote)
+		p1 := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		require.Equal(t, 1, p1.SendParticipantUpdateCallCount())
+		require.Equal(t, remote.Sid, rm.GetParticipantInfoByID("PA_remote").Sid)
+
+		pNew := newMockParticipant("new", types.CurrentProtocol, false, false)
+		require.NoError(t, rm.Join(pNew, nil, nil, iceServersForRoom))
+		res := pNew.SendJoinResponseArgsForCall(0)
+		require.Len(t, res.OtherParticipants, 2)
+
+		disconnected := proto.Clone(remote).(*livekit.ParticipantInfo)
+		disconnected.State = livekit.ParticipantInfo_DISCONNECTED
+		rm.updateRemoteParticipant(disconnected)
+		require.Nil(t, rm.GetParticipantInfoByID("PA_remote"))
+	})
+
+	t.Run("relayed tracks are subscribed to", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close()
+
+		rm.updateRemoteParticipant(remote)
+		track := &typesfakes.FakeMediaTrack{}
+		track.IDReturns("TR_remote")
+		track.IsOpenReturns(true)
+		rm.addRelayedTrack(remote, track)
+
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeLocalParticipant)
+			require.Equal(t, 1, fp.SubscribeToTrackCallCount())
+			require.Equal(t, livekit.TrackID("TR_remote"), fp.SubscribeToTrackArgsForCall(0))
+		}
+
+		// denied until subscription permissions of the publisher are relayed
+		res := rm.ResolveMediaTrackForSubscriber("p0", "TR_remote")
+		require.Equal(t, track, res.Track)
+		require.False(t, res.HasPermission)
+
+		link := &relayLink{
+			permissions: map[livekit.ParticipantID]*livekit.SubscriptionPermission{
+				livekit.ParticipantID(remote.Sid): {
+					TrackPermissions: []*livekit.TrackPermission{
+						{ParticipantIdentity: "p0", TrackSids: []string{"TR_remote"}},
+					},
+				},
+			},
+		}
+		rm.lock.Lock()
+		rm.relay = &RoomRelay{links: map[livekit.NodeID]*relayLink{"remote": link}}
+		rm.lock.Unlock()
+		require.True(t, rm.ResolveMediaTrackForSubscriber("p0", "TR_remote").HasPermission)
+		require.False(t, rm.ResolveMediaTrackForSubscriber("p1", "TR_remote").HasPermission)
+		rm.lock.Lock()
+		rm.relay = nil
+		rm.lock.Unlock()
+
+		rm.removeRelayedTrack(track)
+		require.Nil(t, rm.ResolveMediaTrackForSubscriber("p0", "TR_remote").Track)
+	})
+
+	t.Run("relayed tracks follow last-N selection", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2, lastN: config.LastNConfig{Speakers: 1}})
+		defer rm.Close()
+
+		rm.updateRemoteParticipant(remote)
+		audio := &typesfakes.FakeMediaTrack{}
+		audio.IDReturns("TR_remote")
+		audio.KindReturns(livekit.TrackType_AUDIO)
+		audio.PublisherIDReturns(livekit.ParticipantID(remote.Sid))
+		audio.IsOpenReturns(true)
+		rm.addRelayedTrack(remote, audio)
+
+		// not selected, since the remote publisher is not known to last-N without a relay
+		video := &typesfakes.FakeMediaTrack{}
+		video.IDReturns("TR_remote_video")
+		video.KindReturns(livekit.TrackType_VIDEO)
+		video.SourceReturns(livekit.TrackSource_CAMERA)
+		video.PublisherIDReturns(livekit.ParticipantID(remote.Sid))
+		video.IsOpenReturns(true)
+		rm.addRelayedTrack(remote, video)
+
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeLocalParticipant)
+			require.Equal(t, 1, fp.SubscribeToTrackCallCount())
+			require.Equal(t, livekit.TrackID("TR_remote"), fp.SubscribeToTrackArgsForCall(0))
+		}
+	})
+}
+
+func TestSubscriptionPinning(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{
+		num:                  2,
+		subscriptionPriority: config.SubscriptionPriorityConfig{Policy: []config.SubscriptionPriorityClass{config.SubscriptionPriorityClassPinned}},
+	})
+	defer rm.Close()
+	participants := rm.GetParticipants()
+	pub := participants[0].(*typesfakes.FakeLocalParticipant)
+	sub := participants[1].(*typesfakes.FakeLocalParticipant)
+
+	video := &typesfakes.FakeMediaTrack{}
+	video.IDReturns("video")
+	video.KindReturns(livekit.TrackType_VIDEO)
+	video.PublisherIDReturns(pub.ID())
+	video.PublisherIdentityReturns(pub.Identity())
+	video.IsOpenReturns(true)
+	pub.OnTrackPublishedArgsForCall(0)(pub, video)
+
+	// subscribing does not pin
+	rm.UpdateSubscriptions(sub, []livekit.TrackID{"video"}, nil, true)
+	require.False(t, rm.IsSubscriptionPinned(sub.ID(), "video"))
+
+	require.NoError(t, rm.SetSubscriptionPinned(sub, "video", true))
+	require.True(t, rm.IsSubscriptionPinned(sub.ID(), "video"))
+	require.Equal(t, 1, sub.UpdateSubscriptionPrioritiesCallCount())
+
+	require.NoError(t, rm.SetSubscriptionPinned(sub, "video", false))
+	require.False(t, rm.IsSubscriptionPinned(sub.ID(), "video"))
+	require.Equal(t, 2, sub.UpdateSubscriptionPrioritiesCallCount())
+
+	require.ErrorIs(t, rm.SetSubscriptionPinned(sub, "unknown", true), ErrTrackNotFound)
+}
+
+func TestSubscriptionPriorityRooms(t *testing.T) {
+	conf := config.SubscriptionPriorityConfig{
+		Policy: []config.SubscriptionPriorityClass{config.SubscriptionPriorityClassScreenShare},
+		Rooms: []config.SubscriptionPriorityRoomConfig{
+			{RoomPattern: "^class-", Policy: []config.SubscriptionPriorityClass{config.SubscriptionPriorityClassPinned}},
+			{RoomPattern: "^ro", Policy: []config.SubscriptionPriorityClass{config.SubscriptionPriorityClassActiveSpeaker}},
+		},
+	}
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 1, subscriptionPriority: conf})
+	defer rm.Close()
+	require.True(t, rm.subPriority.isRanked(config.SubscriptionPriorityClassActiveSpeaker))
+	require.False(t, rm.subPriority.isRanked(config.SubscriptionPriorityClassScreenShare))
+
+	// other rooms use the default policy, or none when it is empty
+	conf.Rooms = conf.Rooms[:1]
+	rm = newRoomWithParticipants(t, testRoomOpts{num: 1, subscriptionPriority: conf})
+	defer rm.Close()
+	require.True(t, rm.subPriority.isRanked(config.SubscriptionPriorityClassScreenShare))
+
+	conf.Policy = nil
+	rm = newRoomWithParticipants(t, testRoomOpts{num: 1, subscriptionPriority: conf})
+	defer rm.Close()
+	require.Nil(t, rm.subPriority)
+}
+
+func TestLastNSubscriptions(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 3, lastN: config.LastNConfig{Speakers: 1}})
+	defer rm.Close()
+
+	videoTracks := make(map[livekit.ParticipantID]livekit.TrackID)
+	for _, p := range rm.GetParticipants() {
+		fp := p.(*typesfakes.FakeLocalParticipant)
+		video := &typesfakes.FakeMediaTrack{}
+		video.IDReturns(livekit.TrackID("video_" + p.Identity()))
+		video.KindReturns(livekit.TrackType_VIDEO)
+		video.SourceReturns(livekit.TrackSource_CAMERA)
+		fp.GetPublishedTracksReturns([]types.MediaTrack{video})
+		videoTracks[p.ID()] = video.ID()
+	}
+
+	subscribeCount := func() int {
+		count := 0
+		for _, p := range rm.GetParticipants() {
+			count += p.(*typesfakes.FakeLocalParticipant).SubscribeToTrackCallCount()
+		}
+		return count
+	}
+	// the only selected publisher is subscribed to by the two others
+	require.Eventually(t, func() bool { return subscribeCount() == 2 }, time.Second, defaultDelay)
+
+	var speaker *typesfakes.FakeLocalParticipant
+	for _, p := range rm.GetParticipants() {
+		if !rm.lastN.IsSelected(p.ID()) {
+			speaker = p.(*typesfakes.FakeLocalParticipant)
+			break
+		}
+	}
+	speaker.GetAudioLevelReturns(0.5, true)
+
+	require.Eventually(t, func() bool { return rm.lastN.IsSelected(speaker.ID()) }, time.Second, defaultDelay)
+	require.Eventually(t, func() bool { return subscribeCount() == 4 }, time.Second, defaultDelay)
+	for _, p := range rm.GetParticipants() {
+		fp := p.(*typesfakes.FakeLocalParticipant)
+		if fp == speaker {
+			continue
+		}
+		require.Equal(t, videoTracks[speaker.ID()], fp.SubscribeToTrackArgsForCall(fp.SubscribeToTrackCallCount()-1))
+	}
+
+	// explicit subscriptions pin tracks
+	for _, p := range rm.GetParticipants() {
+		if p == speaker {
+			continue
+		}
+		video := p.GetPublishedTracks()[0].(*typesfakes.FakeMediaTrack)
+		video.IsOpenReturns(true)
+		rm.trackManager.AddTrack(video, p.Identity(), p.ID())
+		rm.UpdateSubscriptions(speaker, []livekit.TrackID{video.ID()}, nil, true)
+		require.True(t, rm.lastN.IsPinned(speaker.ID(), video.ID()))
+	}
+}
+
+func TestLastNRoomPattern(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 1, lastN: config.LastNConfig{RoomPattern: "^webinar-", Speakers: 1}})
+	defer rm.Close()
+	require.Nil(t, rm.lastN)
+
+	rm = newRoomWithParticipants(t, testRoomOpts{num: 1, lastN: config.LastNConfig{RoomPattern: "^ro", Speakers: 1}})
+	defer rm.Close()
+	require.NotNil(t, rm.lastN)
+}
+
+func TestSpatialSubscriptions(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2, spatial: config.SpatialConfig{
+		Enabled:       true,
+		PositionTopic: "lk.position",
+		AudioRadius:   10,
+		VideoRadius:   10,
+		VideoQualities: []config.SpatialVideoQuality{
+			{MaxDistance: 5, Quality: "high"},
+		},
+	}})
+	defer rm.Close()
+
+	participants := rm.GetParticipants()
+	for _, p := range participants {
+		fp := p.(*typesfakes.FakeLocalParticipant)
+		video := &typesfakes.FakeMediaTrack{}
+		video.IDReturns(livekit.TrackID("video_" + p.Identity()))
+		video.KindReturns(livekit.TrackType_VIDEO)
+		video.SourceReturns(livekit.TrackSource_CAMERA)
+		fp.GetPublishedTracksReturns([]types.MediaTrack{video})
+	}
+	p0 := participants[0].(*typesfakes.FakeLocalParticipant)
+	p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+	sendPosition := func(p types.LocalParticipant, x float64) {
+		rm.onDataPacket(p, &livekit.DataPacket{
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					Topic:   proto.String("lk.position"),
+					Payload: []byte(fmt.Sprintf(`{"x": %f}`, x)),
+				},
+			},
+		})
+	}
+
+	// without positions, everything is subscribed, with quality left to the subscriber
+	rm.subscribeToExistingTracks(p0)
+	require.Equal(t, 1, p0.SubscribeToTrackCallCount())
+	require.Equal(t, livekit.TrackID("video_"+p1.Identity()), p0.SubscribeToTrackArgsForCall(0))
+	require.Zero(t, p0.UpdateSubscribedTrackSettingsCallCount())
+
+	// out of range once placed
+	sendPosition(p0, 0)
+	sendPosition(p1, 20)
+	require.Eventually(t, func() bool { return p0.UnsubscribeFromTrackCallCount() == 1 }, time.Second, defaultDelay)
+
+	sendPosition(p1, 7)
+	require.Eventually(t, func() bool { return p0.SubscribeToTrackCallCount() == 2 }, time.Second, defaultDelay)
+	require.Equal(t, livekit.TrackID("video_"+p1.Identity()), p0.SubscribeToTrackArgsForCall(1))
+	require.Eventually(t, func() bool { return p0.UpdateSubscribedTrackSettingsCallCount() == 1 }, time.Second, defaultDelay)
+	_, settings := p0.UpdateSubscribedTrackSettingsArgsForCall(0)
+	require.Equal(t, livekit.VideoQuality_LOW, settings.Quality)
+
+	sendPosition(p1, 2)
+	require.Eventually(t, func() bool { return p0.UpdateSubscribedTrackSettingsCallCount() == 2 }, time.Second, defaultDelay)
+	_, settings = p0.UpdateSubscribedTrackSettingsArgsForCall(1)
+	require.Equal(t, livekit.VideoQuality_HIGH, settings.Quality)
+
+	sendPosition(p1, 20)
+	require.Eventually(t, func() bool { return p0.UnsubscribeFromTrackCallCount() == 2 }, time.Second, defaultDelay)
+
+	t.Run("relayed tracks", func(t *testing.T) {
+		remote := &livekit.ParticipantInfo{
+			Sid:      "PA_remote",
+			Identity: "remote",
+			State:    livekit.ParticipantInfo_ACTIVE,
+			Metadata: `{"position": {"x": 20}}`,
+		}
+		remoteID := livekit.ParticipantID(remote.Sid)
+		newRelayedVideo := func(trackID livekit.TrackID) *typesfakes.FakeMediaTrack {
+			video := &typesfakes.FakeMediaTrack{}
+			video.IDReturns(trackID)
+			video.KindReturns(livekit.TrackType_VIDEO)
+			video.SourceReturns(livekit.TrackSource_CAMERA)
+			video.PublisherIDReturns(remoteID)
+			video.IsOpenReturns(true)
+			return video
+		}
+
+		// out of range, placed with metadata
+		rm.updateRemoteParticipant(remote)
+		require.Eventually(t, func() bool { return !rm.spatial.GetState(p0.ID(), remoteID).Video }, time.Second, defaultDelay)
+		subscribed := p0.SubscribeToTrackCallCount()
+		rm.addRelayedTrack(remote, newRelayedVideo("TR_far"))
+		require.Equal(t, subscribed, p0.SubscribeToTrackCallCount())
+
+		// in range, placed with a relayed data packet
+		rm.updatePositionFromData(remoteID, &livekit.DataPacket{
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					ParticipantSid: remote.Sid,
+					Topic:          proto.String("lk.position"),
+					Payload:        []byte(`{"x": 7}`),
+				},
+			},
+		})
+		require.Eventually(t, func() bool { return rm.spatial.GetState(p0.ID(), remoteID).Video }, time.Second, defaultDelay)
+		updated := p0.UpdateSubscribedTrackSettingsCallCount()
+		rm.addRelayedTrack(remote, newRelayedVideo("TR_near"))
+		require.Equal(t, subscribed+1, p0.SubscribeToTrackCallCount())
+		require.Equal(t, livekit.TrackID("TR_near"), p0.SubscribeToTrackArgsForCall(subscribed))
+		require.Equal(t, updated+1, p0.UpdateSubscribedTrackSettingsCallCount())
+		_, settings := p0.UpdateSubscribedTrackSettingsArgsForCall(updated)
+		require.Equal(t, livekit.VideoQuality_LOW, settings.Quality)
+
+		disconnected := proto.Clone(remote).(*livekit.ParticipantInfo)
+		disconnected.State = livekit.ParticipantInfo_DISCONNECTED
+		rm.updateRemoteParticipant(disconnected)
+		require.Eventually(t, func() bool { return rm.spatial.GetState(p0.ID(), remoteID) == spatialStateUnplaced }, time.Second, defaultDelay)
+	})
+}
+
 type testRoomOpts struct {
 	num                  int
 	numHidden            int
 	protocol             types.ProtocolVersion
 	audioSmoothIntervals uint32
+	lastN                config.LastNConfig
+	spatial              config.SpatialConfig
+	dataHistory          config.DataHistoryConfig
+	subscriptionPriority config.SubscriptionPriorityConfig
 }
 
 func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *Room {
@@ -731,6 +1167,12 @@ func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *Room {
 			UpdateInterval:  audioUpdateInterval,
 			SmoothIntervals: opts.audioSmoothIntervals,
 		},
+		&config.RoomConfig{
+			LastN:                opts.lastN,
+			Spatial:              opts.spatial,
+			DataHistory:          opts.dataHistory,
+			SubscriptionPriority: opts.subscriptionPriority,
+		},
 		&livekit.ServerInfo{
 			Edition:  livekit.ServerInfo_Standard,
 			Version:  version.Version,
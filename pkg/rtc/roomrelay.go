@@ -0,0 +1,1134 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/mediatransportutil/pkg/twcc"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	relaySessionPrefix = "RS_"
+	relayRTCPChanSize  = 100
+)
+
+type RelayMessageWriter interface {
+	WriteRelayMessage(ctx context.Context, nodeID livekit.NodeID, msg *routing.RelayMessage) error
+}
+
+type RoomRelayParams struct {
+	Room                    *Room
+	NodeID                  livekit.NodeID
+	Router                  RelayMessageWriter
+	Config                  WebRTCConfig
+	CongestionControlConfig config.CongestionControlConfig
+	AudioConfig             config.AudioConfig
+	VideoConfig             config.VideoConfig
+	PLIThrottleConfig       config.PLIThrottleConfig
+	KeyFrameCacheConfig     config.KeyFrameCacheConfig
+	Telemetry               telemetry.TelemetryService
+	Logger                  logger.Logger
+}
+
+// RoomRelay connects a room to the same room hosted on other nodes. Tracks published by local participants
+// are forwarded to every other node over a WebRTC transport, and tracks relayed from other nodes are
+// added to the room as if they were published locally.
+type RoomRelay struct {
+	params   RoomRelayParams
+	opsQueue *sutils.OpsQueue
+
+	lock   sync.RWMutex
+	links  map[livekit.NodeID]*relayLink
+	closed bool
+
+	// last active speakers sent, for nodes joining later. only accessed from the ops queue
+	speakersPayload []byte
+}
+
+func NewRoomRelay(params RoomRelayParams) *RoomRelay {
+	r := &RoomRelay{
+		params:   params,
+		opsQueue: sutils.NewOpsQueue(params.Logger, "room-relay", 500),
+		links:    make(map[livekit.NodeID]*relayLink),
+	}
+	r.opsQueue.Start()
+	return r
+}
+
+// Start connects to the nodes already hosting the room
+func (r *RoomRelay) Start(nodeIDs []livekit.NodeID) {
+	r.opsQueue.Enqueue(func() {
+		for _, nodeID := range nodeIDs {
+			if nodeID == r.params.NodeID {
+				continue
+			}
+			r.lock.RLock()
+			link := r.links[nodeID]
+			r.lock.RUnlock()
+			if link != nil {
+				continue
+			}
+			// the remote node resets its link on join, it has to be sent ahead of participants synced on creation
+			r.sendMessage(nodeID, &routing.RelayMessage{Type: routing.RelayMessageJoin})
+			r.getOrCreateLink(nodeID)
+		}
+	})
+}
+
+func (r *RoomRelay) HandleMessage(msg *routing.RelayMessage) {
+	if msg.NodeID == r.params.NodeID {
+		return
+	}
+
+	r.opsQueue.Enqueue(func() {
+		switch msg.Type {
+		case routing.RelayMessageJoin:
+			// remote node (re)started hosting the room, anything negotiated with it earlier is stale
+			r.closeLink(msg.NodeID)
+			r.getOrCreateLink(msg.NodeID)
+
+		case routing.RelayMessageLeave:
+			r.closeLink(msg.NodeID)
+
+		default:
+			link, _ := r.getOrCreateLink(msg.NodeID)
+			if link != nil {
+				link.handleMessage(msg)
+			}
+		}
+	})
+}
+
+// SyncParticipant forwards state and tracks of a local participant to other nodes
+func (r *RoomRelay) SyncParticipant(p types.LocalParticipant) {
+	r.opsQueue.Enqueue(func() {
+		for _, link := range r.getLinks() {
+			link.syncParticipant(p)
+		}
+	})
+}
+
+// SendData forwards a data packet published on this node to other nodes
+func (r *RoomRelay) SendData(dp *livekit.DataPacket) {
+	payload, err := proto.Marshal(dp)
+	if err != nil {
+		r.params.Logger.Errorw("could not marshal data packet for relay", err)
+		return
+	}
+
+	r.opsQueue.Enqueue(func() {
+		for _, link := range r.getLinks() {
+			r.sendMessage(link.nodeID, &routing.RelayMessage{
+				Type:    routing.RelayMessageData,
+				Payload: payload,
+			})
+		}
+	})
+}
+
+// SendSpeakers forwards active speakers among local participants to other nodes
+func (r *RoomRelay) SendSpeakers(speakers []*livekit.SpeakerInfo) {
+	payload, err := proto.Marshal(&livekit.ActiveSpeakerUpdate{Speakers: speakers})
+	if err != nil {
+		r.params.Logger.Errorw("could not marshal speakers for relay", err)
+		return
+	}
+
+	r.opsQueue.Enqueue(func() {
+		r.speakersPayload = payload
+		for _, link := range r.getLinks() {
+			r.sendMessage(link.nodeID, &routing.RelayMessage{
+				Type:    routing.RelayMessageSpeakers,
+				Payload: payload,
+			})
+		}
+	})
+}
+
+// RemoteSpeakers returns active speakers among participants hosted by other nodes
+func (r *RoomRelay) RemoteSpeakers() []*livekit.SpeakerInfo {
+	var speakers []*livekit.SpeakerInfo
+	for _, link := range r.getLinks() {
+		speakers = append(speakers, link.getSpeakers()...)
+	}
+	return speakers
+}
+
+// HasPermission checks subscription permissions of a participant hosted by another node,
+// subscriptions are denied until they have been received
+func (r *RoomRelay) HasPermission(publisherID livekit.ParticipantID, trackID livekit.TrackID, sub types.LocalParticipant) bool {
+	for _, link := range r.getLinks() {
+		if allowed, ok := link.hasPermission(publisherID, trackID, sub); ok {
+			return allowed
+		}
+	}
+	return false
+}
+
+// RelayedTracks returns tracks of participants hosted by other nodes
+func (r *RoomRelay) RelayedTracks() []types.MediaTrack {
+	var tracks []types.MediaTrack
+	for _, link := range r.getLinks() {
+		tracks = append(tracks, link.getRelayedTracks()...)
+	}
+	return tracks
+}
+
+func (r *RoomRelay) Close() {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return
+	}
+	r.closed = true
+	r.lock.Unlock()
+
+	r.opsQueue.Enqueue(func() {
+		for _, link := range r.getLinks() {
+			r.sendMessage(link.nodeID, &routing.RelayMessage{Type: routing.RelayMessageLeave})
+			r.closeLink(link.nodeID)
+		}
+	})
+	r.opsQueue.Stop()
+}
+
+func (r *RoomRelay) getLinks() []*relayLink {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	links := make([]*relayLink, 0, len(r.links))
+	for _, link := range r.links {
+		links = append(links, link)
+	}
+	return links
+}
+
+// should be called from the ops queue
+func (r *RoomRelay) getOrCreateLink(nodeID livekit.NodeID) (*relayLink, bool) {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil, false
+	}
+	if link := r.links[nodeID]; link != nil {
+		r.lock.Unlock()
+		return link, false
+	}
+	link := newRelayLink(r, nodeID)
+	r.links[nodeID] = link
+	r.lock.Unlock()
+
+	if err := link.startSender(); err != nil {
+		r.params.Logger.Errorw("could not start relay", err, "remoteNodeID", nodeID)
+	}
+	if r.speakersPayload != nil {
+		r.sendMessage(nodeID, &routing.RelayMessage{
+			Type:    routing.RelayMessageSpeakers,
+			Payload: r.speakersPayload,
+		})
+	}
+	return link, true
+}
+
+// should be called from the ops queue
+func (r *RoomRelay) closeLink(nodeID livekit.NodeID) {
+	r.lock.Lock()
+	link := r.links[nodeID]
+	delete(r.links, nodeID)
+	r.lock.Unlock()
+
+	if link != nil {
+		link.close()
+	}
+}
+
+func (r *RoomRelay) sendMessage(nodeID livekit.NodeID, msg *routing.RelayMessage) {
+	msg.RoomName = r.params.Room.Name()
+	msg.NodeID = r.params.NodeID
+	if err := r.params.Router.WriteRelayMessage(context.Background(), nodeID, msg); err != nil {
+		r.params.Logger.Warnw("could not send relay message", err, "remoteNodeID", nodeID, "type", msg.Type)
+	}
+}
+
+// ----------------------------------------
+
+type relayPermission struct {
+	ParticipantID livekit.ParticipantID `json:"participant_id"`
+	// proto encoded livekit.SubscriptionPermission
+	Permission []byte `json:"permission"`
+}
+
+type relayMaxQuality struct {
+	TrackID   livekit.TrackID                `json:"track_id"`
+	Qualities []types.SubscribedCodecQuality `json:"qualities"`
+}
+
+type relayDownTrack struct {
+	track        *MediaTrack
+	downTrack    *sfu.DownTrack
+	transport    *PCTransport
+	rtpSender    *webrtc.RTPSender
+	maxQualities []types.SubscribedCodecQuality
+}
+
+type pendingRelayedTrack struct {
+	track       *webrtc.TrackRemote
+	rtpReceiver *webrtc.RTPReceiver
+}
+
+// relayLink is the connection to a single remote node, state is only modified from the relay ops queue
+type relayLink struct {
+	relay  *RoomRelay
+	nodeID livekit.NodeID
+	config WebRTCConfig
+	logger logger.Logger
+
+	// forwards tracks of local participants to the remote node
+	sender        *PCTransport
+	senderSession string
+	downTracks    map[livekit.TrackID]*relayDownTrack
+
+	// receives tracks of participants hosted by the remote node
+	receiver        *PCTransport
+	receiverSession string
+	rtcpCh          chan []rtcp.Packet
+	twcc            *twcc.Responder
+	pendingTracks   map[livekit.TrackID]*pendingRelayedTrack
+
+	lock          sync.RWMutex
+	participants  map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
+	permissions   map[livekit.ParticipantID]*livekit.SubscriptionPermission
+	relayedTracks map[livekit.TrackID]*MediaTrack
+	speakers      []*livekit.SpeakerInfo
+}
+
+func newRelayLink(relay *RoomRelay, nodeID livekit.NodeID) *relayLink {
+	l := &relayLink{
+		relay:         relay,
+		nodeID:        nodeID,
+		config:        relay.params.Config,
+		logger:        relay.params.Logger.WithValues("remoteNodeID", nodeID),
+		downTracks:    make(map[livekit.TrackID]*relayDownTrack),
+		pendingTracks: make(map[livekit.TrackID]*pendingRelayedTrack),
+		participants:  make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
+		permissions:   make(map[livekit.ParticipantID]*livekit.SubscriptionPermission),
+		relayedTracks: make(map[livekit.TrackID]*MediaTrack),
+	}
+	l.config.SetBufferFactory(relay.params.Room.GetBufferFactory())
+	return l
+}
+
+func (l *relayLink) transportParams(directionConfig DirectionConfig, target livekit.SignalTarget) TransportParams {
+	return TransportParams{
+		ParticipantID:           livekit.ParticipantID(l.relay.params.NodeID),
+		ParticipantIdentity:     livekit.ParticipantIdentity(l.relay.params.NodeID),
+		ProtocolVersion:         types.CurrentProtocol,
+		Config:                  &l.config,
+		DirectionConfig:         directionConfig,
+		CongestionControlConfig: l.relay.params.CongestionControlConfig,
+		Telemetry:               l.relay.params.Telemetry,
+		EnabledCodecs:           l.relay.params.Room.ToProto().EnabledCodecs,
+		Logger:                  LoggerWithPCTarget(l.logger, target),
+		ClientInfo:              ClientInfo{ClientInfo: &livekit.ClientInfo{Sdk: livekit.ClientInfo_GO}},
+	}
+}
+
+func (l *relayLink) sendMessage(msgType routing.RelayMessageType, fromSender bool, session string, payload []byte) {
+	l.relay.sendMessage(l.nodeID, &routing.RelayMessage{
+		Type:       msgType,
+		FromSender: fromSender,
+		Session:    session,
+		Payload:    payload,
+	})
+}
+
+func (l *relayLink) sendJSON(msgType routing.RelayMessageType, fromSender bool, session string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	l.sendMessage(msgType, fromSender, session, payload)
+	return nil
+}
+
+func (l *relayLink) handleMessage(msg *routing.RelayMessage) {
+	var err error
+	switch msg.Type {
+	case routing.RelayMessageOffer:
+		err = l.handleOffer(msg)
+	case routing.RelayMessageAnswer:
+		err = l.handleAnswer(msg)
+	case routing.RelayMessageCandidate:
+		err = l.handleCandidate(msg)
+	case routing.RelayMessageParticipant:
+		err = l.handleParticipant(msg)
+	case routing.RelayMessagePermission:
+		err = l.handlePermission(msg)
+	case routing.RelayMessageMaxQuality:
+		err = l.handleMaxQuality(msg)
+	case routing.RelayMessageSpeakers:
+		err = l.handleSpeakers(msg)
+	case routing.RelayMessageData:
+		dp := &livekit.DataPacket{}
+		if err = proto.Unmarshal(msg.Payload, dp); err == nil {
+			l.relay.params.Room.retainDataPacket(dp)
+			l.relay.params.Room.updatePositionFromData(livekit.ParticipantID(dp.GetUser().GetParticipantSid()), dp)
+			BroadcastDataPacketForRoom(l.relay.params.Room, nil, dp, l.logger)
+		}
+	}
+	if err != nil {
+		l.logger.Warnw("could not handle relay message", err, "type", msg.Type)
+	}
+}
+
+func (l *relayLink) close() {
+	l.closeSender()
+	l.closeReceiver()
+
+	l.lock.Lock()
+	participants := l.participants
+	l.participants = make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo)
+	l.speakers = nil
+	l.lock.Unlock()
+
+	for _, pi := range participants {
+		pi = proto.Clone(pi).(*livekit.ParticipantInfo)
+		pi.State = livekit.ParticipantInfo_DISCONNECTED
+		l.relay.params.Room.updateRemoteParticipant(pi)
+	}
+}
+
+// ---------- sending side ----------
+
+func (l *relayLink) startSender() error {
+	session := utils.NewGuid(relaySessionPrefix)
+	params := l.transportParams(l.config.Subscriber, livekit.SignalTarget_SUBSCRIBER)
+	params.IsOfferer = true
+	params.IsSendSide = true
+	sender, err := NewPCTransport(params)
+	if err != nil {
+		return err
+	}
+
+	sender.OnOffer(func(sd webrtc.SessionDescription) error {
+		return l.sendJSON(routing.RelayMessageOffer, true, session, sd)
+	})
+	sender.OnICECandidate(func(c *webrtc.ICECandidate) error {
+		if c == nil {
+			return nil
+		}
+		return l.sendJSON(routing.RelayMessageCandidate, true, session, c.ToJSON())
+	})
+	sender.OnInitialConnected(func() {
+		l.relay.opsQueue.Enqueue(func() {
+			if l.senderSession != session {
+				return
+			}
+			for _, rdt := range l.downTracks {
+				rdt.downTrack.SetConnected()
+			}
+		})
+	})
+	restart := func() {
+		l.relay.opsQueue.Enqueue(func() {
+			if l.senderSession != session {
+				return
+			}
+			l.logger.Infow("restarting relay sender", "session", session)
+			l.closeSender()
+			if err := l.startSender(); err != nil {
+				l.logger.Errorw("could not restart relay sender", err)
+			}
+		})
+	}
+	sender.OnFailed(func(_ bool) { restart() })
+	sender.OnNegotiationFailed(restart)
+
+	l.sender = sender
+	l.senderSession = session
+
+	for _, p := range l.relay.params.Room.GetParticipants() {
+		if !p.Hidden() {
+			l.syncParticipant(p)
+		}
+	}
+	return nil
+}
+
+func (l *relayLink) closeSender() {
+	if l.sender == nil {
+		return
+	}
+
+	for trackID, rdt := range l.downTracks {
+		delete(l.downTracks, trackID)
+		if len(rdt.maxQualities) != 0 {
+			// remote node no longer needs any layer
+			off := make([]types.SubscribedCodecQuality, 0, len(rdt.maxQualities))
+			for _, q := range rdt.maxQualities {
+				off = append(off, types.SubscribedCodecQuality{CodecMime: q.CodecMime, Quality: livekit.VideoQuality_OFF})
+			}
+			rdt.track.NotifySubscriberNodeMaxQuality(l.nodeID, off)
+		}
+		go rdt.downTrack.Close()
+	}
+
+	l.sender.Close()
+	l.sender = nil
+	l.senderSession = ""
+}
+
+func (l *relayLink) syncParticipant(p types.LocalParticipant) {
+	if l.sender == nil {
+		return
+	}
+
+	pi := p.ToProto()
+	payload, err := proto.Marshal(pi)
+	if err != nil {
+		l.logger.Errorw("could not marshal participant for relay", err, "participant", p.Identity())
+		return
+	}
+	if pi.State != livekit.ParticipantInfo_DISCONNECTED {
+		// permissions are needed before tracks of the participant can be subscribed to
+		l.syncPermission(p)
+	}
+	l.sendMessage(routing.RelayMessageParticipant, true, "", payload)
+
+	published := make(map[livekit.TrackID]*MediaTrack)
+	if pi.State != livekit.ParticipantInfo_DISCONNECTED {
+		for _, t := range p.GetPublishedTracks() {
+			if mt, ok := t.(*MediaTrack); ok {
+				published[mt.ID()] = mt
+			}
+		}
+	}
+
+	changed := false
+	for trackID, rdt := range l.downTracks {
+		if rdt.track.PublisherID() != p.ID() {
+			continue
+		}
+		if _, ok := published[trackID]; !ok {
+			l.removeDownTrack(trackID)
+			changed = true
+		}
+	}
+
+	for trackID, mt := range published {
+		if rdt := l.downTracks[trackID]; rdt != nil {
+			rdt.downTrack.PubMute(mt.IsMuted())
+			continue
+		}
+		if l.addDownTrack(mt) {
+			changed = true
+		}
+	}
+
+	if changed {
+		l.sender.Negotiate(false)
+	}
+}
+
+func (l *relayLink) syncPermission(p types.LocalParticipant) {
+	permission, _ := p.SubscriptionPermission()
+	if permission == nil {
+		// no restrictions
+		permission = &livekit.SubscriptionPermission{AllParticipants: true}
+	}
+	payload, err := proto.Marshal(permission)
+	if err != nil {
+		l.logger.Errorw("could not marshal subscription permission for relay", err, "participant", p.Identity())
+		return
+	}
+	if err = l.sendJSON(routing.RelayMessagePermission, true, "", relayPermission{
+		ParticipantID: p.ID(),
+		Permission:    payload,
+	}); err != nil {
+		l.logger.Errorw("could not send subscription permission", err, "participant", p.Identity())
+	}
+}
+
+func (l *relayLink) addDownTrack(mt *MediaTrack) bool {
+	receiver := mt.PrimaryReceiver()
+	if receiver == nil {
+		// not receiving yet, will be added on a later sync
+		return false
+	}
+
+	codec := receiver.Codec()
+	switch mt.Kind() {
+	case livekit.TrackType_AUDIO:
+		codec.RTCPFeedback = l.config.Subscriber.RTCPFeedback.Audio
+	case livekit.TrackType_VIDEO:
+		codec.RTCPFeedback = l.config.Subscriber.RTCPFeedback.Video
+	}
+
+	trackID := mt.ID()
+	downTrack, err := sfu.NewDownTrack(sfu.DowntrackParams{
+		Codecs:        []webrtc.RTPCodecParameters{codec},
+		Receiver:      receiver,
+		BufferFactory: l.config.BufferFactory,
+		SubID:         livekit.ParticipantID(l.nodeID),
+		StreamID:      PackStreamID(mt.PublisherID(), trackID),
+		MaxTrack:      l.config.Receiver.PacketBufferSize,
+		Pacer:         l.sender.GetPacer(),
+		Logger:        LoggerWithTrack(l.logger, trackID, false),
+	})
+	if err != nil {
+		l.logger.Errorw("could not create relay down track", err, "trackID", trackID)
+		return false
+	}
+
+	downTrack.OnBinding(func(err error) {
+		if err != nil {
+			l.logger.Warnw("could not bind relay down track", err, "trackID", trackID)
+			return
+		}
+		if err := receiver.AddDownTrack(downTrack); err != nil && err != sfu.ErrReceiverClosed {
+			l.logger.Errorw("could not add relay down track", err, "trackID", trackID)
+		}
+		downTrack.PubMute(mt.IsMuted())
+	})
+	downTrack.OnCloseHandler(func(_ bool) {
+		l.relay.opsQueue.Enqueue(func() {
+			l.onDownTrackClosed(trackID, downTrack)
+		})
+	})
+
+	if mt.Kind() == livekit.TrackType_VIDEO {
+		// until the remote node reports what its subscribers need
+		downTrack.SetMaxSpatialLayer(buffer.VideoQualityToSpatialLayer(livekit.VideoQuality_HIGH, mt.ToProto()))
+		downTrack.SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+	}
+
+	info := mt.ToProto()
+	rtpSender, transceiver, err := l.sender.AddTrack(downTrack, types.AddTrackParams{Stereo: info.Stereo})
+	if err != nil {
+		l.logger.Errorw("could not add relay track", err, "trackID", trackID)
+		go downTrack.Close()
+		return false
+	}
+	downTrack.SetTransceiver(transceiver)
+	l.sender.AddDownTrackToStreamAllocator(downTrack, streamallocator.AddTrackParams{
+		Source:      mt.Source(),
+		IsSimulcast: mt.IsSimulcast(),
+		PublisherID: mt.PublisherID(),
+	})
+	if l.sender.HasEverConnected() {
+		downTrack.SetConnected()
+	}
+
+	l.downTracks[trackID] = &relayDownTrack{
+		track:     mt,
+		downTrack: downTrack,
+		transport: l.sender,
+		rtpSender: rtpSender,
+	}
+	return true
+}
+
+func (l *relayLink) removeDownTrack(trackID livekit.TrackID) {
+	rdt := l.downTracks[trackID]
+	if rdt == nil {
+		return
+	}
+	delete(l.downTracks, trackID)
+
+	rdt.transport.RemoveDownTrackFromStreamAllocator(rdt.downTrack)
+	if err := rdt.transport.RemoveTrack(rdt.rtpSender); err != nil {
+		l.logger.Debugw("could not remove relay track", "error", err, "trackID", trackID)
+	}
+	go rdt.downTrack.Close()
+}
+
+func (l *relayLink) onDownTrackClosed(trackID livekit.TrackID, downTrack *sfu.DownTrack) {
+	rdt := l.downTracks[trackID]
+	if rdt == nil || rdt.downTrack != downTrack {
+		return
+	}
+
+	// published track closed
+	l.removeDownTrack(trackID)
+	l.sender.Negotiate(false)
+}
+
+func (l *relayLink) handleAnswer(msg *routing.RelayMessage) error {
+	if l.sender == nil || msg.Session != l.senderSession {
+		return nil
+	}
+
+	var sd webrtc.SessionDescription
+	if err := json.Unmarshal(msg.Payload, &sd); err != nil {
+		return err
+	}
+	l.sender.HandleRemoteDescription(sd)
+	return nil
+}
+
+func (l *relayLink) handleMaxQuality(msg *routing.RelayMessage) error {
+	var mq relayMaxQuality
+	if err := json.Unmarshal(msg.Payload, &mq); err != nil {
+		return err
+	}
+
+	rdt := l.downTracks[mq.TrackID]
+	if rdt == nil {
+		return nil
+	}
+	rdt.maxQualities = mq.Qualities
+	rdt.track.NotifySubscriberNodeMaxQuality(l.nodeID, mq.Qualities)
+
+	// relay a single layer, the highest one needed by the remote node
+	maxLayer := buffer.InvalidLayerSpatial
+	ti := rdt.track.ToProto()
+	for _, q := range mq.Qualities {
+		if layer := buffer.VideoQualityToSpatialLayer(q.Quality, ti); layer > maxLayer {
+			maxLayer = layer
+		}
+	}
+	if maxLayer == buffer.InvalidLayerSpatial {
+		// all qualities off, no subscriber on the remote node needs video
+		rdt.downTrack.Mute(true)
+		return nil
+	}
+	rdt.downTrack.SetMaxSpatialLayer(maxLayer)
+	rdt.downTrack.Mute(false)
+	return nil
+}
+
+// ---------- receiving side ----------
+
+func (l *relayLink) startReceiver(session string) error {
+	receiver, err := NewPCTransport(l.transportParams(l.config.Publisher, livekit.SignalTarget_PUBLISHER))
+	if err != nil {
+		return err
+	}
+
+	receiver.OnAnswer(func(sd webrtc.SessionDescription) error {
+		return l.sendJSON(routing.RelayMessageAnswer, false, session, sd)
+	})
+	receiver.OnICECandidate(func(c *webrtc.ICECandidate) error {
+		if c == nil {
+			return nil
+		}
+		return l.sendJSON(routing.RelayMessageCandidate, false, session, c.ToJSON())
+	})
+	receiver.OnTrack(func(track *webrtc.TrackRemote, rtpReceiver *webrtc.RTPReceiver) {
+		l.relay.opsQueue.Enqueue(func() {
+			if l.receiverSession != session {
+				return
+			}
+			l.onTrack(track, rtpReceiver)
+		})
+	})
+	receiver.OnFailed(func(_ bool) {
+		l.relay.opsQueue.Enqueue(func() {
+			if l.receiverSession != session {
+				return
+			}
+			// remote sender restarts with a new session
+			l.logger.Infow("relay receiver failed", "session", session)
+			l.closeReceiver()
+		})
+	})
+
+	rtcpCh := make(chan []rtcp.Packet, relayRTCPChanSize)
+	go l.rtcpWorker(receiver, rtcpCh)
+
+	l.receiver = receiver
+	l.receiverSession = session
+	l.rtcpCh = rtcpCh
+	return nil
+}
+
+func (l *relayLink) closeReceiver() {
+	if l.receiver == nil {
+		return
+	}
+
+	l.lock.Lock()
+	relayedTracks := l.relayedTracks
+	l.relayedTracks = make(map[livekit.TrackID]*MediaTrack)
+	l.lock.Unlock()
+
+	for _, mt := range relayedTracks {
+		l.relay.params.Room.removeRelayedTrack(mt)
+		mt.Close(false)
+	}
+	l.pendingTracks = make(map[livekit.TrackID]*pendingRelayedTrack)
+
+	l.receiver.Close()
+	l.receiver = nil
+	l.receiverSession = ""
+	l.twcc = nil
+
+	select {
+	case l.rtcpCh <- nil:
+	default:
+	}
+	l.rtcpCh = nil
+}
+
+func (l *relayLink) rtcpWorker(receiver *PCTransport, rtcpCh chan []rtcp.Packet) {
+	for pkts := range rtcpCh {
+		if pkts == nil {
+			return
+		}
+
+		if err := receiver.WriteRTCP(pkts); err != nil && !IsEOF(err) {
+			l.logger.Debugw("could not write relay RTCP", "error", err)
+		}
+	}
+}
+
+func (l *relayLink) postRtcp(rtcpCh chan []rtcp.Packet, pkts []rtcp.Packet) {
+	select {
+	case rtcpCh <- pkts:
+	default:
+		l.logger.Warnw("relay rtcp channel full", nil)
+	}
+}
+
+func (l *relayLink) handleOffer(msg *routing.RelayMessage) error {
+	var sd webrtc.SessionDescription
+	if err := json.Unmarshal(msg.Payload, &sd); err != nil {
+		return err
+	}
+
+	if l.receiver != nil && l.receiverSession != msg.Session {
+		// remote sender was restarted
+		l.closeReceiver()
+	}
+	if l.receiver == nil {
+		if err := l.startReceiver(msg.Session); err != nil {
+			return err
+		}
+	}
+
+	l.receiver.HandleRemoteDescription(sd)
+	return nil
+}
+
+func (l *relayLink) handleCandidate(msg *routing.RelayMessage) error {
+	var candidate webrtc.ICECandidateInit
+	if err := json.Unmarshal(msg.Payload, &candidate); err != nil {
+		return err
+	}
+
+	if msg.FromSender {
+		if l.receiver != nil && msg.Session == l.receiverSession {
+			l.receiver.AddICECandidate(candidate)
+		}
+	} else {
+		if l.sender != nil && msg.Session == l.senderSession {
+			l.sender.AddICECandidate(candidate)
+		}
+	}
+	return nil
+}
+
+func (l *relayLink) handleParticipant(msg *routing.RelayMessage) error {
+	pi := &livekit.ParticipantInfo{}
+	if err := proto.Unmarshal(msg.Payload, pi); err != nil {
+		return err
+	}
+
+	identity := livekit.ParticipantIdentity(pi.Identity)
+	l.lock.Lock()
+	existing := l.participants[identity]
+	if existing != nil && existing.Sid == pi.Sid && pi.Version < existing.Version {
+		// out of order update
+		l.lock.Unlock()
+		return nil
+	}
+	if pi.State == livekit.ParticipantInfo_DISCONNECTED {
+		if existing != nil && existing.Sid == pi.Sid {
+			delete(l.participants, identity)
+		}
+		delete(l.permissions, livekit.ParticipantID(pi.Sid))
+	} else {
+		l.participants[identity] = pi
+	}
+
+	var removed []*MediaTrack
+	for trackID, mt := range l.relayedTracks {
+		if mt.PublisherIdentity() != identity {
+			continue
+		}
+		ti := findTrackInfo(pi, trackID)
+		if mt.PublisherID() != livekit.ParticipantID(pi.Sid) || pi.State == livekit.ParticipantInfo_DISCONNECTED || ti == nil {
+			delete(l.relayedTracks, trackID)
+			removed = append(removed, mt)
+			continue
+		}
+		mt.SetMuted(ti.Muted)
+	}
+	l.lock.Unlock()
+
+	for _, mt := range removed {
+		l.relay.params.Room.removeRelayedTrack(mt)
+		mt.Close(false)
+	}
+
+	// participant has to be known before its tracks are subscribed to
+	l.relay.params.Room.updateRemoteParticipant(pi)
+
+	for trackID, pt := range l.pendingTracks {
+		ppi, ti := l.getTrackInfo(pt.track.StreamID())
+		if ti == nil {
+			continue
+		}
+		delete(l.pendingTracks, trackID)
+		l.addRelayedTrack(ppi, ti, pt.track, pt.rtpReceiver)
+	}
+	return nil
+}
+
+func (l *relayLink) handlePermission(msg *routing.RelayMessage) error {
+	var rp relayPermission
+	if err := json.Unmarshal(msg.Payload, &rp); err != nil {
+		return err
+	}
+	permission := &livekit.SubscriptionPermission{}
+	if err := proto.Unmarshal(rp.Permission, permission); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	l.permissions[rp.ParticipantID] = permission
+	var tracks []*MediaTrack
+	for _, mt := range l.relayedTracks {
+		if mt.PublisherID() == rp.ParticipantID {
+			tracks = append(tracks, mt)
+		}
+	}
+	l.lock.Unlock()
+
+	room := l.relay.params.Room
+	for _, mt := range tracks {
+		if !permission.AllParticipants {
+			mt.RevokeDisallowedSubscribers(room.relayedTrackAllowedSubscribers(permission, mt.ID()))
+		}
+		// subscriptions waiting for permission are retried
+		room.trackManager.NotifyTrackChanged(mt.ID())
+	}
+	return nil
+}
+
+func (l *relayLink) handleSpeakers(msg *routing.RelayMessage) error {
+	update := &livekit.ActiveSpeakerUpdate{}
+	if err := proto.Unmarshal(msg.Payload, update); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	l.speakers = update.Speakers
+	l.lock.Unlock()
+	return nil
+}
+
+func (l *relayLink) getSpeakers() []*livekit.SpeakerInfo {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.speakers
+}
+
+// hasPermission returns false until permissions of the publisher have been received
+func (l *relayLink) hasPermission(publisherID livekit.ParticipantID, trackID livekit.TrackID, sub types.LocalParticipant) (bool, bool) {
+	l.lock.RLock()
+	permission, ok := l.permissions[publisherID]
+	l.lock.RUnlock()
+	if !ok {
+		return false, false
+	}
+	return relayedPermissionAllows(permission, trackID, sub.Identity(), sub.ID()), true
+}
+
+func (l *relayLink) onTrack(track *webrtc.TrackRemote, rtpReceiver *webrtc.RTPReceiver) {
+	pi, ti := l.getTrackInfo(track.StreamID())
+	if ti == nil {
+		// participant state not received yet
+		_, trackID := UnpackStreamID(track.StreamID())
+		l.pendingTracks[trackID] = &pendingRelayedTrack{
+			track:       track,
+			rtpReceiver: rtpReceiver,
+		}
+		return
+	}
+
+	l.addRelayedTrack(pi, ti, track, rtpReceiver)
+}
+
+func (l *relayLink) getTrackInfo(streamID string) (*livekit.ParticipantInfo, *livekit.TrackInfo) {
+	participantID, trackID := UnpackStreamID(streamID)
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	for _, pi := range l.participants {
+		if livekit.ParticipantID(pi.Sid) != participantID {
+			continue
+		}
+		if ti := findTrackInfo(pi, trackID); ti != nil {
+			return pi, ti
+		}
+	}
+	return nil, nil
+}
+
+func (l *relayLink) addRelayedTrack(
+	pi *livekit.ParticipantInfo,
+	ti *livekit.TrackInfo,
+	track *webrtc.TrackRemote,
+	rtpReceiver *webrtc.RTPReceiver,
+) {
+	trackID := livekit.TrackID(ti.Sid)
+	l.lock.RLock()
+	_, ok := l.relayedTracks[trackID]
+	l.lock.RUnlock()
+	if ok {
+		// a single codec is relayed per track
+		return
+	}
+
+	params := l.relay.params
+	mt := NewMediaTrack(MediaTrackParams{
+		TrackInfo:           proto.Clone(ti).(*livekit.TrackInfo),
+		ParticipantID:       livekit.ParticipantID(pi.Sid),
+		ParticipantIdentity: livekit.ParticipantIdentity(pi.Identity),
+		ParticipantVersion:  pi.Version,
+		IsRelayed:           true,
+		RTCPChan:            l.rtcpCh,
+		BufferFactory:       l.config.BufferFactory,
+		ReceiverConfig:      l.config.Receiver,
+		SubscriberConfig:    l.config.Subscriber,
+		PLIThrottleConfig:   params.PLIThrottleConfig,
+		KeyFrameCacheConfig: params.KeyFrameCacheConfig,
+		AudioConfig:         params.AudioConfig,
+		VideoConfig:         params.VideoConfig,
+		Telemetry:           params.Telemetry,
+		Logger:              LoggerWithTrack(l.logger, trackID, true),
+	})
+	mt.OnSubscribedMaxQualityChange(func(trackID livekit.TrackID, _ []*livekit.SubscribedCodec, maxSubscribedQualities []types.SubscribedCodecQuality) error {
+		return l.sendJSON(routing.RelayMessageMaxQuality, false, "", &relayMaxQuality{
+			TrackID:   trackID,
+			Qualities: maxSubscribedQualities,
+		})
+	})
+	mt.AddOnClose(func() {
+		l.relay.opsQueue.Enqueue(func() {
+			l.onRelayedTrackClosed(trackID, mt)
+		})
+	})
+
+	if l.twcc == nil {
+		rtcpCh := l.rtcpCh
+		l.twcc = twcc.NewTransportWideCCResponder(uint32(track.SSRC()))
+		l.twcc.OnFeedback(func(pkts []rtcp.Packet) {
+			l.postRtcp(rtcpCh, pkts)
+		})
+	}
+	mt.AddReceiver(rtpReceiver, track, l.twcc, l.receiver.GetMid(rtpReceiver))
+	mt.SetMuted(ti.Muted)
+
+	l.lock.Lock()
+	l.relayedTracks[trackID] = mt
+	l.lock.Unlock()
+
+	params.Room.addRelayedTrack(pi, mt)
+}
+
+func (l *relayLink) onRelayedTrackClosed(trackID livekit.TrackID, mt *MediaTrack) {
+	l.lock.Lock()
+	if l.relayedTracks[trackID] != mt {
+		l.lock.Unlock()
+		return
+	}
+	delete(l.relayedTracks, trackID)
+	l.lock.Unlock()
+
+	l.relay.params.Room.removeRelayedTrack(mt)
+}
+
+func (l *relayLink) getRelayedTracks() []types.MediaTrack {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	tracks := make([]types.MediaTrack, 0, len(l.relayedTracks))
+	for _, mt := range l.relayedTracks {
+		tracks = append(tracks, mt)
+	}
+	return tracks
+}
+
+func findTrackInfo(pi *livekit.ParticipantInfo, trackID livekit.TrackID) *livekit.TrackInfo {
+	for _, ti := range pi.Tracks {
+		if livekit.TrackID(ti.Sid) == trackID {
+			return ti
+		}
+	}
+	return nil
+}
+
+// relayedPermissionAllows matches subscribers by identity, or by sid when the publisher only knew that
+func relayedPermissionAllows(
+	permission *livekit.SubscriptionPermission,
+	trackID livekit.TrackID,
+	subIdentity livekit.ParticipantIdentity,
+	subID livekit.ParticipantID,
+) bool {
+	if permission.AllParticipants {
+		return true
+	}
+	for _, tp := range permission.TrackPermissions {
+		if tp.ParticipantIdentity != "" {
+			if livekit.ParticipantIdentity(tp.ParticipantIdentity) != subIdentity {
+				continue
+			}
+		} else if livekit.ParticipantID(tp.ParticipantSid) != subID {
+			continue
+		}
+		if tp.AllTracks {
+			return true
+		}
+		for _, sid := range tp.TrackSids {
+			if livekit.TrackID(sid) == trackID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,340 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestRoomRelayNegotiation(t *testing.T) {
+	router := newTestRelayRouter()
+	rmA, relayA := newRoomWithRelay(t, router, "A")
+	defer rmA.Close()
+	rmB, relayB := newRoomWithRelay(t, router, "B")
+	defer rmB.Close()
+
+	// speakers sent before the link exists are sent once it does
+	relayA.SendSpeakers([]*livekit.SpeakerInfo{{Sid: "PA_speaker", Level: 0.5, Active: true}})
+
+	relayB.Start(nil)
+	relayA.Start([]livekit.NodeID{"A", "B"})
+
+	// participants of each node are known on the other
+	pA := rmA.GetParticipants()[0]
+	pB := rmB.GetParticipants()[0]
+	require.Eventually(t, func() bool { return rmB.GetParticipantInfoByID(pA.ID()) != nil }, time.Second, defaultDelay)
+	require.Eventually(t, func() bool { return rmA.GetParticipantInfoByID(pB.ID()) != nil }, time.Second, defaultDelay)
+
+	require.Eventually(t, func() bool {
+		speakers := rmB.GetActiveSpeakers()
+		return len(speakers) == 1 && speakers[0].Sid == "PA_speaker"
+	}, time.Second, defaultDelay)
+
+	relayA.SendSpeakers(nil)
+	require.Eventually(t, func() bool { return len(rmB.GetActiveSpeakers()) == 0 }, time.Second, defaultDelay)
+
+	// offers of a remote sender are answered in its session, restarting the receiver on a new session
+	newOffer := func() (*webrtc.PeerConnection, webrtc.SessionDescription) {
+		pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		_, err = pc.AddTransceiverFromKind(webrtc.RTPCodecTypeAudio, webrtc.RtpTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+		offer, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		gatheringComplete := webrtc.GatheringCompletePromise(pc)
+		require.NoError(t, pc.SetLocalDescription(offer))
+		<-gatheringComplete
+		return pc, *pc.LocalDescription()
+	}
+	sendOffer := func(session string, offer webrtc.SessionDescription) {
+		payload, err := json.Marshal(offer)
+		require.NoError(t, err)
+		relayA.HandleMessage(&routing.RelayMessage{Type: routing.RelayMessageOffer, NodeID: "C", FromSender: true, Session: session, Payload: payload})
+	}
+	receiverOf := func(nodeID livekit.NodeID) (receiver *PCTransport, session string) {
+		runInRelayQueue(relayA, func() {
+			if link := relayA.links[nodeID]; link != nil {
+				receiver, session = link.receiver, link.receiverSession
+			}
+		})
+		return
+	}
+
+	pc, offer := newOffer()
+	defer pc.Close()
+	sendOffer("RS_1", offer)
+	var answer *routing.RelayMessage
+	require.Eventually(t, func() bool {
+		answer = router.lastSentTo("C", routing.RelayMessageAnswer)
+		return answer != nil
+	}, time.Second, defaultDelay)
+	require.Equal(t, "RS_1", answer.Session)
+	require.False(t, answer.FromSender)
+
+	var sd webrtc.SessionDescription
+	require.NoError(t, json.Unmarshal(answer.Payload, &sd))
+	require.NoError(t, pc.SetRemoteDescription(sd))
+	for _, msg := range router.sentTo("C", routing.RelayMessageCandidate) {
+		var candidate webrtc.ICECandidateInit
+		require.NoError(t, json.Unmarshal(msg.Payload, &candidate))
+		require.NoError(t, pc.AddICECandidate(candidate))
+	}
+	require.Eventually(t, func() bool {
+		receiver, _ := receiverOf("C")
+		return receiver != nil && receiver.HasEverConnected()
+	}, 10*time.Second, defaultDelay)
+	receiver, _ := receiverOf("C")
+
+	pc2, offer2 := newOffer()
+	defer pc2.Close()
+	sendOffer("RS_2", offer2)
+	require.Eventually(t, func() bool {
+		answer = router.lastSentTo("C", routing.RelayMessageAnswer)
+		return answer.Session == "RS_2"
+	}, time.Second, defaultDelay)
+	restarted, session := receiverOf("C")
+	require.Equal(t, "RS_2", session)
+	require.NotSame(t, receiver, restarted)
+}
+
+func TestRoomRelayMaxQuality(t *testing.T) {
+	router := newTestRelayRouter()
+	rm, relay := newRoomWithRelay(t, router, "A")
+	defer rm.Close()
+
+	ti := &livekit.TrackInfo{
+		Sid:  "TR_video",
+		Type: livekit.TrackType_VIDEO,
+		Layers: []*livekit.VideoLayer{
+			{Quality: livekit.VideoQuality_LOW},
+			{Quality: livekit.VideoQuality_MEDIUM},
+			{Quality: livekit.VideoQuality_HIGH},
+		},
+	}
+	mt := NewMediaTrack(MediaTrackParams{
+		TrackInfo: ti,
+		Logger:    logger.GetLogger(),
+	})
+	downTrack, err := sfu.NewDownTrack(sfu.DowntrackParams{
+		Codecs:   []webrtc.RTPCodecParameters{{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}}},
+		Receiver: &testRelayReceiver{trackID: "TR_video"},
+		SubID:    "B",
+		Logger:   logger.GetLogger(),
+	})
+	require.NoError(t, err)
+	defer downTrack.Close()
+	downTrack.SetMaxSpatialLayer(buffer.VideoQualityToSpatialLayer(livekit.VideoQuality_HIGH, ti))
+
+	var link *relayLink
+	runInRelayQueue(relay, func() {
+		link = newRelayLink(relay, "B")
+		link.downTracks["TR_video"] = &relayDownTrack{track: mt, downTrack: downTrack}
+		relay.lock.Lock()
+		relay.links["B"] = link
+		relay.lock.Unlock()
+	})
+
+	sendMaxQuality := func(qualities ...livekit.VideoQuality) {
+		mq := relayMaxQuality{TrackID: "TR_video"}
+		for _, q := range qualities {
+			mq.Qualities = append(mq.Qualities, types.SubscribedCodecQuality{CodecMime: webrtc.MimeTypeVP8, Quality: q})
+		}
+		payload, err := json.Marshal(mq)
+		require.NoError(t, err)
+		relay.HandleMessage(&routing.RelayMessage{Type: routing.RelayMessageMaxQuality, NodeID: "B", Payload: payload})
+		runInRelayQueue(relay, func() {})
+	}
+	isMuted := func() bool {
+		return downTrack.DebugInfo()["Muted"].(bool)
+	}
+
+	// only the highest quality needed on the remote node is relayed
+	sendMaxQuality(livekit.VideoQuality_LOW, livekit.VideoQuality_MEDIUM)
+	require.Equal(t, int32(1), downTrack.MaxLayer().Spatial)
+	require.False(t, isMuted())
+
+	// no subscriber on the remote node needs video
+	sendMaxQuality(livekit.VideoQuality_OFF)
+	require.True(t, isMuted())
+
+	sendMaxQuality(livekit.VideoQuality_HIGH)
+	require.Equal(t, int32(2), downTrack.MaxLayer().Spatial)
+	require.False(t, isMuted())
+	runInRelayQueue(relay, func() {
+		require.Equal(t, livekit.VideoQuality_HIGH, link.downTracks["TR_video"].maxQualities[0].Quality)
+		// down tracks are closed by the test
+		delete(link.downTracks, "TR_video")
+	})
+}
+
+func TestRoomRelayHandover(t *testing.T) {
+	router := newTestRelayRouter()
+	rm, relay := newRoomWithRelay(t, router, "A")
+	defer rm.Close()
+
+	remote := &livekit.ParticipantInfo{
+		Sid:      "PA_remote",
+		Identity: "remote",
+		State:    livekit.ParticipantInfo_ACTIVE,
+	}
+	sendFromB := func(msgType routing.RelayMessageType, payload []byte) {
+		relay.HandleMessage(&routing.RelayMessage{Type: msgType, NodeID: "B", FromSender: true, Payload: payload})
+	}
+	sendParticipant := func(pi *livekit.ParticipantInfo) {
+		payload, err := proto.Marshal(pi)
+		require.NoError(t, err)
+		sendFromB(routing.RelayMessageParticipant, payload)
+	}
+	getLink := func() *relayLink {
+		var link *relayLink
+		runInRelayQueue(relay, func() {
+			link = relay.links["B"]
+		})
+		return link
+	}
+
+	sendParticipant(remote)
+	speakers, err := proto.Marshal(&livekit.ActiveSpeakerUpdate{Speakers: []*livekit.SpeakerInfo{{Sid: remote.Sid, Level: 0.5, Active: true}}})
+	require.NoError(t, err)
+	sendFromB(routing.RelayMessageSpeakers, speakers)
+	require.Eventually(t, func() bool { return rm.GetParticipantInfoByID("PA_remote") != nil }, time.Second, defaultDelay)
+	require.Eventually(t, func() bool { return len(rm.GetActiveSpeakers()) == 1 }, time.Second, defaultDelay)
+	link := getLink()
+	require.NotNil(t, link)
+
+	// a restarted remote node is connected to afresh
+	sendFromB(routing.RelayMessageJoin, nil)
+	require.Eventually(t, func() bool { return rm.GetParticipantInfoByID("PA_remote") == nil }, time.Second, defaultDelay)
+	require.Empty(t, rm.GetActiveSpeakers())
+	require.NotSame(t, link, getLink())
+
+	// participants of a node leaving the room are disconnected
+	sendParticipant(remote)
+	require.Eventually(t, func() bool { return rm.GetParticipantInfoByID("PA_remote") != nil }, time.Second, defaultDelay)
+	sendFromB(routing.RelayMessageLeave, nil)
+	require.Eventually(t, func() bool { return rm.GetParticipantInfoByID("PA_remote") == nil }, time.Second, defaultDelay)
+	require.Nil(t, getLink())
+}
+
+// ----------------------------------------
+
+// delivers messages to relays of other nodes, recording messages sent to nodes without a relay
+type testRelayRouter struct {
+	lock   sync.RWMutex
+	relays map[livekit.NodeID]*RoomRelay
+	sent   map[livekit.NodeID][]*routing.RelayMessage
+}
+
+func newTestRelayRouter() *testRelayRouter {
+	return &testRelayRouter{
+		relays: make(map[livekit.NodeID]*RoomRelay),
+		sent:   make(map[livekit.NodeID][]*routing.RelayMessage),
+	}
+}
+
+func (r *testRelayRouter) WriteRelayMessage(_ context.Context, nodeID livekit.NodeID, msg *routing.RelayMessage) error {
+	m := *msg
+	r.lock.Lock()
+	relay := r.relays[nodeID]
+	if relay == nil {
+		r.sent[nodeID] = append(r.sent[nodeID], &m)
+	}
+	r.lock.Unlock()
+
+	if relay != nil {
+		relay.HandleMessage(&m)
+	}
+	return nil
+}
+
+func (r *testRelayRouter) sentTo(nodeID livekit.NodeID, msgType routing.RelayMessageType) []*routing.RelayMessage {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var msgs []*routing.RelayMessage
+	for _, msg := range r.sent[nodeID] {
+		if msg.Type == msgType {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func (r *testRelayRouter) lastSentTo(nodeID livekit.NodeID, msgType routing.RelayMessageType) *routing.RelayMessage {
+	msgs := r.sentTo(nodeID, msgType)
+	if len(msgs) == 0 {
+		return nil
+	}
+	return msgs[len(msgs)-1]
+}
+
+func newRoomWithRelay(t *testing.T, router *testRelayRouter, nodeID livekit.NodeID) (*Room, *RoomRelay) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+	rm.lock.Lock()
+	rm.protoRoom.EnabledCodecs = []*livekit.Codec{{Mime: webrtc.MimeTypeOpus}}
+	rm.lock.Unlock()
+	relay := NewRoomRelay(RoomRelayParams{
+		Room:   rm,
+		NodeID: nodeID,
+		Router: router,
+		Logger: logger.GetLogger(),
+	})
+	rm.SetRelay(relay)
+
+	router.lock.Lock()
+	router.relays[nodeID] = relay
+	router.lock.Unlock()
+	return rm, relay
+}
+
+func runInRelayQueue(relay *RoomRelay, f func()) {
+	done := make(chan struct{})
+	relay.opsQueue.Enqueue(func() {
+		f()
+		close(done)
+	})
+	<-done
+}
+
+type testRelayReceiver struct {
+	sfu.TrackReceiver
+	trackID livekit.TrackID
+}
+
+func (r *testRelayReceiver) TrackID() livekit.TrackID {
+	return r.trackID
+}
+
+func (r *testRelayReceiver) DeleteDownTrack(_ livekit.ParticipantID) {}
+
+func (r *testRelayReceiver) GetReferenceLayerRTPTimestamp(ts uint32, _ int32, _ int32) (uint32, error) {
+	return ts, nil
+}
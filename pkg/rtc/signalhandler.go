@@ -90,7 +90,15 @@ func HandleParticipantSignal(room types.Room, participant types.LocalParticipant
 
 	case *livekit.SignalRequest_UpdateMetadata:
 		if participant.ClaimGrants().Video.GetCanUpdateOwnMetadata() {
+			attributes := GetUpdateParticipantMetadataAttributes(msg.UpdateMetadata)
+			if err := room.ValidateParticipantAttributes(participant, attributes); err != nil {
+				pLogger.Warnw("could not update attributes", err)
+				return nil
+			}
 			room.UpdateParticipantMetadata(participant, msg.UpdateMetadata.Name, msg.UpdateMetadata.Metadata)
+			if err := room.UpdateParticipantAttributes(participant, attributes); err != nil {
+				pLogger.Warnw("could not update attributes", err)
+			}
 		}
 	}
 	return nil
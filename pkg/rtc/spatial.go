@@ -0,0 +1,237 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+func (p Position) Distance(o Position) float64 {
+	return math.Sqrt((p.X-o.X)*(p.X-o.X) + (p.Y-o.Y)*(p.Y-o.Y) + (p.Z-o.Z)*(p.Z-o.Z))
+}
+
+// ParsePositionMetadata extracts a position from participant metadata, when it's a JSON object with a position field
+func ParsePositionMetadata(metadata string) (Position, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(metadata), "{") {
+		return Position{}, false
+	}
+
+	var md struct {
+		Position *Position `json:"position"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &md); err != nil || md.Position == nil {
+		return Position{}, false
+	}
+	return *md.Position, true
+}
+
+type spatialVideoQuality struct {
+	maxDistance float64
+	quality     livekit.VideoQuality
+}
+
+// SpatialState is what a subscriber should be subscribed to from a publisher
+type SpatialState struct {
+	Audio   bool
+	Video   bool
+	Quality livekit.VideoQuality
+}
+
+// participants without a position are not placed in the space yet, their subscriptions are not managed,
+// with quality left to the subscriber
+var spatialStateUnplaced = SpatialState{Audio: true, Video: true, Quality: livekit.VideoQuality_OFF}
+
+type SpatialChange struct {
+	SubscriberID livekit.ParticipantID
+	PublisherID  livekit.ParticipantID
+	Previous     SpatialState
+	Current      SpatialState
+}
+
+// Spatial tracks participant positions and decides which microphone and camera tracks
+// participants should be subscribed to
+type Spatial struct {
+	config         config.SpatialConfig
+	videoQualities []spatialVideoQuality
+
+	lock      sync.RWMutex
+	positions map[livekit.ParticipantID]Position
+	states    map[livekit.ParticipantID]map[livekit.ParticipantID]SpatialState // subscriber -> publisher -> state
+}
+
+func NewSpatial(conf config.SpatialConfig) *Spatial {
+	s := &Spatial{
+		config:    conf,
+		positions: make(map[livekit.ParticipantID]Position),
+		states:    make(map[livekit.ParticipantID]map[livekit.ParticipantID]SpatialState),
+	}
+	for _, vq := range conf.VideoQualities {
+		quality, ok := livekit.VideoQuality_value[strings.ToUpper(vq.Quality)]
+		if !ok || livekit.VideoQuality(quality) == livekit.VideoQuality_OFF {
+			continue
+		}
+		s.videoQualities = append(s.videoQualities, spatialVideoQuality{
+			maxDistance: vq.MaxDistance,
+			quality:     livekit.VideoQuality(quality),
+		})
+	}
+	sort.Slice(s.videoQualities, func(i, j int) bool {
+		return s.videoQualities[i].maxDistance < s.videoQualities[j].maxDistance
+	})
+	return s
+}
+
+func (s *Spatial) PositionTopic() string {
+	return s.config.PositionTopic
+}
+
+// IsSpatialTrack returns true for tracks subscriptions are managed for
+func IsSpatialTrack(track types.MediaTrack) bool {
+	switch track.Source() {
+	case livekit.TrackSource_MICROPHONE:
+		return track.Kind() == livekit.TrackType_AUDIO
+	case livekit.TrackSource_CAMERA:
+		return track.Kind() == livekit.TrackType_VIDEO
+	}
+	return false
+}
+
+// SetPosition updates position of a participant, returning subscription changes of pairs involving it
+func (s *Spatial) SetPosition(participantID livekit.ParticipantID, position Position) []SpatialChange {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if prev, ok := s.positions[participantID]; ok && prev == position {
+		return nil
+	}
+	s.positions[participantID] = position
+
+	var changes []SpatialChange
+	for otherID := range s.positions {
+		if otherID == participantID {
+			continue
+		}
+		if change, ok := s.updateLocked(participantID, otherID); ok {
+			changes = append(changes, change)
+		}
+		if change, ok := s.updateLocked(otherID, participantID); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func (s *Spatial) RemoveParticipant(participantID livekit.ParticipantID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.positions, participantID)
+	delete(s.states, participantID)
+	for _, states := range s.states {
+		delete(states, participantID)
+	}
+}
+
+func (s *Spatial) GetState(subscriberID livekit.ParticipantID, publisherID livekit.ParticipantID) SpatialState {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if state, ok := s.states[subscriberID][publisherID]; ok {
+		return state
+	}
+	return spatialStateUnplaced
+}
+
+// Allows checks if subscriber should currently be subscribed to a track of publisher,
+// tracks are allowed until both have a position
+func (s *Spatial) Allows(subscriberID livekit.ParticipantID, publisherID livekit.ParticipantID, track types.MediaTrack) bool {
+	if !IsSpatialTrack(track) {
+		return true
+	}
+
+	state := s.GetState(subscriberID, publisherID)
+	if track.Kind() == livekit.TrackType_AUDIO {
+		return state.Audio
+	}
+	return state.Video
+}
+
+func (s *Spatial) updateLocked(subscriberID livekit.ParticipantID, publisherID livekit.ParticipantID) (SpatialChange, bool) {
+	states := s.states[subscriberID]
+	if states == nil {
+		states = make(map[livekit.ParticipantID]SpatialState)
+		s.states[subscriberID] = states
+	}
+	// hysteresis applies once the pair is placed
+	prev, isPlaced := states[publisherID]
+	distance := s.positions[subscriberID].Distance(s.positions[publisherID])
+	next := SpatialState{
+		Audio: s.within(distance, s.config.AudioRadius, prev.Audio),
+		Video: s.within(distance, s.config.VideoRadius, prev.Video),
+	}
+	if next.Video {
+		next.Quality = s.qualityFor(distance, prev)
+	}
+	if !isPlaced {
+		prev = spatialStateUnplaced
+	}
+
+	states[publisherID] = next
+	if next == prev {
+		return SpatialChange{}, false
+	}
+	return SpatialChange{
+		SubscriberID: subscriberID,
+		PublisherID:  publisherID,
+		Previous:     prev,
+		Current:      next,
+	}, true
+}
+
+func (s *Spatial) within(distance float64, radius float64, wasWithin bool) bool {
+	if wasWithin {
+		return distance <= radius+s.config.Hysteresis
+	}
+	return distance <= radius
+}
+
+func (s *Spatial) qualityFor(distance float64, prev SpatialState) livekit.VideoQuality {
+	if len(s.videoQualities) == 0 {
+		return livekit.VideoQuality_HIGH
+	}
+
+	for _, vq := range s.videoQualities {
+		wasWithin := prev.Video && prev.Quality >= vq.quality
+		if s.within(distance, vq.maxDistance, wasWithin) {
+			return vq.quality
+		}
+	}
+	return livekit.VideoQuality_LOW
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestSpatial(t *testing.T) {
+	conf := config.SpatialConfig{
+		AudioRadius: 20,
+		VideoRadius: 30,
+		VideoQualities: []config.SpatialVideoQuality{
+			{MaxDistance: 15, Quality: "medium"},
+			{MaxDistance: 5, Quality: "high"},
+		},
+		Hysteresis: 2,
+	}
+	newSpatialTrack := func(kind livekit.TrackType) *typesfakes.FakeMediaTrack {
+		track := &typesfakes.FakeMediaTrack{}
+		track.KindReturns(kind)
+		if kind == livekit.TrackType_AUDIO {
+			track.SourceReturns(livekit.TrackSource_MICROPHONE)
+		} else {
+			track.SourceReturns(livekit.TrackSource_CAMERA)
+		}
+		return track
+	}
+
+	t.Run("subscriptions follow distance", func(t *testing.T) {
+		s := NewSpatial(conf)
+		require.Empty(t, s.SetPosition("p1", Position{}))
+		// allowed until both have a position
+		require.True(t, s.Allows("p1", "p2", newSpatialTrack(livekit.TrackType_VIDEO)))
+
+		changes := s.SetPosition("p2", Position{X: 3})
+		require.Len(t, changes, 2)
+		for _, change := range changes {
+			require.Equal(t, spatialStateUnplaced, change.Previous)
+			require.Equal(t, SpatialState{Audio: true, Video: true, Quality: livekit.VideoQuality_HIGH}, change.Current)
+		}
+
+		s.SetPosition("p2", Position{X: 10})
+		require.Equal(t, SpatialState{Audio: true, Video: true, Quality: livekit.VideoQuality_MEDIUM}, s.GetState("p1", "p2"))
+
+		s.SetPosition("p2", Position{X: 25})
+		require.Equal(t, SpatialState{Video: true, Quality: livekit.VideoQuality_LOW}, s.GetState("p1", "p2"))
+
+		s.SetPosition("p2", Position{Y: 40})
+		require.Equal(t, SpatialState{}, s.GetState("p1", "p2"))
+		require.Equal(t, SpatialState{}, s.GetState("p2", "p1"))
+		require.False(t, s.Allows("p1", "p2", newSpatialTrack(livekit.TrackType_AUDIO)))
+	})
+
+	t.Run("hysteresis", func(t *testing.T) {
+		s := NewSpatial(conf)
+		s.SetPosition("p1", Position{})
+		s.SetPosition("p2", Position{X: 19})
+		require.True(t, s.GetState("p1", "p2").Audio)
+
+		// within margin, stays subscribed
+		require.Empty(t, s.SetPosition("p2", Position{X: 21}))
+		require.True(t, s.GetState("p1", "p2").Audio)
+
+		s.SetPosition("p2", Position{X: 23})
+		require.False(t, s.GetState("p1", "p2").Audio)
+
+		// has to get back within the radius
+		s.SetPosition("p2", Position{X: 21})
+		require.False(t, s.GetState("p1", "p2").Audio)
+	})
+
+	t.Run("remove participant", func(t *testing.T) {
+		s := NewSpatial(conf)
+		s.SetPosition("p1", Position{})
+		s.SetPosition("p2", Position{X: 1})
+		s.RemoveParticipant("p2")
+		require.Equal(t, spatialStateUnplaced, s.GetState("p1", "p2"))
+		require.Empty(t, s.SetPosition("p1", Position{X: 2}))
+	})
+
+	t.Run("position metadata", func(t *testing.T) {
+		position, ok := ParsePositionMetadata(`{"name": "a", "position": {"x": 1, "y": 2, "z": 3}}`)
+		require.True(t, ok)
+		require.Equal(t, Position{X: 1, Y: 2, Z: 3}, position)
+
+		_, ok = ParsePositionMetadata("plain metadata")
+		require.False(t, ok)
+		_, ok = ParsePositionMetadata(`{"name": "a"}`)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+)
+
+// SubscriptionPriorityPolicy ranks video subscribed to by class, as configured for a room.
+// Ranked classes take the highest stream allocator priorities in order, other video takes the lowest,
+// so that it is reduced or paused first when downstream bandwidth is short.
+type SubscriptionPriorityPolicy struct {
+	ranks map[config.SubscriptionPriorityClass]uint8
+
+	lock     sync.RWMutex
+	speakers map[livekit.ParticipantID]struct{}
+	pins     map[livekit.ParticipantID]map[livekit.TrackID]struct{} // subscriber -> tracks pinned by admins
+}
+
+func NewSubscriptionPriorityPolicy(conf config.SubscriptionPriorityConfig, logger logger.Logger) *SubscriptionPriorityPolicy {
+	s := &SubscriptionPriorityPolicy{
+		ranks:    make(map[config.SubscriptionPriorityClass]uint8),
+		speakers: make(map[livekit.ParticipantID]struct{}),
+		pins:     make(map[livekit.ParticipantID]map[livekit.TrackID]struct{}),
+	}
+	for _, class := range conf.Policy {
+		switch class {
+		case config.SubscriptionPriorityClassScreenShare,
+			config.SubscriptionPriorityClassActiveSpeaker,
+			config.SubscriptionPriorityClassPinned:
+			if _, ok := s.ranks[class]; !ok {
+				s.ranks[class] = streamallocator.PriorityMax - uint8(len(s.ranks))
+			}
+		default:
+			logger.Warnw("ignoring unknown subscription priority class", nil, "class", class)
+		}
+	}
+	return s
+}
+
+func (s *SubscriptionPriorityPolicy) isRanked(class config.SubscriptionPriorityClass) bool {
+	_, ok := s.ranks[class]
+	return ok
+}
+
+// Priority returns the priority of a video track for a subscriber, 0 for other tracks
+func (s *SubscriptionPriorityPolicy) Priority(subscriberID livekit.ParticipantID, track types.MediaTrack) uint8 {
+	if track.Kind() != livekit.TrackType_VIDEO {
+		return 0
+	}
+
+	s.lock.RLock()
+	_, isSpeaker := s.speakers[track.PublisherID()]
+	_, isPinned := s.pins[subscriberID][track.ID()]
+	s.lock.RUnlock()
+
+	priority := streamallocator.PriorityMin
+	for class, rank := range s.ranks {
+		var matches bool
+		switch class {
+		case config.SubscriptionPriorityClassScreenShare:
+			matches = track.Source() == livekit.TrackSource_SCREEN_SHARE
+		case config.SubscriptionPriorityClassActiveSpeaker:
+			matches = isSpeaker
+		case config.SubscriptionPriorityClassPinned:
+			matches = isPinned
+		}
+		if matches && rank > priority {
+			priority = rank
+		}
+	}
+	return priority
+}
+
+// SetActiveSpeakers returns true when priorities could have changed
+func (s *SubscriptionPriorityPolicy) SetActiveSpeakers(speakers []livekit.ParticipantID) bool {
+	if !s.isRanked(config.SubscriptionPriorityClassActiveSpeaker) {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	changed := len(speakers) != len(s.speakers)
+	next := make(map[livekit.ParticipantID]struct{}, len(speakers))
+	for _, pID := range speakers {
+		if _, ok := s.speakers[pID]; !ok {
+			changed = true
+		}
+		next[pID] = struct{}{}
+	}
+	s.speakers = next
+	return changed
+}
+
+// Pin marks a track as pinned for a subscriber, returning true when priorities could have changed
+func (s *SubscriptionPriorityPolicy) Pin(subscriberID livekit.ParticipantID, trackID livekit.TrackID) bool {
+	if !s.isRanked(config.SubscriptionPriorityClassPinned) {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	pins := s.pins[subscriberID]
+	if pins == nil {
+		pins = make(map[livekit.TrackID]struct{})
+		s.pins[subscriberID] = pins
+	}
+	if _, ok := pins[trackID]; ok {
+		return false
+	}
+	pins[trackID] = struct{}{}
+	return true
+}
+
+func (s *SubscriptionPriorityPolicy) Unpin(subscriberID livekit.ParticipantID, trackID livekit.TrackID) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	pins := s.pins[subscriberID]
+	if _, ok := pins[trackID]; !ok {
+		return false
+	}
+	delete(pins, trackID)
+	if len(pins) == 0 {
+		delete(s.pins, subscriberID)
+	}
+	return true
+}
+
+func (s *SubscriptionPriorityPolicy) IsPinned(subscriberID livekit.ParticipantID, trackID livekit.TrackID) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, ok := s.pins[subscriberID][trackID]
+	return ok
+}
+
+func (s *SubscriptionPriorityPolicy) RemoveSubscriber(subscriberID livekit.ParticipantID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.pins, subscriberID)
+}
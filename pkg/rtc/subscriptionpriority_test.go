@@ -0,0 +1,86 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+)
+
+func TestSubscriptionPriorityPolicy(t *testing.T) {
+	newTrack := func(trackID livekit.TrackID, publisherID livekit.ParticipantID, kind livekit.TrackType, source livekit.TrackSource) *typesfakes.FakeMediaTrack {
+		track := &typesfakes.FakeMediaTrack{}
+		track.IDReturns(trackID)
+		track.PublisherIDReturns(publisherID)
+		track.KindReturns(kind)
+		track.SourceReturns(source)
+		return track
+	}
+	screen := newTrack("screen", "p1", livekit.TrackType_VIDEO, livekit.TrackSource_SCREEN_SHARE)
+	camera1 := newTrack("camera1", "p1", livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA)
+	camera2 := newTrack("camera2", "p2", livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA)
+	mic := newTrack("mic", "p2", livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE)
+
+	s := NewSubscriptionPriorityPolicy(config.SubscriptionPriorityConfig{
+		Policy: []config.SubscriptionPriorityClass{
+			config.SubscriptionPriorityClassScreenShare,
+			config.SubscriptionPriorityClassActiveSpeaker,
+			config.SubscriptionPriorityClassPinned,
+			"unknown",
+		},
+	}, logger.GetLogger())
+
+	require.Equal(t, streamallocator.PriorityMax, s.Priority("sub", screen))
+	require.Equal(t, streamallocator.PriorityMin, s.Priority("sub", camera1))
+	require.Equal(t, streamallocator.PriorityMin, s.Priority("sub", camera2))
+	require.Zero(t, s.Priority("sub", mic))
+
+	require.True(t, s.SetActiveSpeakers([]livekit.ParticipantID{"p2"}))
+	require.False(t, s.SetActiveSpeakers([]livekit.ParticipantID{"p2"}))
+	require.Equal(t, streamallocator.PriorityMax-1, s.Priority("sub", camera2))
+
+	// pins are per subscriber, the highest class applies
+	require.True(t, s.Pin("sub", "camera1"))
+	require.False(t, s.Pin("sub", "camera1"))
+	require.True(t, s.Pin("sub", "camera2"))
+	require.Equal(t, streamallocator.PriorityMax-2, s.Priority("sub", camera1))
+	require.Equal(t, streamallocator.PriorityMin, s.Priority("other", camera1))
+	require.Equal(t, streamallocator.PriorityMax-1, s.Priority("sub", camera2))
+
+	require.True(t, s.Unpin("sub", "camera1"))
+	require.False(t, s.Unpin("sub", "camera1"))
+	require.Equal(t, streamallocator.PriorityMin, s.Priority("sub", camera1))
+
+	s.RemoveSubscriber("sub")
+	require.True(t, s.SetActiveSpeakers(nil))
+	require.Equal(t, streamallocator.PriorityMin, s.Priority("sub", camera2))
+
+	t.Run("unranked classes are not tracked", func(t *testing.T) {
+		s := NewSubscriptionPriorityPolicy(config.SubscriptionPriorityConfig{
+			Policy: []config.SubscriptionPriorityClass{config.SubscriptionPriorityClassActiveSpeaker},
+		}, logger.GetLogger())
+		require.False(t, s.Pin("sub", "camera1"))
+		// screen share is not ranked, it comes last with other video
+		require.Equal(t, streamallocator.PriorityMin, s.Priority("sub", screen))
+	})
+}
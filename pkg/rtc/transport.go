@@ -17,6 +17,7 @@ package rtc
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -44,6 +45,8 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/livekit-server/pkg/sfu/rtpextension"
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
@@ -192,7 +195,8 @@ type PCTransport struct {
 	resetShortConnOnICERestart atomic.Bool
 	signalingRTT               atomic.Uint32 // milliseconds
 
-	onFullyEstablished func()
+	onFullyEstablished        func()
+	onReliableDataChannelOpen func()
 
 	debouncedNegotiate func(func())
 	debouncePending    bool
@@ -257,6 +261,8 @@ type TransportParams struct {
 	IsSendSide                   bool
 	AllowPlayoutDelay            bool
 	DataChannelMaxBufferedAmount uint64
+	// transports without data channels are fully established once connected
+	NoDataChannels bool
 }
 
 func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimator cc.BandwidthEstimator)) (*webrtc.PeerConnection, *webrtc.MediaEngine, error) {
@@ -399,12 +405,13 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 		filteredRemoteCandidates: utils.NewDedupedSlice[string](maxICECandidates),
 	}
 	if params.IsSendSide {
+		t.pacer = newPacer(params.CongestionControlConfig.Pacer, params.Logger)
 		t.streamAllocator = streamallocator.NewStreamAllocator(streamallocator.StreamAllocatorParams{
 			Config: params.CongestionControlConfig,
+			Pacer:  t.pacer,
 			Logger: params.Logger.WithComponent(sutils.ComponentCongestionControl),
 		})
 		t.streamAllocator.Start()
-		t.pacer = pacer.NewPassThrough(params.Logger)
 	}
 
 	if err := t.createPeerConnection(); err != nil {
@@ -416,6 +423,25 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 	return t, nil
 }
 
+func newPacer(conf config.CongestionControlPacerConfig, lgr logger.Logger) pacer.Pacer {
+	switch conf.Type {
+	case config.CongestionControlPacerTypeNoQueue:
+		return pacer.NewNoQueue(lgr)
+	case config.CongestionControlPacerTypePriority:
+		return pacer.NewPriority(pacer.PriorityParams{
+			Interval:     conf.Interval,
+			PacingFactor: conf.PacingFactor,
+			MaxQueued:    conf.MaxQueued,
+			OnQueueDelay: func(kind pacer.PacketKind, queueDelay time.Duration) {
+				prometheus.RecordPacerQueueDelay(kind.String(), queueDelay)
+			},
+			Logger: lgr,
+		})
+	default:
+		return pacer.NewPassThrough(lgr)
+	}
+}
+
 func (t *PCTransport) createPeerConnection() error {
 	var bwe cc.BandwidthEstimator
 	pc, me, err := newPeerConnection(t.params, func(estimator cc.BandwidthEstimator) {
@@ -682,6 +708,10 @@ func (t *PCTransport) onDataChannel(dc *webrtc.DataChannel) {
 		})
 
 		t.maybeNotifyFullyEstablished()
+		// data channels opened by the remote are ready to send
+		if onReliableDataChannelOpen := t.getOnReliableDataChannelOpen(); onReliableDataChannelOpen != nil {
+			onReliableDataChannelOpen()
+		}
 	case LossyDataChannel:
 		t.lock.Lock()
 		t.lossyDC = dc
@@ -711,7 +741,7 @@ func (t *PCTransport) isFullyEstablished() bool {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
-	return t.reliableDCOpened && t.lossyDCOpened && !t.connectedAt.IsZero()
+	return (t.params.NoDataChannels || (t.reliableDCOpened && t.lossyDCOpened)) && !t.connectedAt.IsZero()
 }
 
 func (t *PCTransport) SetPreferTCP(preferTCP bool) {
@@ -832,6 +862,14 @@ func (t *PCTransport) CreateDataChannel(label string, dci *webrtc.DataChannelIni
 		t.maybeNotifyFullyEstablished()
 	}
 
+	// with relaxed ACKs, data channels are ready once dialed, before the remote acknowledges them
+	reliableDCOpenHandler := func() {
+		t.params.Logger.Debugw(dc.Label() + " data channel acknowledged")
+		if onReliableDataChannelOpen := t.getOnReliableDataChannelOpen(); onReliableDataChannelOpen != nil {
+			onReliableDataChannelOpen()
+		}
+	}
+
 	dcCloseHandler := func() {
 		t.params.Logger.Debugw(dc.Label() + " data channel close")
 	}
@@ -847,9 +885,13 @@ func (t *PCTransport) CreateDataChannel(label string, dci *webrtc.DataChannelIni
 	case ReliableDataChannel:
 		t.reliableDC = dc
 		if t.params.DirectionConfig.StrictACKs {
-			t.reliableDC.OnOpen(dcReadyHandler)
+			t.reliableDC.OnOpen(func() {
+				dcReadyHandler()
+				reliableDCOpenHandler()
+			})
 		} else {
 			t.reliableDC.OnDial(dcReadyHandler)
+			t.reliableDC.OnOpen(reliableDCOpenHandler)
 		}
 		t.reliableDC.OnClose(dcCloseHandler)
 		t.reliableDC.OnError(dcErrorHandler)
@@ -913,6 +955,13 @@ func (t *PCTransport) HasEverConnected() bool {
 }
 
 func (t *PCTransport) WriteRTCP(pkts []rtcp.Packet) error {
+	if bf := t.params.Config.BufferFactory; bf != nil {
+		if pc := bf.PacketCapture(); pc != nil {
+			if b, err := rtcp.Marshal(pkts); err == nil {
+				pc.CaptureRTCP(buffer.PacketDirectionOutbound, b)
+			}
+		}
+	}
 	return t.pc.WriteRTCP(pkts)
 }
 
@@ -1022,6 +1071,20 @@ func (t *PCTransport) getOnFullyEstablished() func() {
 	return t.onFullyEstablished
 }
 
+// OnReliableDataChannelOpen is called when the reliable data channel is open on both sides
+func (t *PCTransport) OnReliableDataChannelOpen(f func()) {
+	t.lock.Lock()
+	t.onReliableDataChannelOpen = f
+	t.lock.Unlock()
+}
+
+func (t *PCTransport) getOnReliableDataChannelOpen() func() {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.onReliableDataChannelOpen
+}
+
 func (t *PCTransport) OnFailed(f func(isShortLived bool)) {
 	t.lock.Lock()
 	t.onFailed = f
@@ -1163,24 +1226,41 @@ func (t *PCTransport) OnStreamStateChange(f func(update *streamallocator.StreamS
 	t.streamAllocator.OnStreamStateChange(f)
 }
 
-func (t *PCTransport) AddTrackToStreamAllocator(subTrack types.SubscribedTrack) {
-	if t.streamAllocator == nil {
-		return
-	}
-
-	t.streamAllocator.AddTrack(subTrack.DownTrack(), streamallocator.AddTrackParams{
+func (t *PCTransport) AddTrackToStreamAllocator(subTrack types.SubscribedTrack, priority uint8) {
+	t.AddDownTrackToStreamAllocator(subTrack.DownTrack(), streamallocator.AddTrackParams{
 		Source:      subTrack.MediaTrack().Source(),
+		Priority:    priority,
 		IsSimulcast: subTrack.MediaTrack().IsSimulcast(),
 		PublisherID: subTrack.MediaTrack().PublisherID(),
 	})
 }
 
+func (t *PCTransport) AddDownTrackToStreamAllocator(downTrack *sfu.DownTrack, params streamallocator.AddTrackParams) {
+	if t.streamAllocator == nil {
+		return
+	}
+
+	t.streamAllocator.AddTrack(downTrack, params)
+}
+
 func (t *PCTransport) RemoveTrackFromStreamAllocator(subTrack types.SubscribedTrack) {
+	t.RemoveDownTrackFromStreamAllocator(subTrack.DownTrack())
+}
+
+func (t *PCTransport) RemoveDownTrackFromStreamAllocator(downTrack *sfu.DownTrack) {
+	if t.streamAllocator == nil {
+		return
+	}
+
+	t.streamAllocator.RemoveTrack(downTrack)
+}
+
+func (t *PCTransport) SetTrackPriorityOfStreamAllocator(subTrack types.SubscribedTrack, priority uint8) {
 	if t.streamAllocator == nil {
 		return
 	}
 
-	t.streamAllocator.RemoveTrack(subTrack.DownTrack())
+	t.streamAllocator.SetTrackPriority(subTrack.DownTrack(), priority)
 }
 
 func (t *PCTransport) SetAllowPauseOfStreamAllocator(allowPause bool) {
@@ -1664,6 +1744,91 @@ func (t *PCTransport) filterCandidates(sd webrtc.SessionDescription, preferTCP b
 	return sd
 }
 
+// signalRTXStreams adds RTX streams of down tracks to an offer, pion does not signal them for senders
+func (t *PCTransport) signalRTXStreams(sd webrtc.SessionDescription) webrtc.SessionDescription {
+	rtxSSRCs := make(map[uint32]uint32)
+	var downTracks []*sfu.DownTrack
+	for _, tr := range t.pc.GetTransceivers() {
+		sender := tr.Sender()
+		if sender == nil {
+			continue
+		}
+		dt, ok := sender.Track().(*sfu.DownTrack)
+		if !ok || dt.RTXSSRC() == 0 {
+			continue
+		}
+		if encodings := sender.GetParameters().Encodings; len(encodings) != 0 {
+			rtxSSRCs[uint32(encodings[0].SSRC)] = dt.RTXSSRC()
+			downTracks = append(downTracks, dt)
+		}
+	}
+	if len(rtxSSRCs) == 0 {
+		return sd
+	}
+
+	parsed, err := sd.Unmarshal()
+	if err != nil {
+		t.params.Logger.Errorw("could not unmarshal SDP to signal RTX streams", err)
+		return sd
+	}
+
+	addRTXSSRCGroups(parsed, rtxSSRCs)
+
+	bytes, err := parsed.Marshal()
+	if err != nil {
+		t.params.Logger.Errorw("could not marshal SDP to signal RTX streams", err)
+		return sd
+	}
+	sd.SDP = string(bytes)
+
+	// RTX is used only on streams signalled in offers, not when the remote offers, e. g. WHEP
+	for _, dt := range downTracks {
+		dt.SetRTXSignalled()
+	}
+	return sd
+}
+
+// addRTXSSRCGroups adds an FID group and ssrc attributes of the RTX stream of each media stream with an RTX SSRC
+func addRTXSSRCGroups(parsed *sdp.SessionDescription, rtxSSRCs map[uint32]uint32) {
+	for _, m := range parsed.MediaDescriptions {
+		var groups []sdp.Attribute
+		var rtxAttrs []sdp.Attribute
+		grouped := make(map[uint32]bool)
+		for _, a := range m.Attributes {
+			if a.Key == sdp.AttrKeySSRCGroup && strings.HasPrefix(a.Value, "FID ") {
+				// already signalled
+				groups, rtxAttrs = nil, nil
+				break
+			}
+			if a.Key != sdp.AttrKeySSRC {
+				continue
+			}
+
+			ssrcValue, attr, found := strings.Cut(a.Value, " ")
+			if !found {
+				continue
+			}
+			ssrc, err := strconv.ParseUint(ssrcValue, 10, 32)
+			if err != nil {
+				continue
+			}
+			rtxSSRC, ok := rtxSSRCs[uint32(ssrc)]
+			if !ok {
+				continue
+			}
+
+			if !grouped[rtxSSRC] {
+				grouped[rtxSSRC] = true
+				groups = append(groups, sdp.Attribute{Key: sdp.AttrKeySSRCGroup, Value: fmt.Sprintf("FID %d %d", ssrc, rtxSSRC)})
+			}
+			rtxAttrs = append(rtxAttrs, sdp.Attribute{Key: sdp.AttrKeySSRC, Value: fmt.Sprintf("%d %s", rtxSSRC, attr)})
+		}
+
+		m.Attributes = append(m.Attributes, groups...)
+		m.Attributes = append(m.Attributes, rtxAttrs...)
+	}
+}
+
 func (t *PCTransport) clearSignalStateCheckTimer() {
 	if t.signalStateCheckTimer != nil {
 		t.signalStateCheckTimer.Stop()
@@ -1774,6 +1939,9 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 	if preferTCP {
 		t.params.Logger.Debugw("local offer (filtered)", "sdp", offer.SDP)
 	}
+	if t.params.DirectionConfig.RTX {
+		offer = t.signalRTXStreams(offer)
+	}
 
 	// indicate waiting for remote
 	t.setNegotiationState(NegotiationStateRemote)
@@ -557,3 +557,49 @@ func TestConfigureAudioTransceiver(t *testing.T) {
 		})
 	}
 }
+
+func TestAddRTXSSRCGroups(t *testing.T) {
+	offer := `v=0
+o=- 1 2 IN IP4 0.0.0.0
+s=-
+t=0 0
+m=video 9 UDP/TLS/RTP/SAVPF 96 97
+c=IN IP4 0.0.0.0
+a=mid:0
+a=rtpmap:96 VP8/90000
+a=rtpmap:97 rtx/90000
+a=fmtp:97 apt=96
+a=ssrc:1111 cname:stream
+a=ssrc:1111 msid:stream track
+a=sendonly
+m=audio 9 UDP/TLS/RTP/SAVPF 111
+c=IN IP4 0.0.0.0
+a=mid:1
+a=rtpmap:111 opus/48000/2
+a=ssrc:2222 cname:stream
+a=sendonly
+`
+	parsed := &sdp.SessionDescription{}
+	require.NoError(t, parsed.Unmarshal([]byte(strings.ReplaceAll(offer, "\n", "\r\n"))))
+
+	addRTXSSRCGroups(parsed, map[uint32]uint32{1111: 3333})
+
+	var video []string
+	for _, a := range parsed.MediaDescriptions[0].Attributes {
+		if a.Key == sdp.AttrKeySSRC || a.Key == sdp.AttrKeySSRCGroup {
+			video = append(video, a.Key+":"+a.Value)
+		}
+	}
+	require.Equal(t, []string{
+		"ssrc:1111 cname:stream",
+		"ssrc:1111 msid:stream track",
+		"ssrc-group:FID 1111 3333",
+		"ssrc:3333 cname:stream",
+		"ssrc:3333 msid:stream track",
+	}, video)
+	require.Len(t, parsed.MediaDescriptions[1].Attributes, 4)
+
+	// already signalled streams are left as is
+	addRTXSSRCGroups(parsed, map[uint32]uint32{1111: 3333})
+	require.Len(t, parsed.MediaDescriptions[0].Attributes, 10)
+}
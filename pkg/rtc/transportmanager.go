@@ -67,7 +67,9 @@ type TransportManagerParams struct {
 	TURNSEnabled                 bool
 	AllowPlayoutDelay            bool
 	DataChannelMaxBufferedAmount uint64
-	Logger                       logger.Logger
+	// the subscriber answers offers of the client instead of offering, without data channels
+	ReceiveOnly bool
+	Logger      logger.Logger
 }
 
 type TransportManager struct {
@@ -155,10 +157,11 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 		EnabledCodecs:                params.EnabledSubscribeCodecs,
 		Logger:                       LoggerWithPCTarget(params.Logger, livekit.SignalTarget_SUBSCRIBER),
 		ClientInfo:                   params.ClientInfo,
-		IsOfferer:                    true,
+		IsOfferer:                    !params.ReceiveOnly,
 		IsSendSide:                   true,
 		AllowPlayoutDelay:            params.AllowPlayoutDelay,
 		DataChannelMaxBufferedAmount: params.DataChannelMaxBufferedAmount,
+		NoDataChannels:               params.ReceiveOnly,
 	})
 	if err != nil {
 		return nil, err
@@ -178,7 +181,7 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 			t.onAnyTransportFailed()
 		}
 	})
-	if !t.params.Migration {
+	if !t.params.Migration && !t.params.ReceiveOnly {
 		if err := t.createDataChannelsForSubscriber(nil); err != nil {
 			return nil, err
 		}
@@ -245,6 +248,10 @@ func (t *TransportManager) OnSubscriberOffer(f func(offer webrtc.SessionDescript
 	t.subscriber.OnOffer(f)
 }
 
+func (t *TransportManager) OnSubscriberAnswer(f func(answer webrtc.SessionDescription) error) {
+	t.subscriber.OnAnswer(f)
+}
+
 func (t *TransportManager) OnSubscriberInitialConnected(f func()) {
 	t.onSubscriberInitialConnected = f
 }
@@ -285,6 +292,10 @@ func (t *TransportManager) OnPrimaryTransportFullyEstablished(f func()) {
 	t.getTransport(true).OnFullyEstablished(f)
 }
 
+func (t *TransportManager) OnPrimaryTransportReliableDataChannelOpen(f func()) {
+	t.getTransport(true).OnReliableDataChannelOpen(f)
+}
+
 func (t *TransportManager) OnAnyTransportFailed(f func()) {
 	t.onAnyTransportFailed = f
 }
@@ -294,8 +305,12 @@ func (t *TransportManager) OnAnyTransportNegotiationFailed(f func()) {
 	t.subscriber.OnNegotiationFailed(f)
 }
 
-func (t *TransportManager) AddSubscribedTrack(subTrack types.SubscribedTrack) {
-	t.subscriber.AddTrackToStreamAllocator(subTrack)
+func (t *TransportManager) AddSubscribedTrack(subTrack types.SubscribedTrack, priority uint8) {
+	t.subscriber.AddTrackToStreamAllocator(subTrack, priority)
+}
+
+func (t *TransportManager) SetSubscribedTrackPriority(subTrack types.SubscribedTrack, priority uint8) {
+	t.subscriber.SetTrackPriorityOfStreamAllocator(subTrack, priority)
 }
 
 func (t *TransportManager) RemoveSubscribedTrack(subTrack types.SubscribedTrack) {
@@ -409,6 +424,11 @@ func (t *TransportManager) LastPublisherOffer() webrtc.SessionDescription {
 }
 
 func (t *TransportManager) HandleOffer(offer webrtc.SessionDescription, shouldPend bool) {
+	if t.params.ReceiveOnly {
+		t.subscriber.HandleRemoteDescription(offer)
+		return
+	}
+
 	t.lock.Lock()
 	if shouldPend {
 		t.pendingOfferPublisher = &offer
@@ -463,6 +483,11 @@ func (t *TransportManager) AddICECandidate(candidate webrtc.ICECandidateInit, ta
 }
 
 func (t *TransportManager) NegotiateSubscriber(force bool) {
+	// offers of the client negotiate receive only subscribers
+	if t.params.ReceiveOnly {
+		return
+	}
+
 	t.subscriber.Negotiate(force)
 }
 
@@ -500,6 +525,9 @@ func (t *TransportManager) ICERestart(iceConfig *livekit.ICEConfig) error {
 	if iceConfig != nil {
 		t.SetICEConfig(iceConfig)
 	}
+	if t.params.ReceiveOnly {
+		return nil
+	}
 
 	return t.subscriber.ICERestart()
 }
@@ -78,6 +78,29 @@ type SubscribedCodecQuality struct {
 
 // ---------------------------------------------
 
+// PublishBitrateCaps are upstream video bitrate caps of a participant, in bits per second, 0 when not capped
+type PublishBitrateCaps struct {
+	// set by admins, for all video tracks of the participant and for individual tracks
+	MaxBitrate       int64
+	MaxTrackBitrates map[livekit.TrackID]int64
+	// in effect for each video track, combining caps of admins, grants and room configuration
+	TrackBitrates map[livekit.TrackID]int64
+}
+
+// ---------------------------------------------
+
+// SubscriptionPriority is the stream allocator priority of a subscribed video track, 0 when not set.
+// Priorities of admins override others, otherwise the higher of room policy and client priority applies
+type SubscriptionPriority struct {
+	TrackID  livekit.TrackID
+	Admin    uint8
+	Policy   uint8
+	Client   uint8
+	Priority uint8
+}
+
+// ---------------------------------------------
+
 type ParticipantCloseReason int
 
 const (
@@ -246,6 +269,8 @@ type Participant interface {
 
 	SetName(name string)
 	SetMetadata(metadata string)
+	Attributes() map[string]string
+	UpdateAttributes(update map[string]string) map[string]string
 
 	IsPublisher() bool
 	GetPublishedTrack(trackID livekit.TrackID) MediaTrack
@@ -333,12 +358,17 @@ type LocalParticipant interface {
 	CanPublishSource(source livekit.TrackSource) bool
 	CanSubscribe() bool
 	CanPublishData() bool
+	CanPublishDataTopic(topic string) bool
+	IsSubscribedToDataTopic(topic string) bool
+	SetDataTopicSubscriptions(topics []string)
 
 	// PeerConnection
 	AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget)
 	HandleOffer(sdp webrtc.SessionDescription)
 	AddTrack(req *livekit.AddTrackRequest)
 	SetTrackMuted(trackID livekit.TrackID, muted bool, fromAdmin bool) *livekit.TrackInfo
+	SetPublishBitrateCap(trackID livekit.TrackID, bitrate int64) error
+	GetPublishBitrateCaps() PublishBitrateCaps
 
 	HandleAnswer(sdp webrtc.SessionDescription)
 	Negotiate(force bool)
@@ -352,6 +382,10 @@ type LocalParticipant interface {
 	UnsubscribeFromTrack(trackID livekit.TrackID)
 	UpdateSubscribedTrackSettings(trackID livekit.TrackID, settings *livekit.UpdateTrackSettings)
 	GetSubscribedTracks() []SubscribedTrack
+	SetSubscriptionPriority(trackID livekit.TrackID, priority uint8) error
+	GetSubscriptionPriorities() []SubscriptionPriority
+	// re-evaluates priorities of subscribed tracks after room allocation policy inputs changed
+	UpdateSubscriptionPriorities()
 	VerifySubscribeParticipantInfo(pID livekit.ParticipantID, version uint32)
 	// WaitUntilSubscribed waits until all subscriptions have been settled, or if the timeout
 	// has been reached. If the timeout expires, it will return an error.
@@ -387,6 +421,8 @@ type LocalParticipant interface {
 	// OnParticipantUpdate - metadata or permission is updated
 	OnParticipantUpdate(callback func(LocalParticipant))
 	OnDataPacket(callback func(LocalParticipant, *livekit.DataPacket))
+	// OnDataChannelOpen - participant is active and data can be sent to it reliably
+	OnDataChannelOpen(callback func(LocalParticipant))
 	OnSubscribeStatusChanged(fn func(publisherID livekit.ParticipantID, subscribed bool))
 	OnClose(callback func(LocalParticipant))
 	OnClaimsChanged(callback func(LocalParticipant))
@@ -433,6 +469,8 @@ type Room interface {
 	ResolveMediaTrackForSubscriber(subIdentity livekit.ParticipantIdentity, trackID livekit.TrackID) MediaResolverResult
 	GetLocalParticipants() []LocalParticipant
 	UpdateParticipantMetadata(participant LocalParticipant, name string, metadata string)
+	UpdateParticipantAttributes(participant LocalParticipant, attributes map[string]string) error
+	ValidateParticipantAttributes(participant LocalParticipant, attributes map[string]string) error
 }
 
 // MediaTrack represents a media track
@@ -62,6 +62,16 @@ type FakeLocalParticipant struct {
 		result2 *webrtc.RTPTransceiver
 		result3 error
 	}
+	AttributesStub        func() map[string]string
+	attributesMutex       sync.RWMutex
+	attributesArgsForCall []struct {
+	}
+	attributesReturns struct {
+		result1 map[string]string
+	}
+	attributesReturnsOnCall map[int]struct {
+		result1 map[string]string
+	}
 	CacheDownTrackStub        func(livekit.TrackID, *webrtc.RTPTransceiver, sfu.DownTrackState)
 	cacheDownTrackMutex       sync.RWMutex
 	cacheDownTrackArgsForCall []struct {
@@ -79,6 +89,17 @@ type FakeLocalParticipant struct {
 	canPublishDataReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	CanPublishDataTopicStub        func(string) bool
+	canPublishDataTopicMutex       sync.RWMutex
+	canPublishDataTopicArgsForCall []struct {
+		arg1 string
+	}
+	canPublishDataTopicReturns struct {
+		result1 bool
+	}
+	canPublishDataTopicReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	CanPublishSourceStub        func(livekit.TrackSource) bool
 	canPublishSourceMutex       sync.RWMutex
 	canPublishSourceArgsForCall []struct {
@@ -284,6 +305,16 @@ type FakeLocalParticipant struct {
 	getPlayoutDelayConfigReturnsOnCall map[int]struct {
 		result1 *livekit.PlayoutDelay
 	}
+	GetPublishBitrateCapsStub        func() types.PublishBitrateCaps
+	getPublishBitrateCapsMutex       sync.RWMutex
+	getPublishBitrateCapsArgsForCall []struct {
+	}
+	getPublishBitrateCapsReturns struct {
+		result1 types.PublishBitrateCaps
+	}
+	getPublishBitrateCapsReturnsOnCall map[int]struct {
+		result1 types.PublishBitrateCaps
+	}
 	GetPublishedTrackStub        func(livekit.TrackID) types.MediaTrack
 	getPublishedTrackMutex       sync.RWMutex
 	getPublishedTrackArgsForCall []struct {
@@ -325,6 +356,16 @@ type FakeLocalParticipant struct {
 	getSubscribedTracksReturnsOnCall map[int]struct {
 		result1 []types.SubscribedTrack
 	}
+	GetSubscriptionPrioritiesStub        func() []types.SubscriptionPriority
+	getSubscriptionPrioritiesMutex       sync.RWMutex
+	getSubscriptionPrioritiesArgsForCall []struct {
+	}
+	getSubscriptionPrioritiesReturns struct {
+		result1 []types.SubscriptionPriority
+	}
+	getSubscriptionPrioritiesReturnsOnCall map[int]struct {
+		result1 []types.SubscriptionPriority
+	}
 	GetTrailerStub        func() []byte
 	getTrailerMutex       sync.RWMutex
 	getTrailerArgsForCall []struct {
@@ -479,6 +520,17 @@ type FakeLocalParticipant struct {
 	isSubscribedToReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	IsSubscribedToDataTopicStub        func(string) bool
+	isSubscribedToDataTopicMutex       sync.RWMutex
+	isSubscribedToDataTopicArgsForCall []struct {
+		arg1 string
+	}
+	isSubscribedToDataTopicReturns struct {
+		result1 bool
+	}
+	isSubscribedToDataTopicReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IssueFullReconnectStub        func(types.ParticipantCloseReason)
 	issueFullReconnectMutex       sync.RWMutex
 	issueFullReconnectArgsForCall []struct {
@@ -521,6 +573,11 @@ type FakeLocalParticipant struct {
 	onCloseArgsForCall []struct {
 		arg1 func(types.LocalParticipant)
 	}
+	OnDataChannelOpenStub        func(func(types.LocalParticipant))
+	onDataChannelOpenMutex       sync.RWMutex
+	onDataChannelOpenArgsForCall []struct {
+		arg1 func(types.LocalParticipant)
+	}
 	OnDataPacketStub        func(func(types.LocalParticipant, *livekit.DataPacket))
 	onDataPacketMutex       sync.RWMutex
 	onDataPacketArgsForCall []struct {
@@ -679,6 +736,11 @@ type FakeLocalParticipant struct {
 	sendSpeakerUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetDataTopicSubscriptionsStub        func([]string)
+	setDataTopicSubscriptionsMutex       sync.RWMutex
+	setDataTopicSubscriptionsArgsForCall []struct {
+		arg1 []string
+	}
 	SetICEConfigStub        func(*livekit.ICEConfig)
 	setICEConfigMutex       sync.RWMutex
 	setICEConfigArgsForCall []struct {
@@ -718,6 +780,18 @@ type FakeLocalParticipant struct {
 	setPermissionReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	SetPublishBitrateCapStub        func(livekit.TrackID, int64) error
+	setPublishBitrateCapMutex       sync.RWMutex
+	setPublishBitrateCapArgsForCall []struct {
+		arg1 livekit.TrackID
+		arg2 int64
+	}
+	setPublishBitrateCapReturns struct {
+		result1 error
+	}
+	setPublishBitrateCapReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetResponseSinkStub        func(routing.MessageSink)
 	setResponseSinkMutex       sync.RWMutex
 	setResponseSinkArgsForCall []struct {
@@ -738,6 +812,18 @@ type FakeLocalParticipant struct {
 	setSubscriberChannelCapacityArgsForCall []struct {
 		arg1 int64
 	}
+	SetSubscriptionPriorityStub        func(livekit.TrackID, uint8) error
+	setSubscriptionPriorityMutex       sync.RWMutex
+	setSubscriptionPriorityArgsForCall []struct {
+		arg1 livekit.TrackID
+		arg2 uint8
+	}
+	setSubscriptionPriorityReturns struct {
+		result1 error
+	}
+	setSubscriptionPriorityReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetTrackMutedStub        func(livekit.TrackID, bool, bool) *livekit.TrackInfo
 	setTrackMutedMutex       sync.RWMutex
 	setTrackMutedArgsForCall []struct {
@@ -851,6 +937,17 @@ type FakeLocalParticipant struct {
 	unsubscribeFromTrackArgsForCall []struct {
 		arg1 livekit.TrackID
 	}
+	UpdateAttributesStub        func(map[string]string) map[string]string
+	updateAttributesMutex       sync.RWMutex
+	updateAttributesArgsForCall []struct {
+		arg1 map[string]string
+	}
+	updateAttributesReturns struct {
+		result1 map[string]string
+	}
+	updateAttributesReturnsOnCall map[int]struct {
+		result1 map[string]string
+	}
 	UpdateLastSeenSignalStub        func()
 	updateLastSeenSignalMutex       sync.RWMutex
 	updateLastSeenSignalArgsForCall []struct {
@@ -911,6 +1008,10 @@ type FakeLocalParticipant struct {
 	updateSubscriptionPermissionReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UpdateSubscriptionPrioritiesStub        func()
+	updateSubscriptionPrioritiesMutex       sync.RWMutex
+	updateSubscriptionPrioritiesArgsForCall []struct {
+	}
 	UpdateVideoLayersStub        func(*livekit.UpdateVideoLayers) error
 	updateVideoLayersMutex       sync.RWMutex
 	updateVideoLayersArgsForCall []struct {
@@ -1144,6 +1245,59 @@ func (fake *FakeLocalParticipant) AddTransceiverFromTrackToSubscriberReturnsOnCa
 	}{result1, result2, result3}
 }
 
+func (fake *FakeLocalParticipant) Attributes() map[string]string {
+	fake.attributesMutex.Lock()
+	ret, specificReturn := fake.attributesReturnsOnCall[len(fake.attributesArgsForCall)]
+	fake.attributesArgsForCall = append(fake.attributesArgsForCall, struct {
+	}{})
+	stub := fake.AttributesStub
+	fakeReturns := fake.attributesReturns
+	fake.recordInvocation("Attributes", []interface{}{})
+	fake.attributesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) AttributesCallCount() int {
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
+	return len(fake.attributesArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) AttributesCalls(stub func() map[string]string) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = stub
+}
+
+func (fake *FakeLocalParticipant) AttributesReturns(result1 map[string]string) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	fake.attributesReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) AttributesReturnsOnCall(i int, result1 map[string]string) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	if fake.attributesReturnsOnCall == nil {
+		fake.attributesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+		})
+	}
+	fake.attributesReturnsOnCall[i] = struct {
+		result1 map[string]string
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) CacheDownTrack(arg1 livekit.TrackID, arg2 *webrtc.RTPTransceiver, arg3 sfu.DownTrackState) {
 	fake.cacheDownTrackMutex.Lock()
 	fake.cacheDownTrackArgsForCall = append(fake.cacheDownTrackArgsForCall, struct {
@@ -1231,6 +1385,67 @@ func (fake *FakeLocalParticipant) CanPublishDataReturnsOnCall(i int, result1 boo
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) CanPublishDataTopic(arg1 string) bool {
+	fake.canPublishDataTopicMutex.Lock()
+	ret, specificReturn := fake.canPublishDataTopicReturnsOnCall[len(fake.canPublishDataTopicArgsForCall)]
+	fake.canPublishDataTopicArgsForCall = append(fake.canPublishDataTopicArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CanPublishDataTopicStub
+	fakeReturns := fake.canPublishDataTopicReturns
+	fake.recordInvocation("CanPublishDataTopic", []interface{}{arg1})
+	fake.canPublishDataTopicMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) CanPublishDataTopicCallCount() int {
+	fake.canPublishDataTopicMutex.RLock()
+	defer fake.canPublishDataTopicMutex.RUnlock()
+	return len(fake.canPublishDataTopicArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) CanPublishDataTopicCalls(stub func(string) bool) {
+	fake.canPublishDataTopicMutex.Lock()
+	defer fake.canPublishDataTopicMutex.Unlock()
+	fake.CanPublishDataTopicStub = stub
+}
+
+func (fake *FakeLocalParticipant) CanPublishDataTopicArgsForCall(i int) string {
+	fake.canPublishDataTopicMutex.RLock()
+	defer fake.canPublishDataTopicMutex.RUnlock()
+	argsForCall := fake.canPublishDataTopicArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) CanPublishDataTopicReturns(result1 bool) {
+	fake.canPublishDataTopicMutex.Lock()
+	defer fake.canPublishDataTopicMutex.Unlock()
+	fake.CanPublishDataTopicStub = nil
+	fake.canPublishDataTopicReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) CanPublishDataTopicReturnsOnCall(i int, result1 bool) {
+	fake.canPublishDataTopicMutex.Lock()
+	defer fake.canPublishDataTopicMutex.Unlock()
+	fake.CanPublishDataTopicStub = nil
+	if fake.canPublishDataTopicReturnsOnCall == nil {
+		fake.canPublishDataTopicReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.canPublishDataTopicReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) CanPublishSource(arg1 livekit.TrackSource) bool {
 	fake.canPublishSourceMutex.Lock()
 	ret, specificReturn := fake.canPublishSourceReturnsOnCall[len(fake.canPublishSourceArgsForCall)]
@@ -2310,6 +2525,59 @@ func (fake *FakeLocalParticipant) GetPlayoutDelayConfigReturnsOnCall(i int, resu
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetPublishBitrateCaps() types.PublishBitrateCaps {
+	fake.getPublishBitrateCapsMutex.Lock()
+	ret, specificReturn := fake.getPublishBitrateCapsReturnsOnCall[len(fake.getPublishBitrateCapsArgsForCall)]
+	fake.getPublishBitrateCapsArgsForCall = append(fake.getPublishBitrateCapsArgsForCall, struct {
+	}{})
+	stub := fake.GetPublishBitrateCapsStub
+	fakeReturns := fake.getPublishBitrateCapsReturns
+	fake.recordInvocation("GetPublishBitrateCaps", []interface{}{})
+	fake.getPublishBitrateCapsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetPublishBitrateCapsCallCount() int {
+	fake.getPublishBitrateCapsMutex.RLock()
+	defer fake.getPublishBitrateCapsMutex.RUnlock()
+	return len(fake.getPublishBitrateCapsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetPublishBitrateCapsCalls(stub func() types.PublishBitrateCaps) {
+	fake.getPublishBitrateCapsMutex.Lock()
+	defer fake.getPublishBitrateCapsMutex.Unlock()
+	fake.GetPublishBitrateCapsStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetPublishBitrateCapsReturns(result1 types.PublishBitrateCaps) {
+	fake.getPublishBitrateCapsMutex.Lock()
+	defer fake.getPublishBitrateCapsMutex.Unlock()
+	fake.GetPublishBitrateCapsStub = nil
+	fake.getPublishBitrateCapsReturns = struct {
+		result1 types.PublishBitrateCaps
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetPublishBitrateCapsReturnsOnCall(i int, result1 types.PublishBitrateCaps) {
+	fake.getPublishBitrateCapsMutex.Lock()
+	defer fake.getPublishBitrateCapsMutex.Unlock()
+	fake.GetPublishBitrateCapsStub = nil
+	if fake.getPublishBitrateCapsReturnsOnCall == nil {
+		fake.getPublishBitrateCapsReturnsOnCall = make(map[int]struct {
+			result1 types.PublishBitrateCaps
+		})
+	}
+	fake.getPublishBitrateCapsReturnsOnCall[i] = struct {
+		result1 types.PublishBitrateCaps
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetPublishedTrack(arg1 livekit.TrackID) types.MediaTrack {
 	fake.getPublishedTrackMutex.Lock()
 	ret, specificReturn := fake.getPublishedTrackReturnsOnCall[len(fake.getPublishedTrackArgsForCall)]
@@ -2530,6 +2798,59 @@ func (fake *FakeLocalParticipant) GetSubscribedTracksReturnsOnCall(i int, result
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetSubscriptionPriorities() []types.SubscriptionPriority {
+	fake.getSubscriptionPrioritiesMutex.Lock()
+	ret, specificReturn := fake.getSubscriptionPrioritiesReturnsOnCall[len(fake.getSubscriptionPrioritiesArgsForCall)]
+	fake.getSubscriptionPrioritiesArgsForCall = append(fake.getSubscriptionPrioritiesArgsForCall, struct {
+	}{})
+	stub := fake.GetSubscriptionPrioritiesStub
+	fakeReturns := fake.getSubscriptionPrioritiesReturns
+	fake.recordInvocation("GetSubscriptionPriorities", []interface{}{})
+	fake.getSubscriptionPrioritiesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetSubscriptionPrioritiesCallCount() int {
+	fake.getSubscriptionPrioritiesMutex.RLock()
+	defer fake.getSubscriptionPrioritiesMutex.RUnlock()
+	return len(fake.getSubscriptionPrioritiesArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetSubscriptionPrioritiesCalls(stub func() []types.SubscriptionPriority) {
+	fake.getSubscriptionPrioritiesMutex.Lock()
+	defer fake.getSubscriptionPrioritiesMutex.Unlock()
+	fake.GetSubscriptionPrioritiesStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetSubscriptionPrioritiesReturns(result1 []types.SubscriptionPriority) {
+	fake.getSubscriptionPrioritiesMutex.Lock()
+	defer fake.getSubscriptionPrioritiesMutex.Unlock()
+	fake.GetSubscriptionPrioritiesStub = nil
+	fake.getSubscriptionPrioritiesReturns = struct {
+		result1 []types.SubscriptionPriority
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSubscriptionPrioritiesReturnsOnCall(i int, result1 []types.SubscriptionPriority) {
+	fake.getSubscriptionPrioritiesMutex.Lock()
+	defer fake.getSubscriptionPrioritiesMutex.Unlock()
+	fake.GetSubscriptionPrioritiesStub = nil
+	if fake.getSubscriptionPrioritiesReturnsOnCall == nil {
+		fake.getSubscriptionPrioritiesReturnsOnCall = make(map[int]struct {
+			result1 []types.SubscriptionPriority
+		})
+	}
+	fake.getSubscriptionPrioritiesReturnsOnCall[i] = struct {
+		result1 []types.SubscriptionPriority
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetTrailer() []byte {
 	fake.getTrailerMutex.Lock()
 	ret, specificReturn := fake.getTrailerReturnsOnCall[len(fake.getTrailerArgsForCall)]
@@ -3365,6 +3686,67 @@ func (fake *FakeLocalParticipant) IsSubscribedToReturnsOnCall(i int, result1 boo
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) IsSubscribedToDataTopic(arg1 string) bool {
+	fake.isSubscribedToDataTopicMutex.Lock()
+	ret, specificReturn := fake.isSubscribedToDataTopicReturnsOnCall[len(fake.isSubscribedToDataTopicArgsForCall)]
+	fake.isSubscribedToDataTopicArgsForCall = append(fake.isSubscribedToDataTopicArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.IsSubscribedToDataTopicStub
+	fakeReturns := fake.isSubscribedToDataTopicReturns
+	fake.recordInvocation("IsSubscribedToDataTopic", []interface{}{arg1})
+	fake.isSubscribedToDataTopicMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) IsSubscribedToDataTopicCallCount() int {
+	fake.isSubscribedToDataTopicMutex.RLock()
+	defer fake.isSubscribedToDataTopicMutex.RUnlock()
+	return len(fake.isSubscribedToDataTopicArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) IsSubscribedToDataTopicCalls(stub func(string) bool) {
+	fake.isSubscribedToDataTopicMutex.Lock()
+	defer fake.isSubscribedToDataTopicMutex.Unlock()
+	fake.IsSubscribedToDataTopicStub = stub
+}
+
+func (fake *FakeLocalParticipant) IsSubscribedToDataTopicArgsForCall(i int) string {
+	fake.isSubscribedToDataTopicMutex.RLock()
+	defer fake.isSubscribedToDataTopicMutex.RUnlock()
+	argsForCall := fake.isSubscribedToDataTopicArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) IsSubscribedToDataTopicReturns(result1 bool) {
+	fake.isSubscribedToDataTopicMutex.Lock()
+	defer fake.isSubscribedToDataTopicMutex.Unlock()
+	fake.IsSubscribedToDataTopicStub = nil
+	fake.isSubscribedToDataTopicReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) IsSubscribedToDataTopicReturnsOnCall(i int, result1 bool) {
+	fake.isSubscribedToDataTopicMutex.Lock()
+	defer fake.isSubscribedToDataTopicMutex.Unlock()
+	fake.IsSubscribedToDataTopicStub = nil
+	if fake.isSubscribedToDataTopicReturnsOnCall == nil {
+		fake.isSubscribedToDataTopicReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isSubscribedToDataTopicReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) IssueFullReconnect(arg1 types.ParticipantCloseReason) {
 	fake.issueFullReconnectMutex.Lock()
 	fake.issueFullReconnectArgsForCall = append(fake.issueFullReconnectArgsForCall, struct {
@@ -3608,6 +3990,38 @@ func (fake *FakeLocalParticipant) OnCloseArgsForCall(i int) func(types.LocalPart
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) OnDataChannelOpen(arg1 func(types.LocalParticipant)) {
+	fake.onDataChannelOpenMutex.Lock()
+	fake.onDataChannelOpenArgsForCall = append(fake.onDataChannelOpenArgsForCall, struct {
+		arg1 func(types.LocalParticipant)
+	}{arg1})
+	stub := fake.OnDataChannelOpenStub
+	fake.recordInvocation("OnDataChannelOpen", []interface{}{arg1})
+	fake.onDataChannelOpenMutex.Unlock()
+	if stub != nil {
+		fake.OnDataChannelOpenStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) OnDataChannelOpenCallCount() int {
+	fake.onDataChannelOpenMutex.RLock()
+	defer fake.onDataChannelOpenMutex.RUnlock()
+	return len(fake.onDataChannelOpenArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) OnDataChannelOpenCalls(stub func(func(types.LocalParticipant))) {
+	fake.onDataChannelOpenMutex.Lock()
+	defer fake.onDataChannelOpenMutex.Unlock()
+	fake.OnDataChannelOpenStub = stub
+}
+
+func (fake *FakeLocalParticipant) OnDataChannelOpenArgsForCall(i int) func(types.LocalParticipant) {
+	fake.onDataChannelOpenMutex.RLock()
+	defer fake.onDataChannelOpenMutex.RUnlock()
+	argsForCall := fake.onDataChannelOpenArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) OnDataPacket(arg1 func(types.LocalParticipant, *livekit.DataPacket)) {
 	fake.onDataPacketMutex.Lock()
 	fake.onDataPacketArgsForCall = append(fake.onDataPacketArgsForCall, struct {
@@ -4521,6 +4935,43 @@ func (fake *FakeLocalParticipant) SendSpeakerUpdateReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SetDataTopicSubscriptions(arg1 []string) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setDataTopicSubscriptionsMutex.Lock()
+	fake.setDataTopicSubscriptionsArgsForCall = append(fake.setDataTopicSubscriptionsArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.SetDataTopicSubscriptionsStub
+	fake.recordInvocation("SetDataTopicSubscriptions", []interface{}{arg1Copy})
+	fake.setDataTopicSubscriptionsMutex.Unlock()
+	if stub != nil {
+		fake.SetDataTopicSubscriptionsStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetDataTopicSubscriptionsCallCount() int {
+	fake.setDataTopicSubscriptionsMutex.RLock()
+	defer fake.setDataTopicSubscriptionsMutex.RUnlock()
+	return len(fake.setDataTopicSubscriptionsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetDataTopicSubscriptionsCalls(stub func([]string)) {
+	fake.setDataTopicSubscriptionsMutex.Lock()
+	defer fake.setDataTopicSubscriptionsMutex.Unlock()
+	fake.SetDataTopicSubscriptionsStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetDataTopicSubscriptionsArgsForCall(i int) []string {
+	fake.setDataTopicSubscriptionsMutex.RLock()
+	defer fake.setDataTopicSubscriptionsMutex.RUnlock()
+	argsForCall := fake.setDataTopicSubscriptionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SetICEConfig(arg1 *livekit.ICEConfig) {
 	fake.setICEConfigMutex.Lock()
 	fake.setICEConfigArgsForCall = append(fake.setICEConfigArgsForCall, struct {
@@ -4755,6 +5206,68 @@ func (fake *FakeLocalParticipant) SetPermissionReturnsOnCall(i int, result1 bool
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SetPublishBitrateCap(arg1 livekit.TrackID, arg2 int64) error {
+	fake.setPublishBitrateCapMutex.Lock()
+	ret, specificReturn := fake.setPublishBitrateCapReturnsOnCall[len(fake.setPublishBitrateCapArgsForCall)]
+	fake.setPublishBitrateCapArgsForCall = append(fake.setPublishBitrateCapArgsForCall, struct {
+		arg1 livekit.TrackID
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.SetPublishBitrateCapStub
+	fakeReturns := fake.setPublishBitrateCapReturns
+	fake.recordInvocation("SetPublishBitrateCap", []interface{}{arg1, arg2})
+	fake.setPublishBitrateCapMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) SetPublishBitrateCapCallCount() int {
+	fake.setPublishBitrateCapMutex.RLock()
+	defer fake.setPublishBitrateCapMutex.RUnlock()
+	return len(fake.setPublishBitrateCapArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetPublishBitrateCapCalls(stub func(livekit.TrackID, int64) error) {
+	fake.setPublishBitrateCapMutex.Lock()
+	defer fake.setPublishBitrateCapMutex.Unlock()
+	fake.SetPublishBitrateCapStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetPublishBitrateCapArgsForCall(i int) (livekit.TrackID, int64) {
+	fake.setPublishBitrateCapMutex.RLock()
+	defer fake.setPublishBitrateCapMutex.RUnlock()
+	argsForCall := fake.setPublishBitrateCapArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLocalParticipant) SetPublishBitrateCapReturns(result1 error) {
+	fake.setPublishBitrateCapMutex.Lock()
+	defer fake.setPublishBitrateCapMutex.Unlock()
+	fake.SetPublishBitrateCapStub = nil
+	fake.setPublishBitrateCapReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) SetPublishBitrateCapReturnsOnCall(i int, result1 error) {
+	fake.setPublishBitrateCapMutex.Lock()
+	defer fake.setPublishBitrateCapMutex.Unlock()
+	fake.SetPublishBitrateCapStub = nil
+	if fake.setPublishBitrateCapReturnsOnCall == nil {
+		fake.setPublishBitrateCapReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setPublishBitrateCapReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) SetResponseSink(arg1 routing.MessageSink) {
 	fake.setResponseSinkMutex.Lock()
 	fake.setResponseSinkArgsForCall = append(fake.setResponseSinkArgsForCall, struct {
@@ -4883,6 +5396,68 @@ func (fake *FakeLocalParticipant) SetSubscriberChannelCapacityArgsForCall(i int)
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) SetSubscriptionPriority(arg1 livekit.TrackID, arg2 uint8) error {
+	fake.setSubscriptionPriorityMutex.Lock()
+	ret, specificReturn := fake.setSubscriptionPriorityReturnsOnCall[len(fake.setSubscriptionPriorityArgsForCall)]
+	fake.setSubscriptionPriorityArgsForCall = append(fake.setSubscriptionPriorityArgsForCall, struct {
+		arg1 livekit.TrackID
+		arg2 uint8
+	}{arg1, arg2})
+	stub := fake.SetSubscriptionPriorityStub
+	fakeReturns := fake.setSubscriptionPriorityReturns
+	fake.recordInvocation("SetSubscriptionPriority", []interface{}{arg1, arg2})
+	fake.setSubscriptionPriorityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) SetSubscriptionPriorityCallCount() int {
+	fake.setSubscriptionPriorityMutex.RLock()
+	defer fake.setSubscriptionPriorityMutex.RUnlock()
+	return len(fake.setSubscriptionPriorityArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetSubscriptionPriorityCalls(stub func(livekit.TrackID, uint8) error) {
+	fake.setSubscriptionPriorityMutex.Lock()
+	defer fake.setSubscriptionPriorityMutex.Unlock()
+	fake.SetSubscriptionPriorityStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetSubscriptionPriorityArgsForCall(i int) (livekit.TrackID, uint8) {
+	fake.setSubscriptionPriorityMutex.RLock()
+	defer fake.setSubscriptionPriorityMutex.RUnlock()
+	argsForCall := fake.setSubscriptionPriorityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLocalParticipant) SetSubscriptionPriorityReturns(result1 error) {
+	fake.setSubscriptionPriorityMutex.Lock()
+	defer fake.setSubscriptionPriorityMutex.Unlock()
+	fake.SetSubscriptionPriorityStub = nil
+	fake.setSubscriptionPriorityReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) SetSubscriptionPriorityReturnsOnCall(i int, result1 error) {
+	fake.setSubscriptionPriorityMutex.Lock()
+	defer fake.setSubscriptionPriorityMutex.Unlock()
+	fake.SetSubscriptionPriorityStub = nil
+	if fake.setSubscriptionPriorityReturnsOnCall == nil {
+		fake.setSubscriptionPriorityReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setSubscriptionPriorityReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) SetTrackMuted(arg1 livekit.TrackID, arg2 bool, arg3 bool) *livekit.TrackInfo {
 	fake.setTrackMutedMutex.Lock()
 	ret, specificReturn := fake.setTrackMutedReturnsOnCall[len(fake.setTrackMutedArgsForCall)]
@@ -5477,6 +6052,67 @@ func (fake *FakeLocalParticipant) UnsubscribeFromTrackArgsForCall(i int) livekit
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) UpdateAttributes(arg1 map[string]string) map[string]string {
+	fake.updateAttributesMutex.Lock()
+	ret, specificReturn := fake.updateAttributesReturnsOnCall[len(fake.updateAttributesArgsForCall)]
+	fake.updateAttributesArgsForCall = append(fake.updateAttributesArgsForCall, struct {
+		arg1 map[string]string
+	}{arg1})
+	stub := fake.UpdateAttributesStub
+	fakeReturns := fake.updateAttributesReturns
+	fake.recordInvocation("UpdateAttributes", []interface{}{arg1})
+	fake.updateAttributesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) UpdateAttributesCallCount() int {
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
+	return len(fake.updateAttributesArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) UpdateAttributesCalls(stub func(map[string]string) map[string]string) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = stub
+}
+
+func (fake *FakeLocalParticipant) UpdateAttributesArgsForCall(i int) map[string]string {
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
+	argsForCall := fake.updateAttributesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) UpdateAttributesReturns(result1 map[string]string) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = nil
+	fake.updateAttributesReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) UpdateAttributesReturnsOnCall(i int, result1 map[string]string) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = nil
+	if fake.updateAttributesReturnsOnCall == nil {
+		fake.updateAttributesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+		})
+	}
+	fake.updateAttributesReturnsOnCall[i] = struct {
+		result1 map[string]string
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) UpdateLastSeenSignal() {
 	fake.updateLastSeenSignalMutex.Lock()
 	fake.updateLastSeenSignalArgsForCall = append(fake.updateLastSeenSignalArgsForCall, struct {
@@ -5793,6 +6429,30 @@ func (fake *FakeLocalParticipant) UpdateSubscriptionPermissionReturnsOnCall(i in
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) UpdateSubscriptionPriorities() {
+	fake.updateSubscriptionPrioritiesMutex.Lock()
+	fake.updateSubscriptionPrioritiesArgsForCall = append(fake.updateSubscriptionPrioritiesArgsForCall, struct {
+	}{})
+	stub := fake.UpdateSubscriptionPrioritiesStub
+	fake.recordInvocation("UpdateSubscriptionPriorities", []interface{}{})
+	fake.updateSubscriptionPrioritiesMutex.Unlock()
+	if stub != nil {
+		fake.UpdateSubscriptionPrioritiesStub()
+	}
+}
+
+func (fake *FakeLocalParticipant) UpdateSubscriptionPrioritiesCallCount() int {
+	fake.updateSubscriptionPrioritiesMutex.RLock()
+	defer fake.updateSubscriptionPrioritiesMutex.RUnlock()
+	return len(fake.updateSubscriptionPrioritiesArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) UpdateSubscriptionPrioritiesCalls(stub func()) {
+	fake.updateSubscriptionPrioritiesMutex.Lock()
+	defer fake.updateSubscriptionPrioritiesMutex.Unlock()
+	fake.UpdateSubscriptionPrioritiesStub = stub
+}
+
 func (fake *FakeLocalParticipant) UpdateVideoLayers(arg1 *livekit.UpdateVideoLayers) error {
 	fake.updateVideoLayersMutex.Lock()
 	ret, specificReturn := fake.updateVideoLayersReturnsOnCall[len(fake.updateVideoLayersArgsForCall)]
@@ -5959,10 +6619,14 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.addTrackToSubscriberMutex.RUnlock()
 	fake.addTransceiverFromTrackToSubscriberMutex.RLock()
 	defer fake.addTransceiverFromTrackToSubscriberMutex.RUnlock()
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
 	fake.cacheDownTrackMutex.RLock()
 	defer fake.cacheDownTrackMutex.RUnlock()
 	fake.canPublishDataMutex.RLock()
 	defer fake.canPublishDataMutex.RUnlock()
+	fake.canPublishDataTopicMutex.RLock()
+	defer fake.canPublishDataTopicMutex.RUnlock()
 	fake.canPublishSourceMutex.RLock()
 	defer fake.canPublishSourceMutex.RUnlock()
 	fake.canSkipBroadcastMutex.RLock()
@@ -6003,6 +6667,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getPendingTrackMutex.RUnlock()
 	fake.getPlayoutDelayConfigMutex.RLock()
 	defer fake.getPlayoutDelayConfigMutex.RUnlock()
+	fake.getPublishBitrateCapsMutex.RLock()
+	defer fake.getPublishBitrateCapsMutex.RUnlock()
 	fake.getPublishedTrackMutex.RLock()
 	defer fake.getPublishedTrackMutex.RUnlock()
 	fake.getPublishedTracksMutex.RLock()
@@ -6011,6 +6677,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getSubscribedParticipantsMutex.RUnlock()
 	fake.getSubscribedTracksMutex.RLock()
 	defer fake.getSubscribedTracksMutex.RUnlock()
+	fake.getSubscriptionPrioritiesMutex.RLock()
+	defer fake.getSubscriptionPrioritiesMutex.RUnlock()
 	fake.getTrailerMutex.RLock()
 	defer fake.getTrailerMutex.RUnlock()
 	fake.handleAnswerMutex.RLock()
@@ -6045,6 +6713,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.isRecorderMutex.RUnlock()
 	fake.isSubscribedToMutex.RLock()
 	defer fake.isSubscribedToMutex.RUnlock()
+	fake.isSubscribedToDataTopicMutex.RLock()
+	defer fake.isSubscribedToDataTopicMutex.RUnlock()
 	fake.issueFullReconnectMutex.RLock()
 	defer fake.issueFullReconnectMutex.RUnlock()
 	fake.maybeStartMigrationMutex.RLock()
@@ -6057,6 +6727,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.onClaimsChangedMutex.RUnlock()
 	fake.onCloseMutex.RLock()
 	defer fake.onCloseMutex.RUnlock()
+	fake.onDataChannelOpenMutex.RLock()
+	defer fake.onDataChannelOpenMutex.RUnlock()
 	fake.onDataPacketMutex.RLock()
 	defer fake.onDataPacketMutex.RUnlock()
 	fake.onICEConfigChangedMutex.RLock()
@@ -6097,6 +6769,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.sendRoomUpdateMutex.RUnlock()
 	fake.sendSpeakerUpdateMutex.RLock()
 	defer fake.sendSpeakerUpdateMutex.RUnlock()
+	fake.setDataTopicSubscriptionsMutex.RLock()
+	defer fake.setDataTopicSubscriptionsMutex.RUnlock()
 	fake.setICEConfigMutex.RLock()
 	defer fake.setICEConfigMutex.RUnlock()
 	fake.setMetadataMutex.RLock()
@@ -6109,6 +6783,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.setNameMutex.RUnlock()
 	fake.setPermissionMutex.RLock()
 	defer fake.setPermissionMutex.RUnlock()
+	fake.setPublishBitrateCapMutex.RLock()
+	defer fake.setPublishBitrateCapMutex.RUnlock()
 	fake.setResponseSinkMutex.RLock()
 	defer fake.setResponseSinkMutex.RUnlock()
 	fake.setSignalSourceValidMutex.RLock()
@@ -6117,6 +6793,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.setSubscriberAllowPauseMutex.RUnlock()
 	fake.setSubscriberChannelCapacityMutex.RLock()
 	defer fake.setSubscriberChannelCapacityMutex.RUnlock()
+	fake.setSubscriptionPriorityMutex.RLock()
+	defer fake.setSubscriptionPriorityMutex.RUnlock()
 	fake.setTrackMutedMutex.RLock()
 	defer fake.setTrackMutedMutex.RUnlock()
 	fake.startMutex.RLock()
@@ -6143,6 +6821,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.uncacheDownTrackMutex.RUnlock()
 	fake.unsubscribeFromTrackMutex.RLock()
 	defer fake.unsubscribeFromTrackMutex.RUnlock()
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
 	fake.updateLastSeenSignalMutex.RLock()
 	defer fake.updateLastSeenSignalMutex.RUnlock()
 	fake.updateMediaLossMutex.RLock()
@@ -6157,6 +6837,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.updateSubscribedTrackSettingsMutex.RUnlock()
 	fake.updateSubscriptionPermissionMutex.RLock()
 	defer fake.updateSubscriptionPermissionMutex.RUnlock()
+	fake.updateSubscriptionPrioritiesMutex.RLock()
+	defer fake.updateSubscriptionPrioritiesMutex.RUnlock()
 	fake.updateVideoLayersMutex.RLock()
 	defer fake.updateVideoLayersMutex.RUnlock()
 	fake.verifySubscribeParticipantInfoMutex.RLock()
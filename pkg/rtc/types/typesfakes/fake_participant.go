@@ -10,6 +10,16 @@ import (
 )
 
 type FakeParticipant struct {
+	AttributesStub        func() map[string]string
+	attributesMutex       sync.RWMutex
+	attributesArgsForCall []struct {
+	}
+	attributesReturns struct {
+		result1 map[string]string
+	}
+	attributesReturnsOnCall map[int]struct {
+		result1 map[string]string
+	}
 	CanSkipBroadcastStub        func() bool
 	canSkipBroadcastMutex       sync.RWMutex
 	canSkipBroadcastArgsForCall []struct {
@@ -191,6 +201,17 @@ type FakeParticipant struct {
 	toProtoReturnsOnCall map[int]struct {
 		result1 *livekit.ParticipantInfo
 	}
+	UpdateAttributesStub        func(map[string]string) map[string]string
+	updateAttributesMutex       sync.RWMutex
+	updateAttributesArgsForCall []struct {
+		arg1 map[string]string
+	}
+	updateAttributesReturns struct {
+		result1 map[string]string
+	}
+	updateAttributesReturnsOnCall map[int]struct {
+		result1 map[string]string
+	}
 	UpdateSubscriptionPermissionStub        func(*livekit.SubscriptionPermission, utils.TimedVersion, func(participantIdentity livekit.ParticipantIdentity) types.LocalParticipant, func(participantID livekit.ParticipantID) types.LocalParticipant) error
 	updateSubscriptionPermissionMutex       sync.RWMutex
 	updateSubscriptionPermissionArgsForCall []struct {
@@ -220,6 +241,59 @@ type FakeParticipant struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeParticipant) Attributes() map[string]string {
+	fake.attributesMutex.Lock()
+	ret, specificReturn := fake.attributesReturnsOnCall[len(fake.attributesArgsForCall)]
+	fake.attributesArgsForCall = append(fake.attributesArgsForCall, struct {
+	}{})
+	stub := fake.AttributesStub
+	fakeReturns := fake.attributesReturns
+	fake.recordInvocation("Attributes", []interface{}{})
+	fake.attributesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) AttributesCallCount() int {
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
+	return len(fake.attributesArgsForCall)
+}
+
+func (fake *FakeParticipant) AttributesCalls(stub func() map[string]string) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = stub
+}
+
+func (fake *FakeParticipant) AttributesReturns(result1 map[string]string) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	fake.attributesReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+func (fake *FakeParticipant) AttributesReturnsOnCall(i int, result1 map[string]string) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	if fake.attributesReturnsOnCall == nil {
+		fake.attributesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+		})
+	}
+	fake.attributesReturnsOnCall[i] = struct {
+		result1 map[string]string
+	}{result1}
+}
+
 func (fake *FakeParticipant) CanSkipBroadcast() bool {
 	fake.canSkipBroadcastMutex.Lock()
 	ret, specificReturn := fake.canSkipBroadcastReturnsOnCall[len(fake.canSkipBroadcastArgsForCall)]
@@ -1170,6 +1244,67 @@ func (fake *FakeParticipant) ToProtoReturnsOnCall(i int, result1 *livekit.Partic
 	}{result1}
 }
 
+func (fake *FakeParticipant) UpdateAttributes(arg1 map[string]string) map[string]string {
+	fake.updateAttributesMutex.Lock()
+	ret, specificReturn := fake.updateAttributesReturnsOnCall[len(fake.updateAttributesArgsForCall)]
+	fake.updateAttributesArgsForCall = append(fake.updateAttributesArgsForCall, struct {
+		arg1 map[string]string
+	}{arg1})
+	stub := fake.UpdateAttributesStub
+	fakeReturns := fake.updateAttributesReturns
+	fake.recordInvocation("UpdateAttributes", []interface{}{arg1})
+	fake.updateAttributesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) UpdateAttributesCallCount() int {
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
+	return len(fake.updateAttributesArgsForCall)
+}
+
+func (fake *FakeParticipant) UpdateAttributesCalls(stub func(map[string]string) map[string]string) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = stub
+}
+
+func (fake *FakeParticipant) UpdateAttributesArgsForCall(i int) map[string]string {
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
+	argsForCall := fake.updateAttributesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) UpdateAttributesReturns(result1 map[string]string) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = nil
+	fake.updateAttributesReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+func (fake *FakeParticipant) UpdateAttributesReturnsOnCall(i int, result1 map[string]string) {
+	fake.updateAttributesMutex.Lock()
+	defer fake.updateAttributesMutex.Unlock()
+	fake.UpdateAttributesStub = nil
+	if fake.updateAttributesReturnsOnCall == nil {
+		fake.updateAttributesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+		})
+	}
+	fake.updateAttributesReturnsOnCall[i] = struct {
+		result1 map[string]string
+	}{result1}
+}
+
 func (fake *FakeParticipant) UpdateSubscriptionPermission(arg1 *livekit.SubscriptionPermission, arg2 utils.TimedVersion, arg3 func(participantIdentity livekit.ParticipantIdentity) types.LocalParticipant, arg4 func(participantID livekit.ParticipantID) types.LocalParticipant) error {
 	fake.updateSubscriptionPermissionMutex.Lock()
 	ret, specificReturn := fake.updateSubscriptionPermissionReturnsOnCall[len(fake.updateSubscriptionPermissionArgsForCall)]
@@ -1298,6 +1433,8 @@ func (fake *FakeParticipant) UpdateVideoLayersReturnsOnCall(i int, result1 error
 func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
 	fake.canSkipBroadcastMutex.RLock()
 	defer fake.canSkipBroadcastMutex.RUnlock()
 	fake.closeMutex.RLock()
@@ -1336,6 +1473,8 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.subscriptionPermissionMutex.RUnlock()
 	fake.toProtoMutex.RLock()
 	defer fake.toProtoMutex.RUnlock()
+	fake.updateAttributesMutex.RLock()
+	defer fake.updateAttributesMutex.RUnlock()
 	fake.updateSubscriptionPermissionMutex.RLock()
 	defer fake.updateSubscriptionPermissionMutex.RUnlock()
 	fake.updateVideoLayersMutex.RLock()
@@ -82,6 +82,18 @@ type FakeRoom struct {
 	syncStateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UpdateParticipantAttributesStub        func(types.LocalParticipant, map[string]string) error
+	updateParticipantAttributesMutex       sync.RWMutex
+	updateParticipantAttributesArgsForCall []struct {
+		arg1 types.LocalParticipant
+		arg2 map[string]string
+	}
+	updateParticipantAttributesReturns struct {
+		result1 error
+	}
+	updateParticipantAttributesReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UpdateParticipantMetadataStub        func(types.LocalParticipant, string, string)
 	updateParticipantMetadataMutex       sync.RWMutex
 	updateParticipantMetadataArgsForCall []struct {
@@ -121,6 +133,18 @@ type FakeRoom struct {
 	updateVideoLayersReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ValidateParticipantAttributesStub        func(types.LocalParticipant, map[string]string) error
+	validateParticipantAttributesMutex       sync.RWMutex
+	validateParticipantAttributesArgsForCall []struct {
+		arg1 types.LocalParticipant
+		arg2 map[string]string
+	}
+	validateParticipantAttributesReturns struct {
+		result1 error
+	}
+	validateParticipantAttributesReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -504,6 +528,68 @@ func (fake *FakeRoom) SyncStateReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRoom) UpdateParticipantAttributes(arg1 types.LocalParticipant, arg2 map[string]string) error {
+	fake.updateParticipantAttributesMutex.Lock()
+	ret, specificReturn := fake.updateParticipantAttributesReturnsOnCall[len(fake.updateParticipantAttributesArgsForCall)]
+	fake.updateParticipantAttributesArgsForCall = append(fake.updateParticipantAttributesArgsForCall, struct {
+		arg1 types.LocalParticipant
+		arg2 map[string]string
+	}{arg1, arg2})
+	stub := fake.UpdateParticipantAttributesStub
+	fakeReturns := fake.updateParticipantAttributesReturns
+	fake.recordInvocation("UpdateParticipantAttributes", []interface{}{arg1, arg2})
+	fake.updateParticipantAttributesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoom) UpdateParticipantAttributesCallCount() int {
+	fake.updateParticipantAttributesMutex.RLock()
+	defer fake.updateParticipantAttributesMutex.RUnlock()
+	return len(fake.updateParticipantAttributesArgsForCall)
+}
+
+func (fake *FakeRoom) UpdateParticipantAttributesCalls(stub func(types.LocalParticipant, map[string]string) error) {
+	fake.updateParticipantAttributesMutex.Lock()
+	defer fake.updateParticipantAttributesMutex.Unlock()
+	fake.UpdateParticipantAttributesStub = stub
+}
+
+func (fake *FakeRoom) UpdateParticipantAttributesArgsForCall(i int) (types.LocalParticipant, map[string]string) {
+	fake.updateParticipantAttributesMutex.RLock()
+	defer fake.updateParticipantAttributesMutex.RUnlock()
+	argsForCall := fake.updateParticipantAttributesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoom) UpdateParticipantAttributesReturns(result1 error) {
+	fake.updateParticipantAttributesMutex.Lock()
+	defer fake.updateParticipantAttributesMutex.Unlock()
+	fake.UpdateParticipantAttributesStub = nil
+	fake.updateParticipantAttributesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoom) UpdateParticipantAttributesReturnsOnCall(i int, result1 error) {
+	fake.updateParticipantAttributesMutex.Lock()
+	defer fake.updateParticipantAttributesMutex.Unlock()
+	fake.UpdateParticipantAttributesStub = nil
+	if fake.updateParticipantAttributesReturnsOnCall == nil {
+		fake.updateParticipantAttributesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateParticipantAttributesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRoom) UpdateParticipantMetadata(arg1 types.LocalParticipant, arg2 string, arg3 string) {
 	fake.updateParticipantMetadataMutex.Lock()
 	fake.updateParticipantMetadataArgsForCall = append(fake.updateParticipantMetadataArgsForCall, struct {
@@ -707,6 +793,68 @@ func (fake *FakeRoom) UpdateVideoLayersReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRoom) ValidateParticipantAttributes(arg1 types.LocalParticipant, arg2 map[string]string) error {
+	fake.validateParticipantAttributesMutex.Lock()
+	ret, specificReturn := fake.validateParticipantAttributesReturnsOnCall[len(fake.validateParticipantAttributesArgsForCall)]
+	fake.validateParticipantAttributesArgsForCall = append(fake.validateParticipantAttributesArgsForCall, struct {
+		arg1 types.LocalParticipant
+		arg2 map[string]string
+	}{arg1, arg2})
+	stub := fake.ValidateParticipantAttributesStub
+	fakeReturns := fake.validateParticipantAttributesReturns
+	fake.recordInvocation("ValidateParticipantAttributes", []interface{}{arg1, arg2})
+	fake.validateParticipantAttributesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoom) ValidateParticipantAttributesCallCount() int {
+	fake.validateParticipantAttributesMutex.RLock()
+	defer fake.validateParticipantAttributesMutex.RUnlock()
+	return len(fake.validateParticipantAttributesArgsForCall)
+}
+
+func (fake *FakeRoom) ValidateParticipantAttributesCalls(stub func(types.LocalParticipant, map[string]string) error) {
+	fake.validateParticipantAttributesMutex.Lock()
+	defer fake.validateParticipantAttributesMutex.Unlock()
+	fake.ValidateParticipantAttributesStub = stub
+}
+
+func (fake *FakeRoom) ValidateParticipantAttributesArgsForCall(i int) (types.LocalParticipant, map[string]string) {
+	fake.validateParticipantAttributesMutex.RLock()
+	defer fake.validateParticipantAttributesMutex.RUnlock()
+	argsForCall := fake.validateParticipantAttributesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoom) ValidateParticipantAttributesReturns(result1 error) {
+	fake.validateParticipantAttributesMutex.Lock()
+	defer fake.validateParticipantAttributesMutex.Unlock()
+	fake.ValidateParticipantAttributesStub = nil
+	fake.validateParticipantAttributesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoom) ValidateParticipantAttributesReturnsOnCall(i int, result1 error) {
+	fake.validateParticipantAttributesMutex.Lock()
+	defer fake.validateParticipantAttributesMutex.Unlock()
+	fake.ValidateParticipantAttributesStub = nil
+	if fake.validateParticipantAttributesReturnsOnCall == nil {
+		fake.validateParticipantAttributesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.validateParticipantAttributesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRoom) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -724,6 +872,8 @@ func (fake *FakeRoom) Invocations() map[string][][]interface{} {
 	defer fake.simulateScenarioMutex.RUnlock()
 	fake.syncStateMutex.RLock()
 	defer fake.syncStateMutex.RUnlock()
+	fake.updateParticipantAttributesMutex.RLock()
+	defer fake.updateParticipantAttributesMutex.RUnlock()
 	fake.updateParticipantMetadataMutex.RLock()
 	defer fake.updateParticipantMetadataMutex.RUnlock()
 	fake.updateSubscriptionPermissionMutex.RLock()
@@ -732,6 +882,8 @@ func (fake *FakeRoom) Invocations() map[string][][]interface{} {
 	defer fake.updateSubscriptionsMutex.RUnlock()
 	fake.updateVideoLayersMutex.RLock()
 	defer fake.updateVideoLayersMutex.RUnlock()
+	fake.validateParticipantAttributesMutex.RLock()
+	defer fake.validateParticipantAttributesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
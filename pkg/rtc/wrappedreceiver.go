@@ -228,6 +228,13 @@ func (d *DummyReceiver) SendPLI(layer int32, force bool) {
 	}
 }
 
+func (d *DummyReceiver) RequestCachedKeyFrame(subscriberID livekit.ParticipantID, layer int32) bool {
+	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
+		return r.RequestCachedKeyFrame(subscriberID, layer)
+	}
+	return false
+}
+
 func (d *DummyReceiver) SetUpTrackPaused(paused bool) {
 	d.settingsLock.Lock()
 	defer d.settingsLock.Unlock()
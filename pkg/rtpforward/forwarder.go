@@ -0,0 +1,360 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtpforward
+
+import (
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v2"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	// SRTPKeyLength is the length of master keys with salts of the AES_CM_128_HMAC_SHA1_80 profile
+	SRTPKeyLength = srtpMasterKeyLength + srtpMasterSaltLength
+
+	srtpMasterKeyLength  = 16
+	srtpMasterSaltLength = 14
+
+	// interval of key frame requests, until the first one is received
+	firstKeyFrameRequestInterval = time.Second
+	defaultKeyFrameInterval      = 2 * time.Second
+)
+
+var ErrInvalidSRTPKey = errors.New("SRTP keys must have 30 bytes, a 16 bytes master key followed by a 14 bytes salt")
+
+type Params struct {
+	// ID of the forward, also identifies the forwarder as a subscriber of the receiver
+	ID          string
+	Receiver    sfu.TrackReceiver
+	Destination *net.UDPAddr
+	// spatial layer of simulcast tracks that is forwarded
+	Layer int32
+	// SRTP is used with a key, plain RTP otherwise
+	SRTPKey []byte
+	// interval of key frame requests of video, so receivers can start decoding at any time
+	KeyFrameInterval time.Duration
+	Logger           logger.Logger
+}
+
+// Stats of a forward
+type Stats struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// TrackForwarder sends media of a track as plain RTP or SRTP to a UDP destination, e. g. ffmpeg or GStreamer.
+// It is attached to the receiver of the track as a TrackSender, forwarding packets of a single layer with
+// contiguous sequence numbers and timestamps, starting at a key frame.
+type TrackForwarder struct {
+	params  Params
+	logger  logger.Logger
+	codec   webrtc.RTPCodecParameters
+	isVideo bool
+	isSVC   bool
+	ssrc    uint32
+
+	conn *net.UDPConn
+	srtp *srtp.Context
+
+	// guards the munger and writing packets
+	lock         sync.Mutex
+	munger       *sfu.RTPMunger
+	started      bool
+	seenKeyFrame bool
+	buf          []byte
+
+	packets atomic.Uint64
+	bytes   atomic.Uint64
+	closed  atomic.Bool
+	done    chan struct{}
+
+	onCloseLock sync.Mutex
+	onClose     func()
+}
+
+func NewTrackForwarder(params Params) (*TrackForwarder, error) {
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+	if params.KeyFrameInterval <= 0 {
+		params.KeyFrameInterval = defaultKeyFrameInterval
+	}
+
+	var srtpContext *srtp.Context
+	if params.SRTPKey != nil {
+		if len(params.SRTPKey) != SRTPKeyLength {
+			return nil, ErrInvalidSRTPKey
+		}
+		var err error
+		srtpContext, err = srtp.CreateContext(
+			params.SRTPKey[:srtpMasterKeyLength],
+			params.SRTPKey[srtpMasterKeyLength:],
+			srtp.ProtectionProfileAes128CmHmacSha1_80,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := net.DialUDP("udp", nil, params.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := OutputCodec(params.Receiver.Codec())
+	return &TrackForwarder{
+		params:  params,
+		logger:  params.Logger,
+		codec:   codec,
+		isVideo: strings.HasPrefix(strings.ToLower(codec.MimeType), "video/"),
+		isSVC:   sfu.IsSvcCodec(codec.MimeType),
+		ssrc:    randomSSRC(),
+		conn:    conn,
+		srtp:    srtpContext,
+		munger:  sfu.NewRTPMunger(params.Logger),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// NewSRTPKey returns a random master key followed by its salt
+func NewSRTPKey() ([]byte, error) {
+	key := make([]byte, SRTPKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// OutputCodec is the codec of forwarded packets. Audio with redundancy is forwarded as its primary Opus stream.
+func OutputCodec(codec webrtc.RTPCodecParameters) webrtc.RTPCodecParameters {
+	if strings.EqualFold(codec.MimeType, sfu.MimeTypeAudioRed) {
+		return webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:    webrtc.MimeTypeOpus,
+				ClockRate:   48000,
+				Channels:    2,
+				SDPFmtpLine: "minptime=10;useinbandfec=1",
+			},
+			PayloadType: 111,
+		}
+	}
+	return codec
+}
+
+func (f *TrackForwarder) Codec() webrtc.RTPCodecParameters {
+	return f.codec
+}
+
+func (f *TrackForwarder) Destination() *net.UDPAddr {
+	return f.params.Destination
+}
+
+func (f *TrackForwarder) SSRC() uint32 {
+	return f.ssrc
+}
+
+func (f *TrackForwarder) Stats() Stats {
+	return Stats{
+		Packets: f.packets.Load(),
+		Bytes:   f.bytes.Load(),
+	}
+}
+
+// OnClose is called once the forward stopped, after Stop or when the track is closed
+func (f *TrackForwarder) OnClose(fn func()) {
+	f.onCloseLock.Lock()
+	f.onClose = fn
+	f.onCloseLock.Unlock()
+}
+
+// Start attaches the forwarder to the receiver
+func (f *TrackForwarder) Start() error {
+	if err := f.params.Receiver.AddDownTrack(f); err != nil {
+		f.Close()
+		return err
+	}
+	if f.isVideo {
+		go f.keyFrameWorker()
+	}
+	return nil
+}
+
+// Stop detaches the forwarder from the receiver
+func (f *TrackForwarder) Stop() {
+	f.params.Receiver.DeleteDownTrack(f.SubscriberID())
+	f.Close()
+}
+
+// Done is closed once the forward stopped
+func (f *TrackForwarder) Done() <-chan struct{} {
+	return f.done
+}
+
+// TrackSender interface
+
+func (f *TrackForwarder) UpTrackLayersChange() {}
+
+func (f *TrackForwarder) UpTrackBitrateAvailabilityChange() {}
+
+func (f *TrackForwarder) UpTrackMaxPublishedLayerChange(_ int32) {}
+
+func (f *TrackForwarder) UpTrackMaxTemporalLayerSeenChange(_ int32) {}
+
+func (f *TrackForwarder) UpTrackBitrateReport(_ []int32, _ sfu.Bitrates) {}
+
+func (f *TrackForwarder) WriteRTP(p *buffer.ExtPacket, layer int32) error {
+	// all layers of SVC codecs are in a single stream
+	if f.isVideo && !f.isSVC && layer != f.params.Layer {
+		return nil
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.closed.Load() {
+		return nil
+	}
+
+	if f.isVideo && !f.seenKeyFrame {
+		if !p.KeyFrame {
+			return nil
+		}
+		f.seenKeyFrame = true
+		f.logger.Debugw("forwarding from key frame", "layer", layer)
+	}
+	if !f.started {
+		f.started = true
+		f.munger.SetLastSnTs(p)
+	}
+
+	tp, err := f.munger.UpdateAndGetSnTs(p, p.Packet.Marker)
+	if err != nil {
+		// padding, duplicates and packets too old to translate are not forwarded
+		return nil
+	}
+
+	hdr := p.Packet.Header
+	hdr.SequenceNumber = uint16(tp.ExtSequenceNumber())
+	hdr.Timestamp = uint32(tp.ExtTimestamp())
+	hdr.SSRC = f.ssrc
+	hdr.PayloadType = uint8(f.codec.PayloadType)
+	// extensions negotiated with the publisher mean nothing to the destination
+	hdr.Extension = false
+	hdr.Extensions = nil
+	hdr.Padding = false
+
+	pkt := rtp.Packet{Header: hdr, Payload: p.Packet.Payload}
+	b, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+	if f.srtp != nil {
+		if f.buf, err = f.srtp.EncryptRTP(f.buf[:0], b, &hdr); err != nil {
+			return err
+		}
+		b = f.buf
+	}
+	if _, err = f.conn.Write(b); err != nil {
+		// destinations not listening yet cause ICMP errors, keep sending
+		f.logger.Debugw("could not forward packet", "error", err)
+		return nil
+	}
+	f.packets.Inc()
+	f.bytes.Add(uint64(len(b)))
+	return nil
+}
+
+func (f *TrackForwarder) Close() {
+	f.lock.Lock()
+	if f.closed.Swap(true) {
+		f.lock.Unlock()
+		return
+	}
+	_ = f.conn.Close()
+	f.lock.Unlock()
+
+	close(f.done)
+	f.logger.Infow("forward stopped", "packets", f.packets.Load(), "bytes", f.bytes.Load())
+
+	f.onCloseLock.Lock()
+	onClose := f.onClose
+	f.onCloseLock.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+}
+
+func (f *TrackForwarder) IsClosed() bool {
+	return f.closed.Load()
+}
+
+func (f *TrackForwarder) ID() string {
+	return f.params.ID
+}
+
+func (f *TrackForwarder) SubscriberID() livekit.ParticipantID {
+	return livekit.ParticipantID(f.params.ID)
+}
+
+func (f *TrackForwarder) TrackInfoAvailable() {}
+
+func (f *TrackForwarder) HandleRTCPSenderReportData(_ webrtc.PayloadType, _ bool, _ int32, _ *buffer.RTCPSenderReportData) error {
+	return nil
+}
+
+// ------------------------------------------------
+
+// keyFrameWorker requests key frames of the layer, frequently until forwarding started, periodically after that
+func (f *TrackForwarder) keyFrameWorker() {
+	f.params.Receiver.SendPLI(f.params.Layer, true)
+
+	ticker := time.NewTicker(firstKeyFrameRequestInterval)
+	defer ticker.Stop()
+	lastRequest := time.Now()
+	for {
+		select {
+		case <-f.done:
+			return
+		case now := <-ticker.C:
+			f.lock.Lock()
+			seenKeyFrame := f.seenKeyFrame
+			f.lock.Unlock()
+			if seenKeyFrame && now.Sub(lastRequest) < f.params.KeyFrameInterval {
+				continue
+			}
+			f.params.Receiver.SendPLI(f.params.Layer, true)
+			lastRequest = now
+		}
+	}
+}
+
+func randomSSRC() uint32 {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
@@ -0,0 +1,232 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtpforward
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/srtp/v2"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type testReceiver struct {
+	sfu.TrackReceiver
+
+	codec webrtc.RTPCodecParameters
+
+	lock       sync.Mutex
+	downTracks map[livekit.ParticipantID]sfu.TrackSender
+	plis       int
+}
+
+func newTestReceiver(mimeType string) *testReceiver {
+	return &testReceiver{
+		codec: webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: mimeType, ClockRate: 90000},
+			PayloadType:        96,
+		},
+		downTracks: make(map[livekit.ParticipantID]sfu.TrackSender),
+	}
+}
+
+func (r *testReceiver) Codec() webrtc.RTPCodecParameters { return r.codec }
+
+func (r *testReceiver) AddDownTrack(track sfu.TrackSender) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.downTracks[track.SubscriberID()] = track
+	return nil
+}
+
+func (r *testReceiver) DeleteDownTrack(subscriberID livekit.ParticipantID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.downTracks, subscriberID)
+}
+
+func (r *testReceiver) SendPLI(_ int32, _ bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.plis++
+}
+
+func (r *testReceiver) PLIs() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.plis
+}
+
+func listen(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func readPacket(t *testing.T, conn *net.UDPConn) []byte {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	b := make([]byte, 1500)
+	n, err := conn.Read(b)
+	require.NoError(t, err)
+	return b[:n]
+}
+
+func videoPacket(sn uint16, ts uint32, layer int32, keyFrame bool, payload []byte) *buffer.ExtPacket {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    100,
+			SequenceNumber: sn,
+			Timestamp:      ts,
+			SSRC:           1234,
+		},
+		Payload: payload,
+	}
+	_ = pkt.Header.SetExtension(1, []byte{1})
+	return &buffer.ExtPacket{
+		Packet:            pkt,
+		ExtSequenceNumber: uint64(sn),
+		ExtTimestamp:      uint64(ts),
+		KeyFrame:          keyFrame,
+		VideoLayer:        buffer.VideoLayer{Spatial: layer},
+	}
+}
+
+func TestTrackForwarder(t *testing.T) {
+	t.Run("forwards a layer from a key frame", func(t *testing.T) {
+		conn := listen(t)
+		receiver := newTestReceiver(webrtc.MimeTypeVP8)
+		f, err := NewTrackForwarder(Params{
+			ID:          "RF_test",
+			Receiver:    receiver,
+			Destination: conn.LocalAddr().(*net.UDPAddr),
+			Layer:       1,
+		})
+		require.NoError(t, err)
+		require.NoError(t, f.Start())
+		require.Eventually(t, func() bool { return receiver.PLIs() > 0 }, time.Second, 10*time.Millisecond)
+
+		// other layers, and the layer before a key frame, are not forwarded
+		require.NoError(t, f.WriteRTP(videoPacket(10, 1000, 0, true, []byte{1}), 0))
+		require.NoError(t, f.WriteRTP(videoPacket(20, 2000, 1, false, []byte{2}), 1))
+		require.NoError(t, f.WriteRTP(videoPacket(21, 3000, 1, true, []byte{3}), 1))
+		// padding only packets are dropped, without gaps in sequence numbers
+		require.NoError(t, f.WriteRTP(videoPacket(22, 3000, 1, false, nil), 1))
+		require.NoError(t, f.WriteRTP(videoPacket(23, 6000, 1, false, []byte{4}), 1))
+
+		var pkt rtp.Packet
+		require.NoError(t, pkt.Unmarshal(readPacket(t, conn)))
+		require.Equal(t, []byte{3}, pkt.Payload)
+		require.Equal(t, f.SSRC(), pkt.SSRC)
+		require.EqualValues(t, 96, pkt.PayloadType)
+		require.False(t, pkt.Extension)
+		firstSN := pkt.SequenceNumber
+
+		require.NoError(t, pkt.Unmarshal(readPacket(t, conn)))
+		require.Equal(t, []byte{4}, pkt.Payload)
+		require.Equal(t, firstSN+1, pkt.SequenceNumber)
+		require.EqualValues(t, 6000, pkt.Timestamp)
+		require.EqualValues(t, 2, f.Stats().Packets)
+
+		closed := make(chan struct{})
+		f.OnClose(func() { close(closed) })
+		f.Stop()
+		require.Empty(t, receiver.downTracks)
+		<-closed
+		<-f.Done()
+	})
+
+	t.Run("SRTP", func(t *testing.T) {
+		conn := listen(t)
+		receiver := newTestReceiver(webrtc.MimeTypeVP8)
+		key, err := NewSRTPKey()
+		require.NoError(t, err)
+		f, err := NewTrackForwarder(Params{
+			ID:          "RF_test",
+			Receiver:    receiver,
+			Destination: conn.LocalAddr().(*net.UDPAddr),
+			SRTPKey:     key,
+		})
+		require.NoError(t, err)
+		require.NoError(t, f.Start())
+		defer f.Stop()
+
+		require.NoError(t, f.WriteRTP(videoPacket(1, 1000, 0, true, []byte{1, 2, 3}), 0))
+
+		decrypter, err := srtp.CreateContext(key[:16], key[16:], srtp.ProtectionProfileAes128CmHmacSha1_80)
+		require.NoError(t, err)
+		b, err := decrypter.DecryptRTP(nil, readPacket(t, conn), nil)
+		require.NoError(t, err)
+		var pkt rtp.Packet
+		require.NoError(t, pkt.Unmarshal(b))
+		require.Equal(t, []byte{1, 2, 3}, pkt.Payload)
+	})
+
+	t.Run("invalid SRTP key", func(t *testing.T) {
+		_, err := NewTrackForwarder(Params{
+			Receiver:    newTestReceiver(webrtc.MimeTypeOpus),
+			Destination: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5004},
+			SRTPKey:     []byte{1, 2, 3},
+		})
+		require.ErrorIs(t, err, ErrInvalidSRTPKey)
+	})
+}
+
+func TestSessionDescription(t *testing.T) {
+	codec := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeH264,
+			ClockRate:   90000,
+			SDPFmtpLine: "packetization-mode=1",
+		},
+		PayloadType: 102,
+	}
+	key := make([]byte, SRTPKeyLength)
+	description := SessionDescription("TR_test", codec, &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5004}, key)
+
+	parsed := sdp.SessionDescription{}
+	require.NoError(t, parsed.Unmarshal([]byte(description)))
+	require.Equal(t, "192.0.2.1", parsed.ConnectionInformation.Address.Address)
+	require.Len(t, parsed.MediaDescriptions, 1)
+	md := parsed.MediaDescriptions[0]
+	require.Equal(t, "video", md.MediaName.Media)
+	require.Equal(t, 5004, md.MediaName.Port.Value)
+	require.Equal(t, []string{"RTP", "SAVP"}, md.MediaName.Protos)
+	rtpmap, _ := md.Attribute("rtpmap")
+	require.Equal(t, "102 H264/90000", rtpmap)
+	fmtp, _ := md.Attribute("fmtp")
+	require.Equal(t, "102 packetization-mode=1", fmtp)
+	_, ok := md.Attribute("crypto")
+	require.True(t, ok)
+
+	opus := OutputCodec(webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: "audio/red"}})
+	description = SessionDescription("TR_test", opus, &net.UDPAddr{IP: net.IPv6loopback, Port: 5006}, nil)
+	require.Contains(t, description, "c=IN IP6 ::1\r\n")
+	require.Contains(t, description, "m=audio 5006 RTP/AVP 111\r\n")
+	require.Contains(t, description, "a=rtpmap:111 opus/48000/2\r\n")
+}
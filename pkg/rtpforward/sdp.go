@@ -0,0 +1,60 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtpforward
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// SessionDescription returns an SDP file describing the forwarded stream, for receivers like ffmpeg
+// (`ffmpeg -protocol_whitelist file,udp,rtp -i stream.sdp`) and GStreamer (`sdpdemux`)
+func SessionDescription(name string, codec webrtc.RTPCodecParameters, destination *net.UDPAddr, srtpKey []byte) string {
+	addrType := "IP4"
+	if destination.IP.To4() == nil {
+		addrType = "IP6"
+	}
+	media, encoding, _ := strings.Cut(codec.MimeType, "/")
+	media = strings.ToLower(media)
+	proto := "RTP/AVP"
+	if srtpKey != nil {
+		proto = "RTP/SAVP"
+	}
+
+	var b strings.Builder
+	b.WriteString("v=0\r\n")
+	fmt.Fprintf(&b, "o=- 0 0 IN %s %s\r\n", addrType, destination.IP)
+	fmt.Fprintf(&b, "s=%s\r\n", name)
+	fmt.Fprintf(&b, "c=IN %s %s\r\n", addrType, destination.IP)
+	b.WriteString("t=0 0\r\n")
+	fmt.Fprintf(&b, "m=%s %d %s %d\r\n", media, destination.Port, proto, codec.PayloadType)
+	rtpmap := fmt.Sprintf("%s/%d", encoding, codec.ClockRate)
+	if codec.Channels > 1 {
+		rtpmap += fmt.Sprintf("/%d", codec.Channels)
+	}
+	fmt.Fprintf(&b, "a=rtpmap:%d %s\r\n", codec.PayloadType, rtpmap)
+	if codec.SDPFmtpLine != "" {
+		fmt.Fprintf(&b, "a=fmtp:%d %s\r\n", codec.PayloadType, codec.SDPFmtpLine)
+	}
+	if srtpKey != nil {
+		fmt.Fprintf(&b, "a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:%s\r\n", base64.StdEncoding.EncodeToString(srtpKey))
+	}
+	b.WriteString("a=recvonly\r\n")
+	return b.String()
+}
@@ -0,0 +1,271 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtpingest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/bot"
+)
+
+const maxPacketSize = 1500
+
+var ErrUnsupportedCodec = errors.New("unsupported codec, expected opus, vp8 or h264")
+
+var codecs = map[string]webrtc.RTPCodecCapability{
+	"opus": {
+		MimeType:    webrtc.MimeTypeOpus,
+		ClockRate:   48000,
+		Channels:    2,
+		SDPFmtpLine: "minptime=10;useinbandfec=1",
+	},
+	"vp8": {
+		MimeType:  webrtc.MimeTypeVP8,
+		ClockRate: 90000,
+	},
+	"h264": {
+		MimeType:    webrtc.MimeTypeH264,
+		ClockRate:   90000,
+		SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+	},
+}
+
+// ParseCodec returns the codec of a name, opus, vp8 or h264
+func ParseCodec(name string) (webrtc.RTPCodecCapability, error) {
+	codec, ok := codecs[strings.ToLower(name)]
+	if !ok {
+		return webrtc.RTPCodecCapability{}, ErrUnsupportedCodec
+	}
+	return codec, nil
+}
+
+type TrackParams struct {
+	Name   string
+	Source livekit.TrackSource
+	Codec  webrtc.RTPCodecCapability
+	// payload type of packets from the encoder, others are dropped. Any payload type is accepted when 0
+	PayloadType uint8
+	Width       uint32
+	Height      uint32
+	// socket receiving packets of the encoder, closed with the track
+	Conn *net.UDPConn
+	// groups tracks of a participant
+	StreamID string
+	Logger   logger.Logger
+}
+
+// Stats of an ingested track
+type Stats struct {
+	Packets uint64
+	Bytes   uint64
+	// packets of other sources or payload types, and packets which are not RTP
+	Dropped uint64
+}
+
+// Track publishes plain RTP received on a UDP port, e. g. from a hardware encoder, as a track of a bot.
+// Packets keep their sequence numbers and timestamps, so the receiver of the room sees loss and jitter of the
+// encoder, as for WebRTC publishers. The first address sending to the port is the source of the track, packets of
+// other addresses are dropped. Key frame requests of subscribers are sent to the source as RTCP PLI.
+type Track struct {
+	params TrackParams
+	logger logger.Logger
+	info   bot.MediaInfo
+	local  *webrtc.TrackLocalStaticRTP
+
+	lock   sync.Mutex
+	source *net.UDPAddr
+	ssrc   uint32
+
+	packets atomic.Uint64
+	bytes   atomic.Uint64
+	dropped atomic.Uint64
+}
+
+var _ bot.Track = (*Track)(nil)
+var _ bot.KeyFrameRequester = (*Track)(nil)
+
+func NewTrack(params TrackParams) (*Track, error) {
+	if params.Logger == nil {
+		params.Logger = logger.GetLogger()
+	}
+	local, err := webrtc.NewTrackLocalStaticRTP(params.Codec, utils.NewGuid(bot.TrackCIDPrefix), params.StreamID)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := livekit.TrackType_AUDIO
+	if strings.HasPrefix(strings.ToLower(params.Codec.MimeType), "video/") {
+		kind = livekit.TrackType_VIDEO
+	}
+	return &Track{
+		params: params,
+		logger: params.Logger,
+		info: bot.MediaInfo{
+			Kind:     kind,
+			MimeType: params.Codec.MimeType,
+			Width:    params.Width,
+			Height:   params.Height,
+		},
+		local: local,
+	}, nil
+}
+
+func (t *Track) Name() string {
+	return t.params.Name
+}
+
+func (t *Track) Source() livekit.TrackSource {
+	return t.params.Source
+}
+
+func (t *Track) Info() bot.MediaInfo {
+	return t.info
+}
+
+func (t *Track) Local() webrtc.TrackLocal {
+	return t.local
+}
+
+// Port is the UDP port receiving packets of the track
+func (t *Track) Port() int {
+	return t.params.Conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// SourceAddr is the address packets are accepted from, once the first one arrived
+func (t *Track) SourceAddr() *net.UDPAddr {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.source
+}
+
+func (t *Track) Stats() Stats {
+	return Stats{
+		Packets: t.packets.Load(),
+		Bytes:   t.bytes.Load(),
+		Dropped: t.dropped.Load(),
+	}
+}
+
+// Close stops receiving packets
+func (t *Track) Close() {
+	_ = t.params.Conn.Close()
+}
+
+// Write publishes received packets until the track is closed, or ctx is done
+func (t *Track) Write(ctx context.Context) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			t.Close()
+		case <-stop:
+		}
+	}()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := t.params.Conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		if !t.isSource(addr) || isRTCP(buf[:n]) {
+			t.dropped.Inc()
+			continue
+		}
+		var pkt rtp.Packet
+		if err = pkt.Unmarshal(buf[:n]); err != nil {
+			t.dropped.Inc()
+			continue
+		}
+		if t.params.PayloadType != 0 && pkt.PayloadType != t.params.PayloadType {
+			t.dropped.Inc()
+			continue
+		}
+
+		t.lock.Lock()
+		if t.ssrc != pkt.SSRC {
+			t.logger.Infow("receiving RTP", "source", addr, "ssrc", pkt.SSRC, "payloadType", pkt.PayloadType)
+			t.ssrc = pkt.SSRC
+		}
+		t.lock.Unlock()
+
+		// extensions of the encoder mean nothing to the room, local extensions are negotiated with it
+		pkt.Extension = false
+		pkt.Extensions = nil
+		if err = t.local.WriteRTP(&pkt); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		t.packets.Inc()
+		t.bytes.Add(uint64(n))
+	}
+}
+
+// RequestKeyFrame sends a PLI to the source of video
+func (t *Track) RequestKeyFrame() {
+	if t.info.Kind != livekit.TrackType_VIDEO {
+		return
+	}
+	t.lock.Lock()
+	source, ssrc := t.source, t.ssrc
+	t.lock.Unlock()
+	if source == nil {
+		return
+	}
+
+	b, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: ssrc}})
+	if err != nil {
+		return
+	}
+	if _, err = t.params.Conn.WriteToUDP(b, source); err != nil {
+		t.logger.Debugw("could not send key frame request", "error", err)
+	}
+}
+
+// isSource latches the source to the first address sending to the port
+func (t *Track) isSource(addr *net.UDPAddr) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.source == nil {
+		t.source = addr
+		return true
+	}
+	return t.source.IP.Equal(addr.IP) && t.source.Port == addr.Port
+}
+
+// isRTCP detects RTCP multiplexed with RTP, by payload types of RFC 5761
+func isRTCP(b []byte) bool {
+	return len(b) >= 2 && b[1] >= 192 && b[1] <= 223
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtpingest
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestParseCodec(t *testing.T) {
+	codec, err := ParseCodec("H264")
+	require.NoError(t, err)
+	require.Equal(t, "video/H264", codec.MimeType)
+
+	_, err = ParseCodec("vp9")
+	require.ErrorIs(t, err, ErrUnsupportedCodec)
+}
+
+func TestTrack(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	codec, err := ParseCodec("vp8")
+	require.NoError(t, err)
+	track, err := NewTrack(TrackParams{
+		Name:        "camera",
+		Source:      livekit.TrackSource_CAMERA,
+		Codec:       codec,
+		PayloadType: 96,
+		Conn:        conn,
+	})
+	require.NoError(t, err)
+	require.Equal(t, livekit.TrackType_VIDEO, track.Info().Kind)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- track.Write(ctx)
+	}()
+
+	dial := func() *net.UDPConn {
+		c, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = c.Close()
+		})
+		return c
+	}
+	send := func(c *net.UDPConn, payloadType uint8) {
+		b, err := (&rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: payloadType, SequenceNumber: 1, SSRC: 5678},
+			Payload: []byte{1, 2, 3},
+		}).Marshal()
+		require.NoError(t, err)
+		_, err = c.Write(b)
+		require.NoError(t, err)
+	}
+
+	encoder := dial()
+	send(encoder, 96)
+	require.Eventually(t, func() bool { return track.Stats().Packets == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, encoder.LocalAddr().String(), track.SourceAddr().String())
+
+	// other payload types, RTCP and other sources are dropped
+	send(encoder, 97)
+	sr, err := rtcp.Marshal([]rtcp.Packet{&rtcp.SenderReport{SSRC: 5678}})
+	require.NoError(t, err)
+	_, err = encoder.Write(sr)
+	require.NoError(t, err)
+	send(dial(), 96)
+	require.Eventually(t, func() bool { return track.Stats().Dropped == 3 }, time.Second, 10*time.Millisecond)
+	require.EqualValues(t, 1, track.Stats().Packets)
+
+	// key frames are requested from the source
+	track.RequestKeyFrame()
+	require.NoError(t, encoder.SetReadDeadline(time.Now().Add(time.Second)))
+	b := make([]byte, 1500)
+	n, err := encoder.Read(b)
+	require.NoError(t, err)
+	pkts, err := rtcp.Unmarshal(b[:n])
+	require.NoError(t, err)
+	require.Len(t, pkts, 1)
+	pli, ok := pkts[0].(*rtcp.PictureLossIndication)
+	require.True(t, ok)
+	require.EqualValues(t, 5678, pli.MediaSSRC)
+
+	cancel()
+	require.NoError(t, <-done)
+}
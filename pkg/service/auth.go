@@ -16,6 +16,8 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strings"
@@ -24,6 +26,8 @@ import (
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
 )
 
 const (
@@ -34,6 +38,10 @@ const (
 
 type grantsKey struct{}
 
+type dataTopicGrantKey struct{}
+
+type publishBitrateGrantKey struct{}
+
 var (
 	ErrPermissionDenied          = errors.New("permissions denied")
 	ErrMissingAuthorization      = errors.New("invalid authorization header. Must start with " + bearerPrefix)
@@ -93,7 +101,19 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 
 		// set grants in context
 		ctx := r.Context()
-		r = r.WithContext(context.WithValue(ctx, grantsKey{}, grants))
+		ctx = context.WithValue(ctx, grantsKey{}, grants)
+		extraClaims, err := parseExtraClaims(authToken)
+		if err != nil {
+			handleError(w, http.StatusUnauthorized, errors.New("invalid token: "+authToken+", error: "+err.Error()))
+			return
+		}
+		if extraClaims.DataTopics != nil {
+			ctx = context.WithValue(ctx, dataTopicGrantKey{}, extraClaims.DataTopics)
+		}
+		if extraClaims.PublishBitrate != nil {
+			ctx = context.WithValue(ctx, publishBitrateGrantKey{}, extraClaims.PublishBitrate)
+		}
+		r = r.WithContext(ctx)
 	}
 
 	next.ServeHTTP(w, r)
@@ -112,6 +132,49 @@ func WithGrants(ctx context.Context, grants *auth.ClaimGrants) context.Context {
 	return context.WithValue(ctx, grantsKey{}, grants)
 }
 
+func GetDataTopicGrant(ctx context.Context) *routing.DataTopicGrant {
+	grant, _ := ctx.Value(dataTopicGrantKey{}).(*routing.DataTopicGrant)
+	return grant
+}
+
+func WithDataTopicGrant(ctx context.Context, grant *routing.DataTopicGrant) context.Context {
+	return context.WithValue(ctx, dataTopicGrantKey{}, grant)
+}
+
+func GetPublishBitrateGrant(ctx context.Context) *routing.PublishBitrateGrant {
+	grant, _ := ctx.Value(publishBitrateGrantKey{}).(*routing.PublishBitrateGrant)
+	return grant
+}
+
+func WithPublishBitrateGrant(ctx context.Context, grant *routing.PublishBitrateGrant) context.Context {
+	return context.WithValue(ctx, publishBitrateGrantKey{}, grant)
+}
+
+// claims which aren't part of auth.ClaimGrants
+type extraClaims struct {
+	DataTopics     *routing.DataTopicGrant      `json:"dataTopics"`
+	PublishBitrate *routing.PublishBitrateGrant `json:"publishBitrate"`
+}
+
+// parseExtraClaims reads claims which aren't part of auth.ClaimGrants.
+// it must only be called on tokens that have been verified
+func parseExtraClaims(token string) (*extraClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidAuthorizationToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var claims extraClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
 func SetAuthorizationToken(r *http.Request, token string) {
 	r.Header.Set(authorizationHeader, bearerPrefix+token)
 }
@@ -18,12 +18,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/auth/authfakes"
 
+	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/service"
 )
 
@@ -72,3 +76,54 @@ func TestAuthMiddleware(t *testing.T) {
 	require.Nil(t, grants)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthMiddlewareDataTopics(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider)
+	var dataTopics *routing.DataTopicGrant
+	var publishBitrate *routing.PublishBitrateGrant
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dataTopics = service.GetDataTopicGrant(r.Context())
+		publishBitrate = service.GetPublishBitrateGrant(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, (&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+	token, err := jwt.Signed(sig).
+		Claims(jwt.Claims{
+			Issuer:  api,
+			Subject: "p1",
+			Expiry:  jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}).
+		Claims(map[string]interface{}{
+			"video":          &auth.VideoGrant{Room: "abcdefg", RoomJoin: true},
+			"dataTopics":     &routing.DataTopicGrant{CanPublish: []string{"chat"}},
+			"publishBitrate": &routing.PublishBitrateGrant{MaxBitrate: 1_000_000},
+		}).
+		CompactSerialize()
+	require.NoError(t, err)
+
+	r := &http.Request{Header: http.Header{}}
+	w := httptest.NewRecorder()
+	service.SetAuthorizationToken(r, token)
+	m.ServeHTTP(w, r, handler)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, &routing.DataTopicGrant{CanPublish: []string{"chat"}}, dataTopics)
+	require.Equal(t, &routing.PublishBitrateGrant{MaxBitrate: 1_000_000}, publishBitrate)
+
+	// tokens without the claim are not restricted
+	dataTopics = nil
+	token, err = auth.NewAccessToken(api, secret).AddGrant(&auth.VideoGrant{Room: "abcdefg", RoomJoin: true}).ToJWT()
+	require.NoError(t, err)
+	r = &http.Request{Header: http.Header{}}
+	w = httptest.NewRecorder()
+	service.SetAuthorizationToken(r, token)
+	m.ServeHTTP(w, r, handler)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Nil(t, dataTopics)
+}
@@ -0,0 +1,363 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/bot"
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// RoomService methods managing bots. They are not part of the protocol version in use, and are served next to the
+// generated RoomService server. There are no protobuf messages for them, so requests and responses are JSON only.
+const (
+	StartBotPath = "/twirp/livekit.RoomService/StartBot"
+	ListBotsPath = "/twirp/livekit.RoomService/ListBots"
+	StopBotPath  = "/twirp/livekit.RoomService/StopBot"
+
+	maxBotRequestSize = 64 * 1024
+)
+
+var (
+	ErrBotsNotEnabled  = psrpc.NewErrorf(psrpc.Unimplemented, "bots are not enabled")
+	ErrBotMediaMissing = psrpc.NewErrorf(psrpc.InvalidArgument, "audio or video file is required")
+	ErrBotMediaPath    = psrpc.NewErrorf(psrpc.InvalidArgument, "media file must be a path within the media directory")
+	ErrBotNotFound     = psrpc.NewErrorf(psrpc.NotFound, "bot not found")
+)
+
+type StartBotRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Name     string `json:"name,omitempty"`
+	// Ogg/Opus file, relative to the media directory
+	Audio string `json:"audio,omitempty"`
+	// IVF file with VP8, VP9 or AV1, relative to the media directory
+	Video string `json:"video,omitempty"`
+	// restart files once they end, otherwise the bot leaves
+	Loop bool `json:"loop,omitempty"`
+}
+
+type BotInfo struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+type ListBotsRequest struct {
+	Room string `json:"room"`
+}
+
+type ListBotsResponse struct {
+	Bots []*BotInfo `json:"bots"`
+}
+
+type StopBotRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+type StopBotResponse struct{}
+
+// BotService lets room admins start, list and stop bots, server owned participants publishing media files into a room.
+// Bots run on the node hosting the room, other nodes forward requests to it.
+type BotService struct {
+	conf         config.BotsConfig
+	rooms        RoomProvider
+	startSession routing.NewParticipantCallback
+	region       string
+	forwarder    *RoomForwarder
+
+	lock sync.Mutex
+	bots map[livekit.RoomName]map[livekit.ParticipantIdentity]*bot.Bot
+}
+
+func NewBotService(
+	conf *config.Config,
+	rooms RoomProvider,
+	startSession routing.NewParticipantCallback,
+	forwarder *RoomForwarder,
+) *BotService {
+	return &BotService{
+		conf:         conf.Bots,
+		rooms:        rooms,
+		startSession: startSession,
+		region:       conf.Region,
+		forwarder:    forwarder,
+		bots:         make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*bot.Bot),
+	}
+}
+
+func (s *BotService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		_ = twirp.WriteError(w, twirp.NewErrorf(twirp.BadRoute, "unsupported method %s", r.Method))
+		return
+	}
+	if contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); contentType != "application/json" {
+		_ = twirp.WriteError(w, twirp.NewErrorf(twirp.BadRoute, "unexpected Content-Type: %q", r.Header.Get("Content-Type")))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBotRequestSize))
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.Malformed, err.Error()))
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var (
+		roomName livekit.RoomName
+		handle   func(ctx context.Context) (interface{}, error)
+	)
+	switch r.URL.Path {
+	case StartBotPath:
+		req := &StartBotRequest{}
+		if err = json.Unmarshal(body, req); err == nil {
+			roomName = livekit.RoomName(req.Room)
+			handle = func(ctx context.Context) (interface{}, error) { return s.StartBot(ctx, req) }
+		}
+	case ListBotsPath:
+		req := &ListBotsRequest{}
+		if err = json.Unmarshal(body, req); err == nil {
+			roomName = livekit.RoomName(req.Room)
+			handle = func(ctx context.Context) (interface{}, error) { return s.ListBots(ctx, req) }
+		}
+	case StopBotPath:
+		req := &StopBotRequest{}
+		if err = json.Unmarshal(body, req); err == nil {
+			roomName = livekit.RoomName(req.Room)
+			handle = func(ctx context.Context) (interface{}, error) { return s.StopBot(ctx, req) }
+		}
+	default:
+		_ = twirp.WriteError(w, twirp.NewErrorf(twirp.BadRoute, "no handler for path %s", r.URL.Path))
+		return
+	}
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.Malformed, err.Error()))
+		return
+	}
+	if roomName == "" {
+		_ = twirp.WriteError(w, twirp.RequiredArgumentError("room"))
+		return
+	}
+
+	if err = EnsureAdminPermission(r.Context(), roomName); err != nil {
+		_ = twirp.WriteError(w, twirpAuthError(err))
+		return
+	}
+
+	if s.rooms.GetRoom(r.Context(), roomName) == nil && s.forwarder.forward(w, r, roomName) {
+		return
+	}
+
+	res, err := handle(r.Context())
+	if err != nil {
+		_ = twirp.WriteError(w, err)
+		return
+	}
+	writeJSON(w, res)
+}
+
+// Stop closes all bots, so they don't keep rooms open
+func (s *BotService) Stop() {
+	s.lock.Lock()
+	var bots []*bot.Bot
+	for _, roomBots := range s.bots {
+		for _, b := range roomBots {
+			bots = append(bots, b)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, b := range bots {
+		b.Close()
+	}
+}
+
+// StartBot joins a bot to a room hosted by this node, replacing a participant with the same identity
+func (s *BotService) StartBot(_ context.Context, req *StartBotRequest) (*BotInfo, error) {
+	if s.conf.MediaDir == "" {
+		return nil, ErrBotsNotEnabled
+	}
+	switch {
+	case req.Room == "":
+		return nil, twirp.RequiredArgumentError("room")
+	case req.Identity == "":
+		return nil, twirp.RequiredArgumentError("identity")
+	case req.Audio == "" && req.Video == "":
+		return nil, ErrBotMediaMissing
+	}
+
+	roomName := livekit.RoomName(req.Room)
+	if s.rooms.GetRoom(context.Background(), roomName) == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	identity := livekit.ParticipantIdentity(req.Identity)
+	name := livekit.ParticipantName(req.Name)
+	if name == "" {
+		name = livekit.ParticipantName(identity)
+	}
+
+	var tracks []bot.Track
+	for _, media := range []struct {
+		path   string
+		source livekit.TrackSource
+	}{
+		{req.Audio, livekit.TrackSource_MICROPHONE},
+		{req.Video, livekit.TrackSource_CAMERA},
+	} {
+		if media.path == "" {
+			continue
+		}
+		path, err := s.mediaPath(media.path)
+		if err != nil {
+			return nil, err
+		}
+		track, err := bot.NewFileTrack(bot.TrackParams{
+			Path:   path,
+			Name:   filepath.Base(media.path),
+			Source: media.source,
+			Loop:   req.Loop,
+		}, string(identity))
+		if err != nil {
+			return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+		}
+		tracks = append(tracks, track)
+	}
+
+	b, err := bot.NewBot(bot.Params{
+		RoomName:     roomName,
+		Identity:     identity,
+		Name:         name,
+		Tracks:       tracks,
+		Region:       s.region,
+		StartSession: s.startSession,
+		Logger:       rtc.LoggerWithParticipant(rtc.LoggerWithRoom(logger.GetLogger(), roomName, ""), identity, "", false),
+	})
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+
+	// inserted before starting, so that a bot closing while or right after starting is removed again
+	s.lock.Lock()
+	roomBots := s.bots[roomName]
+	if roomBots == nil {
+		roomBots = make(map[livekit.ParticipantIdentity]*bot.Bot)
+		s.bots[roomName] = roomBots
+	}
+	existing := roomBots[identity]
+	roomBots[identity] = b
+	s.lock.Unlock()
+
+	// joining with the same identity replaces the previous participant
+	if existing != nil {
+		existing.Close()
+	}
+
+	b.OnClose(s.removeBot)
+	if err = b.Start(context.Background()); err != nil {
+		s.removeBot(b)
+		return nil, psrpc.NewError(psrpc.Internal, err)
+	}
+
+	return &BotInfo{Room: string(roomName), Identity: string(identity)}, nil
+}
+
+func (s *BotService) ListBots(_ context.Context, req *ListBotsRequest) (*ListBotsResponse, error) {
+	if req.Room == "" {
+		return nil, twirp.RequiredArgumentError("room")
+	}
+	roomName := livekit.RoomName(req.Room)
+
+	s.lock.Lock()
+	bots := make([]*BotInfo, 0, len(s.bots[roomName]))
+	for identity := range s.bots[roomName] {
+		bots = append(bots, &BotInfo{Room: string(roomName), Identity: string(identity)})
+	}
+	s.lock.Unlock()
+	sort.Slice(bots, func(i, j int) bool {
+		return bots[i].Identity < bots[j].Identity
+	})
+
+	return &ListBotsResponse{Bots: bots}, nil
+}
+
+func (s *BotService) StopBot(_ context.Context, req *StopBotRequest) (*StopBotResponse, error) {
+	switch {
+	case req.Room == "":
+		return nil, twirp.RequiredArgumentError("room")
+	case req.Identity == "":
+		return nil, twirp.RequiredArgumentError("identity")
+	}
+
+	s.lock.Lock()
+	b := s.bots[livekit.RoomName(req.Room)][livekit.ParticipantIdentity(req.Identity)]
+	s.lock.Unlock()
+	if b == nil {
+		return nil, ErrBotNotFound
+	}
+
+	b.Close()
+	return &StopBotResponse{}, nil
+}
+
+func (s *BotService) removeBot(b *bot.Bot) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	roomBots := s.bots[b.RoomName()]
+	if roomBots[b.Identity()] != b {
+		return
+	}
+	delete(roomBots, b.Identity())
+	if len(roomBots) == 0 {
+		delete(s.bots, b.RoomName())
+	}
+}
+
+// mediaPath resolves a file relative to the media directory, without allowing paths outside of it
+func (s *BotService) mediaPath(name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", ErrBotMediaPath
+	}
+	return filepath.Join(s.conf.MediaDir, name), nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const DataHistoryPath = "/admin/data_history"
+
+var (
+	errRoomRequired          = errors.New("room is required")
+	errDataHistoryNotEnabled = errors.New("room does not retain data packets")
+)
+
+type RoomProvider interface {
+	GetRoom(ctx context.Context, roomName livekit.RoomName) *rtc.Room
+}
+
+type dataHistoryResponse struct {
+	Packets []json.RawMessage `json:"packets"`
+}
+
+// DataHistoryService lets room admins fetch (GET) or clear (DELETE) data packets retained by a room,
+// at /admin/data_history?room=<name>. Requests are served by the node hosting the room, other nodes forward them.
+type DataHistoryService struct {
+	rooms     RoomProvider
+	forwarder *RoomForwarder
+}
+
+func NewDataHistoryService(rooms RoomProvider, forwarder *RoomForwarder) *DataHistoryService {
+	return &DataHistoryService{
+		rooms:     rooms,
+		forwarder: forwarder,
+	}
+}
+
+func (s *DataHistoryService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "GET, DELETE")
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if !room.ClearDataHistory() {
+			handleError(w, http.StatusNotFound, errDataHistoryNotEnabled, "room", roomName)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	packets, ok := room.DataHistory()
+	if !ok {
+		handleError(w, http.StatusNotFound, errDataHistoryNotEnabled, "room", roomName)
+		return
+	}
+	res := dataHistoryResponse{Packets: make([]json.RawMessage, 0, len(packets))}
+	for _, dp := range packets {
+		b, err := protojson.Marshal(dp)
+		if err != nil {
+			handleError(w, http.StatusInternalServerError, err, "room", roomName)
+			return
+		}
+		res.Packets = append(res.Packets, b)
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+type testRoomProvider map[livekit.RoomName]*rtc.Room
+
+func (p testRoomProvider) GetRoom(_ context.Context, roomName livekit.RoomName) *rtc.Room {
+	return p[roomName]
+}
+
+func TestDataHistoryService(t *testing.T) {
+	room := rtc.NewRoom(
+		&livekit.Room{Name: "chat"},
+		nil,
+		rtc.WebRTCConfig{},
+		&config.AudioConfig{},
+		&config.RoomConfig{DataHistory: config.DataHistoryConfig{Messages: 10}},
+		&livekit.ServerInfo{},
+		&telemetryfakes.FakeTelemetryService{},
+		nil,
+	)
+	defer room.Close()
+	room.SendDataPacket(&livekit.UserPacket{Payload: []byte("hello")}, livekit.DataPacket_RELIABLE)
+
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "current"}, nil)
+	forwarder, err := service.NewRoomForwarder(router, routing.LocalNode(&livekit.Node{Id: "current"}), psrpc.NewLocalMessageBus())
+	require.NoError(t, err)
+	defer forwarder.Stop()
+	svc := service.NewDataHistoryService(testRoomProvider{"chat": room}, forwarder)
+
+	request := func(method string, roomName string, grant *auth.VideoGrant) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, service.DataHistoryPath+"?room="+roomName, nil)
+		req = req.WithContext(service.WithGrants(req.Context(), &auth.ClaimGrants{Video: grant}))
+		w := httptest.NewRecorder()
+		svc.ServeHTTP(w, req)
+		return w
+	}
+	adminGrant := func(roomName string) *auth.VideoGrant {
+		return &auth.VideoGrant{RoomAdmin: true, Room: roomName}
+	}
+
+	t.Run("requires admin permission", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "chat", &auth.VideoGrant{RoomJoin: true, Room: "chat"}).Code)
+		require.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "chat", adminGrant("other")).Code)
+	})
+
+	t.Run("missing room", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, request(http.MethodGet, "", adminGrant("")).Code)
+		require.Equal(t, http.StatusNotFound, request(http.MethodGet, "other", adminGrant("other")).Code)
+	})
+
+	t.Run("fetch and clear", func(t *testing.T) {
+		w := request(http.MethodGet, "chat", adminGrant("chat"))
+		require.Equal(t, http.StatusOK, w.Code)
+		var res struct {
+			Packets []struct {
+				User struct {
+					Payload []byte `json:"payload"`
+				} `json:"user"`
+			} `json:"packets"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Packets, 1)
+		require.Equal(t, []byte("hello"), res.Packets[0].User.Payload)
+
+		require.Equal(t, http.StatusNoContent, request(http.MethodDelete, "chat", adminGrant("chat")).Code)
+
+		w = request(http.MethodGet, "chat", adminGrant("chat"))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"packets":[]}`, w.Body.String())
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		require.Equal(t, http.StatusMethodNotAllowed, request(http.MethodPost, "chat", adminGrant("chat")).Code)
+	})
+}
+
+func TestDataHistoryServiceForwarding(t *testing.T) {
+	room := rtc.NewRoom(
+		&livekit.Room{Name: "chat"},
+		nil,
+		rtc.WebRTCConfig{},
+		&config.AudioConfig{},
+		&config.RoomConfig{DataHistory: config.DataHistoryConfig{Messages: 10}},
+		&livekit.ServerInfo{},
+		&telemetryfakes.FakeTelemetryService{},
+		nil,
+	)
+	defer room.Close()
+	room.SendDataPacket(&livekit.UserPacket{Payload: []byte("hello")}, livekit.DataPacket_RELIABLE)
+
+	// the room is hosted by the remote node
+	bus := psrpc.NewLocalMessageBus()
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(&livekit.Node{Id: "remote"}, nil)
+
+	remoteForwarder, err := service.NewRoomForwarder(router, routing.LocalNode(&livekit.Node{Id: "remote"}), bus)
+	require.NoError(t, err)
+	defer remoteForwarder.Stop()
+	remote := service.NewDataHistoryService(testRoomProvider{"chat": room}, remoteForwarder)
+	// forwarded requests are authenticated again by the remote node
+	authMiddleware := service.NewAPIKeyAuthMiddleware(auth.NewSimpleKeyProvider("key", "secret"))
+	require.NoError(t, remoteForwarder.Serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authMiddleware.ServeHTTP(w, r, remote.ServeHTTP)
+	})))
+
+	localForwarder, err := service.NewRoomForwarder(router, routing.LocalNode(&livekit.Node{Id: "local"}), bus)
+	require.NoError(t, err)
+	defer localForwarder.Stop()
+	local := service.NewDataHistoryService(testRoomProvider{}, localForwarder)
+
+	request := func(secret string) *httptest.ResponseRecorder {
+		grant := &auth.VideoGrant{RoomAdmin: true, Room: "chat"}
+		token, err := auth.NewAccessToken("key", secret).AddGrant(grant).ToJWT()
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, service.DataHistoryPath+"?room=chat", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req = req.WithContext(service.WithGrants(req.Context(), &auth.ClaimGrants{Video: grant}))
+		w := httptest.NewRecorder()
+		local.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("secret")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "aGVsbG8=")
+
+	require.Equal(t, http.StatusUnauthorized, request("other-secret").Code)
+}
@@ -19,19 +19,21 @@ import (
 )
 
 var (
-	ErrEgressNotFound          = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
-	ErrEgressNotConnected      = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
-	ErrIdentityEmpty           = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
-	ErrIngressNotConnected     = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
-	ErrIngressNotFound         = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
-	ErrIngressNonReusable      = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
-	ErrMetadataExceedsLimits   = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
-	ErrOperationFailed         = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
-	ErrParticipantNotFound     = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
-	ErrRoomNotFound            = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
-	ErrRoomLockFailed          = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
-	ErrRoomUnlockFailed        = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
-	ErrRemoteUnmuteNoteEnabled = psrpc.NewErrorf(psrpc.FailedPrecondition, "remote unmute not enabled")
-	ErrTrackNotFound           = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
-	ErrWebHookMissingAPIKey    = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
+	ErrAttributesExceedLimits      = psrpc.NewErrorf(psrpc.InvalidArgument, "attributes exceed limits")
+	ErrEgressNotFound              = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
+	ErrEgressNotConnected          = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
+	ErrIdentityEmpty               = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
+	ErrIngressNotConnected         = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
+	ErrIngressNotFound             = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
+	ErrIngressNonReusable          = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
+	ErrMetadataExceedsLimits       = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
+	ErrOperationFailed             = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
+	ErrParticipantNotFound         = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
+	ErrRoomNotFound                = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
+	ErrRoomMetadataVersionConflict = psrpc.NewErrorf(psrpc.Aborted, "room metadata version does not match")
+	ErrRoomLockFailed              = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
+	ErrRoomUnlockFailed            = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
+	ErrRemoteUnmuteNoteEnabled     = psrpc.NewErrorf(psrpc.FailedPrecondition, "remote unmute not enabled")
+	ErrTrackNotFound               = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
+	ErrWebHookMissingAPIKey        = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
 )
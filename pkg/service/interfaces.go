@@ -40,6 +40,10 @@ type ObjectStore interface {
 	StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error
 	DeleteRoom(ctx context.Context, roomName livekit.RoomName) error
 
+	// version of room metadata, incremented with each update. updates should be made while holding the room lock
+	LoadRoomMetadataVersion(ctx context.Context, roomName livekit.RoomName) (uint64, error)
+	StoreRoomMetadataVersion(ctx context.Context, roomName livekit.RoomName, version uint64) error
+
 	StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error
 	DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
 }
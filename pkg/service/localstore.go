@@ -29,6 +29,8 @@ type LocalStore struct {
 	// map of roomName => room
 	rooms        map[livekit.RoomName]*livekit.Room
 	roomInternal map[livekit.RoomName]*livekit.RoomInternal
+	// map of roomName => version of room metadata
+	roomMetadataVersions map[livekit.RoomName]uint64
 	// map of roomName => { identity: participant }
 	participants map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
 
@@ -38,10 +40,11 @@ type LocalStore struct {
 
 func NewLocalStore() *LocalStore {
 	return &LocalStore{
-		rooms:        make(map[livekit.RoomName]*livekit.Room),
-		roomInternal: make(map[livekit.RoomName]*livekit.RoomInternal),
-		participants: make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
-		lock:         sync.RWMutex{},
+		rooms:                make(map[livekit.RoomName]*livekit.Room),
+		roomInternal:         make(map[livekit.RoomName]*livekit.RoomInternal),
+		roomMetadataVersions: make(map[livekit.RoomName]uint64),
+		participants:         make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
+		lock:                 sync.RWMutex{},
 	}
 }
 
@@ -102,6 +105,22 @@ func (s *LocalStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 	delete(s.participants, livekit.RoomName(room.Name))
 	delete(s.rooms, livekit.RoomName(room.Name))
 	delete(s.roomInternal, livekit.RoomName(room.Name))
+	delete(s.roomMetadataVersions, livekit.RoomName(room.Name))
+	return nil
+}
+
+func (s *LocalStore) LoadRoomMetadataVersion(_ context.Context, roomName livekit.RoomName) (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.roomMetadataVersions[roomName], nil
+}
+
+func (s *LocalStore) StoreRoomMetadataVersion(_ context.Context, roomName livekit.RoomName, version uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.roomMetadataVersions[roomName] = version
 	return nil
 }
 
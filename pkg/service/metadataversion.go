@@ -0,0 +1,54 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// UpdateRoomMetadataRequest does not have a version field in the protocol version in use,
+// the expected version of compare-and-set updates is passed as a request header instead
+const (
+	// UpdateRoomMetadata fails with a conflict when the current version doesn't match
+	ExpectedMetadataVersionHeader = "X-Expected-Metadata-Version"
+	// version of room metadata after an update, or the current version in case of conflict
+	MetadataVersionHeader = "X-Metadata-Version"
+
+	// held while metadata is written, in addition to the time allowed to confirm the write
+	roomMetadataLockDuration = 5 * time.Second
+)
+
+type expectedMetadataVersionKey struct{}
+
+// WithMetadataVersion passes the expected metadata version header of requests to handlers
+func WithMetadataVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if version := r.Header.Get(ExpectedMetadataVersionHeader); version != "" {
+			r = r.WithContext(WithExpectedMetadataVersion(r.Context(), version))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func WithExpectedMetadataVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, expectedMetadataVersionKey{}, version)
+}
+
+func GetExpectedMetadataVersion(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(expectedMetadataVersionKey{}).(string)
+	return version, ok
+}
@@ -0,0 +1,355 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/pcap"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	PacketCapturePath = "/admin/packet_capture"
+
+	maxPacketCaptureRequestSize = 4 * 1024
+)
+
+var (
+	errPacketCaptureNotEnabled     = errors.New("packet capture is not enabled")
+	errPacketCaptureIdentity       = errors.New("identity is required")
+	errPacketCaptureNotFound       = errors.New("packet capture not found")
+	errPacketCaptureInvalidPayload = errors.New("payload_bytes must be -1 for complete packets, or at least 0")
+)
+
+type StartPacketCaptureRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// seconds, defaults to and is capped by the configured max duration
+	Duration int `json:"duration,omitempty"`
+	// bytes, defaults to and is capped by the configured max size
+	MaxSize uint64 `json:"max_size,omitempty"`
+	// bytes of RTP payload kept after headers, 0 for headers only, -1 for complete packets
+	PayloadBytes int `json:"payload_bytes,omitempty"`
+}
+
+type PacketCaptureInfo struct {
+	Room      string `json:"room"`
+	Identity  string `json:"identity"`
+	Filename  string `json:"filename"`
+	StartedAt int64  `json:"started_at"`
+	// set once the capture stopped
+	EndedAt int64  `json:"ended_at,omitempty"`
+	Packets uint64 `json:"packets,omitempty"`
+	Dropped uint64 `json:"dropped,omitempty"`
+	Size    uint64 `json:"size,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type packetCapture struct {
+	roomName livekit.RoomName
+	identity livekit.ParticipantIdentity
+	capture  *pcap.Capture
+	factory  *buffer.Factory
+}
+
+func (c *packetCapture) info() PacketCaptureInfo {
+	info := PacketCaptureInfo{
+		Room:     string(c.roomName),
+		Identity: string(c.identity),
+		Filename: c.capture.Filename(),
+	}
+	select {
+	case <-c.capture.Done():
+		res := c.capture.Result()
+		info.StartedAt = res.StartedAt.UnixNano()
+		info.EndedAt = res.EndedAt.UnixNano()
+		info.Packets = res.Packets
+		info.Dropped = res.Dropped
+		info.Size = res.Size
+		info.Reason = string(res.Reason)
+		if res.Error != nil {
+			info.Error = res.Error.Error()
+		}
+	default:
+		info.StartedAt = c.capture.StartedAt().UnixNano()
+	}
+	return info
+}
+
+// PacketCaptureService lets room admins start (POST), list (GET ?room=) and stop (DELETE ?room=&identity=)
+// captures of decrypted RTP/RTCP packets of a participant, written to pcapng files, at /admin/packet_capture.
+// A participant has a single capture, starting another one replaces it. Captures are bounded by the configured
+// duration and size, and keep running until then when the participant leaves.
+// Captures run on the node hosting the room, other nodes forward requests to it.
+type PacketCaptureService struct {
+	conf      config.PacketCaptureConfig
+	rooms     RoomProvider
+	forwarder *RoomForwarder
+
+	lock     sync.Mutex
+	captures map[livekit.RoomName]map[livekit.ParticipantIdentity]*packetCapture
+}
+
+func NewPacketCaptureService(
+	conf *config.Config,
+	rooms RoomProvider,
+	forwarder *RoomForwarder,
+) *PacketCaptureService {
+	return &PacketCaptureService{
+		conf:      conf.PacketCapture,
+		rooms:     rooms,
+		forwarder: forwarder,
+		captures:  make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*packetCapture),
+	}
+}
+
+func (s *PacketCaptureService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.startCapture(w, r)
+	case http.MethodGet:
+		s.listCaptures(w, r)
+	case http.MethodDelete:
+		s.stopCapture(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// Stop completes all captures, so their files are readable
+func (s *PacketCaptureService) Stop() {
+	s.lock.Lock()
+	var captures []*packetCapture
+	for _, roomCaptures := range s.captures {
+		for _, c := range roomCaptures {
+			captures = append(captures, c)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, c := range captures {
+		c.capture.Stop()
+		<-c.capture.Done()
+	}
+}
+
+func (s *PacketCaptureService) startCapture(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPacketCaptureRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req StartPacketCaptureRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	roomName := livekit.RoomName(req.Room)
+	identity := livekit.ParticipantIdentity(req.Identity)
+	switch {
+	case roomName == "":
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	case identity == "":
+		handleError(w, http.StatusBadRequest, errPacketCaptureIdentity)
+		return
+	case req.PayloadBytes < pcap.PayloadFull:
+		handleError(w, http.StatusBadRequest, errPacketCaptureInvalidPayload)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+	if s.conf.OutputDir == "" {
+		handleError(w, http.StatusNotImplemented, errPacketCaptureNotEnabled)
+		return
+	}
+
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		handleError(w, http.StatusNotFound, ErrParticipantNotFound, "room", roomName, "identity", identity)
+		return
+	}
+
+	duration := s.conf.MaxDuration
+	if d := time.Duration(req.Duration) * time.Second; d > 0 && (duration == 0 || d < duration) {
+		duration = d
+	}
+	maxSize := s.conf.MaxSize
+	if req.MaxSize > 0 && (maxSize == 0 || req.MaxSize < maxSize) {
+		maxSize = req.MaxSize
+	}
+
+	// IDs are safe to use in file names, unlike room names and identities
+	filename := fmt.Sprintf("%s-%s-%s.pcapng", room.ID(), participant.ID(), time.Now().UTC().Format("2006-01-02T150405"))
+	l := rtc.LoggerWithParticipant(rtc.LoggerWithRoom(logger.GetLogger(), roomName, room.ID()), identity, participant.ID(), false)
+	capture, err := pcap.NewCapture(pcap.Params{
+		Path:         filepath.Join(s.conf.OutputDir, filename),
+		Duration:     duration,
+		MaxSize:      maxSize,
+		PayloadBytes: req.PayloadBytes,
+		Logger:       l,
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "identity", identity)
+		return
+	}
+
+	c := &packetCapture{
+		roomName: roomName,
+		identity: identity,
+		capture:  capture,
+		factory:  participant.GetBufferFactory(),
+	}
+	capture.OnStop(func(res pcap.Result) {
+		s.captureStopped(c, res, l)
+	})
+
+	s.lock.Lock()
+	roomCaptures := s.captures[roomName]
+	if roomCaptures == nil {
+		roomCaptures = make(map[livekit.ParticipantIdentity]*packetCapture)
+		s.captures[roomName] = roomCaptures
+	}
+	existing := roomCaptures[identity]
+	roomCaptures[identity] = c
+	s.lock.Unlock()
+	if existing != nil {
+		existing.capture.Stop()
+	}
+
+	capture.Start()
+	c.factory.SetPacketCapture(capture)
+	l.Infow("packet capture started", "filename", capture.Filename(), "duration", duration, "maxSize", maxSize)
+
+	writeJSON(w, c.info())
+}
+
+func (s *PacketCaptureService) listCaptures(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+	if s.rooms.GetRoom(r.Context(), roomName) == nil && s.forwarder.forward(w, r, roomName) {
+		return
+	}
+
+	s.lock.Lock()
+	captures := make([]PacketCaptureInfo, 0, len(s.captures[roomName]))
+	for _, c := range s.captures[roomName] {
+		captures = append(captures, c.info())
+	}
+	s.lock.Unlock()
+	sort.Slice(captures, func(i, j int) bool {
+		return captures[i].Identity < captures[j].Identity
+	})
+
+	writeJSON(w, struct {
+		Captures []PacketCaptureInfo `json:"captures"`
+	}{Captures: captures})
+}
+
+func (s *PacketCaptureService) stopCapture(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	identity := livekit.ParticipantIdentity(r.URL.Query().Get("identity"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if identity == "" {
+		handleError(w, http.StatusBadRequest, errPacketCaptureIdentity)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	s.lock.Lock()
+	c := s.captures[roomName][identity]
+	s.lock.Unlock()
+	if c == nil {
+		if s.rooms.GetRoom(r.Context(), roomName) != nil || !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, errPacketCaptureNotFound, "room", roomName, "identity", identity)
+		}
+		return
+	}
+
+	c.capture.Stop()
+	select {
+	case <-c.capture.Done():
+	case <-r.Context().Done():
+		handleError(w, http.StatusRequestTimeout, r.Context().Err(), "room", roomName, "identity", identity)
+		return
+	}
+	writeJSON(w, c.info())
+}
+
+func (s *PacketCaptureService) captureStopped(c *packetCapture, res pcap.Result, l logger.Logger) {
+	// a newer capture of the participant keeps capturing
+	c.factory.ClearPacketCapture(c.capture)
+
+	s.lock.Lock()
+	roomCaptures := s.captures[c.roomName]
+	if roomCaptures[c.identity] == c {
+		delete(roomCaptures, c.identity)
+		if len(roomCaptures) == 0 {
+			delete(s.captures, c.roomName)
+		}
+	}
+	s.lock.Unlock()
+
+	l.Infow("packet capture stopped",
+		"filename", res.Filename,
+		"reason", res.Reason,
+		"packets", res.Packets,
+		"dropped", res.Dropped,
+		"size", res.Size,
+		"error", res.Error,
+	)
+}
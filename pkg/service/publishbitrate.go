@@ -0,0 +1,204 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	PublishBitratePath = "/admin/publish_bitrate"
+
+	maxPublishBitrateRequestSize = 4 * 1024
+)
+
+var (
+	errPublishBitrateIdentity = errors.New("identity is required")
+	errPublishBitrateNegative = errors.New("max_bitrate must be at least 0")
+)
+
+type SetPublishBitrateRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// video track to cap, all video tracks of the participant when empty
+	Track string `json:"track,omitempty"`
+	// bits per second, 0 removes the cap
+	MaxBitrate int64 `json:"max_bitrate"`
+}
+
+type PublishBitrateInfo struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// set by admins for all video tracks of the participant
+	MaxBitrate int64                     `json:"max_bitrate,omitempty"`
+	Tracks     []PublishBitrateTrackInfo `json:"tracks"`
+}
+
+type PublishBitrateTrackInfo struct {
+	Track string `json:"track"`
+	// set by admins for the track
+	MaxBitrate int64 `json:"max_bitrate,omitempty"`
+	// in effect, combining caps of admins, grants and room configuration
+	Bitrate int64 `json:"bitrate,omitempty"`
+}
+
+// PublishBitrateService lets room admins cap (POST) and inspect (GET ?room=&identity=) upstream video bitrate
+// of a participant at /admin/publish_bitrate. Caps are enforced with REMB/TMMBR feedback to the publisher,
+// the lowest of the admin cap, the publishBitrate grant of the participant and room configuration applies.
+// Caps last until the participant leaves, other nodes forward requests to the node hosting the room.
+type PublishBitrateService struct {
+	rooms     RoomProvider
+	forwarder *RoomForwarder
+}
+
+func NewPublishBitrateService(rooms RoomProvider, forwarder *RoomForwarder) *PublishBitrateService {
+	return &PublishBitrateService{
+		rooms:     rooms,
+		forwarder: forwarder,
+	}
+}
+
+func (s *PublishBitrateService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.setPublishBitrate(w, r)
+	case http.MethodGet:
+		s.getPublishBitrate(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *PublishBitrateService) setPublishBitrate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPublishBitrateRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req SetPublishBitrateRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	roomName := livekit.RoomName(req.Room)
+	identity := livekit.ParticipantIdentity(req.Identity)
+	switch {
+	case roomName == "":
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	case identity == "":
+		handleError(w, http.StatusBadRequest, errPublishBitrateIdentity)
+		return
+	case req.MaxBitrate < 0:
+		handleError(w, http.StatusBadRequest, errPublishBitrateNegative)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		handleError(w, http.StatusNotFound, ErrParticipantNotFound, "room", roomName, "identity", identity)
+		return
+	}
+
+	if err = participant.SetPublishBitrateCap(livekit.TrackID(req.Track), req.MaxBitrate); err != nil {
+		if errors.Is(err, rtc.ErrTrackNotFound) {
+			handleError(w, http.StatusNotFound, ErrTrackNotFound, "room", roomName, "identity", identity, "trackID", req.Track)
+		} else {
+			handleError(w, http.StatusInternalServerError, err, "room", roomName, "identity", identity, "trackID", req.Track)
+		}
+		return
+	}
+
+	writeJSON(w, publishBitrateInfo(roomName, participant))
+}
+
+func (s *PublishBitrateService) getPublishBitrate(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	identity := livekit.ParticipantIdentity(r.URL.Query().Get("identity"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if identity == "" {
+		handleError(w, http.StatusBadRequest, errPublishBitrateIdentity)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		handleError(w, http.StatusNotFound, ErrParticipantNotFound, "room", roomName, "identity", identity)
+		return
+	}
+
+	writeJSON(w, publishBitrateInfo(roomName, participant))
+}
+
+func publishBitrateInfo(roomName livekit.RoomName, participant types.LocalParticipant) PublishBitrateInfo {
+	caps := participant.GetPublishBitrateCaps()
+	info := PublishBitrateInfo{
+		Room:       string(roomName),
+		Identity:   string(participant.Identity()),
+		MaxBitrate: caps.MaxBitrate,
+		Tracks:     make([]PublishBitrateTrackInfo, 0, len(caps.TrackBitrates)),
+	}
+	for trackID, bitrate := range caps.TrackBitrates {
+		info.Tracks = append(info.Tracks, PublishBitrateTrackInfo{
+			Track:      string(trackID),
+			MaxBitrate: caps.MaxTrackBitrates[trackID],
+			Bitrate:    bitrate,
+		})
+	}
+	sort.Slice(info.Tracks, func(i, j int) bool {
+		return info.Tracks[i].Track < info.Tracks[j].Track
+	})
+	return info
+}
@@ -0,0 +1,416 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/recorder"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// RoomService methods of the built-in recorder. They are not part of the protocol version in use,
+// and are served next to the generated RoomService server, taking the messages of track egress.
+const (
+	StartTrackRecordingPath = "/twirp/livekit.RoomService/StartTrackRecording"
+	StopTrackRecordingPath  = "/twirp/livekit.RoomService/StopTrackRecording"
+
+	defaultRecordingFilepath = "{room_name}-{track_id}-{time}"
+	maxRecordingRequestSize  = 64 * 1024
+)
+
+var (
+	ErrRecorderNotEnabled     = psrpc.NewErrorf(psrpc.Unimplemented, "recorder is not enabled")
+	ErrRecordingOutput        = psrpc.NewErrorf(psrpc.InvalidArgument, "recordings can only be written to a file")
+	ErrRecordingFilepath      = psrpc.NewErrorf(psrpc.InvalidArgument, "filepath must be within the recorder output directory")
+	ErrRecordingTrackNotReady = psrpc.NewErrorf(psrpc.FailedPrecondition, "track is not receiving media")
+)
+
+type trackRecording struct {
+	recorder *recorder.TrackRecorder
+	track    types.MediaTrack
+
+	lock sync.Mutex
+	info *livekit.EgressInfo
+}
+
+func (t *trackRecording) Info() *livekit.EgressInfo {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return proto.Clone(t.info).(*livekit.EgressInfo)
+}
+
+// RecorderService records tracks of rooms hosted by this node to local files, as a lightweight alternative to
+// track egress. Recordings are reported as egresses, through egress webhooks and the egress store when available.
+type RecorderService struct {
+	conf      config.RecorderConfig
+	rooms     RoomProvider
+	store     EgressStore
+	telemetry telemetry.TelemetryService
+	forwarder *RoomForwarder
+
+	lock       sync.Mutex
+	recordings map[string]*trackRecording
+}
+
+func NewRecorderService(
+	conf *config.Config,
+	rooms RoomProvider,
+	store EgressStore,
+	ts telemetry.TelemetryService,
+	forwarder *RoomForwarder,
+) *RecorderService {
+	return &RecorderService{
+		conf:       conf.Recorder,
+		rooms:      rooms,
+		store:      store,
+		telemetry:  ts,
+		forwarder:  forwarder,
+		recordings: make(map[string]*trackRecording),
+	}
+}
+
+func (s *RecorderService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		_ = twirp.WriteError(w, twirp.NewErrorf(twirp.BadRoute, "unsupported method %s", r.Method))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRecordingRequestSize))
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.Malformed, err.Error()))
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var (
+		req      proto.Message
+		roomName livekit.RoomName
+	)
+	switch r.URL.Path {
+	case StartTrackRecordingPath:
+		start := &livekit.TrackEgressRequest{}
+		if err = unmarshalTwirpRequest(r, body, start); err == nil {
+			req, roomName = start, livekit.RoomName(start.RoomName)
+		}
+	case StopTrackRecordingPath:
+		stop := &livekit.StopEgressRequest{}
+		if err = unmarshalTwirpRequest(r, body, stop); err == nil {
+			req, roomName = stop, s.recordingRoom(r.Context(), stop.EgressId)
+		}
+	default:
+		_ = twirp.WriteError(w, twirp.NewErrorf(twirp.BadRoute, "no handler for path %s", r.URL.Path))
+		return
+	}
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.Malformed, err.Error()))
+		return
+	}
+
+	if err = EnsureRecordPermission(r.Context()); err != nil {
+		_ = twirp.WriteError(w, twirpAuthError(err))
+		return
+	}
+
+	if roomName != "" && s.rooms.GetRoom(r.Context(), roomName) == nil && s.forwarder.forward(w, r, roomName) {
+		return
+	}
+
+	var info *livekit.EgressInfo
+	switch req := req.(type) {
+	case *livekit.TrackEgressRequest:
+		info, err = s.StartTrackRecording(r.Context(), req)
+	case *livekit.StopEgressRequest:
+		info, err = s.StopTrackRecording(r.Context(), req)
+	}
+	if err != nil {
+		_ = twirp.WriteError(w, err)
+		return
+	}
+	writeTwirpResponse(w, r, info)
+}
+
+func (s *RecorderService) StartTrackRecording(ctx context.Context, req *livekit.TrackEgressRequest) (*livekit.EgressInfo, error) {
+	if s.conf.OutputDir == "" {
+		return nil, ErrRecorderNotEnabled
+	}
+	if req.RoomName == "" {
+		return nil, twirp.RequiredArgumentError("room_name")
+	}
+	if req.TrackId == "" {
+		return nil, twirp.RequiredArgumentError("track_id")
+	}
+	var filepathTemplate string
+	switch o := req.Output.(type) {
+	case nil:
+	case *livekit.TrackEgressRequest_File:
+		if o.File.GetS3() != nil || o.File.GetGcp() != nil || o.File.GetAzure() != nil || o.File.GetAliOSS() != nil {
+			return nil, ErrRecordingOutput
+		}
+		filepathTemplate = o.File.Filepath
+	default:
+		return nil, ErrRecordingOutput
+	}
+
+	room := s.rooms.GetRoom(ctx, livekit.RoomName(req.RoomName))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	var track types.MediaTrack
+	for _, p := range room.GetParticipants() {
+		if track = p.GetPublishedTrack(livekit.TrackID(req.TrackId)); track != nil {
+			break
+		}
+	}
+	if track == nil {
+		return nil, ErrTrackNotFound
+	}
+	// primary codec of the track
+	receivers := track.Receivers()
+	if len(receivers) == 0 {
+		return nil, ErrRecordingTrackNotReady
+	}
+
+	now := time.Now()
+	path, err := s.recordingPath(filepathTemplate, room, track, now)
+	if err != nil {
+		return nil, err
+	}
+
+	egressID := utils.NewGuid(utils.EgressPrefix)
+	l := rtc.LoggerWithTrack(rtc.LoggerWithRoom(logger.GetLogger(), room.Name(), room.ID()), track.ID(), false).
+		WithValues("egressID", egressID)
+	rec, err := recorder.NewTrackRecorder(recorder.Params{
+		ID:       egressID,
+		Receiver: receivers[0],
+		Path:     path,
+		Logger:   l,
+	})
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+
+	recording := &trackRecording{
+		recorder: rec,
+		track:    track,
+		info: &livekit.EgressInfo{
+			EgressId:  egressID,
+			RoomId:    string(room.ID()),
+			RoomName:  string(room.Name()),
+			Status:    livekit.EgressStatus_EGRESS_ACTIVE,
+			StartedAt: now.UnixNano(),
+			UpdatedAt: now.UnixNano(),
+			Request:   &livekit.EgressInfo_Track{Track: req},
+			FileResults: []*livekit.FileInfo{{
+				Filename:  rec.Filename(),
+				StartedAt: now.UnixNano(),
+				Location:  rec.Filename(),
+			}},
+		},
+	}
+	rec.OnEnd(func(res recorder.Result) {
+		s.recordingEnded(recording, res)
+	})
+
+	s.lock.Lock()
+	s.recordings[egressID] = recording
+	s.lock.Unlock()
+
+	if err = rec.Start(); err != nil {
+		return nil, psrpc.NewError(psrpc.Internal, err)
+	}
+	s.setTrackQuality(recording, livekit.VideoQuality_HIGH)
+	l.Infow("recording track", "filename", rec.Filename())
+
+	info := recording.Info()
+	if s.store != nil {
+		if err = s.store.StoreEgress(ctx, info); err != nil {
+			l.Warnw("could not store recording", err)
+		}
+	}
+	s.telemetry.EgressStarted(context.Background(), info)
+	return info, nil
+}
+
+// StopTrackRecording completes a recording, returning its final info
+func (s *RecorderService) StopTrackRecording(ctx context.Context, req *livekit.StopEgressRequest) (*livekit.EgressInfo, error) {
+	if req.EgressId == "" {
+		return nil, twirp.RequiredArgumentError("egress_id")
+	}
+
+	s.lock.Lock()
+	recording := s.recordings[req.EgressId]
+	s.lock.Unlock()
+	if recording == nil {
+		return nil, ErrEgressNotFound
+	}
+
+	recording.recorder.Stop()
+	select {
+	case <-recording.recorder.Done():
+	case <-ctx.Done():
+		return nil, psrpc.NewError(psrpc.DeadlineExceeded, ctx.Err())
+	}
+	return recording.Info(), nil
+}
+
+// Stop completes all recordings
+func (s *RecorderService) Stop() {
+	s.lock.Lock()
+	recordings := make([]*trackRecording, 0, len(s.recordings))
+	for _, recording := range s.recordings {
+		recordings = append(recordings, recording)
+	}
+	s.lock.Unlock()
+
+	for _, recording := range recordings {
+		recording.recorder.Stop()
+		<-recording.recorder.Done()
+	}
+}
+
+func (s *RecorderService) recordingEnded(recording *trackRecording, res recorder.Result) {
+	s.lock.Lock()
+	delete(s.recordings, recording.recorder.ID())
+	s.lock.Unlock()
+	s.setTrackQuality(recording, livekit.VideoQuality_OFF)
+
+	recording.lock.Lock()
+	info := recording.info
+	info.EndedAt = res.EndedAt.UnixNano()
+	info.UpdatedAt = info.EndedAt
+	if res.Error != nil {
+		info.Status = livekit.EgressStatus_EGRESS_FAILED
+		info.Error = res.Error.Error()
+	} else {
+		info.Status = livekit.EgressStatus_EGRESS_COMPLETE
+	}
+	file := info.FileResults[0]
+	file.EndedAt = info.EndedAt
+	file.Duration = res.EndedAt.Sub(res.StartedAt).Nanoseconds()
+	file.Size = res.Size
+	recording.lock.Unlock()
+
+	info = recording.Info()
+	if s.store != nil {
+		if err := s.store.UpdateEgress(context.Background(), info); err != nil {
+			logger.Warnw("could not update recording", err, "egressID", info.EgressId)
+		}
+	}
+	s.telemetry.EgressEnded(context.Background(), info)
+}
+
+// setTrackQuality keeps layers of the track published with dynacast while recording, as if a subscriber wanted them
+func (s *RecorderService) setTrackQuality(recording *trackRecording, quality livekit.VideoQuality) {
+	track, ok := recording.track.(types.LocalMediaTrack)
+	if !ok || track.Kind() != livekit.TrackType_VIDEO {
+		return
+	}
+	track.NotifySubscriberNodeMaxQuality(livekit.NodeID(recording.recorder.ID()), []types.SubscribedCodecQuality{{
+		CodecMime: recording.recorder.MimeType(),
+		Quality:   quality,
+	}})
+}
+
+// recordingRoom finds the room of a recording, which may be on another node
+func (s *RecorderService) recordingRoom(ctx context.Context, egressID string) livekit.RoomName {
+	s.lock.Lock()
+	recording := s.recordings[egressID]
+	s.lock.Unlock()
+	if recording != nil {
+		return livekit.RoomName(recording.info.RoomName)
+	}
+
+	if s.store == nil || egressID == "" {
+		return ""
+	}
+	info, err := s.store.LoadEgress(ctx, egressID)
+	if err != nil {
+		return ""
+	}
+	return livekit.RoomName(info.RoomName)
+}
+
+// recordingPath expands the filepath template of a request, within the output directory
+func (s *RecorderService) recordingPath(template string, room *rtc.Room, track types.MediaTrack, now time.Time) (string, error) {
+	if template == "" {
+		template = defaultRecordingFilepath
+	}
+	name := strings.NewReplacer(
+		"{room_name}", string(room.Name()),
+		"{room_id}", string(room.ID()),
+		"{track_id}", string(track.ID()),
+		"{track_type}", strings.ToLower(track.Kind().String()),
+		"{publisher_identity}", string(track.PublisherIdentity()),
+		"{time}", now.Format("2006-01-02T150405"),
+	).Replace(template)
+	if !filepath.IsLocal(name) {
+		return "", ErrRecordingFilepath
+	}
+	return filepath.Join(s.conf.OutputDir, name), nil
+}
+
+func unmarshalTwirpRequest(r *http.Request, body []byte, req proto.Message) error {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case "application/protobuf":
+		return proto.Unmarshal(body, req)
+	case "application/json":
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, req)
+	default:
+		return fmt.Errorf("unexpected Content-Type: %q", r.Header.Get("Content-Type"))
+	}
+}
+
+func writeTwirpResponse(w http.ResponseWriter, r *http.Request, res proto.Message) {
+	var (
+		b   []byte
+		err error
+	)
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType == "application/protobuf" {
+		b, err = proto.Marshal(res)
+	} else {
+		b, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(res)
+	}
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.InternalErrorWith(err))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(b)
+}
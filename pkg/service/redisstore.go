@@ -39,6 +39,8 @@ const (
 	// RoomsKey is hash of room_name => Room proto
 	RoomsKey        = "rooms"
 	RoomInternalKey = "room_internal"
+	// RoomMetadataVersionKey is hash of room_name => version of room metadata
+	RoomMetadataVersionKey = "room_metadata_version"
 
 	// EgressKey is a hash of egressID => egress info
 	EgressKey        = "egress"
@@ -229,12 +231,25 @@ func (s *RedisStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 	pp := s.rc.Pipeline()
 	pp.HDel(s.ctx, RoomsKey, string(roomName))
 	pp.HDel(s.ctx, RoomInternalKey, string(roomName))
+	pp.HDel(s.ctx, RoomMetadataVersionKey, string(roomName))
 	pp.Del(s.ctx, RoomParticipantsPrefix+string(roomName))
 
 	_, err = pp.Exec(s.ctx)
 	return err
 }
 
+func (s *RedisStore) LoadRoomMetadataVersion(_ context.Context, roomName livekit.RoomName) (uint64, error) {
+	version, err := s.rc.HGet(s.ctx, RoomMetadataVersionKey, string(roomName)).Uint64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (s *RedisStore) StoreRoomMetadataVersion(_ context.Context, roomName livekit.RoomName, version uint64) error {
+	return s.rc.HSet(s.ctx, RoomMetadataVersionKey, string(roomName), version).Err()
+}
+
 func (s *RedisStore) LockRoom(_ context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
 	token := utils.NewGuid("LOCK")
 	key := RoomLockPrefix + string(roomName)
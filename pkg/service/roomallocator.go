@@ -125,7 +125,16 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 			return nil, false, err
 		}
 
-		node, err := r.selector.SelectNode(nodes)
+		nodeLabels, err := r.router.ListNodeLabels()
+		if err != nil {
+			return nil, false, err
+		}
+
+		node, err := selector.SelectNodeForSession(r.selector, nodes, selector.SessionInfo{
+			RoomName:   livekit.RoomName(rm.Name),
+			ClientInfo: joiningClientFromContext(ctx),
+			NodeLabels: nodeLabels,
+		})
 		if err != nil {
 			return nil, false, err
 		}
@@ -153,6 +162,18 @@ func (r *StandardRoomAllocator) ValidateCreateRoom(ctx context.Context, roomName
 	return nil
 }
 
+type joiningClientKey struct{}
+
+// WithJoiningClient attaches info of the client whose join triggered room creation, for session aware node selectors
+func WithJoiningClient(ctx context.Context, clientInfo *livekit.ClientInfo) context.Context {
+	return context.WithValue(ctx, joiningClientKey{}, clientInfo)
+}
+
+func joiningClientFromContext(ctx context.Context) *livekit.ClientInfo {
+	clientInfo, _ := ctx.Value(joiningClientKey{}).(*livekit.ClientInfo)
+	return clientInfo
+}
+
 func applyDefaultRoomConfig(room *livekit.Room, internal *livekit.RoomInternal, conf *config.RoomConfig) {
 	room.EmptyTimeout = conf.EmptyTimeout
 	room.MaxParticipants = conf.MaxParticipants
@@ -0,0 +1,214 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/client"
+	"github.com/livekit/psrpc/pkg/info"
+	"github.com/livekit/psrpc/pkg/rand"
+	"github.com/livekit/psrpc/pkg/server"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+// RoomForwarder is a psrpc service, it is not part of the protocol version in use, so requests and responses are
+// JSON encoded in bytes values
+const (
+	roomForwarderService = "RoomForwarder"
+	roomForwarderMethod  = "ForwardRequest"
+
+	// long enough for WHIP and WHEP sessions to join and answer
+	roomForwarderTimeout    = 20 * time.Second
+	maxForwardedRequestSize = 1024 * 1024
+)
+
+type forwardedRequest struct {
+	Method string      `json:"method"`
+	URI    string      `json:"uri"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+type forwardedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+type forwardedKey struct{}
+
+// RoomForwarder sends admin HTTP requests about a room to the node hosting it over psrpc, for APIs that act on
+// room state held in memory by that node. The hosting node serves them with its own HTTP handler,
+// so requests are authenticated there again, and are not forwarded any further.
+type RoomForwarder struct {
+	router      routing.Router
+	currentNode routing.LocalNode
+	client      *client.RPCClient
+	server      *server.RPCServer
+}
+
+func NewRoomForwarder(router routing.Router, currentNode routing.LocalNode, bus psrpc.MessageBus) (*RoomForwarder, error) {
+	clientDefinition := &info.ServiceDefinition{
+		Name: roomForwarderService,
+		ID:   rand.NewClientID(),
+	}
+	clientDefinition.RegisterMethod(roomForwarderMethod, false, false, true, true)
+	rpcClient, err := client.NewRPCClient(clientDefinition, bus)
+	if err != nil {
+		return nil, err
+	}
+
+	serverDefinition := &info.ServiceDefinition{
+		Name: roomForwarderService,
+		ID:   rand.NewServerID(),
+	}
+	rpcServer := server.NewRPCServer(serverDefinition, bus)
+	serverDefinition.RegisterMethod(roomForwarderMethod, false, false, true, true)
+
+	return &RoomForwarder{
+		router:      router,
+		currentNode: currentNode,
+		client:      rpcClient,
+		server:      rpcServer,
+	}, nil
+}
+
+// Serve handles requests forwarded to this node with handler
+func (f *RoomForwarder) Serve(handler http.Handler) error {
+	return server.RegisterHandler(f.server, roomForwarderMethod, []string{f.currentNode.Id},
+		func(ctx context.Context, req *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+			return f.handleRequest(ctx, handler, req)
+		}, nil)
+}
+
+func (f *RoomForwarder) Stop() {
+	f.server.Close(false)
+	f.client.Close()
+}
+
+func (f *RoomForwarder) handleRequest(ctx context.Context, handler http.Handler, req *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+	var fr forwardedRequest
+	if err := json.Unmarshal(req.Value, &fr); err != nil {
+		return nil, psrpc.NewError(psrpc.MalformedRequest, err)
+	}
+	r, err := http.NewRequestWithContext(context.WithValue(ctx, forwardedKey{}, true), fr.Method, fr.URI, bytes.NewReader(fr.Body))
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.MalformedRequest, err)
+	}
+	if fr.Header != nil {
+		r.Header = fr.Header
+	}
+
+	w := &forwardedResponseWriter{
+		res: forwardedResponse{
+			Status: http.StatusOK,
+			Header: make(http.Header),
+		},
+	}
+	handler.ServeHTTP(w, r)
+
+	res, err := json.Marshal(w.res)
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.Internal, err)
+	}
+	return wrapperspb.Bytes(res), nil
+}
+
+// forward returns false when the room isn't hosted by another node
+func (f *RoomForwarder) forward(w http.ResponseWriter, r *http.Request, roomName livekit.RoomName) bool {
+	if f == nil || f.router == nil || r.Context().Value(forwardedKey{}) != nil {
+		return false
+	}
+	node, err := f.router.GetNodeForRoom(r.Context(), roomName)
+	if err != nil || node == nil || node.Id == f.currentNode.Id {
+		return false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxForwardedRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return true
+	}
+	req, err := json.Marshal(forwardedRequest{
+		Method: r.Method,
+		URI:    r.URL.RequestURI(),
+		Header: r.Header,
+		Body:   body,
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return true
+	}
+
+	res, err := client.RequestSingle[*wrapperspb.BytesValue](
+		r.Context(),
+		f.client,
+		roomForwarderMethod,
+		[]string{node.Id},
+		wrapperspb.Bytes(req),
+		psrpc.WithRequestTimeout(roomForwarderTimeout),
+	)
+	if err != nil {
+		handleError(w, http.StatusBadGateway, err, "room", roomName, "nodeID", node.Id)
+		return true
+	}
+	var fr forwardedResponse
+	if err = json.Unmarshal(res.Value, &fr); err != nil {
+		handleError(w, http.StatusBadGateway, err, "room", roomName, "nodeID", node.Id)
+		return true
+	}
+
+	// values set by the hosting node replace those set here, e. g. CORS headers
+	for key, values := range fr.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(fr.Status)
+	_, _ = w.Write(fr.Body)
+	return true
+}
+
+type forwardedResponseWriter struct {
+	res          forwardedResponse
+	isHeaderSent bool
+}
+
+func (w *forwardedResponseWriter) Header() http.Header {
+	return w.res.Header
+}
+
+func (w *forwardedResponseWriter) WriteHeader(status int) {
+	if w.isHeaderSent {
+		return
+	}
+	w.isHeaderSent = true
+	w.res.Status = status
+}
+
+func (w *forwardedResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.res.Body = append(w.res.Body, b...)
+	return len(b), nil
+}
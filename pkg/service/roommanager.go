@@ -40,6 +40,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
 const (
@@ -47,6 +48,7 @@ const (
 	tokenRefreshInterval = 5 * time.Minute
 	tokenDefaultTTL      = 10 * time.Minute
 	iceConfigTTL         = 5 * time.Minute
+	relayLockDuration    = 5 * time.Second
 )
 
 var affinityEpoch = time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
@@ -128,6 +130,7 @@ func NewLocalRoomManager(
 	// hook up to router
 	router.OnNewParticipantRTC(r.StartSession)
 	router.OnRTCMessage(r.handleRTCMessage)
+	router.OnRelayMessage(r.handleRelayMessage)
 	return r, nil
 }
 
@@ -144,6 +147,22 @@ func (r *RoomManager) deleteRoom(ctx context.Context, roomName livekit.RoomName)
 	delete(r.rooms, roomName)
 	r.lock.Unlock()
 
+	if r.config.Relay.Enabled {
+		// nodes joining the room concurrently must not have its state cleared
+		token, err := r.roomStore.LockRoom(ctx, relayLockName(roomName), relayLockDuration)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = r.roomStore.UnlockRoom(ctx, relayLockName(roomName), token)
+		}()
+
+		retain, err := r.leaveDistributedRoom(ctx, roomName)
+		if err != nil || retain {
+			return err
+		}
+	}
+
 	var err, err2 error
 	wg := sync.WaitGroup{}
 	wg.Add(2)
@@ -381,37 +400,34 @@ func (r *RoomManager) StartSession(
 		subscriberAllowPause = *pi.SubscriberAllowPause
 	}
 	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
-		Identity:                pi.Identity,
-		Name:                    pi.Name,
-		SID:                     sid,
-		Config:                  &rtcConf,
-		Sink:                    responseSink,
-		AudioConfig:             r.config.Audio,
-		VideoConfig:             r.config.Video,
-		ProtocolVersion:         pv,
-		Telemetry:               r.telemetry,
-		Trailer:                 room.Trailer(),
-		PLIThrottleConfig:       r.config.RTC.PLIThrottle,
-		CongestionControlConfig: r.config.RTC.CongestionControl,
-		EnabledCodecs:           protoRoom.EnabledCodecs,
-		Grants:                  pi.Grants,
-		Logger:                  pLogger,
-		ClientConf:              clientConf,
-		ClientInfo:              rtc.ClientInfo{ClientInfo: pi.Client},
-		Region:                  pi.Region,
-		AdaptiveStream:          pi.AdaptiveStream,
-		AllowTCPFallback:        allowFallback,
-		TURNSEnabled:            r.config.IsTURNSEnabled(),
-		GetParticipantInfo: func(pID livekit.ParticipantID) *livekit.ParticipantInfo {
-			if p := room.GetParticipantByID(pID); p != nil {
-				return p.ToProto()
-			}
-			return nil
-		},
+		Identity:                     pi.Identity,
+		Name:                         pi.Name,
+		SID:                          sid,
+		Config:                       &rtcConf,
+		Sink:                         responseSink,
+		AudioConfig:                  r.config.Audio,
+		VideoConfig:                  r.config.Video,
+		ProtocolVersion:              pv,
+		Telemetry:                    r.telemetry,
+		Trailer:                      room.Trailer(),
+		PLIThrottleConfig:            r.config.RTC.PLIThrottle,
+		KeyFrameCacheConfig:          r.config.RTC.KeyFrameCache,
+		CongestionControlConfig:      r.config.RTC.CongestionControl,
+		EnabledCodecs:                protoRoom.EnabledCodecs,
+		Grants:                       pi.Grants,
+		Logger:                       pLogger,
+		ClientConf:                   clientConf,
+		ClientInfo:                   rtc.ClientInfo{ClientInfo: pi.Client},
+		Region:                       pi.Region,
+		AdaptiveStream:               pi.AdaptiveStream,
+		AllowTCPFallback:             allowFallback,
+		TURNSEnabled:                 r.config.IsTURNSEnabled(),
+		GetParticipantInfo:           room.GetParticipantInfoByID,
 		ReconnectOnPublicationError:  reconnectOnPublicationError,
 		ReconnectOnSubscriptionError: reconnectOnSubscriptionError,
 		ReconnectOnDataChannelError:  reconnectOnDataChannelError,
 		DataChannelMaxBufferedAmount: r.config.RTC.DataChannelMaxBufferedAmount,
+		DataLimits:                   r.config.RTC.DataLimits,
 		VersionGenerator:             r.versionGenerator,
 		TrackResolver:                room.ResolveMediaTrackForSubscriber,
 		SubscriberAllowPause:         subscriberAllowPause,
@@ -419,6 +435,11 @@ func (r *RoomManager) StartSession(
 		SubscriptionLimitVideo:       r.config.Limit.SubscriptionLimitVideo,
 		PlayoutDelay:                 roomInternal.GetPlayoutDelay(),
 		SyncStreams:                  roomInternal.GetSyncStreams(),
+		DataTopics:                   pi.DataTopics,
+		PublishBitrate:               pi.PublishBitrate,
+		PublishBitrateConfig:         r.config.Room.PublishBitrate,
+		GetSubscriptionPriority:      room.GetSubscriptionPolicyPriority,
+		ReceiveOnly:                  pi.ReceiveOnly,
 	})
 	if err != nil {
 		return err
@@ -526,7 +547,7 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	}
 
 	// construct ice servers
-	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfig, &r.config.Audio, r.serverInfo, r.telemetry, r.egressLauncher)
+	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfig, &r.config.Audio, &r.config.Room, r.serverInfo, r.telemetry, r.egressLauncher)
 
 	roomTopic := rpc.FormatRoomTopic(roomName)
 	roomServer := utils.Must(rpc.NewTypedRoomServer(r, r.bus))
@@ -568,6 +589,10 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 
 	r.lock.Unlock()
 
+	if r.config.Relay.Enabled {
+		r.joinDistributedRoom(ctx, newRoom)
+	}
+
 	newRoom.Hold()
 
 	r.telemetry.RoomStarted(ctx, newRoom.ToProto())
@@ -707,7 +732,16 @@ func (r *RoomManager) UpdateParticipant(ctx context.Context, req *livekit.Update
 
 	participant.GetLogger().Debugw("updating participant",
 		"metadata", req.Metadata, "permission", req.Permission)
+	attributes := rtc.GetUpdateParticipantRequestAttributes(req)
+	if err := room.ValidateParticipantAttributes(participant, attributes); err != nil {
+		participant.GetLogger().Warnw("could not update attributes", err)
+		return nil, ErrAttributesExceedLimits
+	}
 	room.UpdateParticipantMetadata(participant, req.Name, req.Metadata)
+	if err := room.UpdateParticipantAttributes(participant, attributes); err != nil {
+		participant.GetLogger().Warnw("could not update attributes", err)
+		return nil, ErrAttributesExceedLimits
+	}
 	if req.Permission != nil {
 		participant.SetPermission(req.Permission)
 	}
@@ -907,3 +941,83 @@ func iceServerForStunServers(servers []string) *livekit.ICEServer {
 	}
 	return iceServer
 }
+
+// joinDistributedRoom registers the current node as hosting the room and relays tracks to other nodes hosting it
+func (r *RoomManager) joinDistributedRoom(ctx context.Context, room *rtc.Room) {
+	token, err := r.roomStore.LockRoom(ctx, relayLockName(room.Name()), relayLockDuration)
+	if err != nil {
+		room.Logger.Errorw("could not lock room", err)
+		return
+	}
+	defer func() {
+		_ = r.roomStore.UnlockRoom(ctx, relayLockName(room.Name()), token)
+	}()
+
+	nodeID := livekit.NodeID(r.currentNode.Id)
+	if err := r.router.AddNodeForRoom(ctx, room.Name(), nodeID); err != nil {
+		room.Logger.Errorw("could not add node for room", err)
+	}
+
+	rtcConf := *r.rtcConfig
+	relay := rtc.NewRoomRelay(rtc.RoomRelayParams{
+		Room:                    room,
+		NodeID:                  nodeID,
+		Router:                  r.router,
+		Config:                  rtcConf,
+		CongestionControlConfig: r.config.RTC.CongestionControl,
+		AudioConfig:             r.config.Audio,
+		VideoConfig:             r.config.Video,
+		PLIThrottleConfig:       r.config.RTC.PLIThrottle,
+		KeyFrameCacheConfig:     r.config.RTC.KeyFrameCache,
+		Telemetry:               r.telemetry,
+		Logger:                  room.Logger.WithComponent(sutils.ComponentRelay),
+	})
+	room.SetRelay(relay)
+
+	nodeIDs, err := r.router.ListNodesForRoom(ctx, room.Name())
+	if err != nil {
+		room.Logger.Errorw("could not list nodes for room", err)
+		return
+	}
+	relay.Start(nodeIDs)
+}
+
+// leaveDistributedRoom unregisters the current node, returns true when other nodes are still hosting the room.
+// it is called with the relay lock held
+func (r *RoomManager) leaveDistributedRoom(ctx context.Context, roomName livekit.RoomName) (bool, error) {
+	nodeID := livekit.NodeID(r.currentNode.Id)
+	if err := r.router.RemoveNodeForRoom(ctx, roomName, nodeID); err != nil {
+		return false, err
+	}
+
+	nodeIDs, err := r.router.ListNodesForRoom(ctx, roomName)
+	if err != nil {
+		return false, err
+	}
+	if len(nodeIDs) == 0 {
+		return false, nil
+	}
+
+	// hand the room over to a node still hosting it
+	node, err := r.router.GetNodeForRoom(ctx, roomName)
+	if err == nil && livekit.NodeID(node.Id) == nodeID {
+		err = r.router.SetNodeForRoom(ctx, roomName, nodeIDs[0])
+	}
+	return true, err
+}
+
+// nodes hosting a distributed room are added and removed under a lock separate from the one taken by CreateRoom
+func relayLockName(roomName livekit.RoomName) livekit.RoomName {
+	return roomName + "|relay"
+}
+
+func (r *RoomManager) handleRelayMessage(msg *routing.RelayMessage) {
+	room := r.GetRoom(context.Background(), msg.RoomName)
+	if room == nil {
+		return
+	}
+
+	if relay := room.Relay(); relay != nil {
+		relay.HandleMessage(msg)
+	}
+}
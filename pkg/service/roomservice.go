@@ -40,7 +40,7 @@ type RoomService struct {
 	psrpcConf         rpc.PSRPCConfig
 	router            routing.MessageRouter
 	roomAllocator     RoomAllocator
-	roomStore         ServiceStore
+	roomStore         ObjectStore
 	egressLauncher    rtc.EgressLauncher
 	topicFormatter    rpc.TopicFormatter
 	roomClient        rpc.TypedRoomClient
@@ -53,7 +53,7 @@ func NewRoomService(
 	psrpcConf rpc.PSRPCConfig,
 	router routing.MessageRouter,
 	roomAllocator RoomAllocator,
-	serviceStore ServiceStore,
+	objectStore ObjectStore,
 	egressLauncher rtc.EgressLauncher,
 	topicFormatter rpc.TopicFormatter,
 	roomClient rpc.TypedRoomClient,
@@ -65,7 +65,7 @@ func NewRoomService(
 		psrpcConf:         psrpcConf,
 		router:            router,
 		roomAllocator:     roomAllocator,
-		roomStore:         serviceStore,
+		roomStore:         objectStore,
 		egressLauncher:    egressLauncher,
 		topicFormatter:    topicFormatter,
 		roomClient:        roomClient,
@@ -316,6 +316,10 @@ func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.Update
 	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
 		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
 	}
+	attributes := rtc.GetUpdateParticipantRequestAttributes(req)
+	if err := rtc.AttributeLimitsFromConfig(&s.roomConf).ValidateUpdate(attributes); err != nil {
+		return nil, twirp.InvalidArgumentError(ErrAttributesExceedLimits.Error(), err.Error())
+	}
 
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
@@ -349,6 +353,15 @@ func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.Update
 			detailedError = fmt.Errorf("permissions do not match, expected: %v, actual: %v", req.Permission, participant.Permission)
 			return ErrOperationFailed
 		}
+		if len(attributes) != 0 {
+			current := rtc.GetParticipantInfoAttributes(participant)
+			for k, v := range attributes {
+				if current[k] != v {
+					detailedError = fmt.Errorf("attribute %s does not match", k)
+					return ErrOperationFailed
+				}
+			}
+		}
 		return nil
 	})
 	if err != nil {
@@ -420,10 +433,40 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 		return nil, twirpAuthError(err)
 	}
 
-	room, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
+	roomName := livekit.RoomName(req.Room)
+	room, _, err := s.roomStore.LoadRoom(ctx, roomName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var expectedVersion uint64
+	expected, hasExpected := GetExpectedMetadataVersion(ctx)
+	if hasExpected {
+		if expectedVersion, err = strconv.ParseUint(expected, 10, 64); err != nil {
+			return nil, twirp.InvalidArgumentError(ExpectedMetadataVersionHeader, "must be an unsigned integer")
+		}
+	}
+
+	// concurrent updates are serialized from checking the version until the new version is stored.
+	// the room lock itself is taken by CreateRoom, so a separate key is locked
+	lockName := roomMetadataLockName(roomName)
+	token, err := s.roomStore.LockRoom(ctx, lockName, roomMetadataLockDuration+s.apiConf.ExecutionTimeout)
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		_ = s.roomStore.UnlockRoom(ctx, lockName, token)
+	}()
+
+	version, err := s.roomStore.LoadRoomMetadataVersion(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	if hasExpected && version != expectedVersion {
+		_ = twirp.SetHTTPResponseHeader(ctx, MetadataVersionHeader, strconv.FormatUint(version, 10))
+		return nil, twirp.NewError(twirp.Aborted, ErrRoomMetadataVersionConflict.Error()).
+			WithMeta("current_version", strconv.FormatUint(version, 10))
+	}
 
 	// no one has joined the room, would not have been created on an RTC node.
 	// in this case, we'd want to run create again
@@ -436,12 +479,12 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 	}
 
 	if s.psrpcConf.Enabled {
-		_, err := s.roomClient.UpdateRoomMetadata(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+		_, err := s.roomClient.UpdateRoomMetadata(ctx, s.topicFormatter.RoomTopic(ctx, roomName), req)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		err = s.router.WriteRoomRTC(ctx, livekit.RoomName(req.Room), &livekit.RTCNodeMessage{
+		err = s.router.WriteRoomRTC(ctx, roomName, &livekit.RTCNodeMessage{
 			Message: &livekit.RTCNodeMessage_UpdateRoomMetadata{
 				UpdateRoomMetadata: req,
 			},
@@ -452,7 +495,7 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 	}
 
 	err = s.confirmExecution(func() error {
-		room, _, err = s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
+		room, _, err = s.roomStore.LoadRoom(ctx, roomName, false)
 		if err != nil {
 			return err
 		}
@@ -465,9 +508,21 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 		return nil, err
 	}
 
+	// version changes only once metadata has been written
+	version++
+	if err = s.roomStore.StoreRoomMetadataVersion(ctx, roomName, version); err != nil {
+		return nil, err
+	}
+	_ = twirp.SetHTTPResponseHeader(ctx, MetadataVersionHeader, strconv.FormatUint(version, 10))
+
 	return room, nil
 }
 
+// metadata updates lock a key of their own, next to the room lock
+func roomMetadataLockName(roomName livekit.RoomName) livekit.RoomName {
+	return roomName + "|metadata"
+}
+
 func (s *RoomService) writeParticipantMessage(ctx context.Context, room livekit.RoomName, identity livekit.ParticipantIdentity, msg *livekit.RTCNodeMessage) error {
 	if err := EnsureAdminPermission(ctx, room); err != nil {
 		return twirpAuthError(err)
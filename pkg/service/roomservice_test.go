@@ -16,6 +16,7 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/livekit-server/pkg/service/servicefakes"
 )
@@ -124,10 +126,103 @@ func TestMetaDataLimits(t *testing.T) {
 	}
 }
 
+func TestAttributeLimits(t *testing.T) {
+	svc := newTestRoomService(config.RoomConfig{MaxAttributeKeySize: 3, MaxAttributeValueSize: 5})
+	grant := &auth.ClaimGrants{
+		Video: &auth.VideoGrant{},
+	}
+	ctx := service.WithGrants(context.Background(), grant)
+
+	for _, attributes := range []map[string]string{
+		{"long": "abc"},
+		{"key": "abcdefg"},
+	} {
+		req := &livekit.UpdateParticipantRequest{
+			Room:     "testroom",
+			Identity: "123",
+		}
+		rtc.SetUpdateParticipantRequestAttributes(req, attributes)
+		_, err := svc.UpdateParticipant(ctx, req)
+		terr, ok := err.(twirp.Error)
+		require.True(t, ok)
+		require.Equal(t, twirp.InvalidArgument, terr.Code())
+	}
+
+	req := &livekit.UpdateParticipantRequest{
+		Room:     "testroom",
+		Identity: "123",
+	}
+	rtc.SetUpdateParticipantRequestAttributes(req, map[string]string{"key": "abc"})
+	_, err := svc.UpdateParticipant(ctx, req)
+	terr, ok := err.(twirp.Error)
+	require.True(t, ok)
+	require.NotEqual(t, twirp.InvalidArgument, terr.Code())
+}
+
+func TestUpdateRoomMetadataVersion(t *testing.T) {
+	svc := newTestRoomService(config.RoomConfig{})
+	svc.store.LoadRoomReturns(&livekit.Room{Name: "testroom"}, nil, nil)
+	svc.store.LoadRoomMetadataVersionReturns(2, nil)
+	grant := &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"},
+	}
+	ctx := service.WithGrants(context.Background(), grant)
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := svc.UpdateRoomMetadata(service.WithExpectedMetadataVersion(ctx, "1"), &livekit.UpdateRoomMetadataRequest{
+			Room:     "testroom",
+			Metadata: "abc",
+		})
+		terr, ok := err.(twirp.Error)
+		require.True(t, ok)
+		require.Equal(t, twirp.Aborted, terr.Code())
+		require.Equal(t, "2", terr.Meta("current_version"))
+		require.Zero(t, svc.store.StoreRoomMetadataVersionCallCount())
+		require.Equal(t, svc.store.LockRoomCallCount(), svc.store.UnlockRoomCallCount())
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		_, err := svc.UpdateRoomMetadata(service.WithExpectedMetadataVersion(ctx, "latest"), &livekit.UpdateRoomMetadataRequest{
+			Room:     "testroom",
+			Metadata: "abc",
+		})
+		terr, ok := err.(twirp.Error)
+		require.True(t, ok)
+		require.Equal(t, twirp.InvalidArgument, terr.Code())
+	})
+
+	t.Run("version is not incremented when the write fails", func(t *testing.T) {
+		svc.allocator.CreateRoomReturns(nil, false, errors.New("store failed"))
+		_, err := svc.UpdateRoomMetadata(service.WithExpectedMetadataVersion(ctx, "2"), &livekit.UpdateRoomMetadataRequest{
+			Room:     "testroom",
+			Metadata: "abc",
+		})
+		svc.allocator.CreateRoomReturns(nil, false, nil)
+		require.Error(t, err)
+		require.Zero(t, svc.store.StoreRoomMetadataVersionCallCount())
+		require.Equal(t, svc.store.LockRoomCallCount(), svc.store.UnlockRoomCallCount())
+	})
+
+	t.Run("matching version is incremented", func(t *testing.T) {
+		svc.store.LoadRoomReturns(&livekit.Room{Name: "testroom", Metadata: "abc"}, nil, nil)
+		_, err := svc.UpdateRoomMetadata(service.WithExpectedMetadataVersion(ctx, "2"), &livekit.UpdateRoomMetadataRequest{
+			Room:     "testroom",
+			Metadata: "abc",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, svc.store.StoreRoomMetadataVersionCallCount())
+		_, roomName, version := svc.store.StoreRoomMetadataVersionArgsForCall(0)
+		require.Equal(t, livekit.RoomName("testroom"), roomName)
+		require.Equal(t, uint64(3), version)
+
+		require.Equal(t, svc.store.LockRoomCallCount(), svc.store.UnlockRoomCallCount())
+	})
+}
+
 func newTestRoomService(conf config.RoomConfig) *TestRoomService {
 	router := &routingfakes.FakeRouter{}
 	allocator := &servicefakes.FakeRoomAllocator{}
-	store := &servicefakes.FakeServiceStore{}
+	store := &servicefakes.FakeObjectStore{}
 	svc, err := service.NewRoomService(
 		conf,
 		config.APIConfig{ExecutionTimeout: 2},
@@ -155,5 +250,5 @@ type TestRoomService struct {
 	service.RoomService
 	router    *routingfakes.FakeRouter
 	allocator *servicefakes.FakeRoomAllocator
-	store     *servicefakes.FakeServiceStore
+	store     *servicefakes.FakeObjectStore
 }
@@ -0,0 +1,449 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtpforward"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	RTPForwardPath = "/admin/rtp_forward"
+
+	rtpForwardPrefix         = "RF_"
+	maxRTPForwardRequestSize = 4 * 1024
+)
+
+var (
+	errRTPForwardNotEnabled     = errors.New("RTP forwarding is not enabled")
+	errRTPForwardTrackRequired  = errors.New("track_id is required")
+	errRTPForwardIDRequired     = errors.New("id is required")
+	errRTPForwardNotFound       = errors.New("RTP forward not found")
+	errRTPForwardDestination    = errors.New("host and port of the destination are required")
+	errRTPForwardNotAllowed     = errors.New("destination is not in an allowed network")
+	errRTPForwardTrackNotReady  = errors.New("track is not receiving media yet")
+	errRTPForwardInvalidQuality = errors.New("quality must be LOW, MEDIUM or HIGH")
+)
+
+type StartRTPForwardRequest struct {
+	Room    string `json:"room"`
+	TrackID string `json:"track_id"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	// simulcast layer of video, LOW, MEDIUM or HIGH (default)
+	Quality string `json:"quality,omitempty"`
+	// forwards SRTP instead of plain RTP, with the given key or a random one
+	SRTP bool `json:"srtp,omitempty"`
+	// base64 of a 16 bytes master key followed by a 14 bytes salt, for AES_CM_128_HMAC_SHA1_80
+	SRTPKey string `json:"srtp_key,omitempty"`
+	// seconds between key frame requests of video, defaults to and is at least the configured interval
+	KeyFrameInterval int `json:"key_frame_interval,omitempty"`
+}
+
+type RTPForwardInfo struct {
+	ID          string `json:"id"`
+	Room        string `json:"room"`
+	TrackID     string `json:"track_id"`
+	Destination string `json:"destination"`
+	Quality     string `json:"quality,omitempty"`
+	MimeType    string `json:"mime_type"`
+	SSRC        uint32 `json:"ssrc"`
+	SRTP        bool   `json:"srtp,omitempty"`
+	// session description for receivers, includes the SRTP key
+	SDP       string `json:"sdp"`
+	StartedAt int64  `json:"started_at"`
+	// set once the forward stopped
+	EndedAt int64  `json:"ended_at,omitempty"`
+	Packets uint64 `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+type rtpForward struct {
+	roomName  livekit.RoomName
+	track     types.MediaTrack
+	quality   livekit.VideoQuality
+	forwarder *rtpforward.TrackForwarder
+	sdp       string
+	srtp      bool
+	startedAt time.Time
+
+	lock    sync.Mutex
+	endedAt time.Time
+}
+
+func (f *rtpForward) info() RTPForwardInfo {
+	stats := f.forwarder.Stats()
+	info := RTPForwardInfo{
+		ID:          f.forwarder.ID(),
+		Room:        string(f.roomName),
+		TrackID:     string(f.track.ID()),
+		Destination: f.forwarder.Destination().String(),
+		MimeType:    f.forwarder.Codec().MimeType,
+		SSRC:        f.forwarder.SSRC(),
+		SRTP:        f.srtp,
+		SDP:         f.sdp,
+		StartedAt:   f.startedAt.UnixNano(),
+		Packets:     stats.Packets,
+		Bytes:       stats.Bytes,
+	}
+	if f.track.Kind() == livekit.TrackType_VIDEO {
+		info.Quality = f.quality.String()
+	}
+	f.lock.Lock()
+	if !f.endedAt.IsZero() {
+		info.EndedAt = f.endedAt.UnixNano()
+	}
+	f.lock.Unlock()
+	return info
+}
+
+// RTPForwardService lets room admins start (POST), list (GET ?room=) and stop (DELETE ?room=&id=) forwards of
+// published tracks as plain RTP or SRTP to UDP destinations, at /admin/rtp_forward. A simulcast layer of video is
+// forwarded with contiguous sequence numbers and timestamps, starting at a key frame, with key frames requested
+// periodically. Responses include an SDP file describing the stream for the receiver.
+// Forwards stop with their tracks, and run on the node hosting the room, other nodes forward requests to it.
+type RTPForwardService struct {
+	conf            config.RTPForwardConfig
+	allowedNetworks []*net.IPNet
+	rooms           RoomProvider
+	forwarder       *RoomForwarder
+
+	lock     sync.Mutex
+	forwards map[livekit.RoomName]map[string]*rtpForward
+}
+
+func NewRTPForwardService(
+	conf *config.Config,
+	rooms RoomProvider,
+	forwarder *RoomForwarder,
+) (*RTPForwardService, error) {
+	var allowedNetworks []*net.IPNet
+	for _, cidr := range conf.RTPForward.AllowedNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rtp_forward.allowed_networks: %w", err)
+		}
+		allowedNetworks = append(allowedNetworks, network)
+	}
+	return &RTPForwardService{
+		conf:            conf.RTPForward,
+		allowedNetworks: allowedNetworks,
+		rooms:           rooms,
+		forwarder:       forwarder,
+		forwards:        make(map[livekit.RoomName]map[string]*rtpForward),
+	}, nil
+}
+
+func (s *RTPForwardService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.startForward(w, r)
+	case http.MethodGet:
+		s.listForwards(w, r)
+	case http.MethodDelete:
+		s.stopForward(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// Stop stops all forwards
+func (s *RTPForwardService) Stop() {
+	s.lock.Lock()
+	var forwards []*rtpForward
+	for _, roomForwards := range s.forwards {
+		for _, f := range roomForwards {
+			forwards = append(forwards, f)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, f := range forwards {
+		f.forwarder.Stop()
+	}
+}
+
+func (s *RTPForwardService) startForward(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRTPForwardRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req StartRTPForwardRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	roomName := livekit.RoomName(req.Room)
+	trackID := livekit.TrackID(req.TrackID)
+	switch {
+	case roomName == "":
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	case trackID == "":
+		handleError(w, http.StatusBadRequest, errRTPForwardTrackRequired)
+		return
+	case req.Host == "" || req.Port <= 0 || req.Port > 65535:
+		handleError(w, http.StatusBadRequest, errRTPForwardDestination)
+		return
+	}
+	quality := livekit.VideoQuality_HIGH
+	if req.Quality != "" {
+		q, ok := livekit.VideoQuality_value[req.Quality]
+		if !ok || livekit.VideoQuality(q) == livekit.VideoQuality_OFF {
+			handleError(w, http.StatusBadRequest, errRTPForwardInvalidQuality)
+			return
+		}
+		quality = livekit.VideoQuality(q)
+	}
+	var srtpKey []byte
+	if req.SRTPKey != "" {
+		if srtpKey, err = base64.StdEncoding.DecodeString(req.SRTPKey); err != nil || len(srtpKey) != rtpforward.SRTPKeyLength {
+			handleError(w, http.StatusBadRequest, rtpforward.ErrInvalidSRTPKey)
+			return
+		}
+	} else if req.SRTP {
+		if srtpKey, err = rtpforward.NewSRTPKey(); err != nil {
+			handleError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if err = EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+	if !s.conf.Enabled {
+		handleError(w, http.StatusNotImplemented, errRTPForwardNotEnabled)
+		return
+	}
+
+	destination, err := net.ResolveUDPAddr("udp", net.JoinHostPort(req.Host, strconv.Itoa(req.Port)))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err, "room", roomName)
+		return
+	}
+	if !s.isAllowed(destination.IP) {
+		handleError(w, http.StatusForbidden, errRTPForwardNotAllowed, "room", roomName, "destination", destination)
+		return
+	}
+
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+	var track types.MediaTrack
+	for _, p := range room.GetParticipants() {
+		if track = p.GetPublishedTrack(trackID); track != nil {
+			break
+		}
+	}
+	if track == nil {
+		handleError(w, http.StatusNotFound, ErrTrackNotFound, "room", roomName, "trackID", trackID)
+		return
+	}
+	// primary codec of the track
+	receivers := track.Receivers()
+	if len(receivers) == 0 {
+		handleError(w, http.StatusConflict, errRTPForwardTrackNotReady, "room", roomName, "trackID", trackID)
+		return
+	}
+	// audio with redundancy is forwarded as its primary Opus stream, which plain RTP receivers understand
+	receiver := receivers[0].GetPrimaryReceiverForRed()
+
+	layer := int32(0)
+	if track.Kind() == livekit.TrackType_VIDEO {
+		layer = buffer.VideoQualityToSpatialLayer(quality, track.ToProto())
+		if layer == buffer.InvalidLayerSpatial {
+			layer = 0
+		}
+	}
+	keyFrameInterval := s.conf.KeyFrameInterval
+	if d := time.Duration(req.KeyFrameInterval) * time.Second; d > keyFrameInterval {
+		keyFrameInterval = d
+	}
+
+	id := utils.NewGuid(rtpForwardPrefix)
+	l := rtc.LoggerWithTrack(rtc.LoggerWithRoom(logger.GetLogger(), roomName, room.ID()), trackID, false).
+		WithValues("forwardID", id, "destination", destination)
+	forwarder, err := rtpforward.NewTrackForwarder(rtpforward.Params{
+		ID:               id,
+		Receiver:         receiver,
+		Destination:      destination,
+		Layer:            layer,
+		SRTPKey:          srtpKey,
+		KeyFrameInterval: keyFrameInterval,
+		Logger:           l,
+	})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "trackID", trackID)
+		return
+	}
+
+	f := &rtpForward{
+		roomName:  roomName,
+		track:     track,
+		quality:   quality,
+		forwarder: forwarder,
+		sdp:       rtpforward.SessionDescription(id, forwarder.Codec(), destination, srtpKey),
+		srtp:      srtpKey != nil,
+		startedAt: time.Now(),
+	}
+	forwarder.OnClose(func() {
+		s.forwardStopped(f)
+	})
+
+	s.lock.Lock()
+	roomForwards := s.forwards[roomName]
+	if roomForwards == nil {
+		roomForwards = make(map[string]*rtpForward)
+		s.forwards[roomName] = roomForwards
+	}
+	roomForwards[id] = f
+	s.lock.Unlock()
+
+	if err = forwarder.Start(); err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "trackID", trackID)
+		return
+	}
+	s.setTrackQuality(f, quality)
+	l.Infow("RTP forward started", "layer", layer, "srtp", f.srtp, "mime", forwarder.Codec().MimeType)
+
+	writeJSON(w, f.info())
+}
+
+func (s *RTPForwardService) listForwards(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+	if s.rooms.GetRoom(r.Context(), roomName) == nil && s.forwarder.forward(w, r, roomName) {
+		return
+	}
+
+	s.lock.Lock()
+	forwards := make([]RTPForwardInfo, 0, len(s.forwards[roomName]))
+	for _, f := range s.forwards[roomName] {
+		forwards = append(forwards, f.info())
+	}
+	s.lock.Unlock()
+	sort.Slice(forwards, func(i, j int) bool {
+		return forwards[i].StartedAt < forwards[j].StartedAt
+	})
+
+	writeJSON(w, struct {
+		Forwards []RTPForwardInfo `json:"forwards"`
+	}{Forwards: forwards})
+}
+
+func (s *RTPForwardService) stopForward(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	id := r.URL.Query().Get("id")
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if id == "" {
+		handleError(w, http.StatusBadRequest, errRTPForwardIDRequired)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	s.lock.Lock()
+	f := s.forwards[roomName][id]
+	s.lock.Unlock()
+	if f == nil {
+		if s.rooms.GetRoom(r.Context(), roomName) != nil || !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, errRTPForwardNotFound, "room", roomName, "id", id)
+		}
+		return
+	}
+
+	f.forwarder.Stop()
+	writeJSON(w, f.info())
+}
+
+func (s *RTPForwardService) forwardStopped(f *rtpForward) {
+	f.lock.Lock()
+	f.endedAt = time.Now()
+	f.lock.Unlock()
+	s.setTrackQuality(f, livekit.VideoQuality_OFF)
+
+	s.lock.Lock()
+	roomForwards := s.forwards[f.roomName]
+	delete(roomForwards, f.forwarder.ID())
+	if len(roomForwards) == 0 {
+		delete(s.forwards, f.roomName)
+	}
+	s.lock.Unlock()
+}
+
+func (s *RTPForwardService) isAllowed(ip net.IP) bool {
+	if len(s.allowedNetworks) == 0 {
+		return true
+	}
+	for _, network := range s.allowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// setTrackQuality keeps the forwarded layer of a track published with dynacast, as if a subscriber wanted it
+func (s *RTPForwardService) setTrackQuality(f *rtpForward, quality livekit.VideoQuality) {
+	track, ok := f.track.(types.LocalMediaTrack)
+	if !ok || track.Kind() != livekit.TrackType_VIDEO {
+		return
+	}
+	track.NotifySubscriberNodeMaxQuality(livekit.NodeID(f.forwarder.ID()), []types.SubscribedCodecQuality{{
+		CodecMime: f.forwarder.Codec().MimeType,
+		Quality:   quality,
+	}})
+}
@@ -0,0 +1,434 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/bot"
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtpingest"
+)
+
+const (
+	RTPIngestPath = "/admin/rtp_ingest"
+
+	maxRTPIngestRequestSize = 4 * 1024
+)
+
+var (
+	errRTPIngestNotEnabled      = errors.New("RTP ingest is not enabled")
+	errRTPIngestIdentityMissing = errors.New("identity is required")
+	errRTPIngestTrackMissing    = errors.New("audio or video track is required")
+	errRTPIngestAudioCodec      = errors.New("audio codec must be opus")
+	errRTPIngestVideoCodec      = errors.New("video codec must be vp8 or h264")
+	errRTPIngestNoPorts         = errors.New("no UDP port available")
+	errRTPIngestNotFound        = errors.New("RTP ingest not found")
+)
+
+type RTPIngestTrack struct {
+	// opus for audio, vp8 or h264 for video
+	Codec string `json:"codec"`
+	// payload type sent by the encoder, packets of any payload type are accepted when not set
+	PayloadType uint8  `json:"payload_type,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Width       uint32 `json:"width,omitempty"`
+	Height      uint32 `json:"height,omitempty"`
+}
+
+type StartRTPIngestRequest struct {
+	Room     string          `json:"room"`
+	Identity string          `json:"identity"`
+	Name     string          `json:"name,omitempty"`
+	Audio    *RTPIngestTrack `json:"audio,omitempty"`
+	Video    *RTPIngestTrack `json:"video,omitempty"`
+}
+
+type RTPIngestTrackInfo struct {
+	Type     string `json:"type"`
+	MimeType string `json:"mime_type"`
+	// UDP port the encoder sends RTP to
+	Port int `json:"port"`
+	// address packets are accepted from, once the first one arrived
+	Source  string `json:"source,omitempty"`
+	Packets uint64 `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+	Dropped uint64 `json:"dropped"`
+}
+
+type RTPIngestInfo struct {
+	Room     string               `json:"room"`
+	Identity string               `json:"identity"`
+	Tracks   []RTPIngestTrackInfo `json:"tracks"`
+}
+
+type rtpIngest struct {
+	bot    *bot.Bot
+	tracks []*rtpingest.Track
+}
+
+func (i *rtpIngest) info() RTPIngestInfo {
+	info := RTPIngestInfo{
+		Room:     string(i.bot.RoomName()),
+		Identity: string(i.bot.Identity()),
+		Tracks:   make([]RTPIngestTrackInfo, 0, len(i.tracks)),
+	}
+	for _, t := range i.tracks {
+		stats := t.Stats()
+		ti := RTPIngestTrackInfo{
+			Type:     strings.ToLower(t.Info().Kind.String()),
+			MimeType: t.Info().MimeType,
+			Port:     t.Port(),
+			Packets:  stats.Packets,
+			Bytes:    stats.Bytes,
+			Dropped:  stats.Dropped,
+		}
+		if source := t.SourceAddr(); source != nil {
+			ti.Source = source.String()
+		}
+		info.Tracks = append(info.Tracks, ti)
+	}
+	return info
+}
+
+// RTPIngestService lets room admins start (POST), list (GET ?room=) and stop (DELETE ?room=&identity=) ingests of
+// plain RTP, at /admin/rtp_ingest. An ingest allocates a UDP port for each track, publishing packets sent to it,
+// e.g. by a hardware encoder, as tracks of a server owned participant. The participant is a bot, so packets reach
+// the room through a WebRTC publisher transport and are received like those of any other publisher.
+// Ingests run on the node hosting the room, other nodes forward requests to it.
+type RTPIngestService struct {
+	conf         config.RTPIngestConfig
+	bindIP       net.IP
+	rooms        RoomProvider
+	startSession routing.NewParticipantCallback
+	region       string
+	forwarder    *RoomForwarder
+
+	lock    sync.Mutex
+	ingests map[livekit.RoomName]map[livekit.ParticipantIdentity]*rtpIngest
+}
+
+func NewRTPIngestService(
+	conf *config.Config,
+	rooms RoomProvider,
+	startSession routing.NewParticipantCallback,
+	forwarder *RoomForwarder,
+) (*RTPIngestService, error) {
+	var bindIP net.IP
+	if conf.RTPIngest.BindAddress != "" {
+		if bindIP = net.ParseIP(conf.RTPIngest.BindAddress); bindIP == nil {
+			return nil, fmt.Errorf("invalid rtp_ingest.bind_address: %s", conf.RTPIngest.BindAddress)
+		}
+	}
+	if conf.RTPIngest.PortRangeStart > conf.RTPIngest.PortRangeEnd {
+		return nil, errors.New("rtp_ingest.port_range_start must not be after port_range_end")
+	}
+	return &RTPIngestService{
+		conf:         conf.RTPIngest,
+		bindIP:       bindIP,
+		rooms:        rooms,
+		startSession: startSession,
+		region:       conf.Region,
+		forwarder:    forwarder,
+		ingests:      make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*rtpIngest),
+	}, nil
+}
+
+func (s *RTPIngestService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.startIngest(w, r)
+	case http.MethodGet:
+		s.listIngests(w, r)
+	case http.MethodDelete:
+		s.stopIngest(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// Stop closes all ingests, so they don't keep rooms open
+func (s *RTPIngestService) Stop() {
+	s.lock.Lock()
+	var ingests []*rtpIngest
+	for _, roomIngests := range s.ingests {
+		for _, i := range roomIngests {
+			ingests = append(ingests, i)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, i := range ingests {
+		i.bot.Close()
+	}
+}
+
+func (s *RTPIngestService) startIngest(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRTPIngestRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req StartRTPIngestRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	roomName := livekit.RoomName(req.Room)
+	switch {
+	case roomName == "":
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	case req.Identity == "":
+		handleError(w, http.StatusBadRequest, errRTPIngestIdentityMissing)
+		return
+	case req.Audio == nil && req.Video == nil:
+		handleError(w, http.StatusBadRequest, errRTPIngestTrackMissing)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+	if !s.conf.Enabled {
+		handleError(w, http.StatusNotImplemented, errRTPIngestNotEnabled)
+		return
+	}
+
+	if s.rooms.GetRoom(r.Context(), roomName) == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+
+	identity := livekit.ParticipantIdentity(req.Identity)
+	name := livekit.ParticipantName(req.Name)
+	if name == "" {
+		name = livekit.ParticipantName(identity)
+	}
+	l := rtc.LoggerWithParticipant(rtc.LoggerWithRoom(logger.GetLogger(), roomName, ""), identity, "", false)
+
+	ingest := &rtpIngest{}
+	closeTracks := func() {
+		for _, t := range ingest.tracks {
+			t.Close()
+		}
+	}
+	for _, media := range []struct {
+		track  *RTPIngestTrack
+		kind   livekit.TrackType
+		source livekit.TrackSource
+		err    error
+	}{
+		{req.Audio, livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE, errRTPIngestAudioCodec},
+		{req.Video, livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA, errRTPIngestVideoCodec},
+	} {
+		if media.track == nil {
+			continue
+		}
+		codec, err := rtpingest.ParseCodec(media.track.Codec)
+		if err != nil || !strings.HasPrefix(codec.MimeType, strings.ToLower(media.kind.String())+"/") {
+			closeTracks()
+			handleError(w, http.StatusBadRequest, media.err, "codec", media.track.Codec)
+			return
+		}
+		conn, err := s.listen()
+		if err != nil {
+			closeTracks()
+			handleError(w, http.StatusServiceUnavailable, err, "room", roomName, "identity", identity)
+			return
+		}
+		trackName := media.track.Name
+		if trackName == "" {
+			trackName = strings.ToLower(media.kind.String())
+		}
+		track, err := rtpingest.NewTrack(rtpingest.TrackParams{
+			Name:        trackName,
+			Source:      media.source,
+			Codec:       codec,
+			PayloadType: media.track.PayloadType,
+			Width:       media.track.Width,
+			Height:      media.track.Height,
+			Conn:        conn,
+			StreamID:    string(identity),
+			Logger:      l.WithValues("port", conn.LocalAddr().(*net.UDPAddr).Port),
+		})
+		if err != nil {
+			_ = conn.Close()
+			closeTracks()
+			handleError(w, http.StatusInternalServerError, err, "room", roomName, "identity", identity)
+			return
+		}
+		ingest.tracks = append(ingest.tracks, track)
+	}
+
+	tracks := make([]bot.Track, 0, len(ingest.tracks))
+	for _, t := range ingest.tracks {
+		tracks = append(tracks, t)
+	}
+	ingest.bot, err = bot.NewBot(bot.Params{
+		RoomName:     roomName,
+		Identity:     identity,
+		Name:         name,
+		Tracks:       tracks,
+		Region:       s.region,
+		StartSession: s.startSession,
+		Logger:       l,
+	})
+	if err != nil {
+		closeTracks()
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "identity", identity)
+		return
+	}
+
+	// joining with the same identity replaces the previous participant
+	s.lock.Lock()
+	existing := s.ingests[roomName][identity]
+	s.lock.Unlock()
+	if existing != nil {
+		existing.bot.Close()
+	}
+
+	ingest.bot.OnClose(func(_ *bot.Bot) {
+		s.removeIngest(ingest)
+	})
+	if err = ingest.bot.Start(context.Background()); err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "identity", identity)
+		return
+	}
+
+	s.lock.Lock()
+	roomIngests := s.ingests[roomName]
+	if roomIngests == nil {
+		roomIngests = make(map[livekit.ParticipantIdentity]*rtpIngest)
+		s.ingests[roomName] = roomIngests
+	}
+	roomIngests[identity] = ingest
+	s.lock.Unlock()
+
+	info := ingest.info()
+	l.Infow("RTP ingest started", "tracks", info.Tracks)
+	writeJSON(w, info)
+}
+
+func (s *RTPIngestService) listIngests(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+	if s.rooms.GetRoom(r.Context(), roomName) == nil && s.forwarder.forward(w, r, roomName) {
+		return
+	}
+
+	s.lock.Lock()
+	ingests := make([]RTPIngestInfo, 0, len(s.ingests[roomName]))
+	for _, i := range s.ingests[roomName] {
+		ingests = append(ingests, i.info())
+	}
+	s.lock.Unlock()
+	sort.Slice(ingests, func(i, j int) bool {
+		return ingests[i].Identity < ingests[j].Identity
+	})
+
+	writeJSON(w, struct {
+		Ingests []RTPIngestInfo `json:"ingests"`
+	}{Ingests: ingests})
+}
+
+func (s *RTPIngestService) stopIngest(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	identity := livekit.ParticipantIdentity(r.URL.Query().Get("identity"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if identity == "" {
+		handleError(w, http.StatusBadRequest, errRTPIngestIdentityMissing)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	s.lock.Lock()
+	i := s.ingests[roomName][identity]
+	s.lock.Unlock()
+	if i == nil {
+		if s.rooms.GetRoom(r.Context(), roomName) != nil || !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, errRTPIngestNotFound, "room", roomName, "identity", identity)
+		}
+		return
+	}
+
+	i.bot.Close()
+	writeJSON(w, i.info())
+}
+
+func (s *RTPIngestService) removeIngest(i *rtpIngest) {
+	for _, t := range i.tracks {
+		t.Close()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	roomIngests := s.ingests[i.bot.RoomName()]
+	if roomIngests[i.bot.Identity()] != i {
+		return
+	}
+	delete(roomIngests, i.bot.Identity())
+	if len(roomIngests) == 0 {
+		delete(s.ingests, i.bot.RoomName())
+	}
+}
+
+// listen binds a UDP port of the configured range, or a random one
+func (s *RTPIngestService) listen() (*net.UDPConn, error) {
+	if s.conf.PortRangeStart == 0 {
+		return net.ListenUDP("udp", &net.UDPAddr{IP: s.bindIP})
+	}
+	for port := int(s.conf.PortRangeStart); port <= int(s.conf.PortRangeEnd); port++ {
+		if conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: s.bindIP, Port: port}); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, errRTPIngestNoPorts
+}
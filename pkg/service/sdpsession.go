@@ -0,0 +1,398 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+// signaling shared by WHIP and WHEP, where a single offer and answer are exchanged over HTTP
+
+const (
+	sdpContentType     = "application/sdp"
+	trickleContentType = "application/trickle-ice-sdpfrag"
+
+	maxSDPRequestSize = 64 * 1024
+	sdpJoinTimeout    = 5 * time.Second
+	sdpAnswerTimeout  = 10 * time.Second
+	// candidates of the server are trickled after its answer. Answers over HTTP include them,
+	// the answer is sent once none arrived for a while.
+	sdpCandidateQuietPeriod = 200 * time.Millisecond
+	sdpCandidateTimeout     = 2 * time.Second
+)
+
+var (
+	errSDPContentType     = errors.New("unsupported content type")
+	errSDPSessionNotFound = errors.New("session not found")
+	errSDPICERestart      = errors.New("ICE restarts are not supported")
+	errSDPParticipantLeft = errors.New("participant left while connecting")
+	errSDPAnswerTimeout   = errors.New("timed out while waiting for answer")
+)
+
+// sdpSession relays signaling of a participant negotiating a single peer connection over HTTP
+type sdpSession struct {
+	id        string
+	protocol  string
+	roomName  livekit.RoomName
+	identity  livekit.ParticipantIdentity
+	iceUfrag  string
+	target    livekit.SignalTarget
+	logger    logger.Logger
+	requests  routing.MessageSink
+	responses routing.MessageSource
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// join waits for the participant to join the room
+func (ss *sdpSession) join() (*livekit.JoinResponse, error) {
+	res, err := readInitialResponse(ss.responses, sdpJoinTimeout)
+	if err != nil {
+		return nil, err
+	}
+	join := res.GetJoin()
+	if join == nil {
+		return nil, fmt.Errorf("unexpected initial response: %T", res.Message)
+	}
+	ss.logger = ss.logger.WithValues("pID", join.Participant.Sid)
+	return join, nil
+}
+
+// exchange sends the offer, returning the answer with candidates of the server
+func (ss *sdpSession) exchange(ctx context.Context, offer webrtc.SessionDescription) (string, error) {
+	if err := ss.requests.WriteMessage(&livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Offer{Offer: rtc.ToProtoSessionDescription(offer)},
+	}); err != nil {
+		return "", err
+	}
+
+	var (
+		answer     *livekit.SessionDescription
+		candidates []string
+		quiet      <-chan time.Time
+	)
+	deadline := time.After(sdpAnswerTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+
+		case <-deadline:
+			if answer == nil {
+				return "", errSDPAnswerTimeout
+			}
+			return addCandidates(answer.Sdp, candidates)
+
+		case <-quiet:
+			return addCandidates(answer.Sdp, candidates)
+
+		case msg := <-ss.responses.ReadChan():
+			if msg == nil {
+				return "", errSDPParticipantLeft
+			}
+			res, ok := msg.(*livekit.SignalResponse)
+			if !ok {
+				continue
+			}
+			switch m := res.Message.(type) {
+			case *livekit.SignalResponse_Answer:
+				answer = m.Answer
+				deadline = time.After(sdpCandidateTimeout)
+				quiet = time.After(sdpCandidateQuietPeriod)
+
+			case *livekit.SignalResponse_Trickle:
+				if m.Trickle.Target != ss.target {
+					continue
+				}
+				c, err := rtc.FromProtoTrickle(m.Trickle)
+				if err != nil {
+					ss.logger.Warnw("could not parse candidate", err)
+					continue
+				}
+				candidates = append(candidates, c.Candidate)
+				if answer != nil {
+					quiet = time.After(sdpCandidateQuietPeriod)
+				}
+
+			case *livekit.SignalResponse_Leave:
+				return "", errSDPParticipantLeft
+			}
+		}
+	}
+}
+
+// worker keeps the signal connection alive until the participant leaves or the session is deleted
+func (ss *sdpSession) worker() {
+	defer ss.close(false)
+
+	ping := time.NewTicker(rtc.PingIntervalSeconds * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ss.closed:
+			return
+
+		case <-ping.C:
+			if err := ss.requests.WriteMessage(&livekit.SignalRequest{
+				Message: &livekit.SignalRequest_PingReq{PingReq: &livekit.Ping{Timestamp: time.Now().UnixMilli()}},
+			}); err != nil {
+				return
+			}
+
+		case msg := <-ss.responses.ReadChan():
+			if msg == nil {
+				return
+			}
+			if res, ok := msg.(*livekit.SignalResponse); ok {
+				if leave := res.GetLeave(); leave != nil {
+					ss.logger.Infow(ss.protocol+" participant removed from room", "reason", leave.Reason)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (ss *sdpSession) close(sendLeave bool) {
+	ss.closeOnce.Do(func() {
+		if sendLeave {
+			_ = ss.requests.WriteMessage(&livekit.SignalRequest{
+				Message: &livekit.SignalRequest_Leave{
+					Leave: &livekit.LeaveRequest{Reason: livekit.DisconnectReason_CLIENT_INITIATED},
+				},
+			})
+		}
+		ss.requests.Close()
+		ss.responses.Close()
+		close(ss.closed)
+		ss.logger.Infow(ss.protocol+" session ended", "resource", ss.id)
+	})
+}
+
+// sdpSessions serves trickled candidates (PATCH) and leaving (DELETE) at the resources of sessions.
+// Sessions live with the room, requests for sessions of other nodes are forwarded.
+type sdpSessions struct {
+	forwarder *RoomForwarder
+
+	lock     sync.Mutex
+	sessions map[string]*sdpSession
+}
+
+func newSDPSessions(forwarder *RoomForwarder) *sdpSessions {
+	return &sdpSessions{
+		forwarder: forwarder,
+		sessions:  make(map[string]*sdpSession),
+	}
+}
+
+// start keeps the session until it ends
+func (s *sdpSessions) start(session *sdpSession) {
+	s.lock.Lock()
+	s.sessions[session.id] = session
+	s.lock.Unlock()
+
+	go func() {
+		session.worker()
+		s.lock.Lock()
+		delete(s.sessions, session.id)
+		s.lock.Unlock()
+	}()
+}
+
+func (s *sdpSessions) trickle(w http.ResponseWriter, r *http.Request, resourceID string) {
+	if !hasContentType(r, trickleContentType) {
+		handleError(w, http.StatusUnsupportedMediaType, errSDPContentType)
+		return
+	}
+	session, ok := s.get(w, r, resourceID)
+	if !ok {
+		return
+	}
+
+	iceUfrag, candidates, err := parseTrickleFragment(io.LimitReader(r.Body, maxSDPRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err, "resource", resourceID)
+		return
+	}
+	if iceUfrag != "" && iceUfrag != session.iceUfrag {
+		handleError(w, http.StatusUnprocessableEntity, errSDPICERestart, "resource", resourceID)
+		return
+	}
+	for _, c := range candidates {
+		trickle := rtc.ToProtoTrickle(c)
+		trickle.Target = session.target
+		if err = session.requests.WriteMessage(&livekit.SignalRequest{
+			Message: &livekit.SignalRequest_Trickle{Trickle: trickle},
+		}); err != nil {
+			handleError(w, http.StatusInternalServerError, err, "resource", resourceID)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *sdpSessions) leave(w http.ResponseWriter, r *http.Request, resourceID string) {
+	session, ok := s.get(w, r, resourceID)
+	if !ok {
+		return
+	}
+	session.close(true)
+	w.WriteHeader(http.StatusOK)
+}
+
+// get returns sessions of the participant of the token, writing the response when there is none here
+func (s *sdpSessions) get(w http.ResponseWriter, r *http.Request, resourceID string) (*sdpSession, bool) {
+	claims := GetGrants(r.Context())
+	if claims == nil || claims.Video == nil {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return nil, false
+	}
+	roomName, err := EnsureJoinPermission(r.Context())
+	if err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return nil, false
+	}
+
+	s.lock.Lock()
+	session := s.sessions[resourceID]
+	s.lock.Unlock()
+	if session == nil {
+		if roomName == "" || !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, errSDPSessionNotFound, "resource", resourceID)
+		}
+		return nil, false
+	}
+	if session.roomName != roomName || string(session.identity) != claims.Identity {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied, "resource", resourceID)
+		return nil, false
+	}
+	return session, true
+}
+
+// parseOffer returns the audio and video sections of an offer, which are not inactive, and its ICE username fragment
+func parseOffer(offer string) ([]*sdp.MediaDescription, string, error) {
+	parsed := sdp.SessionDescription{}
+	if err := parsed.Unmarshal([]byte(offer)); err != nil {
+		return nil, "", err
+	}
+
+	iceUfrag, _ := parsed.Attribute("ice-ufrag")
+	var mds []*sdp.MediaDescription
+	for _, md := range parsed.MediaDescriptions {
+		if _, ok := mediaKind(md); !ok {
+			continue
+		}
+		if _, ok := md.Attribute("inactive"); ok {
+			continue
+		}
+		if ufrag, ok := md.Attribute("ice-ufrag"); ok && iceUfrag == "" {
+			iceUfrag = ufrag
+		}
+		mds = append(mds, md)
+	}
+	return mds, iceUfrag, nil
+}
+
+func mediaKind(md *sdp.MediaDescription) (livekit.TrackType, bool) {
+	switch md.MediaName.Media {
+	case "audio":
+		return livekit.TrackType_AUDIO, true
+	case "video":
+		return livekit.TrackType_VIDEO, true
+	default:
+		return livekit.TrackType_DATA, false
+	}
+}
+
+// parseTrickleFragment returns the ICE username fragment and candidates of a trickle-ice-sdpfrag body
+func parseTrickleFragment(r io.Reader) (string, []webrtc.ICECandidateInit, error) {
+	var (
+		iceUfrag   string
+		mid        *string
+		mLineIndex *uint16
+		candidates []webrtc.ICECandidateInit
+	)
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		switch {
+		case strings.HasPrefix(line, "m="):
+			index := uint16(0)
+			if mLineIndex != nil {
+				index = *mLineIndex + 1
+			}
+			mLineIndex = &index
+			mid = nil
+
+		case strings.HasPrefix(line, "a=ice-ufrag:"):
+			iceUfrag = strings.TrimPrefix(line, "a=ice-ufrag:")
+
+		case strings.HasPrefix(line, "a=mid:"):
+			m := strings.TrimPrefix(line, "a=mid:")
+			mid = &m
+
+		case strings.HasPrefix(line, "a=candidate:"):
+			candidates = append(candidates, webrtc.ICECandidateInit{
+				Candidate:     strings.TrimPrefix(line, "a="),
+				SDPMid:        mid,
+				SDPMLineIndex: mLineIndex,
+			})
+		}
+	}
+	return iceUfrag, candidates, lines.Err()
+}
+
+// addCandidates adds candidates to all media sections of a description
+func addCandidates(description string, candidates []string) (string, error) {
+	parsed := sdp.SessionDescription{}
+	if err := parsed.Unmarshal([]byte(description)); err != nil {
+		return "", err
+	}
+	for _, md := range parsed.MediaDescriptions {
+		for _, c := range candidates {
+			md.WithValueAttribute("candidate", strings.TrimPrefix(c, "candidate:"))
+		}
+		md.WithPropertyAttribute("end-of-candidates")
+	}
+	b, err := parsed.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func hasContentType(r *http.Request, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == contentType
+}
@@ -52,6 +52,12 @@ type LivekitServer struct {
 	promServer   *http.Server
 	router       routing.Router
 	roomManager  *RoomManager
+	botService   *BotService
+	recorder     *RecorderService
+	capture      *PacketCaptureService
+	rtpForward   *RTPForwardService
+	rtpIngest    *RTPIngestService
+	forwarder    *RoomForwarder
 	signalServer *SignalServer
 	turnServer   *turn.Server
 	currentNode  routing.LocalNode
@@ -66,12 +72,14 @@ func NewLivekitServer(conf *config.Config,
 	ingressService *IngressService,
 	ioService *IOInfoService,
 	rtcService *RTCService,
+	whipService *WHIPService,
 	keyProvider auth.KeyProvider,
 	router routing.Router,
 	roomManager *RoomManager,
 	signalServer *SignalServer,
 	turnServer *turn.Server,
 	currentNode routing.LocalNode,
+	forwarder *RoomForwarder,
 ) (s *LivekitServer, err error) {
 	s = &LivekitServer{
 		config:       conf,
@@ -83,6 +91,7 @@ func NewLivekitServer(conf *config.Config,
 		// turn server starts automatically
 		turnServer:  turnServer,
 		currentNode: currentNode,
+		forwarder:   forwarder,
 		closedChan:  make(chan struct{}),
 	}
 
@@ -95,6 +104,8 @@ func NewLivekitServer(conf *config.Config,
 				return true
 			},
 			AllowedHeaders: []string{"*"},
+			// WHIP and WHEP resources of sessions
+			ExposedHeaders: []string{"Location"},
 			// allow preflight to be cached for a day
 			MaxAge: 86400,
 		}),
@@ -121,16 +132,45 @@ func NewLivekitServer(conf *config.Config,
 		mux.HandleFunc("/debug/goroutine", s.debugGoroutines)
 		mux.HandleFunc("/debug/rooms", s.debugInfo)
 	}
-	mux.Handle(roomServer.PathPrefix(), roomServer)
+	mux.Handle(roomServer.PathPrefix(), WithMetadataVersion(roomServer))
 	mux.Handle(egressServer.PathPrefix(), egressServer)
 	mux.Handle(ingressServer.PathPrefix(), ingressServer)
+	mux.Handle(DataHistoryPath, NewDataHistoryService(roomManager, forwarder))
+	s.botService = NewBotService(conf, roomManager, roomManager.StartSession, forwarder)
+	mux.Handle(StartBotPath, s.botService)
+	mux.Handle(ListBotsPath, s.botService)
+	mux.Handle(StopBotPath, s.botService)
+	s.recorder = NewRecorderService(conf, roomManager, ioService.es, ioService.telemetry, forwarder)
+	mux.Handle(StartTrackRecordingPath, s.recorder)
+	mux.Handle(StopTrackRecordingPath, s.recorder)
+	s.capture = NewPacketCaptureService(conf, roomManager, forwarder)
+	mux.Handle(PacketCapturePath, s.capture)
+	mux.Handle(PublishBitratePath, NewPublishBitrateService(roomManager, forwarder))
+	mux.Handle(SubscriptionPriorityPath, NewSubscriptionPriorityService(roomManager, forwarder))
+	if s.rtpForward, err = NewRTPForwardService(conf, roomManager, forwarder); err != nil {
+		return nil, err
+	}
+	mux.Handle(RTPForwardPath, s.rtpForward)
+	if s.rtpIngest, err = NewRTPIngestService(conf, roomManager, roomManager.StartSession, forwarder); err != nil {
+		return nil, err
+	}
+	mux.Handle(RTPIngestPath, s.rtpIngest)
 	mux.Handle("/rtc", rtcService)
 	mux.HandleFunc("/rtc/validate", rtcService.Validate)
+	mux.Handle(WHIPPath, whipService)
+	mux.Handle(WHIPPath+"/", whipService)
+	whepService := NewWHEPService(conf, roomManager, roomManager.StartSession, forwarder)
+	mux.Handle(WHEPPath, whepService)
+	mux.Handle(WHEPPath+"/", whepService)
 	mux.HandleFunc("/", s.defaultHandler)
 
 	s.httpServer = &http.Server{
 		Handler: configureMiddlewares(mux, middlewares...),
 	}
+	// admin requests forwarded by other nodes are served like those received here
+	if err = forwarder.Serve(s.httpServer.Handler); err != nil {
+		return nil, err
+	}
 
 	if conf.PrometheusPort > 0 {
 		s.promServer = &http.Server{
@@ -273,11 +313,16 @@ func (s *LivekitServer) Start() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	_ = s.httpServer.Shutdown(ctx)
+	s.forwarder.Stop()
 
 	if s.turnServer != nil {
 		_ = s.turnServer.Close()
 	}
 
+	// complete recordings and captures before their tracks close with the rooms
+	s.recorder.Stop()
+	s.capture.Stop()
+	s.rtpForward.Stop()
 	s.roomManager.Stop()
 	s.signalServer.Stop()
 	s.ioService.Stop()
@@ -289,6 +334,9 @@ func (s *LivekitServer) Start() error {
 func (s *LivekitServer) Stop(force bool) {
 	// wait for all participants to exit
 	s.router.Drain()
+	// bots and ingests would otherwise stay until their rooms close
+	s.botService.Stop()
+	s.rtpIngest.Stop()
 	partTicker := time.NewTicker(5 * time.Second)
 	waitingForParticipants := !force && s.roomManager.HasParticipants()
 	for waitingForParticipants {
@@ -96,6 +96,20 @@ type FakeObjectStore struct {
 		result2 *livekit.RoomInternal
 		result3 error
 	}
+	LoadRoomMetadataVersionStub        func(context.Context, livekit.RoomName) (uint64, error)
+	loadRoomMetadataVersionMutex       sync.RWMutex
+	loadRoomMetadataVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomMetadataVersionReturns struct {
+		result1 uint64
+		result2 error
+	}
+	loadRoomMetadataVersionReturnsOnCall map[int]struct {
+		result1 uint64
+		result2 error
+	}
 	LockRoomStub        func(context.Context, livekit.RoomName, time.Duration) (string, error)
 	lockRoomMutex       sync.RWMutex
 	lockRoomArgsForCall []struct {
@@ -137,6 +151,19 @@ type FakeObjectStore struct {
 	storeRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreRoomMetadataVersionStub        func(context.Context, livekit.RoomName, uint64) error
+	storeRoomMetadataVersionMutex       sync.RWMutex
+	storeRoomMetadataVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 uint64
+	}
+	storeRoomMetadataVersionReturns struct {
+		result1 error
+	}
+	storeRoomMetadataVersionReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UnlockRoomStub        func(context.Context, livekit.RoomName, string) error
 	unlockRoomMutex       sync.RWMutex
 	unlockRoomArgsForCall []struct {
@@ -549,6 +576,71 @@ func (fake *FakeObjectStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeObjectStore) LoadRoomMetadataVersion(arg1 context.Context, arg2 livekit.RoomName) (uint64, error) {
+	fake.loadRoomMetadataVersionMutex.Lock()
+	ret, specificReturn := fake.loadRoomMetadataVersionReturnsOnCall[len(fake.loadRoomMetadataVersionArgsForCall)]
+	fake.loadRoomMetadataVersionArgsForCall = append(fake.loadRoomMetadataVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomMetadataVersionStub
+	fakeReturns := fake.loadRoomMetadataVersionReturns
+	fake.recordInvocation("LoadRoomMetadataVersion", []interface{}{arg1, arg2})
+	fake.loadRoomMetadataVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadRoomMetadataVersionCallCount() int {
+	fake.loadRoomMetadataVersionMutex.RLock()
+	defer fake.loadRoomMetadataVersionMutex.RUnlock()
+	return len(fake.loadRoomMetadataVersionArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadRoomMetadataVersionCalls(stub func(context.Context, livekit.RoomName) (uint64, error)) {
+	fake.loadRoomMetadataVersionMutex.Lock()
+	defer fake.loadRoomMetadataVersionMutex.Unlock()
+	fake.LoadRoomMetadataVersionStub = stub
+}
+
+func (fake *FakeObjectStore) LoadRoomMetadataVersionArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomMetadataVersionMutex.RLock()
+	defer fake.loadRoomMetadataVersionMutex.RUnlock()
+	argsForCall := fake.loadRoomMetadataVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) LoadRoomMetadataVersionReturns(result1 uint64, result2 error) {
+	fake.loadRoomMetadataVersionMutex.Lock()
+	defer fake.loadRoomMetadataVersionMutex.Unlock()
+	fake.LoadRoomMetadataVersionStub = nil
+	fake.loadRoomMetadataVersionReturns = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadRoomMetadataVersionReturnsOnCall(i int, result1 uint64, result2 error) {
+	fake.loadRoomMetadataVersionMutex.Lock()
+	defer fake.loadRoomMetadataVersionMutex.Unlock()
+	fake.LoadRoomMetadataVersionStub = nil
+	if fake.loadRoomMetadataVersionReturnsOnCall == nil {
+		fake.loadRoomMetadataVersionReturnsOnCall = make(map[int]struct {
+			result1 uint64
+			result2 error
+		})
+	}
+	fake.loadRoomMetadataVersionReturnsOnCall[i] = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeObjectStore) LockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 time.Duration) (string, error) {
 	fake.lockRoomMutex.Lock()
 	ret, specificReturn := fake.lockRoomReturnsOnCall[len(fake.lockRoomArgsForCall)]
@@ -741,6 +833,69 @@ func (fake *FakeObjectStore) StoreRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeObjectStore) StoreRoomMetadataVersion(arg1 context.Context, arg2 livekit.RoomName, arg3 uint64) error {
+	fake.storeRoomMetadataVersionMutex.Lock()
+	ret, specificReturn := fake.storeRoomMetadataVersionReturnsOnCall[len(fake.storeRoomMetadataVersionArgsForCall)]
+	fake.storeRoomMetadataVersionArgsForCall = append(fake.storeRoomMetadataVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 uint64
+	}{arg1, arg2, arg3})
+	stub := fake.StoreRoomMetadataVersionStub
+	fakeReturns := fake.storeRoomMetadataVersionReturns
+	fake.recordInvocation("StoreRoomMetadataVersion", []interface{}{arg1, arg2, arg3})
+	fake.storeRoomMetadataVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreRoomMetadataVersionCallCount() int {
+	fake.storeRoomMetadataVersionMutex.RLock()
+	defer fake.storeRoomMetadataVersionMutex.RUnlock()
+	return len(fake.storeRoomMetadataVersionArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreRoomMetadataVersionCalls(stub func(context.Context, livekit.RoomName, uint64) error) {
+	fake.storeRoomMetadataVersionMutex.Lock()
+	defer fake.storeRoomMetadataVersionMutex.Unlock()
+	fake.StoreRoomMetadataVersionStub = stub
+}
+
+func (fake *FakeObjectStore) StoreRoomMetadataVersionArgsForCall(i int) (context.Context, livekit.RoomName, uint64) {
+	fake.storeRoomMetadataVersionMutex.RLock()
+	defer fake.storeRoomMetadataVersionMutex.RUnlock()
+	argsForCall := fake.storeRoomMetadataVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) StoreRoomMetadataVersionReturns(result1 error) {
+	fake.storeRoomMetadataVersionMutex.Lock()
+	defer fake.storeRoomMetadataVersionMutex.Unlock()
+	fake.StoreRoomMetadataVersionStub = nil
+	fake.storeRoomMetadataVersionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreRoomMetadataVersionReturnsOnCall(i int, result1 error) {
+	fake.storeRoomMetadataVersionMutex.Lock()
+	defer fake.storeRoomMetadataVersionMutex.Unlock()
+	fake.StoreRoomMetadataVersionStub = nil
+	if fake.storeRoomMetadataVersionReturnsOnCall == nil {
+		fake.storeRoomMetadataVersionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRoomMetadataVersionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) UnlockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 string) error {
 	fake.unlockRoomMutex.Lock()
 	ret, specificReturn := fake.unlockRoomReturnsOnCall[len(fake.unlockRoomArgsForCall)]
@@ -819,12 +974,16 @@ func (fake *FakeObjectStore) Invocations() map[string][][]interface{} {
 	defer fake.loadParticipantMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomMetadataVersionMutex.RLock()
+	defer fake.loadRoomMetadataVersionMutex.RUnlock()
 	fake.lockRoomMutex.RLock()
 	defer fake.lockRoomMutex.RUnlock()
 	fake.storeParticipantMutex.RLock()
 	defer fake.storeParticipantMutex.RUnlock()
 	fake.storeRoomMutex.RLock()
 	defer fake.storeRoomMutex.RUnlock()
+	fake.storeRoomMetadataVersionMutex.RLock()
+	defer fake.storeRoomMetadataVersionMutex.RUnlock()
 	fake.unlockRoomMutex.RLock()
 	defer fake.unlockRoomMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -83,7 +83,7 @@ func NewDefaultSignalServer(
 		prometheus.IncrementParticipantRtcInit(1)
 
 		if rr, ok := router.(*routing.RedisRouter); ok {
-			rtcNode, err := router.GetNodeForRoom(ctx, roomName)
+			rtcNode, err := rr.GetRTCNodeForRoom(ctx, roomName)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,231 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	SubscriptionPriorityPath = "/admin/subscription_priority"
+
+	maxSubscriptionPriorityRequestSize = 4 * 1024
+)
+
+var (
+	errSubscriptionPriorityIdentity = errors.New("identity is required")
+	errSubscriptionPriorityTrack    = errors.New("track is required")
+	errSubscriptionPriorityRange    = errors.New("priority must be between 0 and 255")
+	errSubscriptionPriorityMissing  = errors.New("priority or pinned is required")
+)
+
+type SetSubscriptionPriorityRequest struct {
+	Room string `json:"room"`
+	// subscriber
+	Identity string `json:"identity"`
+	// subscribed video track
+	Track string `json:"track"`
+	// 1 (lowest) to 255 (highest), 0 removes it, kept when omitted
+	Priority *int `json:"priority,omitempty"`
+	// pins the track for room allocation policy, kept when omitted
+	Pinned *bool `json:"pinned,omitempty"`
+}
+
+type SubscriptionPriorityInfo struct {
+	Room     string                          `json:"room"`
+	Identity string                          `json:"identity"`
+	Tracks   []SubscriptionPriorityTrackInfo `json:"tracks"`
+}
+
+type SubscriptionPriorityTrackInfo struct {
+	Track string `json:"track"`
+	// set by admins, room allocation policy and the subscriber
+	AdminPriority  uint8 `json:"admin_priority,omitempty"`
+	PolicyPriority uint8 `json:"policy_priority,omitempty"`
+	ClientPriority uint8 `json:"client_priority,omitempty"`
+	// in effect, the stream allocator picks a default by source when 0
+	Priority uint8 `json:"priority,omitempty"`
+	Pinned   bool  `json:"pinned,omitempty"`
+}
+
+// SubscriptionPriorityService lets room admins set (POST) and inspect (GET ?room=&identity=) priorities of video
+// a participant is subscribed to at /admin/subscription_priority. When downstream bandwidth is short,
+// higher priority video keeps its quality while lower priority video is reduced or paused first.
+// Admin priorities override room allocation policy and client priorities, pinned video is ranked by room allocation
+// policy. Both last until the participant leaves, other nodes forward requests to the node hosting the room.
+type SubscriptionPriorityService struct {
+	rooms     RoomProvider
+	forwarder *RoomForwarder
+}
+
+func NewSubscriptionPriorityService(rooms RoomProvider, forwarder *RoomForwarder) *SubscriptionPriorityService {
+	return &SubscriptionPriorityService{
+		rooms:     rooms,
+		forwarder: forwarder,
+	}
+}
+
+func (s *SubscriptionPriorityService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.setSubscriptionPriority(w, r)
+	case http.MethodGet:
+		s.getSubscriptionPriority(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *SubscriptionPriorityService) setSubscriptionPriority(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSubscriptionPriorityRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req SetSubscriptionPriorityRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	roomName := livekit.RoomName(req.Room)
+	identity := livekit.ParticipantIdentity(req.Identity)
+	trackID := livekit.TrackID(req.Track)
+	switch {
+	case roomName == "":
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	case identity == "":
+		handleError(w, http.StatusBadRequest, errSubscriptionPriorityIdentity)
+		return
+	case trackID == "":
+		handleError(w, http.StatusBadRequest, errSubscriptionPriorityTrack)
+		return
+	case req.Priority == nil && req.Pinned == nil:
+		handleError(w, http.StatusBadRequest, errSubscriptionPriorityMissing)
+		return
+	case req.Priority != nil && (*req.Priority < 0 || *req.Priority > math.MaxUint8):
+		handleError(w, http.StatusBadRequest, errSubscriptionPriorityRange)
+		return
+	}
+	if err = EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		handleError(w, http.StatusNotFound, ErrParticipantNotFound, "room", roomName, "identity", identity)
+		return
+	}
+
+	if req.Pinned != nil {
+		err = room.SetSubscriptionPinned(participant, trackID, *req.Pinned)
+	}
+	if err == nil && req.Priority != nil {
+		err = participant.SetSubscriptionPriority(trackID, uint8(*req.Priority))
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, rtc.ErrTrackNotFound):
+		handleError(w, http.StatusNotFound, ErrTrackNotFound, "room", roomName, "identity", identity, "trackID", trackID)
+		return
+	case errors.Is(err, rtc.ErrSubscriptionPinningDisabled):
+		handleError(w, http.StatusBadRequest, err, "room", roomName)
+		return
+	default:
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "identity", identity, "trackID", trackID)
+		return
+	}
+
+	writeJSON(w, subscriptionPriorityInfo(room, participant))
+}
+
+func (s *SubscriptionPriorityService) getSubscriptionPriority(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	identity := livekit.ParticipantIdentity(r.URL.Query().Get("identity"))
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+	if identity == "" {
+		handleError(w, http.StatusBadRequest, errSubscriptionPriorityIdentity)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, http.StatusUnauthorized, err, "room", roomName)
+		return
+	}
+
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		handleError(w, http.StatusNotFound, ErrParticipantNotFound, "room", roomName, "identity", identity)
+		return
+	}
+
+	writeJSON(w, subscriptionPriorityInfo(room, participant))
+}
+
+func subscriptionPriorityInfo(room *rtc.Room, participant types.LocalParticipant) SubscriptionPriorityInfo {
+	priorities := participant.GetSubscriptionPriorities()
+	info := SubscriptionPriorityInfo{
+		Room:     string(room.Name()),
+		Identity: string(participant.Identity()),
+		Tracks:   make([]SubscriptionPriorityTrackInfo, 0, len(priorities)),
+	}
+	for _, sp := range priorities {
+		info.Tracks = append(info.Tracks, SubscriptionPriorityTrackInfo{
+			Track:          string(sp.TrackID),
+			AdminPriority:  sp.Admin,
+			PolicyPriority: sp.Policy,
+			ClientPriority: sp.Client,
+			Priority:       sp.Priority,
+			Pinned:         room.IsSubscriptionPinned(participant.ID(), sp.TrackID),
+		})
+	}
+	sort.Slice(info.Tracks, func(i, j int) bool {
+		return info.Tracks[i].Track < info.Tracks[j].Track
+	})
+	return info
+}
@@ -0,0 +1,341 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"golang.org/x/exp/slices"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	WHEPPath = "/whep"
+
+	whepResourcePrefix = "WP_"
+
+	whepSubscribeTimeout  = 5 * time.Second
+	whepSubscribeInterval = 50 * time.Millisecond
+)
+
+var (
+	errWHEPNoMedia          = errors.New("offer has no audio or video to receive")
+	errWHEPNoTracks         = errors.New("no tracks to play")
+	errWHEPSubscribeTimeout = errors.New("timed out while subscribing to tracks")
+)
+
+// WHEPService lets players without the LiveKit SDK, like smart TVs and simple web players, receive tracks of
+// rooms over WHEP. A POST to /whep with an offer, authenticated by a token allowing to subscribe, joins the room
+// as a hidden, receive only participant and returns the answer. Tracks are selected by the `track` (track SIDs) and
+// `participant` (identities) query parameters, all tracks of the room by default, one for each offered audio and video
+// section. They are forwarded through the subscriber transport, answering the offer of the player, with the usual
+// down track adaptation. Trickled candidates (PATCH) and leaving (DELETE) are handled at the returned location.
+// Tracks are negotiated once, tracks published later are not added to the session.
+// Sessions run on the node hosting the room, other nodes forward requests to it.
+type WHEPService struct {
+	rooms        RoomProvider
+	startSession routing.NewParticipantCallback
+	region       string
+	forwarder    *RoomForwarder
+	sessions     *sdpSessions
+}
+
+func NewWHEPService(
+	conf *config.Config,
+	rooms RoomProvider,
+	startSession routing.NewParticipantCallback,
+	forwarder *RoomForwarder,
+) *WHEPService {
+	return &WHEPService{
+		rooms:        rooms,
+		startSession: startSession,
+		region:       conf.Region,
+		forwarder:    forwarder,
+		sessions:     newSDPSessions(forwarder),
+	}
+}
+
+func (s *WHEPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resourceID := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, WHEPPath), "/")
+	switch {
+	case resourceID == "" && r.Method == http.MethodPost:
+		s.play(w, r)
+	case resourceID != "" && r.Method == http.MethodPatch:
+		s.sessions.trickle(w, r, resourceID)
+	case resourceID != "" && r.Method == http.MethodDelete:
+		s.sessions.leave(w, r, resourceID)
+	default:
+		if resourceID == "" {
+			w.Header().Set("Allow", "POST")
+		} else {
+			w.Header().Set("Allow", "PATCH, DELETE")
+		}
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *WHEPService) play(w http.ResponseWriter, r *http.Request) {
+	if !hasContentType(r, sdpContentType) {
+		handleError(w, http.StatusUnsupportedMediaType, errSDPContentType)
+		return
+	}
+	claims := GetGrants(r.Context())
+	if claims == nil || claims.Video == nil {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+	roomName, err := EnsureJoinPermission(r.Context())
+	if err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if !claims.Video.GetCanSubscribe() {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+	if claims.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSDPRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	offer := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: string(body)}
+	kinds, iceUfrag, err := parseWHEPOffer(offer.SDP)
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err, "room", roomName)
+		return
+	}
+
+	// sessions live with the room, so PATCH and DELETE requests can find them
+	room := s.rooms.GetRoom(r.Context(), roomName)
+	if room == nil {
+		if !s.forwarder.forward(w, r, roomName) {
+			handleError(w, http.StatusNotFound, ErrRoomNotFound, "room", roomName)
+		}
+		return
+	}
+
+	trackIDs := selectWHEPTracks(room, r.URL.Query(), kinds)
+	if len(trackIDs) == 0 {
+		handleError(w, http.StatusNotFound, errWHEPNoTracks, "room", roomName)
+		return
+	}
+
+	grants := claims.Clone()
+	grants.Video.SetCanPublish(false)
+	grants.Video.SetCanPublishData(false)
+	grants.Video.Hidden = true
+	pi := routing.ParticipantInit{
+		Identity: livekit.ParticipantIdentity(grants.Identity),
+		Name:     livekit.ParticipantName(grants.Name),
+		Client: &livekit.ClientInfo{
+			Sdk:      livekit.ClientInfo_UNKNOWN,
+			Protocol: int32(types.CurrentProtocol),
+			Address:  GetClientIP(r),
+		},
+		Grants:      grants,
+		Region:      s.region,
+		ReceiveOnly: true,
+	}
+	connID := livekit.ConnectionID(utils.NewGuid("CO_"))
+	requests := routing.NewDefaultMessageChannel(connID)
+	responses := routing.NewDefaultMessageChannel(connID)
+	// the signal connection outlives the request
+	if err = s.startSession(context.Background(), roomName, pi, requests, responses); err != nil {
+		requests.Close()
+		responses.Close()
+		prometheus.IncrementParticipantJoinFail(1)
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "participant", pi.Identity)
+		return
+	}
+
+	session := &sdpSession{
+		id:        utils.NewGuid(whepResourcePrefix),
+		protocol:  "WHEP",
+		roomName:  roomName,
+		identity:  pi.Identity,
+		iceUfrag:  iceUfrag,
+		target:    livekit.SignalTarget_SUBSCRIBER,
+		logger:    rtc.LoggerWithParticipant(rtc.LoggerWithRoom(logger.GetLogger(), roomName, room.ID()), pi.Identity, "", false),
+		requests:  requests,
+		responses: responses,
+		closed:    make(chan struct{}),
+	}
+	answer, err := s.negotiate(r.Context(), session, room, trackIDs, offer)
+	if err != nil {
+		prometheus.IncrementParticipantJoinFail(1)
+		session.close(true)
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "participant", pi.Identity)
+		return
+	}
+	prometheus.IncrementParticipantJoin(1)
+
+	s.sessions.start(session)
+	session.logger.Infow("WHEP session started", "connID", connID, "resource", session.id, "tracks", trackIDs)
+
+	w.Header().Set("Content-Type", sdpContentType)
+	w.Header().Set("Location", WHEPPath+"/"+session.id)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(answer))
+}
+
+// negotiate subscribes to the tracks, returning the answer to the offer with candidates of the server.
+// Down tracks are added before the offer is handled, so they are part of the answer.
+func (s *WHEPService) negotiate(
+	ctx context.Context,
+	session *sdpSession,
+	room *rtc.Room,
+	trackIDs []livekit.TrackID,
+	offer webrtc.SessionDescription,
+) (string, error) {
+	if _, err := session.join(); err != nil {
+		return "", err
+	}
+
+	if err := session.requests.WriteMessage(&livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Subscription{
+			Subscription: &livekit.UpdateSubscription{
+				TrackSids: livekit.IDsAsStrings(trackIDs),
+				Subscribe: true,
+			},
+		},
+	}); err != nil {
+		return "", err
+	}
+	if err := waitForSubscribedTracks(ctx, room, session.identity, len(trackIDs)); err != nil {
+		return "", err
+	}
+
+	return session.exchange(ctx, offer)
+}
+
+// waitForSubscribedTracks waits until all tracks are subscribed, or some once subscribing takes too long
+func waitForSubscribedTracks(ctx context.Context, room *rtc.Room, identity livekit.ParticipantIdentity, count int) error {
+	ticker := time.NewTicker(whepSubscribeInterval)
+	defer ticker.Stop()
+	deadline := time.After(whepSubscribeTimeout)
+	subscribed := 0
+	for {
+		if p := room.GetParticipant(identity); p != nil {
+			subscribed = len(p.GetSubscribedTracks())
+		}
+		if subscribed >= count {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			if subscribed == 0 {
+				return errWHEPSubscribeTimeout
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseWHEPOffer returns the kinds of the media sections of the offer to receive and its ICE username fragment
+func parseWHEPOffer(offer string) ([]livekit.TrackType, string, error) {
+	mds, iceUfrag, err := parseOffer(offer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var kinds []livekit.TrackType
+	for _, md := range mds {
+		if _, ok := md.Attribute("sendonly"); ok {
+			continue
+		}
+		kind, _ := mediaKind(md)
+		kinds = append(kinds, kind)
+	}
+	if len(kinds) == 0 {
+		return nil, "", errWHEPNoMedia
+	}
+	return kinds, iceUfrag, nil
+}
+
+// selectWHEPTracks picks a published track for each offered kind, in the order of the `track` query parameters,
+// or of the publishers of the `participant` query parameters, or of all publishers of the room
+func selectWHEPTracks(room *rtc.Room, query url.Values, kinds []livekit.TrackType) []livekit.TrackID {
+	participants := room.GetParticipants()
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].Identity() < participants[j].Identity()
+	})
+
+	var tracks []types.MediaTrack
+	if trackIDs := query["track"]; len(trackIDs) > 0 {
+		for _, trackID := range trackIDs {
+			for _, p := range participants {
+				if track := p.GetPublishedTrack(livekit.TrackID(trackID)); track != nil {
+					tracks = append(tracks, track)
+					break
+				}
+			}
+		}
+	} else {
+		identities := query["participant"]
+		for _, p := range participants {
+			if len(identities) > 0 && !slices.Contains(identities, string(p.Identity())) {
+				continue
+			}
+			tracks = append(tracks, p.GetPublishedTracks()...)
+		}
+	}
+
+	var selected []livekit.TrackID
+	for _, kind := range kinds {
+		for i, track := range tracks {
+			if track != nil && track.Kind() == kind {
+				selected = append(selected, track.ID())
+				tracks[i] = nil
+				break
+			}
+		}
+	}
+	return selected
+}
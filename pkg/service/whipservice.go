@@ -0,0 +1,268 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	WHIPPath = "/whip"
+
+	whipResourcePrefix = "WH_"
+)
+
+var errWHIPNoTracks = errors.New("offer has no audio or video to publish")
+
+// whipTrack is a media section of the offer, published as a track
+type whipTrack struct {
+	cid  string
+	kind livekit.TrackType
+}
+
+// WHIPService lets encoders like OBS and GStreamer publish into rooms over WHIP, https://www.rfc-editor.org/rfc/rfc9725.
+// A POST to /whip with an offer, authenticated by a join token, joins the room as a publish only participant and
+// returns the answer. The participant publishes through its own publisher transport, the session here only relays
+// signaling: trickled candidates of the encoder (PATCH) and leaving (DELETE) at the returned location.
+// Sessions run on the node hosting the room, other nodes forward requests to it.
+type WHIPService struct {
+	router        routing.Router
+	roomAllocator RoomAllocator
+	region        string
+	forwarder     *RoomForwarder
+	sessions      *sdpSessions
+}
+
+func NewWHIPService(
+	conf *config.Config,
+	ra RoomAllocator,
+	router routing.Router,
+	forwarder *RoomForwarder,
+) *WHIPService {
+	return &WHIPService{
+		router:        router,
+		roomAllocator: ra,
+		region:        conf.Region,
+		forwarder:     forwarder,
+		sessions:      newSDPSessions(forwarder),
+	}
+}
+
+func (s *WHIPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resourceID := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, WHIPPath), "/")
+	switch {
+	case resourceID == "" && r.Method == http.MethodPost:
+		s.publish(w, r)
+	case resourceID != "" && r.Method == http.MethodPatch:
+		s.sessions.trickle(w, r, resourceID)
+	case resourceID != "" && r.Method == http.MethodDelete:
+		s.sessions.leave(w, r, resourceID)
+	default:
+		if resourceID == "" {
+			w.Header().Set("Allow", "POST")
+		} else {
+			w.Header().Set("Allow", "PATCH, DELETE")
+		}
+		handleError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *WHIPService) publish(w http.ResponseWriter, r *http.Request) {
+	if !hasContentType(r, sdpContentType) {
+		handleError(w, http.StatusUnsupportedMediaType, errSDPContentType)
+		return
+	}
+	claims := GetGrants(r.Context())
+	if claims == nil || claims.Video == nil {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+	roomName, err := EnsureJoinPermission(r.Context())
+	if err != nil {
+		handleError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if !claims.Video.GetCanPublish() {
+		handleError(w, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+	if claims.Identity == "" {
+		handleError(w, http.StatusBadRequest, ErrIdentityEmpty)
+		return
+	}
+	if roomName == "" {
+		handleError(w, http.StatusBadRequest, errRoomRequired)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSDPRequestSize))
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	// forwarded requests need the body again
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	offer := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: string(body)}
+	tracks, iceUfrag, err := parseWHIPOffer(offer.SDP)
+	if err != nil {
+		handleError(w, http.StatusBadRequest, err, "room", roomName)
+		return
+	}
+
+	if err = s.roomAllocator.ValidateCreateRoom(r.Context(), roomName); err != nil {
+		if errors.Is(err, ErrRoomNotFound) {
+			handleError(w, http.StatusNotFound, err, "room", roomName)
+		} else {
+			handleError(w, http.StatusInternalServerError, err, "room", roomName)
+		}
+		return
+	}
+	room, _, err := s.roomAllocator.CreateRoom(r.Context(), &livekit.CreateRoomRequest{Name: string(roomName)})
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err, "room", roomName)
+		return
+	}
+	// sessions live with the room, so PATCH and DELETE requests can find them
+	if s.forwarder.forward(w, r, roomName) {
+		return
+	}
+
+	grants := claims.Clone()
+	grants.Video.SetCanSubscribe(false)
+	pi := routing.ParticipantInit{
+		Identity: livekit.ParticipantIdentity(grants.Identity),
+		Name:     livekit.ParticipantName(grants.Name),
+		Client: &livekit.ClientInfo{
+			Sdk:      livekit.ClientInfo_UNKNOWN,
+			Protocol: int32(types.CurrentProtocol),
+			Address:  GetClientIP(r),
+		},
+		Grants:         grants,
+		Region:         s.region,
+		DataTopics:     GetDataTopicGrant(r.Context()),
+		PublishBitrate: GetPublishBitrateGrant(r.Context()),
+	}
+	// the signal connection outlives the request
+	connID, requests, responses, err := s.router.StartParticipantSignal(context.Background(), roomName, pi)
+	if err != nil {
+		prometheus.IncrementParticipantJoinFail(1)
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "participant", pi.Identity)
+		return
+	}
+
+	session := &sdpSession{
+		id:        utils.NewGuid(whipResourcePrefix),
+		protocol:  "WHIP",
+		roomName:  roomName,
+		identity:  pi.Identity,
+		iceUfrag:  iceUfrag,
+		target:    livekit.SignalTarget_PUBLISHER,
+		logger:    rtc.LoggerWithParticipant(rtc.LoggerWithRoom(logger.GetLogger(), roomName, livekit.RoomID(room.Sid)), pi.Identity, "", false),
+		requests:  requests,
+		responses: responses,
+		closed:    make(chan struct{}),
+	}
+	answer, err := s.negotiate(r.Context(), session, tracks, offer)
+	if err != nil {
+		prometheus.IncrementParticipantJoinFail(1)
+		session.close(true)
+		handleError(w, http.StatusInternalServerError, err, "room", roomName, "participant", pi.Identity)
+		return
+	}
+	prometheus.IncrementParticipantJoin(1)
+
+	s.sessions.start(session)
+	session.logger.Infow("WHIP session started", "connID", connID, "resource", session.id, "tracks", len(tracks))
+
+	w.Header().Set("Content-Type", sdpContentType)
+	w.Header().Set("Location", WHIPPath+"/"+session.id)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(answer))
+}
+
+// negotiate publishes the tracks of the offer, returning the answer with candidates of the server
+func (s *WHIPService) negotiate(ctx context.Context, session *sdpSession, tracks []whipTrack, offer webrtc.SessionDescription) (string, error) {
+	if _, err := session.join(); err != nil {
+		return "", err
+	}
+
+	for _, t := range tracks {
+		source := livekit.TrackSource_MICROPHONE
+		if t.kind == livekit.TrackType_VIDEO {
+			source = livekit.TrackSource_CAMERA
+		}
+		if err := session.requests.WriteMessage(&livekit.SignalRequest{
+			Message: &livekit.SignalRequest_AddTrack{
+				AddTrack: &livekit.AddTrackRequest{
+					Cid:    t.cid,
+					Name:   strings.ToLower(t.kind.String()),
+					Type:   t.kind,
+					Source: source,
+				},
+			},
+		}); err != nil {
+			return "", err
+		}
+	}
+	return session.exchange(ctx, offer)
+}
+
+// parseWHIPOffer returns the tracks of the offer and its ICE username fragment
+func parseWHIPOffer(offer string) ([]whipTrack, string, error) {
+	mds, iceUfrag, err := parseOffer(offer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var tracks []whipTrack
+	for i, md := range mds {
+		if _, ok := md.Attribute("recvonly"); ok {
+			continue
+		}
+		kind, _ := mediaKind(md)
+
+		// tracks are matched by the id of their msid, or by kind without one
+		cid := fmt.Sprintf("whip_%d", i)
+		if msid, ok := md.Attribute("msid"); ok {
+			if parts := strings.Fields(msid); len(parts) == 2 {
+				cid = parts[1]
+			}
+		}
+		tracks = append(tracks, whipTrack{cid: cid, kind: kind})
+	}
+	if len(tracks) == 0 {
+		return nil, "", errWHIPNoTracks
+	}
+	return tracks, iceUfrag, nil
+}
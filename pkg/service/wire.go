@@ -72,6 +72,8 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		NewRoomAllocator,
 		NewRoomService,
 		NewRTCService,
+		NewRoomForwarder,
+		NewWHIPService,
 		getSignalRelayConfig,
 		NewDefaultSignalServer,
 		routing.NewSignalClient,
@@ -146,7 +148,7 @@ func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webh
 		return nil, ErrWebHookMissingAPIKey
 	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	return telemetry.NewAttributesWebhookNotifier(wc.APIKey, secret, wc.URLs), nil
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
@@ -0,0 +1,243 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	// senders treat the last received limit as current, it is resent in case feedback is lost
+	bitrateCapRefreshInterval = time.Second
+
+	// sent once when a cap is removed, as senders keep applying the last limit they received
+	bitrateCapUncapped = 10_000_000_000
+
+	// multiple of the bitrate of layers subscribers need, lets those layers ramp up
+	bitrateCapDemandHeadroom = 1.5
+
+	tmmbrFMT          = 3
+	tmmbrHeaderLength = 12
+	tmmbrEntryLength  = 8
+	// IPv4 + UDP + RTP headers
+	tmmbrOverhead = 40
+)
+
+var (
+	errTMMBRPacketTooShort = errors.New("TMMBR packet too short")
+	errTMMBRWrongType      = errors.New("not a TMMBR packet")
+)
+
+// TMMBR is a Temporary Maximum Media Stream Bit Rate Request (RFC 5104), which pion/rtcp does not implement
+type TMMBR struct {
+	SenderSSRC uint32
+	Entries    []TMMBREntry
+}
+
+type TMMBREntry struct {
+	SSRC uint32
+	// bits per second
+	Bitrate uint64
+	// bytes per packet
+	Overhead uint16
+}
+
+func (t *TMMBR) DestinationSSRC() []uint32 {
+	ssrcs := make([]uint32, 0, len(t.Entries))
+	for _, e := range t.Entries {
+		ssrcs = append(ssrcs, e.SSRC)
+	}
+	return ssrcs
+}
+
+func (t *TMMBR) Marshal() ([]byte, error) {
+	size := tmmbrHeaderLength + tmmbrEntryLength*len(t.Entries)
+	buf := make([]byte, size)
+
+	hdr, err := rtcp.Header{
+		Count:  tmmbrFMT,
+		Type:   rtcp.TypeTransportSpecificFeedback,
+		Length: uint16(size/4 - 1),
+	}.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	copy(buf, hdr)
+	binary.BigEndian.PutUint32(buf[4:], t.SenderSSRC)
+	// media source SSRC is not used, the entries carry SSRCs
+
+	for i, e := range t.Entries {
+		offset := tmmbrHeaderLength + i*tmmbrEntryLength
+		mantissa, exp := e.Bitrate, uint32(0)
+		for mantissa > 0x1ffff {
+			mantissa >>= 1
+			exp++
+		}
+		binary.BigEndian.PutUint32(buf[offset:], e.SSRC)
+		binary.BigEndian.PutUint32(buf[offset+4:], exp<<26|uint32(mantissa)<<9|uint32(e.Overhead&0x1ff))
+	}
+	return buf, nil
+}
+
+func (t *TMMBR) Unmarshal(rawPacket []byte) error {
+	var hdr rtcp.Header
+	if err := hdr.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+	if hdr.Type != rtcp.TypeTransportSpecificFeedback || hdr.Count != tmmbrFMT {
+		return errTMMBRWrongType
+	}
+	size := (int(hdr.Length) + 1) * 4
+	if size < tmmbrHeaderLength || len(rawPacket) < size {
+		return errTMMBRPacketTooShort
+	}
+
+	t.SenderSSRC = binary.BigEndian.Uint32(rawPacket[4:])
+	t.Entries = t.Entries[:0]
+	for offset := tmmbrHeaderLength; offset+tmmbrEntryLength <= size; offset += tmmbrEntryLength {
+		v := binary.BigEndian.Uint32(rawPacket[offset+4:])
+		t.Entries = append(t.Entries, TMMBREntry{
+			SSRC:     binary.BigEndian.Uint32(rawPacket[offset:]),
+			Bitrate:  uint64(v>>9&0x1ffff) << (v >> 26),
+			Overhead: uint16(v & 0x1ff),
+		})
+	}
+	return nil
+}
+
+func hasTMMBRFeedback(feedback []webrtc.RTCPFeedback) bool {
+	for _, fb := range feedback {
+		if fb.Type == webrtc.TypeRTCPFBCCM && fb.Parameter == "tmmbr" {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------
+
+type BitrateCapParams struct {
+	// send TMMBR in addition to REMB, when the publisher negotiated it
+	UseTMMBR bool
+	// returns the bitrate to cap to, 0 when not capped, and the SSRCs of the track
+	GetCap   func() (int64, []uint32)
+	SendRTCP func(pkts []rtcp.Packet)
+	Logger   logger.Logger
+}
+
+// BitrateCap limits the bitrate of a published track by sending REMB, and optionally TMMBR for single stream tracks,
+// feedback to the publisher.
+// Feedback is refreshed while capped, and the cap is lifted explicitly once it is removed.
+type BitrateCap struct {
+	params BitrateCapParams
+
+	lock         sync.Mutex
+	bitrate      int64
+	refreshTimer *time.Timer
+	isStopped    bool
+}
+
+func NewBitrateCap(params BitrateCapParams) *BitrateCap {
+	return &BitrateCap{
+		params: params,
+	}
+}
+
+// Update sends feedback for the current cap, call it whenever the cap or the SSRCs change
+func (b *BitrateCap) Update() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.isStopped {
+		return
+	}
+
+	bitrate, ssrcs := b.params.GetCap()
+	if len(ssrcs) == 0 {
+		return
+	}
+
+	if bitrate <= 0 {
+		if b.bitrate != 0 {
+			b.params.Logger.Debugw("removing bitrate cap", "previous", b.bitrate)
+			b.bitrate = 0
+			b.send(bitrateCapUncapped, ssrcs)
+		}
+		if b.refreshTimer != nil {
+			b.refreshTimer.Stop()
+			b.refreshTimer = nil
+		}
+		return
+	}
+
+	if bitrate != b.bitrate {
+		b.params.Logger.Debugw("setting bitrate cap", "bitrate", bitrate, "previous", b.bitrate)
+		b.bitrate = bitrate
+	}
+	b.send(bitrate, ssrcs)
+	if b.refreshTimer == nil {
+		b.refreshTimer = time.AfterFunc(bitrateCapRefreshInterval, b.refresh)
+	}
+}
+
+func (b *BitrateCap) Stop() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.isStopped = true
+	if b.refreshTimer != nil {
+		b.refreshTimer.Stop()
+		b.refreshTimer = nil
+	}
+}
+
+func (b *BitrateCap) refresh() {
+	b.lock.Lock()
+	b.refreshTimer = nil
+	b.lock.Unlock()
+
+	b.Update()
+}
+
+func (b *BitrateCap) send(bitrate int64, ssrcs []uint32) {
+	pkts := []rtcp.Packet{
+		&rtcp.ReceiverEstimatedMaximumBitrate{
+			Bitrate: float32(bitrate),
+			SSRCs:   ssrcs,
+		},
+	}
+	// TMMBR limits each stream, there is no good split of the cap between simulcast layers,
+	// so simulcast tracks are left to REMB which limits them in aggregate
+	if b.params.UseTMMBR && len(ssrcs) == 1 {
+		pkts = append(pkts, &TMMBR{
+			Entries: []TMMBREntry{
+				{
+					SSRC:     ssrcs[0],
+					Bitrate:  uint64(bitrate),
+					Overhead: tmmbrOverhead,
+				},
+			},
+		})
+	}
+	b.params.SendRTCP(pkts)
+}
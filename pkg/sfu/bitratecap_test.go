@@ -0,0 +1,121 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+)
+
+func TestTMMBR(t *testing.T) {
+	tmmbr := &TMMBR{
+		SenderSSRC: 1,
+		Entries: []TMMBREntry{
+			{SSRC: 1234, Bitrate: 500_000, Overhead: 40},
+			{SSRC: 5678, Bitrate: 100_000, Overhead: 40},
+		},
+	}
+	raw, err := tmmbr.Marshal()
+	require.NoError(t, err)
+	require.Len(t, raw, 28)
+
+	var parsed TMMBR
+	require.NoError(t, parsed.Unmarshal(raw))
+	require.Equal(t, uint32(1), parsed.SenderSSRC)
+	require.Equal(t, []uint32{1234, 5678}, parsed.DestinationSSRC())
+	// mantissa is 17 bits, precision is lost above that
+	require.InDelta(t, 500_000, parsed.Entries[0].Bitrate, 4)
+	require.Equal(t, uint64(100_000), parsed.Entries[1].Bitrate)
+	require.Equal(t, uint16(40), parsed.Entries[0].Overhead)
+
+	// pion parses it as a raw packet of the same type
+	pkts, err := rtcp.Unmarshal(raw)
+	require.NoError(t, err)
+	require.Len(t, pkts, 1)
+	require.Equal(t, rtcp.TypeTransportSpecificFeedback, pkts[0].(*rtcp.RawPacket).Header().Type)
+}
+
+func TestBitrateCap(t *testing.T) {
+	var lock sync.Mutex
+	bitrate := int64(0)
+	ssrcs := []uint32{1234}
+	var sent [][]rtcp.Packet
+	getSent := func() [][]rtcp.Packet {
+		lock.Lock()
+		defer lock.Unlock()
+		s := sent
+		sent = nil
+		return s
+	}
+
+	b := NewBitrateCap(BitrateCapParams{
+		UseTMMBR: true,
+		GetCap: func() (int64, []uint32) {
+			lock.Lock()
+			defer lock.Unlock()
+			return bitrate, ssrcs
+		},
+		SendRTCP: func(pkts []rtcp.Packet) {
+			lock.Lock()
+			defer lock.Unlock()
+			sent = append(sent, pkts)
+		},
+		Logger: logger.GetLogger(),
+	})
+	defer b.Stop()
+
+	// nothing sent when not capped
+	b.Update()
+	require.Empty(t, getSent())
+
+	lock.Lock()
+	bitrate = 300_000
+	lock.Unlock()
+	b.Update()
+	s := getSent()
+	require.Len(t, s, 1)
+	require.Len(t, s[0], 2)
+	remb := s[0][0].(*rtcp.ReceiverEstimatedMaximumBitrate)
+	require.Equal(t, float32(300_000), remb.Bitrate)
+	require.Equal(t, []uint32{1234}, remb.SSRCs)
+	require.Equal(t, uint64(300_000), s[0][1].(*TMMBR).Entries[0].Bitrate)
+
+	// simulcast tracks are capped in aggregate by REMB only
+	lock.Lock()
+	ssrcs = []uint32{1234, 5678}
+	lock.Unlock()
+	b.Update()
+	s = getSent()
+	require.Len(t, s, 1)
+	require.Len(t, s[0], 1)
+	require.Equal(t, []uint32{1234, 5678}, s[0][0].(*rtcp.ReceiverEstimatedMaximumBitrate).SSRCs)
+
+	// removing the cap lifts it once
+	lock.Lock()
+	bitrate = 0
+	lock.Unlock()
+	b.Update()
+	s = getSent()
+	require.Len(t, s, 1)
+	require.Equal(t, float32(bitrateCapUncapped), s[0][0].(*rtcp.ReceiverEstimatedMaximumBitrate).Bitrate)
+
+	b.Update()
+	require.Empty(t, getSent())
+}
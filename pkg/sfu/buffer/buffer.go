@@ -123,6 +123,8 @@ type Buffer struct {
 
 	packetNotFoundCount atomic.Uint32
 	packetTooOldCount   atomic.Uint32
+
+	captureSource *packetCaptureSource
 }
 
 // NewBuffer constructs a new Buffer
@@ -275,6 +277,10 @@ func (b *Buffer) Write(pkt []byte) (n int, err error) {
 		return
 	}
 
+	if pc := b.captureSource.get(); pc != nil {
+		pc.CaptureRTP(PacketDirectionInbound, pkt)
+	}
+
 	if !b.bound {
 		packet := make([]byte, len(pkt))
 		copy(packet, pkt)
@@ -47,10 +47,11 @@ func NewFactoryOfBufferFactory(trackingPackets int) *FactoryOfBufferFactory {
 
 func (f *FactoryOfBufferFactory) CreateBufferFactory() *Factory {
 	return &Factory{
-		videoPool:   f.videoPool,
-		audioPool:   f.audioPool,
-		rtpBuffers:  make(map[uint32]*Buffer),
-		rtcpReaders: make(map[uint32]*RTCPReader),
+		videoPool:     f.videoPool,
+		audioPool:     f.audioPool,
+		rtpBuffers:    make(map[uint32]*Buffer),
+		rtcpReaders:   make(map[uint32]*RTCPReader),
+		captureSource: &packetCaptureSource{},
 	}
 }
 
@@ -60,6 +61,8 @@ type Factory struct {
 	audioPool   *sync.Pool
 	rtpBuffers  map[uint32]*Buffer
 	rtcpReaders map[uint32]*RTCPReader
+
+	captureSource *packetCaptureSource
 }
 
 func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
@@ -71,6 +74,7 @@ func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io
 			return reader
 		}
 		reader := NewRTCPReader(ssrc)
+		reader.captureSource = f.captureSource
 		f.rtcpReaders[ssrc] = reader
 		reader.OnClose(func() {
 			f.Lock()
@@ -83,6 +87,7 @@ func (f *Factory) GetOrNew(packetType packetio.BufferPacketType, ssrc uint32) io
 			return reader
 		}
 		buffer := NewBuffer(ssrc, f.videoPool, f.audioPool)
+		buffer.captureSource = f.captureSource
 		f.rtpBuffers[ssrc] = buffer
 		buffer.OnClose(func() {
 			f.Lock()
@@ -111,3 +116,23 @@ func (f *Factory) GetRTCPReader(ssrc uint32) *RTCPReader {
 	defer f.RUnlock()
 	return f.rtcpReaders[ssrc]
 }
+
+// SetPacketCapture passes packets of all streams of the factory, current and future ones, to a capture.
+// Capturing stops when set to nil.
+func (f *Factory) SetPacketCapture(pc PacketCapture) {
+	if f.captureSource != nil {
+		f.captureSource.set(pc)
+	}
+}
+
+// PacketCapture returns the capture set on the factory, if any
+func (f *Factory) PacketCapture() PacketCapture {
+	return f.captureSource.get()
+}
+
+// ClearPacketCapture stops capturing, unless another capture was set since
+func (f *Factory) ClearPacketCapture(pc PacketCapture) {
+	if f.captureSource != nil {
+		f.captureSource.clear(pc)
+	}
+}
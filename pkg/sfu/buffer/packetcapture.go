@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"go.uber.org/atomic"
+)
+
+type PacketDirection int
+
+const (
+	// received from the participant
+	PacketDirectionInbound PacketDirection = iota
+	// sent to the participant
+	PacketDirectionOutbound
+)
+
+func (d PacketDirection) String() string {
+	switch d {
+	case PacketDirectionInbound:
+		return "inbound"
+	case PacketDirectionOutbound:
+		return "outbound"
+	default:
+		return "unknown"
+	}
+}
+
+// PacketCapture receives decrypted RTP and RTCP packets of a participant, for debugging.
+// Packets are only valid during the call, and calls are made from media paths, so they should not block.
+type PacketCapture interface {
+	CaptureRTP(direction PacketDirection, pkt []byte)
+	CaptureRTCP(direction PacketDirection, pkt []byte)
+}
+
+type packetCaptureBox struct {
+	PacketCapture
+}
+
+// packetCaptureSource shares the capture of a participant with the streams created by its buffer factory
+type packetCaptureSource struct {
+	capture atomic.Value // packetCaptureBox
+}
+
+func (s *packetCaptureSource) set(pc PacketCapture) {
+	s.capture.Store(packetCaptureBox{pc})
+}
+
+func (s *packetCaptureSource) get() PacketCapture {
+	if s == nil {
+		return nil
+	}
+	box, _ := s.capture.Load().(packetCaptureBox)
+	return box.PacketCapture
+}
+
+func (s *packetCaptureSource) clear(pc PacketCapture) {
+	s.capture.CompareAndSwap(packetCaptureBox{pc}, packetCaptureBox{})
+}
@@ -25,6 +25,8 @@ type RTCPReader struct {
 	closed   atomic.Bool
 	onPacket atomic.Value // func([]byte)
 	onClose  func()
+
+	captureSource *packetCaptureSource
 }
 
 func NewRTCPReader(ssrc uint32) *RTCPReader {
@@ -36,6 +38,9 @@ func (r *RTCPReader) Write(p []byte) (n int, err error) {
 		err = io.EOF
 		return
 	}
+	if pc := r.captureSource.get(); pc != nil {
+		pc.CaptureRTCP(PacketDirectionInbound, p)
+	}
 	if f, ok := r.onPacket.Load().(func([]byte)); ok && f != nil {
 		f(p)
 	}
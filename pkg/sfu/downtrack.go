@@ -19,6 +19,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +35,7 @@ import (
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
@@ -67,6 +70,14 @@ const (
 
 	FlagStopRTXOnPLI = true
 
+	// consecutive receiver reports with low loss to switch from RED to primary encoding
+	adaptiveREDLowLossReports = 3
+
+	MimeTypeRTX = "video/rtx"
+
+	// recently sent packets to pick bandwidth probes from
+	rtxProbeCandidates = 16
+
 	keyFrameIntervalMin = 200
 	keyFrameIntervalMax = 1000
 	flushTimeout        = 1 * time.Second
@@ -129,11 +140,13 @@ type DownTrackState struct {
 	RTPStats                   *buffer.RTPStatsSender
 	DeltaStatsSenderSnapshotId uint32
 	ForwarderState             ForwarderState
+	RTXSSRC                    uint32
+	RTXSequenceNumber          uint16
 }
 
 func (d DownTrackState) String() string {
-	return fmt.Sprintf("DownTrackState{rtpStats: %s, deltaSender: %d, forwarder: %s}",
-		d.RTPStats.ToString(), d.DeltaStatsSenderSnapshotId, d.ForwarderState.String())
+	return fmt.Sprintf("DownTrackState{rtpStats: %s, deltaSender: %d, forwarder: %s, rtxSSRC: %d, rtxSN: %d}",
+		d.RTPStats.ToString(), d.DeltaStatsSenderSnapshotId, d.ForwarderState.String(), d.RTXSSRC, d.RTXSequenceNumber)
 }
 
 // -------------------------------------------------------------------
@@ -199,6 +212,7 @@ type DowntrackParams struct {
 	Pacer             pacer.Pacer
 	Logger            logger.Logger
 	Trailer           []byte
+	AdaptiveRED       config.AdaptiveREDConfig
 }
 
 // DownTrack implements TrackLocal, is the track used to write packets
@@ -218,6 +232,27 @@ type DownTrack struct {
 	payloadType uint8
 	sequencer   *sequencer
 
+	// RTX stream, used when RTX is negotiated, i. e. payload type is not 0, and its SSRC is signalled to the subscriber
+	rtxSSRC           atomic.Uint32
+	rtxPayloadType    uint8
+	rtxSequenceNumber atomic.Uint32
+	isRTXSignalled    atomic.Bool
+
+	// only the first packet of a key frame is flagged, the rest up to the marker are paced as key frame too
+	isSendingKeyFrame atomic.Bool
+
+	// forward error correction, used when RED and ULPFEC are negotiated, i. e. payload types are not 0
+	fecRedPayloadType uint8
+	fecPayloadType    uint8
+	fecProtection     atomic.Float64
+	fecLock           sync.Mutex
+	fecEncoder        *ulpfecEncoder
+
+	// adaptive RED, used when bound to RED with primary encoding also negotiated, i. e. payload type is not 0
+	redPrimaryPayloadType uint8
+	isREDActive           atomic.Bool
+	redLowLossReports     int
+
 	forwarder *Forwarder
 
 	upstreamCodecs            []webrtc.RTPCodecParameters
@@ -348,6 +383,10 @@ func NewDownTrack(params DowntrackParams) (*DownTrack, error) {
 		}
 	}
 	if d.kind == webrtc.RTPCodecTypeVideo {
+		// allocated ahead of negotiation, to be signalled in offers
+		d.rtxSSRC.Store(rand.Uint32())
+		d.rtxSequenceNumber.Store(uint32(rand.Intn(1 << 15)))
+
 		go d.maxLayerNotifierWorker()
 		go d.keyFrameRequester()
 	}
@@ -391,10 +430,18 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 		return codec, nil
 	}
 
-	d.params.Logger.Debugw("DownTrack.Bind", "codecs", d.upstreamCodecs, "matchCodec", codec, "ssrc", t.SSRC())
+	d.params.Logger.Debugw("DownTrack.Bind", "codecs", d.upstreamCodecs, "matchCodec", codec, "ssrc", t.SSRC(), "rtxSSRC", d.rtxSSRC.Load())
 	d.ssrc = uint32(t.SSRC())
 	d.payloadType = uint8(codec.PayloadType)
-	d.writeStream = t.WriteStream()
+	d.rtxPayloadType = findRTXPayloadType(codec.PayloadType, t.CodecParameters())
+	if d.kind == webrtc.RTPCodecTypeVideo {
+		d.fecRedPayloadType, d.fecPayloadType = findFECPayloadTypes(t.CodecParameters())
+	}
+	if d.params.AdaptiveRED.Enabled && strings.EqualFold(codec.MimeType, MimeTypeAudioRed) {
+		d.redPrimaryPayloadType = findOpusPayloadType(t.CodecParameters())
+		d.isREDActive.Store(true)
+	}
+	d.writeStream = &captureWriteStream{TrackLocalWriter: t.WriteStream(), bufferFactory: d.params.BufferFactory}
 	d.mime = strings.ToLower(codec.MimeType)
 	if rr := d.params.BufferFactory.GetOrNew(packetio.RTCPBufferPacket, uint32(t.SSRC())).(*buffer.RTCPReader); rr != nil {
 		rr.OnPacket(func(pkt []byte) {
@@ -564,11 +611,43 @@ func (d *DownTrack) Kind() webrtc.RTPCodecType {
 	return d.kind
 }
 
+func (d *DownTrack) getPacketKind(isKeyFrame bool, marker bool) pacer.PacketKind {
+	switch {
+	case d.kind == webrtc.RTPCodecTypeAudio:
+		return pacer.PacketKindAudio
+	case isKeyFrame:
+		d.isSendingKeyFrame.Store(!marker)
+		return pacer.PacketKindKeyFrame
+	case d.isSendingKeyFrame.Load():
+		if marker {
+			d.isSendingKeyFrame.Store(false)
+		}
+		return pacer.PacketKindKeyFrame
+	default:
+		return pacer.PacketKindVideo
+	}
+}
+
 // RID is required by `webrtc.TrackLocal` interface
 func (d *DownTrack) RID() string {
 	return ""
 }
 
+// RTXSSRC is the SSRC of the RTX stream, 0 for audio
+func (d *DownTrack) RTXSSRC() uint32 {
+	return d.rtxSSRC.Load()
+}
+
+// SetRTXSignalled marks the RTX SSRC as signalled to the subscriber, RTX is not used until then,
+// as subscribers drop packets of unknown SSRCs
+func (d *DownTrack) SetRTXSignalled() {
+	d.isRTXSignalled.Store(true)
+}
+
+func (d *DownTrack) isRTXEnabled() bool {
+	return d.rtxPayloadType != 0 && d.isRTXSignalled.Load()
+}
+
 func (d *DownTrack) SSRC() uint32 {
 	return d.ssrc
 }
@@ -634,6 +713,12 @@ func (d *DownTrack) keyFrameRequester() {
 
 		locked, layer := d.forwarder.CheckSync()
 		if !locked && layer != buffer.InvalidLayerSpatial && d.writable.Load() {
+			if d.params.Receiver.RequestCachedKeyFrame(d.params.SubID, layer) {
+				d.params.Logger.Debugw("requesting cached key frame for layer lock", "layer", layer)
+				ticker.Reset(getInterval())
+				continue
+			}
+
 			d.params.Logger.Debugw("sending PLI for layer lock", "layer", layer)
 			d.params.Receiver.SendPLI(layer, false)
 			d.rtpStats.UpdateLayerLockPliAndTime(1)
@@ -688,6 +773,17 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		return err
 	}
 
+	srcPayload := extPkt.Packet.Payload
+	sendPrimary := false
+	if d.redPrimaryPayloadType != 0 && !d.isREDActive.Load() {
+		if primary, err := extractPrimaryEncodingForRED(srcPayload); err == nil {
+			srcPayload = primary
+			sendPrimary = true
+		} else {
+			d.params.Logger.Debugw("could not extract primary encoding, forwarding red", "error", err)
+		}
+	}
+
 	var payload []byte
 	poolEntity := PacketFactory.Get().(*[]byte)
 	if len(tp.codecBytes) != 0 {
@@ -697,8 +793,8 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		}
 	}
 	if payload == nil {
-		payload = (*poolEntity)[:len(extPkt.Packet.Payload)]
-		copy(payload, extPkt.Packet.Payload)
+		payload = (*poolEntity)[:len(srcPayload)]
+		copy(payload, srcPayload)
 	}
 
 	hdr, err := d.getTranslatedRTPHeader(extPkt, tp)
@@ -709,6 +805,9 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		}
 		return err
 	}
+	if sendPrimary {
+		hdr.PayloadType = d.redPrimaryPayloadType
+	}
 
 	var extensions []pacer.ExtensionData
 	if tp.ddBytes != nil {
@@ -719,6 +818,12 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 			extensions = append(extensions, pacer.ExtensionData{ID: uint8(d.playoutDelayExtID), Payload: val.([]byte)})
 		}
 	}
+	fecProtection := d.fecProtection.Load()
+	if fecProtection != 0 {
+		payload = d.protectWithFEC(hdr, payload, poolEntity)
+	}
+	marker := hdr.Marker
+
 	if d.sequencer != nil {
 		d.sequencer.push(
 			extPkt.Arrival,
@@ -745,6 +850,7 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		},
 	)
 	d.pacer.Enqueue(pacer.Packet{
+		Kind:               d.getPacketKind(extPkt.KeyFrame, marker),
 		Header:             hdr,
 		Extensions:         extensions,
 		Payload:            payload,
@@ -754,6 +860,10 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		Pool:               PacketFactory,
 		PoolEntity:         poolEntity,
 	})
+
+	if fecProtection != 0 && marker {
+		d.writeFEC(fecProtection)
+	}
 	return nil
 }
 
@@ -842,6 +952,7 @@ func (d *DownTrack) WritePaddingRTP(bytesToSend int, paddingOnMute bool, forceMa
 			},
 		)
 		d.pacer.Enqueue(pacer.Packet{
+			Kind:               pacer.PacketKindPadding,
 			Header:             &hdr,
 			Payload:            payload,
 			AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
@@ -856,6 +967,112 @@ func (d *DownTrack) WritePaddingRTP(bytesToSend int, paddingOnMute bool, forceMa
 	return bytesSent
 }
 
+// protectWithFEC adds a media packet to FEC protection and returns the payload to send, encapsulated in RED,
+// as the subscriber recovers lost packets only with media packets received in RED
+func (d *DownTrack) protectWithFEC(hdr *rtp.Header, payload []byte, poolEntity *[]byte) []byte {
+	if len(payload)+redHeaderSizeSingleBlock > cap(*poolEntity) {
+		return payload
+	}
+
+	data, err := d.getFECProtectedPacket(hdr, payload)
+	if err != nil {
+		d.params.Logger.Warnw("could not get FEC protected packet", err)
+		return payload
+	}
+
+	d.fecLock.Lock()
+	if d.fecEncoder == nil {
+		d.fecEncoder = newULPFECEncoder()
+	}
+	d.fecEncoder.Add(hdr.SequenceNumber, data)
+	d.fecLock.Unlock()
+
+	redPayload := (*poolEntity)[:len(payload)+redHeaderSizeSingleBlock]
+	copy(redPayload[redHeaderSizeSingleBlock:], payload)
+	redPayload[0] = hdr.PayloadType
+	hdr.PayloadType = d.fecRedPayloadType
+	return redPayload
+}
+
+// getFECProtectedPacket returns a media packet as it is protected, the fixed RTP header and CSRCs followed by the
+// payload without RED encapsulation. Header extensions are not protected, as some of them are written at send time
+// by the pacer, so recovered packets do not have header extensions.
+func (d *DownTrack) getFECProtectedPacket(hdr *rtp.Header, payload []byte) ([]byte, error) {
+	protected := rtp.Header{
+		Version:        2,
+		Marker:         hdr.Marker,
+		PayloadType:    hdr.PayloadType,
+		SequenceNumber: hdr.SequenceNumber,
+		Timestamp:      hdr.Timestamp,
+		SSRC:           hdr.SSRC,
+		CSRC:           hdr.CSRC,
+	}
+
+	hdrSize := protected.MarshalSize()
+	data := make([]byte, hdrSize+len(payload))
+	if _, err := protected.MarshalTo(data); err != nil {
+		return nil, err
+	}
+	copy(data[hdrSize:], payload)
+	return data, nil
+}
+
+// writeFEC sends FEC packets protecting media packets sent since the last FEC packets,
+// it has to be called at a frame boundary as FEC packets are sent in the sequence number space of media
+func (d *DownTrack) writeFEC(protection float64) {
+	d.fecLock.Lock()
+	if d.fecEncoder == nil || d.fecEncoder.NumMediaPackets() < ulpfecMinMediaPackets {
+		d.fecLock.Unlock()
+		return
+	}
+	fecPayloads := d.fecEncoder.Encode(protection)
+	d.fecLock.Unlock()
+
+	snts, err := d.forwarder.GetSnTsForPadding(len(fecPayloads), false)
+	if err != nil {
+		return
+	}
+
+	// like padding, FEC packets are not retransmitted
+	if d.sequencer != nil {
+		d.sequencer.pushPadding(snts[0].extSequenceNumber, snts[len(snts)-1].extSequenceNumber)
+	}
+
+	for i, fecPayload := range fecPayloads {
+		hdr := rtp.Header{
+			Version:        2,
+			PayloadType:    d.fecRedPayloadType,
+			SequenceNumber: uint16(snts[i].extSequenceNumber),
+			Timestamp:      uint32(snts[i].extTimestamp),
+			SSRC:           d.ssrc,
+			CSRC:           []uint32{},
+		}
+
+		payload := make([]byte, redHeaderSizeSingleBlock+len(fecPayload))
+		payload[0] = d.fecPayloadType
+		copy(payload[redHeaderSizeSingleBlock:], fecPayload)
+
+		d.sendingPacket(
+			&hdr,
+			len(payload),
+			&sendPacketMetadata{
+				packetTime:        time.Now(),
+				extSequenceNumber: snts[i].extSequenceNumber,
+				extTimestamp:      snts[i].extTimestamp,
+				isPadding:         true,
+			},
+		)
+		d.pacer.Enqueue(pacer.Packet{
+			Kind:               pacer.PacketKindVideo,
+			Header:             &hdr,
+			Payload:            payload,
+			AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
+			TransportWideExtID: uint8(d.transportWideExtID),
+			WriteStream:        d.writeStream,
+		})
+	}
+}
+
 // Mute enables or disables media forwarding - subscriber triggered
 func (d *DownTrack) Mute(muted bool) {
 	d.streamAllocatorLock.RLock()
@@ -1036,6 +1253,8 @@ func (d *DownTrack) GetState() DownTrackState {
 		RTPStats:                   d.rtpStats,
 		DeltaStatsSenderSnapshotId: d.deltaStatsSenderSnapshotId,
 		ForwarderState:             d.forwarder.GetState(),
+		RTXSSRC:                    d.rtxSSRC.Load(),
+		RTXSequenceNumber:          uint16(d.rtxSequenceNumber.Load()),
 	}
 	return dts
 }
@@ -1044,6 +1263,11 @@ func (d *DownTrack) SeedState(state DownTrackState) {
 	d.rtpStats.Seed(state.RTPStats)
 	d.deltaStatsSenderSnapshotId = state.DeltaStatsSenderSnapshotId
 	d.forwarder.SeedState(state.ForwarderState)
+	if state.RTXSSRC != 0 {
+		// a re-used transceiver is not renegotiated, continue the signalled RTX stream
+		d.rtxSSRC.Store(state.RTXSSRC)
+		d.rtxSequenceNumber.Store(uint32(state.RTXSequenceNumber))
+	}
 }
 
 func (d *DownTrack) UpTrackLayersChange() {
@@ -1170,18 +1394,84 @@ func (d *DownTrack) IsDeficient() bool {
 	return d.forwarder.IsDeficient()
 }
 
+// maybeSwitchRED switches between forwarding RED and primary encoding based on loss reported by the subscriber.
+// RED is forwarded once loss crosses the threshold, primary encoding once loss stays below half of it.
+func (d *DownTrack) maybeSwitchRED(fractionLost uint8) {
+	if d.redPrimaryPayloadType == 0 {
+		return
+	}
+
+	loss := float64(fractionLost) / 256.0
+	threshold := d.params.AdaptiveRED.LossThreshold
+	switch {
+	case loss >= threshold:
+		d.redLowLossReports = 0
+		if !d.isREDActive.Swap(true) {
+			d.params.Logger.Debugw("switching to red", "loss", loss)
+		}
+
+	case loss < threshold/2:
+		d.redLowLossReports++
+		if d.redLowLossReports >= adaptiveREDLowLossReports && d.isREDActive.Swap(false) {
+			d.params.Logger.Debugw("switching to primary encoding", "loss", loss)
+		}
+
+	default:
+		d.redLowLossReports = 0
+	}
+}
+
+// SetFECProtection sets the ratio of FEC packets to media packets to protect the track with, 0 disables protection.
+// Returns false when FEC is not negotiated with the subscriber.
+func (d *DownTrack) SetFECProtection(protection float64) bool {
+	if d.fecPayloadType == 0 {
+		return false
+	}
+
+	if d.fecProtection.Swap(protection) != protection {
+		d.params.Logger.Debugw("FEC protection changed", "protection", protection)
+	}
+	if protection == 0 {
+		d.fecLock.Lock()
+		if d.fecEncoder != nil {
+			d.fecEncoder.Reset()
+		}
+		d.fecLock.Unlock()
+	}
+	return true
+}
+
+// FECOverhead returns the ratio of FEC packets to media packets the track is protected with
+func (d *DownTrack) FECOverhead() float64 {
+	return d.fecProtection.Load()
+}
+
+// getLayeredBitrate returns bitrates of available layers, including the overhead of FEC protection,
+// so that bandwidth allocation accounts for it
+func (d *DownTrack) getLayeredBitrate() ([]int32, Bitrates) {
+	al, brs := d.params.Receiver.GetLayeredBitrate()
+	if overhead := d.FECOverhead(); overhead != 0 {
+		for i := range brs {
+			for j := range brs[i] {
+				brs[i][j] = int64(float64(brs[i][j]) * (1 + overhead))
+			}
+		}
+	}
+	return al, brs
+}
+
 func (d *DownTrack) BandwidthRequested() int64 {
-	_, brs := d.params.Receiver.GetLayeredBitrate()
+	_, brs := d.getLayeredBitrate()
 	return d.forwarder.BandwidthRequested(brs)
 }
 
 func (d *DownTrack) DistanceToDesired() float64 {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	return d.forwarder.DistanceToDesired(al, brs)
 }
 
 func (d *DownTrack) AllocateOptimal(allowOvershoot bool) VideoAllocation {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation := d.forwarder.AllocateOptimal(al, brs, allowOvershoot)
 	d.postKeyFrameRequestEvent()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
@@ -1189,7 +1479,7 @@ func (d *DownTrack) AllocateOptimal(allowOvershoot bool) VideoAllocation {
 }
 
 func (d *DownTrack) ProvisionalAllocatePrepare() {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	d.forwarder.ProvisionalAllocatePrepare(al, brs)
 }
 
@@ -1231,7 +1521,7 @@ func (d *DownTrack) ProvisionalAllocateCommit() VideoAllocation {
 }
 
 func (d *DownTrack) AllocateNextHigher(availableChannelCapacity int64, allowOvershoot bool) (VideoAllocation, bool) {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation, available := d.forwarder.AllocateNextHigher(availableChannelCapacity, al, brs, allowOvershoot)
 	d.postKeyFrameRequestEvent()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
@@ -1239,7 +1529,7 @@ func (d *DownTrack) AllocateNextHigher(availableChannelCapacity int64, allowOver
 }
 
 func (d *DownTrack) GetNextHigherTransition(allowOvershoot bool) (VideoTransition, bool) {
-	availableLayers, brs := d.params.Receiver.GetLayeredBitrate()
+	availableLayers, brs := d.getLayeredBitrate()
 	transition, available := d.forwarder.GetNextHigherTransition(brs, allowOvershoot)
 	d.params.Logger.Debugw(
 		"stream: get next higher layer",
@@ -1252,7 +1542,7 @@ func (d *DownTrack) GetNextHigherTransition(allowOvershoot bool) (VideoTransitio
 }
 
 func (d *DownTrack) Pause() VideoAllocation {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation := d.forwarder.Pause(al, brs)
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
 	return allocation
@@ -1371,6 +1661,7 @@ func (d *DownTrack) writeBlankFrameRTP(duration float32, generation uint32) chan
 					extTimestamp:      snts[i].extTimestamp,
 				})
 				d.pacer.Enqueue(pacer.Packet{
+					Kind:               d.getPacketKind(false, hdr.Marker),
 					Header:             &hdr,
 					Payload:            payload,
 					AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
@@ -1525,6 +1816,8 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 					sal.OnRTCPReceiverReport(d, r)
 				}
 
+				d.maybeSwitchRED(r.FractionLost)
+
 				d.playoudDelayAcked.Store(true)
 			}
 			if len(rr.Reports) > 0 {
@@ -1614,8 +1907,7 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 			Attempts:       epm.nacked,
 		})
 
-		pktBuff := *src
-		n, err := d.params.Receiver.ReadRTP(pktBuff, uint8(epm.layer), epm.sourceSeqNo)
+		hdr, payload, poolEntity, err := d.getRetransmitPacket(&epm.packetMeta, *src)
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -1628,36 +1920,8 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 			numRepeatedNACKs++
 		}
 
-		var pkt rtp.Packet
-		if err = pkt.Unmarshal(pktBuff[:n]); err != nil {
-			d.params.Logger.Errorw("could not unmarshal rtp packet in retransmit", err)
-			continue
-		}
-		pkt.Header.Marker = epm.marker
-		pkt.Header.SequenceNumber = epm.targetSeqNo
-		pkt.Header.Timestamp = epm.timestamp
-		pkt.Header.SSRC = d.ssrc
-		pkt.Header.PayloadType = d.payloadType
-
-		var payload []byte
-		poolEntity := PacketFactory.Get().(*[]byte)
-		if d.mime == "video/vp8" && len(pkt.Payload) > 0 && len(epm.codecBytes) != 0 {
-			var incomingVP8 buffer.VP8
-			if err = incomingVP8.Unmarshal(pkt.Payload); err != nil {
-				d.params.Logger.Errorw("could not unmarshal VP8 packet", err)
-				PacketFactory.Put(poolEntity)
-				continue
-			}
-
-			payload = d.translateVP8PacketTo(&pkt, &incomingVP8, epm.codecBytes, poolEntity)
-		}
-		if payload == nil {
-			payload = (*poolEntity)[:len(pkt.Payload)]
-			copy(payload, pkt.Payload)
-		}
-
 		d.sendingPacket(
-			&pkt.Header,
+			hdr,
 			len(payload),
 			&sendPacketMetadata{
 				layer:             int32(epm.layer),
@@ -1668,7 +1932,8 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 			},
 		)
 		d.pacer.Enqueue(pacer.Packet{
-			Header:             &pkt.Header,
+			Kind:               pacer.PacketKindRTX,
+			Header:             hdr,
 			Extensions:         []pacer.ExtensionData{{ID: uint8(d.dependencyDescriptorExtID), Payload: epm.ddBytes}},
 			Payload:            payload,
 			AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
@@ -1699,6 +1964,111 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 	}
 }
 
+// getRetransmitPacket reads a sent packet from the receiver and translates it as it was sent.
+// When RTX is negotiated, the packet is encapsulated for the RTX stream (RFC 4588).
+func (d *DownTrack) getRetransmitPacket(pm *packetMeta, pktBuff []byte) (*rtp.Header, []byte, *[]byte, error) {
+	n, err := d.params.Receiver.ReadRTP(pktBuff, uint8(pm.layer), pm.sourceSeqNo)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var pkt rtp.Packet
+	if err = pkt.Unmarshal(pktBuff[:n]); err != nil {
+		d.params.Logger.Errorw("could not unmarshal rtp packet in retransmit", err)
+		return nil, nil, nil, err
+	}
+	pkt.Header.Marker = pm.marker
+	pkt.Header.SequenceNumber = pm.targetSeqNo
+	pkt.Header.Timestamp = pm.timestamp
+	pkt.Header.SSRC = d.ssrc
+	pkt.Header.PayloadType = d.payloadType
+
+	var payload []byte
+	poolEntity := PacketFactory.Get().(*[]byte)
+	if d.mime == "video/vp8" && len(pkt.Payload) > 0 && len(pm.codecBytes) != 0 {
+		var incomingVP8 buffer.VP8
+		if err = incomingVP8.Unmarshal(pkt.Payload); err != nil {
+			d.params.Logger.Errorw("could not unmarshal VP8 packet", err)
+			PacketFactory.Put(poolEntity)
+			return nil, nil, nil, err
+		}
+
+		payload = d.translateVP8PacketTo(&pkt, &incomingVP8, pm.codecBytes, poolEntity)
+	}
+	if payload == nil {
+		payload = (*poolEntity)[:len(pkt.Payload)]
+		copy(payload, pkt.Payload)
+	}
+
+	if !d.isRTXEnabled() {
+		return &pkt.Header, payload, poolEntity, nil
+	}
+
+	// RTX payload is the original sequence number followed by the original payload
+	rtxPoolEntity := PacketFactory.Get().(*[]byte)
+	rtxPayload := (*rtxPoolEntity)[:2+len(payload)]
+	binary.BigEndian.PutUint16(rtxPayload, pm.targetSeqNo)
+	copy(rtxPayload[2:], payload)
+	PacketFactory.Put(poolEntity)
+
+	pkt.Header.Padding = false
+	pkt.Header.SSRC = d.rtxSSRC.Load()
+	pkt.Header.PayloadType = d.rtxPayloadType
+	pkt.Header.SequenceNumber = uint16(d.rtxSequenceNumber.Inc())
+	return &pkt.Header, rtxPayload, rtxPoolEntity, nil
+}
+
+// WriteRTXProbe sends retransmissions of recently sent packets on the RTX stream to probe for bandwidth.
+// Unlike padding, they do not need a frame boundary and do not use sequence numbers of the media stream.
+// Returns 0 when RTX is not negotiated or there is nothing to retransmit.
+func (d *DownTrack) WriteRTXProbe(bytesToSend int) int {
+	if !d.writable.Load() || !d.isRTXEnabled() || d.sequencer == nil {
+		return 0
+	}
+
+	if !d.rtpStats.IsActive() || d.forwarder.IsMuted() {
+		return 0
+	}
+
+	// hold probing till the remote side has reported on the stream
+	if d.rtpStats.LastReceiverReportTime().IsZero() {
+		return 0
+	}
+
+	src := PacketFactory.Get().(*[]byte)
+	defer PacketFactory.Put(src)
+
+	bytesSent := 0
+	for _, pm := range d.sequencer.getRecentPacketMetas(rtxProbeCandidates) {
+		hdr, payload, poolEntity, err := d.getRetransmitPacket(&pm, *src)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			continue
+		}
+
+		d.pacer.Enqueue(pacer.Packet{
+			Kind:               pacer.PacketKindPadding,
+			Header:             hdr,
+			Extensions:         []pacer.ExtensionData{{ID: uint8(d.dependencyDescriptorExtID), Payload: pm.ddBytes}},
+			Payload:            payload,
+			AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
+			TransportWideExtID: uint8(d.transportWideExtID),
+			WriteStream:        d.writeStream,
+			Pool:               PacketFactory,
+			PoolEntity:         poolEntity,
+		})
+
+		bytesSent += hdr.MarshalSize() + len(payload)
+		if bytesSent >= bytesToSend {
+			break
+		}
+	}
+
+	return bytesSent
+}
+
 func (d *DownTrack) getTranslatedRTPHeader(extPkt *buffer.ExtPacket, tp *TranslationParams) (*rtp.Header, error) {
 	tpRTP := tp.rtp
 	hdr := extPkt.Packet.Header
@@ -1883,6 +2253,7 @@ func (d *DownTrack) sendSilentFrameOnMuteForOpus() {
 				},
 			)
 			d.pacer.Enqueue(pacer.Packet{
+				Kind:               pacer.PacketKindAudio,
 				Header:             &hdr,
 				Payload:            payload,
 				AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
@@ -1960,3 +2331,73 @@ func (d *DownTrack) sendingPacket(hdr *rtp.Header, payloadSize int, spmd *sendPa
 }
 
 // -------------------------------------------------------------------------------
+
+// findOpusPayloadType returns payload type of opus, 0 if not negotiated
+func findOpusPayloadType(codecs []webrtc.RTPCodecParameters) uint8 {
+	for _, c := range codecs {
+		if strings.EqualFold(c.MimeType, webrtc.MimeTypeOpus) {
+			return uint8(c.PayloadType)
+		}
+	}
+	return 0
+}
+
+// findFECPayloadTypes returns payload types of RED and ULPFEC for video, 0 when both are not negotiated
+func findFECPayloadTypes(codecs []webrtc.RTPCodecParameters) (uint8, uint8) {
+	var redPayloadType, ulpfecPayloadType uint8
+	for _, c := range codecs {
+		switch {
+		case strings.EqualFold(c.MimeType, MimeTypeVideoRed):
+			redPayloadType = uint8(c.PayloadType)
+		case strings.EqualFold(c.MimeType, MimeTypeULPFEC):
+			ulpfecPayloadType = uint8(c.PayloadType)
+		}
+	}
+	if redPayloadType == 0 || ulpfecPayloadType == 0 {
+		return 0, 0
+	}
+	return redPayloadType, ulpfecPayloadType
+}
+
+// findRTXPayloadType returns the payload type of RTX negotiated for a payload type, 0 if not negotiated
+func findRTXPayloadType(payloadType webrtc.PayloadType, codecs []webrtc.RTPCodecParameters) uint8 {
+	for _, c := range codecs {
+		if !strings.EqualFold(c.MimeType, MimeTypeRTX) {
+			continue
+		}
+
+		for _, param := range strings.Split(c.SDPFmtpLine, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || key != "apt" {
+				continue
+			}
+			if apt, err := strconv.ParseUint(value, 10, 8); err == nil && webrtc.PayloadType(apt) == payloadType {
+				return uint8(c.PayloadType)
+			}
+		}
+	}
+	return 0
+}
+
+// -------------------------------------------------------------------------------
+
+// captureWriteStream passes packets sent to the subscriber to the packet capture of the subscriber, when capturing
+type captureWriteStream struct {
+	webrtc.TrackLocalWriter
+	bufferFactory *buffer.Factory
+}
+
+func (c *captureWriteStream) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	n, err := c.TrackLocalWriter.WriteRTP(header, payload)
+	if err != nil || c.bufferFactory == nil {
+		return n, err
+	}
+
+	if pc := c.bufferFactory.PacketCapture(); pc != nil {
+		pkt := rtp.Packet{Header: *header, Payload: payload}
+		if b, err := pkt.Marshal(); err == nil {
+			pc.CaptureRTP(buffer.PacketDirectionOutbound, b)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestAdaptiveRED(t *testing.T) {
+	d := &DownTrack{
+		params: DowntrackParams{
+			Logger:      logger.GetLogger(),
+			AdaptiveRED: config.AdaptiveREDConfig{Enabled: true, LossThreshold: 0.1},
+		},
+		redPrimaryPayloadType: findOpusPayloadType([]webrtc.RTPCodecParameters{
+			{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: MimeTypeAudioRed}, PayloadType: 63},
+			{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, PayloadType: 111},
+		}),
+	}
+	require.EqualValues(t, 111, d.redPrimaryPayloadType)
+	d.isREDActive.Store(true)
+
+	// switches to primary after consecutive reports with low loss
+	for i := 0; i < adaptiveREDLowLossReports-1; i++ {
+		d.maybeSwitchRED(0)
+		require.True(t, d.isREDActive.Load())
+	}
+	// loss in between thresholds restarts counting
+	d.maybeSwitchRED(20)
+	for i := 0; i < adaptiveREDLowLossReports-1; i++ {
+		d.maybeSwitchRED(5)
+		require.True(t, d.isREDActive.Load())
+	}
+	d.maybeSwitchRED(5)
+	require.False(t, d.isREDActive.Load())
+
+	// stays on primary in between thresholds, switches back to red once loss crosses the threshold
+	d.maybeSwitchRED(20)
+	require.False(t, d.isREDActive.Load())
+	d.maybeSwitchRED(26)
+	require.True(t, d.isREDActive.Load())
+}
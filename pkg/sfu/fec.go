@@ -0,0 +1,168 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	MimeTypeVideoRed = "video/red"
+	MimeTypeULPFEC   = "video/ulpfec"
+
+	ulpfecHeaderSize         = 10
+	ulpfecLevelHeaderSizeL0  = 4
+	ulpfecLevelHeaderSizeL1  = 8
+	ulpfecMaxMediaPacketsL0  = 16
+	ulpfecMaxMediaPackets    = 48
+	ulpfecMinMediaPackets    = 4
+	rtpFixedHeaderSize       = 12
+	redHeaderSizeSingleBlock = 1
+)
+
+type ulpfecMediaPacket struct {
+	sequenceNumber uint16
+	// packet as protected, fixed RTP header with media payload type and CSRCs, followed by media payload.
+	// Header extensions are not protected
+	data []byte
+}
+
+// ulpfecEncoder generates ULPFEC (RFC 5109) packets protecting the media packets of a down track.
+//
+// Media packets are collected in groups of up to 48 consecutive sequence numbers. FEC packets are generated at
+// frame boundaries, once enough media packets are collected, so they can be sent with sequence numbers in between
+// frames. Each FEC packet of a group protects an interleaved subset of its media packets, so a burst of as many
+// lost packets as FEC packets can be recovered.
+type ulpfecEncoder struct {
+	groups  [][]ulpfecMediaPacket
+	current []ulpfecMediaPacket
+}
+
+func newULPFECEncoder() *ulpfecEncoder {
+	return &ulpfecEncoder{}
+}
+
+// Add adds a media packet to protect, packets have to be added in sending order
+func (u *ulpfecEncoder) Add(sequenceNumber uint16, data []byte) {
+	if len(u.current) != 0 {
+		offset := sequenceNumber - u.current[0].sequenceNumber
+		if offset == 0 || offset >= (1<<15) {
+			// out-of-order, should not happen, just be safe and restart protection
+			u.Reset()
+		} else if offset >= ulpfecMaxMediaPackets {
+			u.groups = append(u.groups, u.current)
+			u.current = nil
+		}
+	}
+
+	u.current = append(u.current, ulpfecMediaPacket{sequenceNumber: sequenceNumber, data: data})
+}
+
+// NumMediaPackets returns the number of media packets waiting for protection
+func (u *ulpfecEncoder) NumMediaPackets() int {
+	num := len(u.current)
+	for _, group := range u.groups {
+		num += len(group)
+	}
+	return num
+}
+
+// Encode returns payloads of FEC packets protecting media packets added since the last encode.
+// The number of FEC packets of a group is the protection ratio of its number of media packets, rounded up.
+func (u *ulpfecEncoder) Encode(protection float64) [][]byte {
+	if len(u.current) != 0 {
+		u.groups = append(u.groups, u.current)
+		u.current = nil
+	}
+
+	var payloads [][]byte
+	for _, group := range u.groups {
+		numFEC := int(math.Ceil(float64(len(group)) * protection))
+		if numFEC > len(group) {
+			numFEC = len(group)
+		}
+
+		protected := make([]ulpfecMediaPacket, 0, (len(group)+numFEC-1)/numFEC)
+		for i := 0; i < numFEC; i++ {
+			protected = protected[:0]
+			for j := i; j < len(group); j += numFEC {
+				protected = append(protected, group[j])
+			}
+			payloads = append(payloads, ulpfecPayload(group[0].sequenceNumber, protected))
+		}
+	}
+	u.groups = nil
+
+	return payloads
+}
+
+func (u *ulpfecEncoder) Reset() {
+	u.groups = nil
+	u.current = nil
+}
+
+// ulpfecPayload returns the FEC header, level 0 header and level 0 payload of an FEC packet protecting the given
+// media packets, the mask is relative to the base sequence number
+func ulpfecPayload(baseSequenceNumber uint16, packets []ulpfecMediaPacket) []byte {
+	lBit := false
+	protectionLength := 0
+	for _, p := range packets {
+		if p.sequenceNumber-baseSequenceNumber >= ulpfecMaxMediaPacketsL0 {
+			lBit = true
+		}
+		if len(p.data)-rtpFixedHeaderSize > protectionLength {
+			protectionLength = len(p.data) - rtpFixedHeaderSize
+		}
+	}
+
+	levelHeaderSize := ulpfecLevelHeaderSizeL0
+	if lBit {
+		levelHeaderSize = ulpfecLevelHeaderSizeL1
+	}
+	headersSize := ulpfecHeaderSize + levelHeaderSize
+	payload := make([]byte, headersSize+protectionLength)
+
+	lengthRecovery := uint16(0)
+	mask := payload[ulpfecHeaderSize+2 : headersSize]
+	for _, p := range packets {
+		// P, X, CC, M, PT and timestamp recovery
+		payload[0] ^= p.data[0]
+		payload[1] ^= p.data[1]
+		for i := 4; i < 8; i++ {
+			payload[i] ^= p.data[i]
+		}
+		lengthRecovery ^= uint16(len(p.data) - rtpFixedHeaderSize)
+
+		// everything after the fixed RTP header is protected, i. e. CSRCs and payload
+		for i, b := range p.data[rtpFixedHeaderSize:] {
+			payload[headersSize+i] ^= b
+		}
+
+		offset := p.sequenceNumber - baseSequenceNumber
+		mask[offset/8] |= 0x80 >> (offset % 8)
+	}
+
+	// E bit is 0, L bit in place of RTP version
+	payload[0] &= 0x3f
+	if lBit {
+		payload[0] |= 0x40
+	}
+	binary.BigEndian.PutUint16(payload[2:4], baseSequenceNumber)
+	binary.BigEndian.PutUint16(payload[8:10], lengthRecovery)
+	binary.BigEndian.PutUint16(payload[ulpfecHeaderSize:ulpfecHeaderSize+2], uint16(protectionLength))
+
+	return payload
+}
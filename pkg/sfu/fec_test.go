@@ -0,0 +1,160 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func newULPFECTestPacket(t *testing.T, sn uint16, ts uint32, marker bool, payloadSize int) []byte {
+	hdr := rtp.Header{
+		Version:        2,
+		Marker:         marker,
+		PayloadType:    96,
+		SequenceNumber: sn,
+		Timestamp:      ts,
+		SSRC:           1234,
+	}
+	require.NoError(t, hdr.SetExtension(5, []byte{1, 2, 3}))
+	payload := make([]byte, payloadSize)
+	for i := range payload {
+		payload[i] = byte(int(sn) + i)
+	}
+	b, err := (&rtp.Packet{Header: hdr, Payload: payload}).Marshal()
+	require.NoError(t, err)
+	return b
+}
+
+// recovers a packet protected by an FEC packet from the other packets it protects as described in RFC 5109
+func recoverULPFEC(t *testing.T, fecPayload []byte, received map[uint16][]byte) []byte {
+	levelHeaderSize := ulpfecLevelHeaderSizeL0
+	if fecPayload[0]&0x40 != 0 {
+		levelHeaderSize = ulpfecLevelHeaderSizeL1
+	}
+	baseSN := binary.BigEndian.Uint16(fecPayload[2:4])
+	protectionLength := int(binary.BigEndian.Uint16(fecPayload[ulpfecHeaderSize : ulpfecHeaderSize+2]))
+	mask := fecPayload[ulpfecHeaderSize+2 : ulpfecHeaderSize+levelHeaderSize]
+	headersSize := ulpfecHeaderSize + levelHeaderSize
+	require.Len(t, fecPayload, headersSize+protectionLength)
+
+	recovered := make([]byte, rtpFixedHeaderSize+protectionLength)
+	recovered[0] = fecPayload[0]
+	recovered[1] = fecPayload[1]
+	copy(recovered[4:8], fecPayload[4:8])
+	lengthRecovery := binary.BigEndian.Uint16(fecPayload[8:10])
+	copy(recovered[rtpFixedHeaderSize:], fecPayload[headersSize:])
+
+	missing := -1
+	for i := 0; i < len(mask)*8; i++ {
+		if mask[i/8]&(0x80>>(i%8)) == 0 {
+			continue
+		}
+		pkt, ok := received[baseSN+uint16(i)]
+		if !ok {
+			require.Equal(t, -1, missing, "more than one packet missing")
+			missing = i
+			continue
+		}
+
+		recovered[0] ^= pkt[0]
+		recovered[1] ^= pkt[1]
+		for j := 4; j < 8; j++ {
+			recovered[j] ^= pkt[j]
+		}
+		lengthRecovery ^= uint16(len(pkt) - rtpFixedHeaderSize)
+		for j, b := range pkt[rtpFixedHeaderSize:] {
+			recovered[rtpFixedHeaderSize+j] ^= b
+		}
+	}
+	require.NotEqual(t, -1, missing)
+
+	recovered[0] = 0x80 | (recovered[0] & 0x3f)
+	binary.BigEndian.PutUint16(recovered[2:4], baseSN+uint16(missing))
+	binary.BigEndian.PutUint32(recovered[8:12], 1234)
+	return recovered[:rtpFixedHeaderSize+int(lengthRecovery)]
+}
+
+func TestULPFECEncoder(t *testing.T) {
+	t.Run("recovers bursts up to number of FEC packets", func(t *testing.T) {
+		u := newULPFECEncoder()
+		packets := make(map[uint16][]byte)
+		for i := 0; i < 10; i++ {
+			sn := uint16(65530 + i) // wrap around
+			pkt := newULPFECTestPacket(t, sn, 3000, i == 9, 100+10*i)
+			packets[sn] = pkt
+			u.Add(sn, pkt)
+		}
+		require.Equal(t, 10, u.NumMediaPackets())
+
+		fecPayloads := u.Encode(0.2)
+		require.Len(t, fecPayloads, 2)
+		require.Zero(t, u.NumMediaPackets())
+
+		for _, fecPayload := range fecPayloads {
+			require.Zero(t, fecPayload[0]&0xc0, "E and L bits")
+			require.Equal(t, uint16(65530), binary.BigEndian.Uint16(fecPayload[2:4]))
+		}
+
+		// lose two consecutive packets, each protected by a different FEC packet
+		for _, lost := range []uint16{65535, 0} {
+			received := make(map[uint16][]byte)
+			for sn, pkt := range packets {
+				if sn != 65535 && sn != 0 {
+					received[sn] = pkt
+				}
+			}
+
+			fecPayload := fecPayloads[(lost-65530)%2]
+			require.Equal(t, packets[lost], recoverULPFEC(t, fecPayload, received))
+		}
+	})
+
+	t.Run("long mask", func(t *testing.T) {
+		u := newULPFECEncoder()
+		packets := make(map[uint16][]byte)
+		for i := 0; i < 20; i++ {
+			sn := uint16(100 + 2*i) // gaps of padding in between
+			pkt := newULPFECTestPacket(t, sn, uint32(i*3000), true, 50)
+			packets[sn] = pkt
+			u.Add(sn, pkt)
+		}
+
+		fecPayloads := u.Encode(0.05)
+		require.Len(t, fecPayloads, 1)
+		require.Equal(t, byte(0x40), fecPayloads[0][0]&0xc0, "L bit")
+
+		delete(packets, 138)
+		recovered := recoverULPFEC(t, fecPayloads[0], packets)
+		require.Equal(t, newULPFECTestPacket(t, 138, 19*3000, true, 50), recovered)
+	})
+
+	t.Run("groups limited to mask length", func(t *testing.T) {
+		u := newULPFECEncoder()
+		for i := 0; i < 60; i++ {
+			sn := uint16(i)
+			u.Add(sn, newULPFECTestPacket(t, sn, 3000, i == 59, 10))
+		}
+
+		fecPayloads := u.Encode(0.1)
+		require.Len(t, fecPayloads, 5+2)
+		require.Equal(t, uint16(0), binary.BigEndian.Uint16(fecPayloads[0][2:4]))
+		require.Equal(t, uint16(48), binary.BigEndian.Uint16(fecPayloads[5][2:4]))
+		require.Zero(t, fecPayloads[5][0]&0x40, "short mask for 12 packets")
+	})
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// KeyFrameCache keeps packets of a layer from its most recent key frame on, so down tracks can lock to the layer
+// by replaying them, instead of waiting for a key frame requested from the publisher.
+//
+// Replays are requested by down tracks and served by the forwarding goroutine of the layer, right before the next
+// packet is forwarded, so replayed packets and the packets following them are contiguous. The cache is stale once
+// the key frame is older than the max age, or more packets than the max followed it. A down track is served a key
+// frame once, so it falls back to a PLI when locking to the replayed key frame did not work.
+type KeyFrameCache struct {
+	maxPackets int
+	maxAge     time.Duration
+
+	hasPending atomic.Bool
+
+	lock       sync.Mutex
+	generation uint32
+	keyFrameTS uint64
+	keyFrameAt time.Time
+	packets    []*buffer.ExtPacket
+	overflowed bool
+	pending    map[livekit.ParticipantID]struct{}
+	served     map[livekit.ParticipantID]uint32
+}
+
+func NewKeyFrameCache(maxPackets int, maxAge time.Duration) *KeyFrameCache {
+	return &KeyFrameCache{
+		maxPackets: maxPackets,
+		maxAge:     maxAge,
+		pending:    make(map[livekit.ParticipantID]struct{}),
+		served:     make(map[livekit.ParticipantID]uint32),
+	}
+}
+
+// Add caches a forwarded packet, packets have to be added in the order they are forwarded
+func (c *KeyFrameCache) Add(extPkt *buffer.ExtPacket) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch {
+	case extPkt.KeyFrame && (len(c.packets) == 0 || extPkt.ExtTimestamp != c.keyFrameTS):
+		c.generation++
+		c.keyFrameTS = extPkt.ExtTimestamp
+		c.keyFrameAt = time.Now()
+		c.packets = append(c.packets[:0], cloneExtPacket(extPkt))
+		c.overflowed = false
+		c.served = make(map[livekit.ParticipantID]uint32)
+
+	case len(c.packets) == 0 || c.overflowed:
+
+	case len(c.packets) >= c.maxPackets:
+		// stale until the next key frame, release packets until then
+		c.overflowed = true
+		c.packets = nil
+
+	default:
+		c.packets = append(c.packets, cloneExtPacket(extPkt))
+	}
+}
+
+// Request registers a down track for a replay, returning false when the cache cannot serve it
+func (c *KeyFrameCache) Request(subscriberID livekit.ParticipantID) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.isFreshLocked() {
+		return false
+	}
+	if generation, ok := c.served[subscriberID]; ok && generation == c.generation {
+		return false
+	}
+
+	c.pending[subscriberID] = struct{}{}
+	c.hasPending.Store(true)
+	return true
+}
+
+// TakePending returns down tracks waiting for a replay, with the packets to replay.
+// Packets are nil when the cache went stale after the request, a key frame has to be requested instead.
+func (c *KeyFrameCache) TakePending() ([]livekit.ParticipantID, []*buffer.ExtPacket) {
+	if !c.hasPending.Load() {
+		return nil, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	subscriberIDs := make([]livekit.ParticipantID, 0, len(c.pending))
+	for subscriberID := range c.pending {
+		subscriberIDs = append(subscriberIDs, subscriberID)
+	}
+	c.pending = make(map[livekit.ParticipantID]struct{})
+	c.hasPending.Store(false)
+
+	if !c.isFreshLocked() {
+		return subscriberIDs, nil
+	}
+	for _, subscriberID := range subscriberIDs {
+		c.served[subscriberID] = c.generation
+	}
+	// cached packets are not modified, later packets are appended beyond the returned length
+	return subscriberIDs, c.packets[:len(c.packets):len(c.packets)]
+}
+
+func (c *KeyFrameCache) isFreshLocked() bool {
+	return len(c.packets) != 0 && !c.overflowed && time.Since(c.keyFrameAt) <= c.maxAge
+}
+
+// cloneExtPacket copies a packet, forwarded packets reference buffers which are reused for later packets
+func cloneExtPacket(extPkt *buffer.ExtPacket) *buffer.ExtPacket {
+	clone := *extPkt
+	clone.Packet = extPkt.Packet.Clone()
+	clone.RawPacket = append([]byte(nil), extPkt.RawPacket...)
+	return &clone
+}
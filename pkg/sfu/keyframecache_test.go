@@ -0,0 +1,117 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func newKeyFrameCacheTestPacket(sn uint16, ts uint32, keyFrame bool) *buffer.ExtPacket {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			SequenceNumber: sn,
+			Timestamp:      ts,
+			SSRC:           1234,
+		},
+		Payload: []byte{1, 2, 3},
+	}
+	raw, _ := pkt.Marshal()
+	return &buffer.ExtPacket{
+		Arrival:           time.Now(),
+		ExtSequenceNumber: uint64(sn),
+		ExtTimestamp:      uint64(ts),
+		Packet:            pkt,
+		KeyFrame:          keyFrame,
+		RawPacket:         raw,
+	}
+}
+
+func TestKeyFrameCache(t *testing.T) {
+	c := NewKeyFrameCache(4, time.Second)
+	sub1 := livekit.ParticipantID("sub1")
+	sub2 := livekit.ParticipantID("sub2")
+
+	// nothing to replay before the first key frame
+	c.Add(newKeyFrameCacheTestPacket(1, 1000, false))
+	require.False(t, c.Request(sub1))
+
+	// key frame spanning two packets and a delta frame
+	c.Add(newKeyFrameCacheTestPacket(2, 2000, true))
+	c.Add(newKeyFrameCacheTestPacket(3, 2000, true))
+	pkt := newKeyFrameCacheTestPacket(4, 3000, false)
+	c.Add(pkt)
+	// cached packets are copies
+	pkt.Packet.Payload[0] = 9
+	pkt.RawPacket[len(pkt.RawPacket)-3] = 9
+
+	subscriberIDs, pkts := c.TakePending()
+	require.Empty(t, subscriberIDs)
+	require.Nil(t, pkts)
+
+	require.True(t, c.Request(sub1))
+	require.True(t, c.Request(sub1))
+	subscriberIDs, pkts = c.TakePending()
+	require.Equal(t, []livekit.ParticipantID{sub1}, subscriberIDs)
+	require.Len(t, pkts, 3)
+	require.True(t, pkts[0].KeyFrame)
+	require.EqualValues(t, 2, pkts[0].Packet.SequenceNumber)
+	require.EqualValues(t, 4, pkts[2].Packet.SequenceNumber)
+	require.Equal(t, []byte{1, 2, 3}, pkts[2].Packet.Payload)
+	require.Equal(t, []byte{1, 2, 3}, pkts[2].RawPacket[len(pkts[2].RawPacket)-3:])
+
+	// a key frame is replayed once to a subscriber
+	require.False(t, c.Request(sub1))
+	require.True(t, c.Request(sub2))
+	subscriberIDs, pkts = c.TakePending()
+	require.Equal(t, []livekit.ParticipantID{sub2}, subscriberIDs)
+	require.Len(t, pkts, 3)
+
+	// stale when more packets follow the key frame than cached
+	c.Add(newKeyFrameCacheTestPacket(5, 4000, false))
+	c.Add(newKeyFrameCacheTestPacket(6, 5000, false))
+	require.False(t, c.Request(sub2))
+
+	// next key frame is replayed to subscribers again
+	c.Add(newKeyFrameCacheTestPacket(7, 6000, true))
+	require.True(t, c.Request(sub1))
+	subscriberIDs, pkts = c.TakePending()
+	require.Equal(t, []livekit.ParticipantID{sub1}, subscriberIDs)
+	require.Len(t, pkts, 1)
+	require.EqualValues(t, 7, pkts[0].Packet.SequenceNumber)
+}
+
+func TestKeyFrameCacheMaxAge(t *testing.T) {
+	c := NewKeyFrameCache(100, 50*time.Millisecond)
+	sub := livekit.ParticipantID("sub")
+
+	c.Add(newKeyFrameCacheTestPacket(1, 1000, true))
+	require.True(t, c.Request(sub))
+
+	// stale before the replay, key frame has to be requested instead
+	time.Sleep(100 * time.Millisecond)
+	subscriberIDs, pkts := c.TakePending()
+	require.Equal(t, []livekit.ParticipantID{sub}, subscriberIDs)
+	require.Nil(t, pkts)
+	require.False(t, c.Request(sub))
+}
@@ -15,6 +15,7 @@
 package pacer
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -27,7 +28,36 @@ type ExtensionData struct {
 	Payload []byte
 }
 
+// PacketKind classifies packets for pacers which prioritize some kinds of packets over others
+type PacketKind int
+
+const (
+	PacketKindVideo PacketKind = iota
+	PacketKindAudio
+	PacketKindRTX
+	PacketKindKeyFrame
+	PacketKindPadding
+)
+
+func (p PacketKind) String() string {
+	switch p {
+	case PacketKindVideo:
+		return "video"
+	case PacketKindAudio:
+		return "audio"
+	case PacketKindRTX:
+		return "rtx"
+	case PacketKindKeyFrame:
+		return "key_frame"
+	case PacketKindPadding:
+		return "padding"
+	default:
+		return fmt.Sprintf("%d", int(p))
+	}
+}
+
 type Packet struct {
+	Kind               PacketKind
 	Header             *rtp.Header
 	Extensions         []ExtensionData
 	Payload            []byte
@@ -0,0 +1,329 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gammazero/deque"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	defaultPriorityInterval     = 5 * time.Millisecond
+	defaultPriorityPacingFactor = 2.5
+	defaultPriorityMaxQueued    = 1000
+)
+
+// kinds of packets in order of priority
+var priorityOrder = []PacketKind{
+	PacketKindAudio,
+	PacketKindRTX,
+	PacketKindKeyFrame,
+	PacketKindVideo,
+	PacketKindPadding,
+}
+
+type PriorityParams struct {
+	// budget is replenished continuously and can accumulate up to an interval worth of bytes
+	Interval time.Duration
+	// multiple of bitrate that can be sent, to absorb bursts without adding too much queuing delay
+	PacingFactor float64
+	// maximum number of packets in a queue, packets are dropped when it is full
+	MaxQueued int
+	// called for each packet sent with the time it spent queued
+	OnQueueDelay func(kind PacketKind, queueDelay time.Duration)
+	Logger       logger.Logger
+}
+
+type queuedPacket struct {
+	Packet
+	enqueuedAt time.Time
+	isOrdered  bool
+}
+
+// ssrcQueueState tracks packets of an SSRC that are queued in order
+type ssrcQueueState struct {
+	// queue of the last packet, later packets of the SSRC are not queued with higher priority
+	kind   PacketKind
+	queued int
+}
+
+// Priority is a pacer with a queue per kind of packet. Queues are served in order of priority, so that audio and
+// retransmissions do not queue behind large video key frames, and padding is sent only when nothing else is waiting.
+//
+// Packets of an SSRC do not pass each other, a packet is queued with priority no higher than earlier packets of its
+// SSRC that are still queued, e. g. a key frame waits for queued video of its track, but not for video of other tracks.
+// Retransmissions are exempt, as they are out of order anyway.
+//
+// Sending is limited by a budget derived from the bitrate, i. e. the estimated channel capacity, multiplied by the
+// pacing factor. Audio is always sent, but counts against the budget. When bitrate is not known, packets are sent
+// as soon as possible in order of priority.
+type Priority struct {
+	*Base
+
+	params PriorityParams
+
+	lock           sync.Mutex
+	queues         map[PacketKind]*deque.Deque[queuedPacket]
+	ssrcs          map[uint32]*ssrcQueueState
+	isDropping     bool
+	bitrate        int
+	budget         int
+	lastBudgetAt   time.Time
+	isWakeQueued   bool
+	isWakeTimerSet bool
+	wake           chan struct{}
+	isStopped      bool
+}
+
+func NewPriority(params PriorityParams) *Priority {
+	if params.Interval <= 0 {
+		params.Interval = defaultPriorityInterval
+	}
+	if params.PacingFactor <= 0 {
+		params.PacingFactor = defaultPriorityPacingFactor
+	}
+	if params.MaxQueued <= 0 {
+		params.MaxQueued = defaultPriorityMaxQueued
+	}
+
+	p := &Priority{
+		Base:   NewBase(params.Logger),
+		params: params,
+		queues: make(map[PacketKind]*deque.Deque[queuedPacket], len(priorityOrder)),
+		ssrcs:  make(map[uint32]*ssrcQueueState),
+		wake:   make(chan struct{}, 1),
+	}
+	for _, kind := range priorityOrder {
+		q := &deque.Deque[queuedPacket]{}
+		q.SetMinCapacity(6)
+		p.queues[kind] = q
+	}
+
+	go p.sendWorker()
+	return p
+}
+
+func (p *Priority) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.params.Interval = interval
+}
+
+// SetBitrate sets the bitrate to pace to, 0 if not known
+func (p *Priority) SetBitrate(bitrate int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.bitrate == bitrate {
+		return
+	}
+
+	p.updateBudgetLocked(time.Now())
+	p.bitrate = bitrate
+	p.signalLocked()
+}
+
+func (p *Priority) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.isStopped {
+		return
+	}
+
+	p.isStopped = true
+	for _, q := range p.queues {
+		for q.Len() != 0 {
+			qp := q.PopFront()
+			putPacket(&qp.Packet)
+		}
+	}
+	p.ssrcs = make(map[uint32]*ssrcQueueState)
+	close(p.wake)
+}
+
+func (p *Priority) Enqueue(pkt Packet) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.isStopped {
+		return
+	}
+
+	kind := pkt.Kind
+	if _, ok := p.queues[kind]; !ok {
+		kind = PacketKindVideo
+	}
+	isOrdered := kind != PacketKindRTX
+	var state *ssrcQueueState
+	if isOrdered {
+		state = p.ssrcs[pkt.Header.SSRC]
+		if state != nil && priorityRank(state.kind) > priorityRank(kind) {
+			kind = state.kind
+		}
+	}
+
+	q := p.queues[kind]
+	if q.Len() >= p.params.MaxQueued {
+		if !p.isDropping {
+			p.isDropping = true
+			p.logger.Infow("pacer queue full, dropping packets", "kind", kind, "queued", q.Len())
+		}
+		putPacket(&pkt)
+		return
+	}
+	p.isDropping = false
+
+	if isOrdered {
+		if state == nil {
+			state = &ssrcQueueState{}
+			p.ssrcs[pkt.Header.SSRC] = state
+		}
+		state.kind = kind
+		state.queued++
+	}
+	q.PushBack(queuedPacket{Packet: pkt, enqueuedAt: time.Now(), isOrdered: isOrdered})
+	p.signalLocked()
+}
+
+func priorityRank(kind PacketKind) int {
+	for rank, k := range priorityOrder {
+		if k == kind {
+			return rank
+		}
+	}
+	return len(priorityOrder)
+}
+
+func putPacket(pkt *Packet) {
+	if pkt.Pool != nil && pkt.PoolEntity != nil {
+		pkt.Pool.Put(pkt.PoolEntity)
+	}
+}
+
+func (p *Priority) signalLocked() {
+	if p.isStopped || p.isWakeQueued {
+		return
+	}
+
+	p.isWakeQueued = true
+	p.wake <- struct{}{}
+}
+
+func (p *Priority) updateBudgetLocked(now time.Time) {
+	if p.bitrate <= 0 {
+		p.budget = 0
+		p.lastBudgetAt = now
+		return
+	}
+
+	bytesPerSecond := float64(p.bitrate) * p.params.PacingFactor / 8.0
+	p.budget += int(now.Sub(p.lastBudgetAt).Seconds() * bytesPerSecond)
+	p.lastBudgetAt = now
+
+	if maxBudget := int(p.params.Interval.Seconds() * bytesPerSecond); p.budget > maxBudget {
+		p.budget = maxBudget
+	}
+}
+
+// popLocked returns the highest priority packet that can be sent within the budget
+func (p *Priority) popLocked() (queuedPacket, bool) {
+	for _, kind := range priorityOrder {
+		q := p.queues[kind]
+		if q.Len() == 0 {
+			continue
+		}
+
+		if kind != PacketKindAudio && p.bitrate > 0 && p.budget <= 0 {
+			// out of budget, lower priority packets have to wait too
+			break
+		}
+
+		qp := q.PopFront()
+		if qp.isOrdered {
+			if state := p.ssrcs[qp.Header.SSRC]; state != nil {
+				if state.queued--; state.queued <= 0 {
+					delete(p.ssrcs, qp.Header.SSRC)
+				}
+			}
+		}
+		return qp, true
+	}
+
+	return queuedPacket{}, false
+}
+
+func (p *Priority) hasQueuedLocked() bool {
+	for _, q := range p.queues {
+		if q.Len() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Priority) sendWorker() {
+	for range p.wake {
+		p.lock.Lock()
+		p.isWakeQueued = false
+		p.lock.Unlock()
+
+		for {
+			p.lock.Lock()
+			if p.isStopped {
+				p.lock.Unlock()
+				return
+			}
+
+			now := time.Now()
+			p.updateBudgetLocked(now)
+			qp, ok := p.popLocked()
+			if !ok {
+				if p.hasQueuedLocked() && !p.isWakeTimerSet {
+					// out of budget, check again after budget is replenished
+					p.isWakeTimerSet = true
+					time.AfterFunc(p.params.Interval, func() {
+						p.lock.Lock()
+						defer p.lock.Unlock()
+
+						p.isWakeTimerSet = false
+						p.signalLocked()
+					})
+				}
+				p.lock.Unlock()
+				break
+			}
+			p.lock.Unlock()
+
+			written, _ := p.Base.SendPacket(&qp.Packet)
+
+			p.lock.Lock()
+			p.budget -= written
+			p.lock.Unlock()
+
+			if p.params.OnQueueDelay != nil {
+				p.params.OnQueueDelay(qp.Kind, now.Sub(qp.enqueuedAt))
+			}
+		}
+	}
+}
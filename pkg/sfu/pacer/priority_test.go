@@ -0,0 +1,163 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+)
+
+type testWriteStream struct {
+	lock sync.Mutex
+	sns  []uint16
+}
+
+func (w *testWriteStream) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.sns = append(w.sns, header.SequenceNumber)
+	return header.MarshalSize() + len(payload), nil
+}
+
+func (w *testWriteStream) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *testWriteStream) getSequenceNumbers() []uint16 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return append([]uint16(nil), w.sns...)
+}
+
+func newTestPacket(kind PacketKind, ssrc uint32, sn uint16, size int, w *testWriteStream) Packet {
+	return Packet{
+		Kind:        kind,
+		Header:      &rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: sn},
+		Payload:     make([]byte, size),
+		WriteStream: w,
+	}
+}
+
+func TestPriority(t *testing.T) {
+	t.Run("serves queues in order of priority within budget", func(t *testing.T) {
+		var lock sync.Mutex
+		queueDelays := make(map[PacketKind]time.Duration)
+		p := NewPriority(PriorityParams{
+			Interval:     10 * time.Millisecond,
+			PacingFactor: 1,
+			OnQueueDelay: func(kind PacketKind, queueDelay time.Duration) {
+				lock.Lock()
+				defer lock.Unlock()
+				queueDelays[kind] = queueDelay
+			},
+			Logger: logger.GetLogger(),
+		})
+		defer p.Stop()
+		p.SetBitrate(1_000_000)
+
+		w := &testWriteStream{}
+		// audio is sent regardless of budget, a large one exhausts budget for a while so that the rest queue up
+		p.Enqueue(newTestPacket(PacketKindAudio, 1, 0, 5000, w))
+		p.Enqueue(newTestPacket(PacketKindPadding, 2, 5, 100, w))
+		p.Enqueue(newTestPacket(PacketKindVideo, 3, 4, 100, w))
+		p.Enqueue(newTestPacket(PacketKindKeyFrame, 4, 3, 100, w))
+		p.Enqueue(newTestPacket(PacketKindRTX, 3, 2, 100, w))
+		p.Enqueue(newTestPacket(PacketKindAudio, 1, 1, 100, w))
+
+		require.Eventually(t, func() bool {
+			return len(w.getSequenceNumbers()) == 6
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, []uint16{0, 1, 2, 3, 4, 5}, w.getSequenceNumbers())
+
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, queueDelays, 5)
+		require.Less(t, queueDelays[PacketKindAudio], queueDelays[PacketKindPadding])
+	})
+
+	t.Run("sends without budget when bitrate is not known", func(t *testing.T) {
+		p := NewPriority(PriorityParams{Logger: logger.GetLogger()})
+		defer p.Stop()
+
+		w := &testWriteStream{}
+		for sn := uint16(0); sn < 100; sn++ {
+			p.Enqueue(newTestPacket(PacketKindVideo, 1, sn, 100, w))
+		}
+
+		require.Eventually(t, func() bool {
+			return len(w.getSequenceNumbers()) == 100
+		}, 100*time.Millisecond, 5*time.Millisecond)
+	})
+
+	t.Run("packets of an ssrc do not pass each other", func(t *testing.T) {
+		p := NewPriority(PriorityParams{
+			Interval:     10 * time.Millisecond,
+			PacingFactor: 1,
+			Logger:       logger.GetLogger(),
+		})
+		defer p.Stop()
+		p.SetBitrate(1_000_000)
+
+		w := &testWriteStream{}
+		p.Enqueue(newTestPacket(PacketKindAudio, 1, 0, 5000, w))
+		// key frame of a track waits for its queued video, but not for video of other tracks
+		p.Enqueue(newTestPacket(PacketKindVideo, 2, 1, 100, w))
+		p.Enqueue(newTestPacket(PacketKindVideo, 3, 4, 100, w))
+		p.Enqueue(newTestPacket(PacketKindKeyFrame, 2, 2, 100, w))
+		p.Enqueue(newTestPacket(PacketKindKeyFrame, 4, 3, 100, w))
+		// retransmissions are exempt
+		p.Enqueue(newTestPacket(PacketKindRTX, 2, 0, 100, w))
+
+		require.Eventually(t, func() bool {
+			return len(w.getSequenceNumbers()) == 6
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, []uint16{0, 0, 3, 1, 4, 2}, w.getSequenceNumbers())
+	})
+
+	t.Run("drops packets when a queue is full", func(t *testing.T) {
+		p := NewPriority(PriorityParams{
+			Interval:     10 * time.Millisecond,
+			PacingFactor: 1,
+			MaxQueued:    2,
+			Logger:       logger.GetLogger(),
+		})
+		defer p.Stop()
+		p.SetBitrate(1_000_000)
+
+		w := &testWriteStream{}
+		// exhausts budget, so that the rest queue up
+		p.Enqueue(newTestPacket(PacketKindAudio, 1, 0, 5000, w))
+		require.Eventually(t, func() bool {
+			return len(w.getSequenceNumbers()) == 1
+		}, time.Second, time.Millisecond)
+		for sn := uint16(1); sn < 5; sn++ {
+			p.Enqueue(newTestPacket(PacketKindVideo, 2, sn, 100, w))
+		}
+
+		require.Eventually(t, func() bool {
+			return len(w.getSequenceNumbers()) == 3
+		}, time.Second, 5*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, []uint16{0, 1, 2}, w.getSequenceNumbers())
+	})
+}
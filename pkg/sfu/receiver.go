@@ -61,6 +61,9 @@ type TrackReceiver interface {
 	GetAudioLevel() (float64, bool)
 
 	SendPLI(layer int32, force bool)
+	// RequestCachedKeyFrame replays the cached key frame of a layer to a down track,
+	// returning false when there is none to replay and a PLI is needed
+	RequestCachedKeyFrame(subscriberID livekit.ParticipantID, layer int32) bool
 
 	SetUpTrackPaused(paused bool)
 	SetMaxExpectedSpatialLayer(layer int32)
@@ -88,8 +91,9 @@ type TrackReceiver interface {
 type WebRTCReceiver struct {
 	logger logger.Logger
 
-	pliThrottleConfig config.PLIThrottleConfig
-	audioConfig       config.AudioConfig
+	pliThrottleConfig   config.PLIThrottleConfig
+	keyFrameCacheConfig config.KeyFrameCacheConfig
+	audioConfig         config.AudioConfig
 
 	trackID        livekit.TrackID
 	streamID       string
@@ -108,9 +112,10 @@ type WebRTCReceiver struct {
 
 	twcc *twcc.Responder
 
-	bufferMu sync.RWMutex
-	buffers  [buffer.DefaultMaxLayerSpatial + 1]*buffer.Buffer
-	rtt      uint32
+	bufferMu       sync.RWMutex
+	buffers        [buffer.DefaultMaxLayerSpatial + 1]*buffer.Buffer
+	keyFrameCaches [buffer.DefaultMaxLayerSpatial + 1]*KeyFrameCache
+	rtt            uint32
 
 	upTrackMu sync.RWMutex
 	upTracks  [buffer.DefaultMaxLayerSpatial + 1]*webrtc.TrackRemote
@@ -129,6 +134,11 @@ type WebRTCReceiver struct {
 	primaryReceiver atomic.Pointer[RedPrimaryReceiver]
 	redReceiver     atomic.Pointer[RedReceiver]
 	redPktWriter    func(pkt *buffer.ExtPacket, spatialLayer int32)
+
+	bitrateCap        *BitrateCap
+	maxBitrate        atomic.Int64
+	maxExpectedLayer  atomic.Int32
+	maxSignalledLayer int32
 }
 
 // SVC-TODO: Have to use more conditions to differentiate between
@@ -159,6 +169,14 @@ func WithPliThrottleConfig(pliThrottleConfig config.PLIThrottleConfig) ReceiverO
 	}
 }
 
+// WithKeyFrameCacheConfig enables caching of the most recent key frame of each layer
+func WithKeyFrameCacheConfig(keyFrameCacheConfig config.KeyFrameCacheConfig) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.keyFrameCacheConfig = keyFrameCacheConfig
+		return w
+	}
+}
+
 // WithAudioConfig sets up parameters for active speaker detection
 func WithAudioConfig(audioConfig config.AudioConfig) ReceiverOpts {
 	return func(w *WebRTCReceiver) *WebRTCReceiver {
@@ -232,6 +250,17 @@ func NewWebRTCReceiver(
 	})
 	w.connectionStats.Start(w.trackInfo)
 
+	if w.kind == webrtc.RTPCodecTypeVideo {
+		w.maxExpectedLayer.Store(buffer.DefaultMaxLayerSpatial)
+		w.maxSignalledLayer = maxSignalledLayer(trackInfo)
+		w.bitrateCap = NewBitrateCap(BitrateCapParams{
+			UseTMMBR: hasTMMBRFeedback(w.codec.RTCPFeedback),
+			GetCap:   w.getBitrateCap,
+			SendRTCP: w.sendRTCP,
+			Logger:   logger,
+		})
+	}
+
 	w.streamTrackerManager = NewStreamTrackerManager(logger, trackInfo, w.isSVC, w.codec.ClockRate, trackersConfig)
 	w.streamTrackerManager.SetListener(w)
 	// SVC-TODO: Handle DD for non-SVC cases???
@@ -365,8 +394,14 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 	w.upTracks[layer] = track
 	w.upTrackMu.Unlock()
 
+	var keyFrameCache *KeyFrameCache
+	if w.Kind() == webrtc.RTPCodecTypeVideo && !w.isSVC && w.keyFrameCacheConfig.Enabled {
+		keyFrameCache = NewKeyFrameCache(w.keyFrameCacheConfig.MaxPackets, w.keyFrameCacheConfig.MaxAge)
+	}
+
 	w.bufferMu.Lock()
 	w.buffers[layer] = buff
+	w.keyFrameCaches[layer] = keyFrameCache
 	rtt := w.rtt
 	w.bufferMu.Unlock()
 	buff.SetRTT(rtt)
@@ -376,6 +411,11 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 		w.streamTrackerManager.AddTracker(layer)
 	}
 
+	if w.bitrateCap != nil && w.maxBitrate.Load() > 0 {
+		// cap the new stream too
+		w.bitrateCap.Update()
+	}
+
 	go w.forwardRTP(layer)
 }
 
@@ -418,6 +458,10 @@ func (w *WebRTCReceiver) AddDownTrack(track TrackSender) error {
 func (w *WebRTCReceiver) SetMaxExpectedSpatialLayer(layer int32) {
 	w.streamTrackerManager.SetMaxExpectedSpatialLayer(layer)
 
+	if w.bitrateCap != nil && w.maxExpectedLayer.Swap(layer) != layer {
+		w.bitrateCap.Update()
+	}
+
 	if layer == buffer.InvalidLayerSpatial {
 		w.connectionStats.UpdateLayerMute(true)
 	} else {
@@ -510,6 +554,72 @@ func (w *WebRTCReceiver) sendRTCP(packets []rtcp.Packet) {
 	}
 }
 
+// SetMaxBitrate caps the bitrate the publisher sends for the track, 0 removes the cap.
+// It is further limited to what the max expected spatial layer, i. e. subscriber demand, needs.
+func (w *WebRTCReceiver) SetMaxBitrate(bitrate int64) {
+	if w.bitrateCap == nil || w.maxBitrate.Swap(bitrate) == bitrate {
+		return
+	}
+
+	w.bitrateCap.Update()
+}
+
+func (w *WebRTCReceiver) getBitrateCap() (int64, []uint32) {
+	w.upTrackMu.RLock()
+	ssrcs := make([]uint32, 0, len(w.upTracks))
+	for _, t := range w.upTracks {
+		if t != nil {
+			ssrcs = append(ssrcs, uint32(t.SSRC()))
+		}
+	}
+	w.upTrackMu.RUnlock()
+
+	maxBitrate := w.maxBitrate.Load()
+	if maxBitrate <= 0 {
+		return 0, ssrcs
+	}
+
+	// layers above the max expected layer are not needed by subscribers, so bitrate they would use is not needed either,
+	// except for headroom to let the expected layers ramp up.
+	// Compared against the signalled layers, as the layers seen stop once capped, which would lift the cap again.
+	maxExpectedLayer := w.maxExpectedLayer.Load()
+	if maxExpectedLayer >= 0 && maxExpectedLayer < w.maxSignalledLayer {
+		_, brs := w.streamTrackerManager.GetLayeredBitrate()
+		var demand int64
+		for s := int32(0); s <= maxExpectedLayer; s++ {
+			var layerBitrate int64
+			for _, br := range brs[s] {
+				if br > layerBitrate {
+					layerBitrate = br
+				}
+			}
+			if w.isSVC {
+				// SVC bitrates are cumulative across spatial layers
+				demand = layerBitrate
+			} else {
+				demand += layerBitrate
+			}
+		}
+		if demand > 0 {
+			if demand = int64(float64(demand) * bitrateCapDemandHeadroom); demand < maxBitrate {
+				return demand, ssrcs
+			}
+		}
+	}
+
+	return maxBitrate, ssrcs
+}
+
+func maxSignalledLayer(trackInfo *livekit.TrackInfo) int32 {
+	maxLayer := buffer.InvalidLayerSpatial
+	for _, layer := range trackInfo.GetLayers() {
+		if spatialLayer := buffer.VideoQualityToSpatialLayer(layer.Quality, trackInfo); spatialLayer > maxLayer {
+			maxLayer = spatialLayer
+		}
+	}
+	return maxLayer
+}
+
 func (w *WebRTCReceiver) SendPLI(layer int32, force bool) {
 	// SVC-TODO :  should send LRR (Layer Refresh Request) instead of PLI
 	buff := w.getBuffer(layer)
@@ -520,6 +630,21 @@ func (w *WebRTCReceiver) SendPLI(layer int32, force bool) {
 	buff.SendPLI(force)
 }
 
+func (w *WebRTCReceiver) RequestCachedKeyFrame(subscriberID livekit.ParticipantID, layer int32) bool {
+	if layer < 0 || int(layer) >= len(w.keyFrameCaches) {
+		return false
+	}
+
+	w.bufferMu.RLock()
+	keyFrameCache := w.keyFrameCaches[layer]
+	w.bufferMu.RUnlock()
+	if keyFrameCache == nil {
+		return false
+	}
+
+	return keyFrameCache.Request(subscriberID)
+}
+
 func (w *WebRTCReceiver) SetRTCPCh(ch chan []rtcp.Packet) {
 	w.rtcpCh = ch
 }
@@ -646,6 +771,7 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 	for {
 		w.bufferMu.RLock()
 		buf := w.buffers[layer]
+		keyFrameCache := w.keyFrameCaches[layer]
 		redPktWriter := w.redPktWriter
 		w.bufferMu.RUnlock()
 		pkt, err := buf.ReadExtended(pktBuf)
@@ -664,10 +790,19 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 			}
 		}
 
+		if keyFrameCache != nil {
+			// replay ahead of the packet, so down tracks continue from the replayed packets with it
+			w.replayKeyFrame(keyFrameCache, layer)
+		}
+
 		w.downTrackSpreader.Broadcast(func(dt TrackSender) {
 			_ = dt.WriteRTP(pkt, spatialLayer)
 		})
 
+		if keyFrameCache != nil {
+			keyFrameCache.Add(pkt)
+		}
+
 		if redPktWriter != nil {
 			redPktWriter(pkt, spatialLayer)
 		}
@@ -685,8 +820,35 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 	}
 }
 
+func (w *WebRTCReceiver) replayKeyFrame(keyFrameCache *KeyFrameCache, layer int32) {
+	subscriberIDs, pkts := keyFrameCache.TakePending()
+	if len(subscriberIDs) == 0 {
+		return
+	}
+	if pkts == nil {
+		// cache went stale since the request
+		w.SendPLI(layer, false)
+		return
+	}
+
+	for _, subscriberID := range subscriberIDs {
+		dt := w.downTrackSpreader.GetDownTrack(subscriberID)
+		if dt == nil {
+			continue
+		}
+
+		w.logger.Debugw("replaying cached key frame", "subscriberID", subscriberID, "layer", layer, "packets", len(pkts))
+		for _, pkt := range pkts {
+			_ = dt.WriteRTP(pkt, layer)
+		}
+	}
+}
+
 // closeTracks close all tracks from Receiver
 func (w *WebRTCReceiver) closeTracks() {
+	if w.bitrateCap != nil {
+		w.bitrateCap.Stop()
+	}
 	w.connectionStats.Close()
 	w.streamTrackerManager.Close()
 
@@ -24,7 +24,14 @@ import (
 
 	"github.com/gammazero/workerpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/streamtracker"
 )
 
 func TestWebRTCReceiver_OnCloseHandler(t *testing.T) {
@@ -52,6 +59,69 @@ func TestWebRTCReceiver_OnCloseHandler(t *testing.T) {
 	}
 }
 
+func TestWebRTCReceiver_BitrateCap(t *testing.T) {
+	trackInfo := &livekit.TrackInfo{
+		Type: livekit.TrackType_VIDEO,
+		Layers: []*livekit.VideoLayer{
+			{Quality: livekit.VideoQuality_LOW},
+			{Quality: livekit.VideoQuality_MEDIUM},
+			{Quality: livekit.VideoQuality_HIGH},
+		},
+	}
+	stm := &StreamTrackerManager{
+		logger:            logger.GetLogger(),
+		trackInfo:         trackInfo,
+		maxPublishedLayer: 2,
+		availableLayers:   []int32{0, 1, 2},
+	}
+	for layer, bitrate := range []int64{100_000, 300_000, 1_000_000} {
+		stm.trackers[layer] = &testBitrateTracker{bitrate: bitrate}
+	}
+	w := &WebRTCReceiver{
+		trackInfo:            trackInfo,
+		streamTrackerManager: stm,
+		maxSignalledLayer:    maxSignalledLayer(trackInfo),
+	}
+	w.maxBitrate.Store(2_000_000)
+	w.maxExpectedLayer.Store(buffer.DefaultMaxLayerSpatial)
+
+	// all layers needed, capped at max bitrate
+	bitrate, _ := w.getBitrateCap()
+	require.Equal(t, int64(2_000_000), bitrate)
+
+	// only low layer needed, capped at its bitrate with headroom
+	w.maxExpectedLayer.Store(0)
+	bitrate, _ = w.getBitrateCap()
+	require.Equal(t, int64(150_000), bitrate)
+
+	// upper layers stop once capped, cap stays
+	stm.removeAvailableLayer(2)
+	stm.removeAvailableLayer(1)
+	stm.maxPublishedLayer = 0
+	bitrate, _ = w.getBitrateCap()
+	require.Equal(t, int64(150_000), bitrate)
+
+	// medium layer needed again, it is not available yet, so headroom is on the low layer only
+	w.maxExpectedLayer.Store(1)
+	bitrate, _ = w.getBitrateCap()
+	require.Equal(t, int64(150_000), bitrate)
+
+	// medium layer resumes
+	stm.addAvailableLayer(1)
+	bitrate, _ = w.getBitrateCap()
+	require.Equal(t, int64(600_000), bitrate)
+}
+
+type testBitrateTracker struct {
+	streamtracker.StreamTrackerWorker
+
+	bitrate int64
+}
+
+func (t *testBitrateTracker) BitrateTemporalCumulative() []int64 {
+	return []int64{t.bitrate, 0, 0, 0}
+}
+
 func BenchmarkWriteRTP(b *testing.B) {
 	cases := []int{1, 2, 5, 10, 100, 250, 500}
 	workers := runtime.NumCPU()
@@ -43,6 +43,14 @@ type TranslationParamsRTP struct {
 	extTimestamp      uint64
 }
 
+func (t *TranslationParamsRTP) ExtSequenceNumber() uint64 {
+	return t.extSequenceNumber
+}
+
+func (t *TranslationParamsRTP) ExtTimestamp() uint64 {
+	return t.extTimestamp
+}
+
 type SnTs struct {
 	extSequenceNumber uint64
 	extTimestamp      uint64
@@ -331,6 +331,33 @@ func (s *sequencer) getExtPacketMetas(seqNo []uint16) []extPacketMeta {
 	return extPacketMetas
 }
 
+// getRecentPacketMetas returns up to count most recently sequenced packets, newest first.
+// Unlike NACKed packets, they are not counted as retransmitted.
+func (s *sequencer) getRecentPacketMetas(count int) []packetMeta {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.initialized {
+		return nil
+	}
+
+	packetMetas := make([]packetMeta, 0, count)
+	extHighestSNAdjusted := s.extHighestSN - s.snOffset
+	for i := uint64(0); i < uint64(s.size) && i <= extHighestSNAdjusted && len(packetMetas) < count; i++ {
+		slot := int((extHighestSNAdjusted - i) % uint64(s.size))
+		if s.isInvalidSlot(slot) {
+			continue
+		}
+
+		pm := s.meta[slot]
+		pm.codecBytes = append([]byte{}, pm.codecBytes...)
+		pm.ddBytes = append([]byte{}, pm.ddBytes...)
+		packetMetas = append(packetMetas, pm)
+	}
+
+	return packetMetas
+}
+
 func (s *sequencer) getRefTime(at time.Time) uint32 {
 	return uint32(at.UnixMilli() - s.startTime)
 }
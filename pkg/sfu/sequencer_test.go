@@ -266,3 +266,27 @@ func Test_sequencer_getNACKSeqNo_no_exclusion(t *testing.T) {
 		})
 	}
 }
+
+func Test_sequencer_getRecentPacketMetas(t *testing.T) {
+	seq := newSequencer(10, true, logger.GetLogger())
+	require.Empty(t, seq.getRecentPacketMetas(4))
+
+	for i := uint64(1); i < 6; i++ {
+		seq.push(time.Now(), i, i+100, 123, false, 0, nil, []byte{byte(i)})
+	}
+	// padding is not sequenced
+	seq.pushPadding(106, 107)
+	seq.push(time.Now(), 6, 108, 123, true, 0, nil, []byte{6})
+
+	res := seq.getRecentPacketMetas(4)
+	require.Len(t, res, 4)
+	for i, sn := range []uint16{108, 105, 104, 103} {
+		require.Equal(t, sn, res[i].targetSeqNo)
+	}
+	require.Equal(t, []byte{6}, res[0].ddBytes)
+
+	// recent packets are not counted as NACKed
+	time.Sleep((ignoreRetransmission + 10) * time.Millisecond)
+	require.Len(t, seq.getExtPacketMetas([]uint16{108}), 1)
+	require.Len(t, seq.getRecentPacketMetas(10), 6)
+}
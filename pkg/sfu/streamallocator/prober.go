@@ -32,6 +32,10 @@
 // There are two options for probing
 //   - Use padding only RTP packets: This one is preferable as
 //     probe rate can be controlled more tightly.
+//     When a subscriber has negotiated RTX, retransmissions of recently
+//     sent packets on the RTX stream are used instead. They do not
+//     consume sequence numbers of the media stream and need not wait
+//     for a frame boundary.
 //   - Resume a paused stream or forward a higher spatial layer:
 //     Have to find a stream at probing rate. Also, a stream could
 //     get a key frame unexpectedly boosting rate in the probing
@@ -31,6 +31,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 )
 
 const (
@@ -135,6 +136,8 @@ func (e Event) String() string {
 
 type StreamAllocatorParams struct {
 	Config config.CongestionControlConfig
+	// optional, paced to committed channel capacity
+	Pacer  pacer.Pacer
 	Logger logger.Logger
 }
 
@@ -685,7 +688,7 @@ func (s *StreamAllocator) handleSignalSendProbe(event *Event) {
 
 	bytesSent := 0
 	for _, track := range s.getTracks() {
-		sent := track.WritePaddingRTP(bytesToSend)
+		sent := track.WriteProbeRTP(bytesToSend)
 		bytesSent += sent
 		bytesToSend -= sent
 		if bytesToSend <= 0 {
@@ -750,8 +753,15 @@ func (s *StreamAllocator) handleSignalRTCPReceiverReport(event *Event) {
 	track := s.videoTracks[event.TrackID]
 	s.videoTracksMu.Unlock()
 
-	if track != nil {
-		track.ProcessRTCPReceiverReport(rr)
+	if track == nil {
+		return
+	}
+
+	track.ProcessRTCPReceiverReport(rr)
+
+	if s.params.Config.FEC.Enabled && track.UpdateFECProtection(rr, s.params.Config.FEC) {
+		// FEC overhead is accounted for in bandwidth needed by the track
+		s.allocateTrack(track)
 	}
 }
 
@@ -847,7 +857,7 @@ func (s *StreamAllocator) handleNewEstimateInNonProbe() {
 		return
 	}
 
-	s.committedChannelCapacity = estimateToCommit
+	s.setCommittedChannelCapacity(estimateToCommit)
 
 	// reset to get new set of samples for next trend
 	s.channelObserver = s.newChannelObserverNonProbe()
@@ -1042,12 +1052,19 @@ func (s *StreamAllocator) onProbeDone(isNotFailing bool, isGoalReached bool) {
 	}
 
 	if highestEstimateInProbe > s.committedChannelCapacity {
-		s.committedChannelCapacity = highestEstimateInProbe
+		s.setCommittedChannelCapacity(highestEstimateInProbe)
 	}
 
 	s.maybeBoostDeficientTracks()
 }
 
+func (s *StreamAllocator) setCommittedChannelCapacity(committedChannelCapacity int64) {
+	s.committedChannelCapacity = committedChannelCapacity
+	if s.params.Pacer != nil {
+		s.params.Pacer.SetBitrate(int(committedChannelCapacity))
+	}
+}
+
 func (s *StreamAllocator) maybeBoostDeficientTracks() {
 	availableChannelCapacity := s.getAvailableHeadroom(false)
 	if availableChannelCapacity <= 0 {
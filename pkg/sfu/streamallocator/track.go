@@ -16,10 +16,13 @@ package streamallocator
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"time"
 
 	"github.com/livekit/mediatransportutil"
+
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 	"github.com/pion/rtcp"
@@ -28,6 +31,12 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 )
 
+const (
+	// FEC protection is a multiple of loss, in steps to avoid re-allocation on small changes of loss
+	fecProtectionLossMultiplier = 2.0
+	fecProtectionStep           = 0.1
+)
+
 type Track struct {
 	downTrack   *sfu.DownTrack
 	source      livekit.TrackSource
@@ -54,6 +63,8 @@ type Track struct {
 	// STREAM-ALLOCATOR-EXPERIMENTAL-TODO: remove after experimental
 	receiverReportHistory []string
 
+	fecProtection float64
+
 	isDirty bool
 
 	streamState StreamState
@@ -152,8 +163,14 @@ func (t *Track) SetMaxLayer(layer buffer.VideoLayer) bool {
 	return true
 }
 
-func (t *Track) WritePaddingRTP(bytesToSend int) int {
-	return t.downTrack.WritePaddingRTP(bytesToSend, false, false)
+// WriteProbeRTP sends probe packets, retransmissions on the RTX stream when in use, topped up with padding
+// when there is not enough to retransmit
+func (t *Track) WriteProbeRTP(bytesToSend int) int {
+	sent := t.downTrack.WriteRTXProbe(bytesToSend)
+	if sent < bytesToSend {
+		sent += t.downTrack.WritePaddingRTP(bytesToSend-sent, false, false)
+	}
+	return sent
 }
 
 func (t *Track) AllocateOptimal(allowOvershoot bool) sfu.VideoAllocation {
@@ -287,6 +304,27 @@ func (t *Track) ProcessRTCPReceiverReport(rr rtcp.ReceptionReport) {
 	t.updateReceiverReportHistory()
 }
 
+// UpdateFECProtection sets FEC protection of the track based on loss reported by the subscriber,
+// returns true when protection changed, bandwidth needed by the track changes with it
+func (t *Track) UpdateFECProtection(rr rtcp.ReceptionReport, fecConfig config.CongestionControlFECConfig) bool {
+	loss := float64(rr.FractionLost) / 256.0
+
+	protection := t.fecProtection
+	switch {
+	case loss >= fecConfig.LossThreshold:
+		protection = math.Min(math.Ceil(loss*fecProtectionLossMultiplier/fecProtectionStep)*fecProtectionStep, fecConfig.MaxOverhead)
+	case loss < fecConfig.LossThreshold/2:
+		protection = 0
+	}
+	if protection == t.fecProtection || !t.downTrack.SetFECProtection(protection) {
+		return false
+	}
+
+	t.logger.Debugw("stream allocator: FEC protection changed", "trackID", t.ID(), "loss", loss, "from", t.fecProtection, "to", protection)
+	t.fecProtection = protection
+	return true
+}
+
 func (t *Track) GetRTCPReceiverReportDelta() (uint32, uint32, uint32) {
 	deltaPackets := t.highestSequenceNumber - t.highestSequenceNumberAtLastRead
 	t.highestSequenceNumberAtLastRead = t.highestSequenceNumber
@@ -0,0 +1,126 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/webhook"
+)
+
+const (
+	attributesNotifierQueueSize = 100
+	attributesNotifierTimeout   = 10 * time.Second
+)
+
+// AttributesNotifier queues participant_attributes_changed events, with changed attributes in a top level field
+type AttributesNotifier interface {
+	QueueNotifyAttributesChanged(ctx context.Context, event *livekit.WebhookEvent, changed map[string]string) error
+}
+
+// AttributesWebhookNotifier sends events with the protocol's notifier, except for participant_attributes_changed,
+// which carries a field WebhookEvent does not define. Those are signed the same way and sent once, without retries.
+type AttributesWebhookNotifier struct {
+	webhook.QueuedNotifier
+
+	apiKey    string
+	apiSecret string
+	urls      []string
+	client    *http.Client
+	worker    core.QueueWorker
+}
+
+func NewAttributesWebhookNotifier(apiKey, apiSecret string, urls []string) *AttributesWebhookNotifier {
+	return &AttributesWebhookNotifier{
+		QueuedNotifier: webhook.NewDefaultNotifier(apiKey, apiSecret, urls),
+		apiKey:         apiKey,
+		apiSecret:      apiSecret,
+		urls:           urls,
+		client:         &http.Client{Timeout: attributesNotifierTimeout},
+		worker: core.NewQueueWorker(core.QueueWorkerParams{
+			QueueSize:    attributesNotifierQueueSize,
+			DropWhenFull: true,
+		}),
+	}
+}
+
+func (n *AttributesWebhookNotifier) QueueNotifyAttributesChanged(_ context.Context, event *livekit.WebhookEvent, changed map[string]string) error {
+	encoded, err := encodeAttributesChangedEvent(event, changed)
+	if err != nil {
+		return err
+	}
+
+	n.worker.Submit(func() {
+		for _, url := range n.urls {
+			if err := n.send(url, encoded); err != nil {
+				logger.Warnw("failed to send webhook", err, "url", url, "event", event.Event)
+			}
+		}
+	})
+	return nil
+}
+
+func (n *AttributesWebhookNotifier) send(url string, encoded []byte) error {
+	sum := sha256.Sum256(encoded)
+	token, err := auth.NewAccessToken(n.apiKey, n.apiSecret).
+		SetValidFor(5 * time.Minute).
+		SetSha256(base64.StdEncoding.EncodeToString(sum[:])).
+		ToJWT()
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", token)
+	// same mime type as the protocol's notifier, so that the signature is checked prior to parsing
+	r.Header.Set("content-type", "application/webhook+json")
+	res, err := n.client.Do(r)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	return nil
+}
+
+func encodeAttributesChangedEvent(event *livekit.WebhookEvent, changed map[string]string) ([]byte, error) {
+	encoded, err := protojson.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]json.RawMessage
+	if err = json.Unmarshal(encoded, &values); err != nil {
+		return nil, err
+	}
+	if values[changedAttributesField], err = json.Marshal(changed); err != nil {
+		return nil, err
+	}
+	return json.Marshal(values)
+}
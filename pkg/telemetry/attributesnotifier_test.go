@@ -0,0 +1,77 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestAttributesWebhookNotifier(t *testing.T) {
+	const (
+		apiKey    = "mykey"
+		apiSecret = "mysecret"
+	)
+
+	received := make(chan []byte, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := webhook.Receive(r, auth.NewSimpleKeyProvider(apiKey, apiSecret))
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- data
+	}))
+	defer s.Close()
+
+	n := telemetry.NewAttributesWebhookNotifier(apiKey, apiSecret, []string{s.URL})
+	err := n.QueueNotifyAttributesChanged(context.Background(), &livekit.WebhookEvent{
+		Event:       telemetry.EventParticipantAttributesChanged,
+		Room:        &livekit.Room{Name: "room"},
+		Participant: &livekit.ParticipantInfo{Identity: "p1"},
+	}, map[string]string{"a": "1", "b": ""})
+	require.NoError(t, err)
+
+	var data []byte
+	select {
+	case data = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not received")
+	}
+
+	var payload struct {
+		Event             string            `json:"event"`
+		ChangedAttributes map[string]string `json:"changed_attributes"`
+		Participant       struct {
+			Identity string `json:"identity"`
+		} `json:"participant"`
+	}
+	require.NoError(t, json.Unmarshal(data, &payload))
+	require.Equal(t, telemetry.EventParticipantAttributesChanged, payload.Event)
+	require.Equal(t, "p1", payload.Participant.Identity)
+	require.Equal(t, map[string]string{"a": "1", "b": ""}, payload.ChangedAttributes)
+}
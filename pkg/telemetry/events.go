@@ -188,6 +188,40 @@ func (t *telemetryService) ParticipantLeft(ctx context.Context,
 	})
 }
 
+// EventParticipantAttributesChanged is not defined by the protocol version in use, neither are attributes of
+// ParticipantInfo, so the event carries changed attributes in a top level "changed_attributes" field,
+// with an empty value for deleted keys
+const (
+	EventParticipantAttributesChanged = "participant_attributes_changed"
+	changedAttributesField            = "changed_attributes"
+)
+
+func (t *telemetryService) ParticipantAttributesChanged(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+	changed map[string]string,
+) {
+	t.enqueue(func() {
+		event := &livekit.WebhookEvent{
+			Event:       EventParticipantAttributesChanged,
+			Room:        room,
+			Participant: participant,
+		}
+		n, ok := t.notifier.(AttributesNotifier)
+		if !ok {
+			t.NotifyEvent(ctx, event)
+			return
+		}
+
+		event.CreatedAt = time.Now().Unix()
+		event.Id = utils.NewGuid("EV_")
+		if err := n.QueueNotifyAttributesChanged(ctx, event, changed); err != nil {
+			logger.Warnw("failed to notify webhook", err, "event", event.Event)
+		}
+	})
+}
+
 func (t *telemetryService) TrackPublishRequested(
 	ctx context.Context,
 	participantID livekit.ParticipantID,
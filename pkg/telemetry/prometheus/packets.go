@@ -15,6 +15,8 @@
 package prometheus
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/atomic"
 
@@ -56,6 +58,7 @@ var (
 	promRTT             *prometheus.HistogramVec
 	promParticipantJoin *prometheus.CounterVec
 	promConnections     *prometheus.GaugeVec
+	promPacerQueueDelay *prometheus.HistogramVec
 
 	promPacketTotalIncomingInitial    prometheus.Counter
 	promPacketTotalIncomingRetransmit prometheus.Counter
@@ -137,6 +140,13 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"kind"})
+	promPacerQueueDelay = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "pacer",
+		Name:        "queue_delay_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000},
+	}, []string{"kind"})
 
 	prometheus.MustRegister(promPacketTotal)
 	prometheus.MustRegister(promPacketBytes)
@@ -149,6 +159,7 @@ func initPacketStats(nodeID string, nodeType livekit.NodeType, env string) {
 	prometheus.MustRegister(promRTT)
 	prometheus.MustRegister(promParticipantJoin)
 	prometheus.MustRegister(promConnections)
+	prometheus.MustRegister(promPacerQueueDelay)
 
 	promPacketTotalIncomingInitial = promPacketTotal.WithLabelValues(string(Incoming), transmissionInitial)
 	promPacketTotalIncomingRetransmit = promPacketTotal.WithLabelValues(string(Incoming), transmissionRetransmit)
@@ -244,6 +255,10 @@ func RecordRTT(direction Direction, trackSource livekit.TrackSource, trackType l
 	}
 }
 
+func RecordPacerQueueDelay(kind string, queueDelay time.Duration) {
+	promPacerQueueDelay.WithLabelValues(kind).Observe(float64(queueDelay) / float64(time.Millisecond))
+}
+
 func IncrementParticipantJoin(join uint32) {
 	if join > 0 {
 		participantSignalConnected.Add(uint64(join))
@@ -38,6 +38,7 @@ type BytesTrackStats struct {
 	trackID         livekit.TrackID
 	pID             livekit.ParticipantID
 	send, recv      atomic.Uint64
+	dropped         atomic.Uint32
 	lastStatsReport atomic.Value // *time.Time
 	telemetry       TelemetryService
 }
@@ -63,6 +64,11 @@ func (s *BytesTrackStats) AddBytes(bytes uint64, isSend bool) {
 	s.report(false)
 }
 
+// AddDropped counts received packets that were dropped, reported as lost packets of the upstream
+func (s *BytesTrackStats) AddDropped(packets uint32) {
+	s.dropped.Add(packets)
+}
+
 func (s *BytesTrackStats) Report() {
 	s.report(true)
 }
@@ -82,10 +88,11 @@ func (s *BytesTrackStats) report(force bool) {
 		s.lastStatsReport.Store(&now)
 	}
 
-	if recv := s.recv.Swap(0); recv > 0 {
+	recv, dropped := s.recv.Swap(0), s.dropped.Swap(0)
+	if recv > 0 || dropped > 0 {
 		s.telemetry.TrackStats(StatsKeyForData(livekit.StreamType_UPSTREAM, s.pID, s.trackID), &livekit.AnalyticsStat{
 			Streams: []*livekit.AnalyticsStream{
-				{PrimaryBytes: recv},
+				{PrimaryBytes: recv, PacketsLost: dropped},
 			},
 		})
 	}
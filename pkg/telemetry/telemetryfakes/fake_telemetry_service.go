@@ -77,6 +77,14 @@ type FakeTelemetryService struct {
 		arg4 *livekit.AnalyticsClientMeta
 		arg5 bool
 	}
+	ParticipantAttributesChangedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, map[string]string)
+	participantAttributesChangedMutex       sync.RWMutex
+	participantAttributesChangedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 map[string]string
+	}
 	ParticipantJoinedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.ClientInfo, *livekit.AnalyticsClientMeta, bool)
 	participantJoinedMutex       sync.RWMutex
 	participantJoinedArgsForCall []struct {
@@ -602,6 +610,41 @@ func (fake *FakeTelemetryService) ParticipantActiveArgsForCall(i int) (context.C
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeTelemetryService) ParticipantAttributesChanged(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 map[string]string) {
+	fake.participantAttributesChangedMutex.Lock()
+	fake.participantAttributesChangedArgsForCall = append(fake.participantAttributesChangedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 map[string]string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ParticipantAttributesChangedStub
+	fake.recordInvocation("ParticipantAttributesChanged", []interface{}{arg1, arg2, arg3, arg4})
+	fake.participantAttributesChangedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantAttributesChangedStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantAttributesChangedCallCount() int {
+	fake.participantAttributesChangedMutex.RLock()
+	defer fake.participantAttributesChangedMutex.RUnlock()
+	return len(fake.participantAttributesChangedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantAttributesChangedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, map[string]string)) {
+	fake.participantAttributesChangedMutex.Lock()
+	defer fake.participantAttributesChangedMutex.Unlock()
+	fake.ParticipantAttributesChangedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantAttributesChangedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, map[string]string) {
+	fake.participantAttributesChangedMutex.RLock()
+	defer fake.participantAttributesChangedMutex.RUnlock()
+	argsForCall := fake.participantAttributesChangedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) ParticipantJoined(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *livekit.ClientInfo, arg5 *livekit.AnalyticsClientMeta, arg6 bool) {
 	fake.participantJoinedMutex.Lock()
 	fake.participantJoinedArgsForCall = append(fake.participantJoinedArgsForCall, struct {
@@ -1363,6 +1406,8 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.notifyEventMutex.RUnlock()
 	fake.participantActiveMutex.RLock()
 	defer fake.participantActiveMutex.RUnlock()
+	fake.participantAttributesChangedMutex.RLock()
+	defer fake.participantAttributesChangedMutex.RUnlock()
 	fake.participantJoinedMutex.RLock()
 	defer fake.participantJoinedMutex.RUnlock()
 	fake.participantLeftMutex.RLock()
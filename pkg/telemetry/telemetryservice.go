@@ -41,6 +41,8 @@ type TelemetryService interface {
 	ParticipantResumed(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, nodeID livekit.NodeID, reason livekit.ReconnectReason)
 	// ParticipantLeft - the participant leaves the room, only sent if ParticipantActive has been called before
 	ParticipantLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, shouldSendEvent bool)
+	// ParticipantAttributesChanged - attributes of the participant were updated, changed has an empty value for deleted keys
+	ParticipantAttributesChanged(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, changed map[string]string)
 	// TrackPublishRequested - a publication attempt has been received
 	TrackPublishRequested(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo)
 	// TrackPublished - a publication attempt has been successful
@@ -0,0 +1,173 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/testutils"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func writeTestMedia(t *testing.T, dir string) {
+	// one second of VP8 at 30fps
+	ivf := make([]byte, 32)
+	copy(ivf[0:], "DKIF")
+	binary.LittleEndian.PutUint16(ivf[6:], 32)
+	copy(ivf[8:], "VP80")
+	binary.LittleEndian.PutUint16(ivf[12:], 320)
+	binary.LittleEndian.PutUint16(ivf[14:], 180)
+	binary.LittleEndian.PutUint32(ivf[16:], 30)
+	binary.LittleEndian.PutUint32(ivf[20:], 1)
+	for i := 0; i < 30; i++ {
+		frame := []byte{0x00, 0xff, 0xff, 0xff, 0xff}
+		header := make([]byte, 12)
+		binary.LittleEndian.PutUint32(header[0:], uint32(len(frame)))
+		binary.LittleEndian.PutUint64(header[4:], uint64(i))
+		ivf = append(ivf, header...)
+		ivf = append(ivf, frame...)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "video.ivf"), ivf, 0644))
+
+	// one second of opus
+	w, err := oggwriter.New(filepath.Join(dir, "audio.ogg"), 48000, 2)
+	require.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		require.NoError(t, w.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{SequenceNumber: uint16(i), Timestamp: uint32(i * 960)},
+			Payload: []byte{0xfc, 0xff, 0xfe},
+		}))
+	}
+	require.NoError(t, w.Close())
+}
+
+func botRequest(t *testing.T, path string, body interface{}, resp ...interface{}) *http.Response {
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost:%d%s", defaultServerPort, path), bytes.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	testclient.SetAuthorizationToken(req.Header, adminRoomToken(testRoom))
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	if len(resp) != 0 && res.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(resp[0]))
+	}
+	return res
+}
+
+func TestBotPublisher(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	mediaDir := t.TempDir()
+	writeTestMedia(t, mediaDir)
+
+	logger.Infow("----------------STARTING TEST----------------", "test", t.Name())
+	s := createSingleNodeServer(func(conf *config.Config) {
+		conf.Bots.MediaDir = mediaDir
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	waitUntilConnected(t, c1)
+	defer c1.Stop()
+
+	// files must be within the media directory
+	res := botRequest(t, service.StartBotPath, service.StartBotRequest{Room: testRoom, Identity: "bot", Audio: "../audio.ogg"})
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res = botRequest(t, service.StartBotPath, service.StartBotRequest{
+		Room:     testRoom,
+		Identity: "bot",
+		Audio:    "audio.ogg",
+		Video:    "video.ivf",
+		Loop:     true,
+	})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var botID livekit.ParticipantID
+	testutils.WithTimeout(t, func() string {
+		for _, p := range c1.RemoteParticipants() {
+			if p.Identity == "bot" {
+				botID = livekit.ParticipantID(p.Sid)
+			}
+		}
+		if botID == "" {
+			return "c1 did not see the bot"
+		}
+		if len(c1.SubscribedTracks()[botID]) != 2 {
+			return "c1 did not subscribe to tracks of the bot"
+		}
+		return ""
+	})
+
+	room := s.RoomManager().GetRoom(context.Background(), testRoom)
+	require.NotNil(t, room)
+	bot := room.GetParticipant("bot")
+	require.NotNil(t, bot)
+	sources := make(map[livekit.TrackSource]string)
+	for _, track := range bot.GetPublishedTracks() {
+		sources[track.Source()] = track.ToProto().MimeType
+	}
+	require.Equal(t, map[livekit.TrackSource]string{
+		livekit.TrackSource_MICROPHONE: "audio/opus",
+		livekit.TrackSource_CAMERA:     "video/VP8",
+	}, sources)
+
+	var bots service.ListBotsResponse
+	res = botRequest(t, service.ListBotsPath, service.ListBotsRequest{Room: testRoom}, &bots)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, []*service.BotInfo{{Room: testRoom, Identity: "bot"}}, bots.Bots)
+
+	res = botRequest(t, service.StopBotPath, service.StopBotRequest{Room: testRoom, Identity: "bot"})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	testutils.WithTimeout(t, func() string {
+		if room.GetParticipant("bot") != nil {
+			return "bot did not leave"
+		}
+		return ""
+	})
+
+	res = botRequest(t, service.StopBotPath, service.StopBotRequest{Room: testRoom, Identity: "bot"})
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func packetCaptureRequest(t *testing.T, method string, query string, body interface{}, v interface{}) int {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s?%s", defaultServerPort, service.PacketCapturePath, query), bytes.NewReader(payload))
+	require.NoError(t, err)
+	testclient.SetAuthorizationToken(req.Header, adminRoomToken(testRoom))
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK && v != nil {
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(b, v))
+	}
+	return res.StatusCode
+}
+
+func TestPacketCapture(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	outputDir := t.TempDir()
+
+	logger.Infow("----------------STARTING TEST----------------", "test", t.Name())
+	s := createSingleNodeServer(func(conf *config.Config) {
+		conf.PacketCapture.OutputDir = outputDir
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	c2 := createRTCClient("c2", defaultServerPort, nil)
+	waitUntilConnected(t, c1, c2)
+	defer c1.Stop()
+	defer c2.Stop()
+
+	writer, err := c1.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	status := packetCaptureRequest(t, http.MethodPost, "", service.StartPacketCaptureRequest{Room: testRoom, Identity: "unknown"}, nil)
+	require.Equal(t, http.StatusNotFound, status)
+
+	var info service.PacketCaptureInfo
+	status = packetCaptureRequest(t, http.MethodPost, "", service.StartPacketCaptureRequest{
+		Room:         testRoom,
+		Identity:     "c1",
+		PayloadBytes: -1,
+	}, &info)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, outputDir, filepath.Dir(info.Filename))
+	require.Equal(t, ".pcapng", filepath.Ext(info.Filename))
+
+	var list struct {
+		Captures []service.PacketCaptureInfo `json:"captures"`
+	}
+	status = packetCaptureRequest(t, http.MethodGet, "room="+testRoom, nil, &list)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, list.Captures, 1)
+	require.Equal(t, "c1", list.Captures[0].Identity)
+
+	time.Sleep(time.Second)
+
+	status = packetCaptureRequest(t, http.MethodDelete, "room="+testRoom+"&identity=c1", nil, &info)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "requested", info.Reason)
+	require.Empty(t, info.Error)
+	require.NotZero(t, info.Packets)
+
+	data, err := os.ReadFile(info.Filename)
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), info.Size)
+	// pcapng section header
+	require.Equal(t, uint32(0x0a0d0d0a), binary.LittleEndian.Uint32(data))
+
+	status = packetCaptureRequest(t, http.MethodDelete, "room="+testRoom+"&identity=c1", nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/testutils"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func recordTrackRequest(t *testing.T, path string, req proto.Message) (*livekit.EgressInfo, int) {
+	body, err := protojson.Marshal(req)
+	require.NoError(t, err)
+	httpReq, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost:%d%s", defaultServerPort, path), bytes.NewReader(body))
+	require.NoError(t, err)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	token, err := auth.NewAccessToken(testApiKey, testApiSecret).
+		AddGrant(&auth.VideoGrant{RoomRecord: true}).
+		ToJWT()
+	require.NoError(t, err)
+	testclient.SetAuthorizationToken(httpReq.Header, token)
+
+	res, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, res.StatusCode
+	}
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	info := &livekit.EgressInfo{}
+	require.NoError(t, protojson.Unmarshal(b, info))
+	return info, res.StatusCode
+}
+
+func TestTrackRecording(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	outputDir := t.TempDir()
+
+	logger.Infow("----------------STARTING TEST----------------", "test", t.Name())
+	s := createSingleNodeServer(func(conf *config.Config) {
+		conf.Recorder.OutputDir = outputDir
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	waitUntilConnected(t, c1)
+	defer c1.Stop()
+
+	writer, err := c1.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	var trackID string
+	testutils.WithTimeout(t, func() string {
+		ids := c1.GetPublishedTrackIDs()
+		if len(ids) == 0 {
+			return "track was not published"
+		}
+		trackID = ids[0]
+		return ""
+	})
+
+	// track can be recorded once media is received
+	var (
+		info   *livekit.EgressInfo
+		status int
+	)
+	testutils.WithTimeout(t, func() string {
+		info, status = recordTrackRequest(t, service.StartTrackRecordingPath, &livekit.TrackEgressRequest{
+			RoomName: testRoom,
+			TrackId:  trackID,
+			Output:   &livekit.TrackEgressRequest_File{File: &livekit.DirectFileOutput{Filepath: "{room_name}/{track_id}"}},
+		})
+		if status != http.StatusOK {
+			return fmt.Sprintf("could not start recording, status %d", status)
+		}
+		return ""
+	})
+	require.Equal(t, livekit.EgressStatus_EGRESS_ACTIVE, info.Status)
+	filename := filepath.Join(outputDir, testRoom, trackID+".ogg")
+	require.Equal(t, filename, info.FileResults[0].Filename)
+
+	// only files within the output directory
+	_, status = recordTrackRequest(t, service.StartTrackRecordingPath, &livekit.TrackEgressRequest{
+		RoomName: testRoom,
+		TrackId:  trackID,
+		Output:   &livekit.TrackEgressRequest_File{File: &livekit.DirectFileOutput{Filepath: "../track"}},
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	time.Sleep(time.Second)
+
+	info, status = recordTrackRequest(t, service.StopTrackRecordingPath, &livekit.StopEgressRequest{EgressId: info.EgressId})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, livekit.EgressStatus_EGRESS_COMPLETE, info.Status)
+	require.NotZero(t, info.EndedAt)
+
+	stat, err := os.Stat(filename)
+	require.NoError(t, err)
+	require.Equal(t, stat.Size(), info.FileResults[0].Size)
+	// more than the Ogg headers
+	require.Greater(t, stat.Size(), int64(1000))
+
+	_, status = recordTrackRequest(t, service.StopTrackRecordingPath, &livekit.StopEgressRequest{EgressId: info.EgressId})
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/testutils"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func rtpForwardRequest(t *testing.T, method string, query string, body interface{}, v interface{}) int {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s?%s", defaultServerPort, service.RTPForwardPath, query), bytes.NewReader(payload))
+	require.NoError(t, err)
+	testclient.SetAuthorizationToken(req.Header, adminRoomToken(testRoom))
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK && v != nil {
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(b, v))
+	}
+	return res.StatusCode
+}
+
+func TestRTPForward(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	logger.Infow("----------------STARTING TEST----------------", "test", t.Name())
+	s := createSingleNodeServer(func(conf *config.Config) {
+		conf.RTPForward.Enabled = true
+		conf.RTPForward.AllowedNetworks = []string{"127.0.0.0/8"}
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	c2 := createRTCClient("c2", defaultServerPort, nil)
+	waitUntilConnected(t, c1, c2)
+	defer c1.Stop()
+	defer c2.Stop()
+
+	writer, err := c1.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	var trackID string
+	testutils.WithTimeout(t, func() string {
+		tracks := c2.SubscribedTracks()[c1.ID()]
+		if len(tracks) != 1 {
+			return "c2 did not subscribe to the track of c1"
+		}
+		trackID = tracks[0].ID()
+		return ""
+	})
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	status := rtpForwardRequest(t, http.MethodPost, "", service.StartRTPForwardRequest{Room: testRoom, TrackID: "TR_unknown", Host: "127.0.0.1", Port: port}, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	status = rtpForwardRequest(t, http.MethodPost, "", service.StartRTPForwardRequest{Room: testRoom, TrackID: trackID, Host: "192.0.2.1", Port: port}, nil)
+	require.Equal(t, http.StatusForbidden, status)
+
+	var info service.RTPForwardInfo
+	status = rtpForwardRequest(t, http.MethodPost, "", service.StartRTPForwardRequest{Room: testRoom, TrackID: trackID, Host: "127.0.0.1", Port: port}, &info)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, strings.HasPrefix(info.ID, "RF_"))
+	require.Equal(t, trackID, info.TrackID)
+	require.Contains(t, info.SDP, fmt.Sprintf("m=audio %d RTP/AVP", port))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	b := make([]byte, 1500)
+	n, err := conn.Read(b)
+	require.NoError(t, err)
+	var pkt rtp.Packet
+	require.NoError(t, pkt.Unmarshal(b[:n]))
+	require.Equal(t, info.SSRC, pkt.SSRC)
+
+	var list struct {
+		Forwards []service.RTPForwardInfo `json:"forwards"`
+	}
+	status = rtpForwardRequest(t, http.MethodGet, "room="+testRoom, nil, &list)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, list.Forwards, 1)
+	require.Equal(t, info.ID, list.Forwards[0].ID)
+
+	status = rtpForwardRequest(t, http.MethodDelete, "room="+testRoom+"&id="+info.ID, nil, &info)
+	require.Equal(t, http.StatusOK, status)
+	require.NotZero(t, info.EndedAt)
+	require.NotZero(t, info.Packets)
+
+	status = rtpForwardRequest(t, http.MethodDelete, "room="+testRoom+"&id="+info.ID, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/testutils"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func rtpIngestRequest(t *testing.T, method string, query string, body interface{}, v interface{}) int {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s?%s", defaultServerPort, service.RTPIngestPath, query), bytes.NewReader(payload))
+	require.NoError(t, err)
+	testclient.SetAuthorizationToken(req.Header, adminRoomToken(testRoom))
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK && v != nil {
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(b, v))
+	}
+	return res.StatusCode
+}
+
+func TestRTPIngest(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	logger.Infow("----------------STARTING TEST----------------", "test", t.Name())
+	s := createSingleNodeServer(func(conf *config.Config) {
+		conf.RTPIngest.Enabled = true
+		conf.RTPIngest.BindAddress = "127.0.0.1"
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	waitUntilConnected(t, c1)
+	defer c1.Stop()
+
+	status := rtpIngestRequest(t, http.MethodPost, "", service.StartRTPIngestRequest{
+		Room:     testRoom,
+		Identity: "encoder",
+		Audio:    &service.RTPIngestTrack{Codec: "vp8"},
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	var info service.RTPIngestInfo
+	status = rtpIngestRequest(t, http.MethodPost, "", service.StartRTPIngestRequest{
+		Room:     testRoom,
+		Identity: "encoder",
+		Audio:    &service.RTPIngestTrack{Codec: "opus", PayloadType: 111},
+	}, &info)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, info.Tracks, 1)
+	require.Equal(t, "audio/opus", info.Tracks[0].MimeType)
+
+	// encoder sending Opus frames
+	encoder, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: info.Tracks[0].Port})
+	require.NoError(t, err)
+	defer encoder.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for sn := uint16(0); ; sn++ {
+			b, _ := (&rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    111,
+					SequenceNumber: sn,
+					Timestamp:      uint32(sn) * 960,
+					SSRC:           1234,
+				},
+				Payload: []byte{0xfc, 0xff, 0xfe},
+			}).Marshal()
+			_, _ = encoder.Write(b)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	testutils.WithTimeout(t, func() string {
+		for _, p := range c1.RemoteParticipants() {
+			if p.Identity == "encoder" && len(c1.SubscribedTracks()[livekit.ParticipantID(p.Sid)]) == 1 {
+				return ""
+			}
+		}
+		return "c1 did not subscribe to the ingested track"
+	})
+
+	var list struct {
+		Ingests []service.RTPIngestInfo `json:"ingests"`
+	}
+	status = rtpIngestRequest(t, http.MethodGet, "room="+testRoom, nil, &list)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, list.Ingests, 1)
+	require.Equal(t, encoder.LocalAddr().String(), list.Ingests[0].Tracks[0].Source)
+	require.NotZero(t, list.Ingests[0].Tracks[0].Packets)
+
+	status = rtpIngestRequest(t, http.MethodDelete, "room="+testRoom+"&identity=encoder", nil, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	room := s.RoomManager().GetRoom(context.Background(), testRoom)
+	require.NotNil(t, room)
+	testutils.WithTimeout(t, func() string {
+		if room.GetParticipant("encoder") != nil {
+			return "ingest did not leave"
+		}
+		return ""
+	})
+
+	status = rtpIngestRequest(t, http.MethodDelete, "room="+testRoom+"&identity=encoder", nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
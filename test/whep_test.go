@@ -0,0 +1,118 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/testutils"
+)
+
+func TestWHEPPlayback(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestWHEPPlayback")
+	defer finish()
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	c2 := createRTCClient("c2", defaultServerPort, nil)
+	waitUntilConnected(t, c1, c2)
+	defer c1.Stop()
+	defer c2.Stop()
+
+	writer, err := c1.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	// player receiving over WHEP
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close()
+	_, err = pc.AddTransceiverFromKind(webrtc.RTPCodecTypeAudio, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionRecvonly})
+	require.NoError(t, err)
+	var received atomic.Int32
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		for {
+			if _, _, err := track.ReadRTP(); err != nil {
+				return
+			}
+			received.Inc()
+		}
+	})
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	gathered := webrtc.GatheringCompletePromise(pc)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	<-gathered
+
+	var trackID string
+	testutils.WithTimeout(t, func() string {
+		tracks := c2.SubscribedTracks()[c1.ID()]
+		if len(tracks) != 1 {
+			return "c2 did not subscribe to the track of c1"
+		}
+		trackID = tracks[0].ID()
+		return ""
+	})
+
+	// tokens have to allow subscribing
+	publishOnly := &auth.VideoGrant{RoomJoin: true, Room: testRoom}
+	publishOnly.SetCanSubscribe(false)
+	res, _ := sdpRequest(t, joinTokenWithGrant("whep", publishOnly), http.MethodPost, service.WHEPPath, "application/sdp", pc.LocalDescription().SDP)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, _ = sdpRequest(t, joinToken(testRoom, "whep"), http.MethodPost, service.WHEPPath+"?track=TR_unknown", "application/sdp", pc.LocalDescription().SDP)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, answer := sdpRequest(t, joinToken(testRoom, "whep"), http.MethodPost, service.WHEPPath+"?track="+trackID, "application/sdp", pc.LocalDescription().SDP)
+	require.Equal(t, http.StatusCreated, res.StatusCode, answer)
+	require.Equal(t, "application/sdp", res.Header.Get("Content-Type"))
+	location := res.Header.Get("Location")
+	require.True(t, strings.HasPrefix(location, service.WHEPPath+"/"))
+	require.Contains(t, answer, "a=candidate:")
+	require.Contains(t, answer, "a=sendonly")
+	require.NoError(t, pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: answer}))
+
+	testutils.WithTimeout(t, func() string {
+		if received.Load() == 0 {
+			return "WHEP player did not receive media"
+		}
+		return ""
+	})
+
+	// the player is hidden
+	time.Sleep(100 * time.Millisecond)
+	for _, p := range c2.RemoteParticipants() {
+		require.NotEqual(t, "whep", p.Identity)
+	}
+
+	res, _ = sdpRequest(t, joinToken(testRoom, "whep"), http.MethodDelete, location, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res, _ = sdpRequest(t, joinToken(testRoom, "whep"), http.MethodDelete, location, "", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
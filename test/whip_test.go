@@ -0,0 +1,149 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/testutils"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func whipRequest(t *testing.T, method string, path string, contentType string, body string) (*http.Response, string) {
+	return sdpRequest(t, joinToken(testRoom, "whip"), method, path, contentType, body)
+}
+
+func sdpRequest(t *testing.T, token string, method string, path string, contentType string, body string) (*http.Response, string) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", defaultServerPort, path), strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	testclient.SetAuthorizationToken(req.Header, token)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, string(b)
+}
+
+func TestWHIPPublish(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestWHIPPublish")
+	defer finish()
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	waitUntilConnected(t, c1)
+	defer c1.Stop()
+
+	// encoder publishing over WHIP
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close()
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", "whip")
+	require.NoError(t, err)
+	_, err = pc.AddTransceiverFromTrack(track, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+	require.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	gathered := webrtc.GatheringCompletePromise(pc)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	<-gathered
+
+	res, _ := whipRequest(t, http.MethodPost, service.WHIPPath, "text/plain", pc.LocalDescription().SDP)
+	require.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+
+	res, answer := whipRequest(t, http.MethodPost, service.WHIPPath, "application/sdp", pc.LocalDescription().SDP)
+	require.Equal(t, http.StatusCreated, res.StatusCode, answer)
+	require.Equal(t, "application/sdp", res.Header.Get("Content-Type"))
+	location := res.Header.Get("Location")
+	require.True(t, strings.HasPrefix(location, service.WHIPPath+"/"))
+	// candidates of the server are part of the answer
+	require.Contains(t, answer, "a=candidate:")
+	require.NoError(t, pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: answer}))
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = track.WriteSample(media.Sample{Data: []byte{0xfc, 0xff, 0xfe}, Duration: 20 * time.Millisecond})
+			}
+		}
+	}()
+
+	var whipID livekit.ParticipantID
+	testutils.WithTimeout(t, func() string {
+		for _, p := range c1.RemoteParticipants() {
+			if p.Identity == "whip" {
+				whipID = livekit.ParticipantID(p.Sid)
+				if len(p.Tracks) != 1 || p.Tracks[0].Source != livekit.TrackSource_MICROPHONE {
+					return "track of WHIP participant was not published"
+				}
+			}
+		}
+		if whipID == "" {
+			return "c1 did not see the WHIP participant"
+		}
+		if len(c1.SubscribedTracks()[whipID]) != 1 {
+			return "c1 did not subscribe to the WHIP track"
+		}
+		return ""
+	})
+
+	// trickled candidates, without ICE restarts
+	ufrag := pc.LocalDescription().SDP[strings.Index(pc.LocalDescription().SDP, "a=ice-ufrag:"):]
+	ufrag = strings.TrimSpace(ufrag[len("a=ice-ufrag:"):strings.Index(ufrag, "\n")])
+	fragment := "a=ice-ufrag:" + ufrag + "\r\nm=audio 9 UDP/TLS/RTP/SAVPF 111\r\na=mid:0\r\n" +
+		"a=candidate:1 1 udp 2130706431 127.0.0.1 9999 typ host\r\n"
+	res, body := whipRequest(t, http.MethodPatch, location, "application/trickle-ice-sdpfrag", fragment)
+	require.Equal(t, http.StatusNoContent, res.StatusCode, body)
+	res, _ = whipRequest(t, http.MethodPatch, location, "application/trickle-ice-sdpfrag", "a=ice-ufrag:restarted\r\n")
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	res, _ = whipRequest(t, http.MethodDelete, location, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	testutils.WithTimeout(t, func() string {
+		for _, p := range c1.RemoteParticipants() {
+			if p.Identity == "whip" {
+				return "WHIP participant did not leave"
+			}
+		}
+		return ""
+	})
+
+	res, _ = whipRequest(t, http.MethodDelete, location, "", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}